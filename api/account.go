@@ -1,20 +1,24 @@
 package api
 
 import (
-	"database/sql"
 	"errors"
 	"net/http"
+	"time"
 
 	db "github.com/XiaozhouCui/go-bank/db/sqlc"
+	"github.com/XiaozhouCui/go-bank/db/util"
 	"github.com/XiaozhouCui/go-bank/token"
 	"github.com/gin-gonic/gin"
-	"github.com/lib/pq"
+	"github.com/jackc/pgx/v5/pgtype"
 )
 
 type createAccountRequest struct {
 	// binding is for validation
 	// "currency" validator is registered in server.go, to replace binding "oneof=USD EUR CAD"
 	Currency string `json:"currency" binding:"required,currency"`
+	// "account_type" validator is registered in server.go, defaults to checking when omitted
+	Type     string `json:"type" binding:"omitempty,account_type"`
+	Nickname string `json:"nickname" binding:"max=64"`
 }
 
 func (server *Server) createAccount(ctx *gin.Context) {
@@ -28,26 +32,33 @@ func (server *Server) createAccount(ctx *gin.Context) {
 	// get the payload from the auth middleware
 	authPayload := ctx.MustGet(authorizationPayloadKey).(*token.Payload)
 
+	accountType := req.Type
+	if accountType == "" {
+		accountType = util.Checking
+	}
+
 	// if req is valid, create account in db under the current user's name
 	arg := db.CreateAccountParams{
 		Owner:    authPayload.Username,
 		Currency: req.Currency,
 		Balance:  0,
+		Type:     accountType,
+		Nickname: req.Nickname,
 	}
-	account, err := server.store.CreateAccount(ctx, arg)
+	account, err := server.store.CreateAccountTx(ctx, arg)
 	if err != nil {
-		// handle pq error differently
-		if pqErr, ok := err.(*pq.Error); ok {
-			switch pqErr.Code.Name() {
-			case "foreign_key_violation", "unique_violation":
-				ctx.JSON(http.StatusForbidden, errorResponse(err))
-				return
-			}
+		// handle postgres constraint violations differently
+		switch db.ErrorCode(err) {
+		case db.ForeignKeyViolation, db.UniqueViolation:
+			ctx.JSON(http.StatusForbidden, errorResponse(err))
+			return
 		}
 		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
 		return
 	}
 
+	auditTarget(ctx, "accounts", account.ID)
+
 	// return the account
 	ctx.JSON(http.StatusOK, account)
 }
@@ -67,7 +78,7 @@ func (server *Server) getAccount(ctx *gin.Context) {
 	account, err := server.store.GetAccount(ctx, req.ID)
 	if err != nil {
 		// 2 error scenarios: 404 and 500
-		if err == sql.ErrNoRows {
+		if errors.Is(err, db.ErrRecordNotFound) {
 			ctx.JSON(http.StatusNotFound, errorResponse(err))
 			return
 		}
@@ -75,35 +86,36 @@ func (server *Server) getAccount(ctx *gin.Context) {
 		return
 	}
 
-	// only logged-in user can get his own account info
-	authPayload := ctx.MustGet(authorizationPayloadKey).(*token.Payload)
-	if account.Owner != authPayload.Username {
-		err := errors.New("account doesn't belong to the authenticated user")
-		ctx.JSON(http.StatusUnauthorized, errorResponse(err))
+	// only the owner and the members of the account can get its info
+	if _, valid := server.authorizeAccount(ctx, account, db.AccountRoleCanView); !valid {
 		return
 	}
 	ctx.JSON(http.StatusOK, account)
 }
 
 type listAccountRequest struct {
-	PageID   int32 `form:"page_id" binding:"required,min=1"`
-	PageSize int32 `form:"page_size" binding:"required,min=5,max=10"`
+	PageID   int32  `form:"page_id" binding:"required,min=1"`
+	PageSize int32  `form:"page_size" binding:"required,min=5,max=10"`
+	Type     string `form:"type" binding:"omitempty,account_type"`
+	Currency string `form:"currency" binding:"omitempty,currency"`
 }
 
 func (server *Server) listAccount(ctx *gin.Context) {
 	var req listAccountRequest
-	// validate the request query params (e.g. /accounts?page_id=1&page_size=5)
+	// validate the request query params (e.g. /accounts?page_id=1&page_size=5&type=savings&currency=USD)
 	if err := ctx.ShouldBindQuery(&req); err != nil {
 		ctx.JSON(http.StatusBadRequest, errorResponse(err))
 		return
 	}
 
-	// current user can only list his own accounts
+	// current user can only list their own accounts, and the accounts shared with them
 	authPayload := ctx.MustGet(authorizationPayloadKey).(*token.Payload)
 	arg := db.ListAccountsParams{
-		Owner:  authPayload.Username,
-		Limit:  req.PageSize,
-		Offset: (req.PageID - 1) * req.PageSize,
+		Username: authPayload.Username,
+		Type:     pgtype.Text{String: req.Type, Valid: req.Type != ""},         // no filter if type is omitted
+		Currency: pgtype.Text{String: req.Currency, Valid: req.Currency != ""}, // no filter if currency is omitted
+		Limit:    req.PageSize,
+		Offset:   (req.PageID - 1) * req.PageSize,
 	}
 	accounts, err := server.store.ListAccounts(ctx, arg)
 	if err != nil {
@@ -113,3 +125,160 @@ func (server *Server) listAccount(ctx *gin.Context) {
 
 	ctx.JSON(http.StatusOK, accounts)
 }
+
+type getAccountBalanceRequest struct {
+	// RFC 3339 timestamp, defaults to now
+	At time.Time `form:"at"`
+}
+
+type accountBalanceResponse struct {
+	AccountID int64     `json:"account_id"`
+	Currency  string    `json:"currency"`
+	At        time.Time `json:"at"`
+	Balance   int64     `json:"balance"`
+}
+
+// owner or member gets the balance of an account at a point in time (e.g. /accounts/1/balance?at=2023-01-31T23:59:59Z),
+// read from the running balance recorded on each entry
+func (server *Server) getAccountBalance(ctx *gin.Context) {
+	var uri getAccountRequest
+	if err := ctx.ShouldBindUri(&uri); err != nil {
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+	var req getAccountBalanceRequest
+	if err := ctx.ShouldBindQuery(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+	if req.At.IsZero() {
+		req.At = time.Now()
+	}
+
+	account, err := server.store.GetAccount(ctx, uri.ID)
+	if err != nil {
+		if errors.Is(err, db.ErrRecordNotFound) {
+			ctx.JSON(http.StatusNotFound, errorResponse(err))
+			return
+		}
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+
+	if _, valid := server.authorizeAccount(ctx, account, db.AccountRoleCanView); !valid {
+		return
+	}
+
+	if req.At.Before(account.CreatedAt) {
+		err := errors.New("the account was opened after the requested time")
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+
+	balance, err := server.store.GetAccountBalanceAt(ctx, db.GetAccountBalanceAtParams{
+		At:        req.At,
+		AccountID: account.ID,
+	})
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+
+	ctx.JSON(http.StatusOK, accountBalanceResponse{
+		AccountID: account.ID,
+		Currency:  account.Currency,
+		At:        req.At,
+		Balance:   balance,
+	})
+}
+
+type accountStatusRequest struct {
+	Reason string `json:"reason" binding:"required,max=255"`
+}
+
+// owner closes their own account, the balance must be zero
+func (server *Server) closeAccount(ctx *gin.Context) {
+	var uri getAccountRequest
+	if err := ctx.ShouldBindUri(&uri); err != nil {
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+	var req accountStatusRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+
+	account, err := server.store.GetAccount(ctx, uri.ID)
+	if err != nil {
+		if errors.Is(err, db.ErrRecordNotFound) {
+			ctx.JSON(http.StatusNotFound, errorResponse(err))
+			return
+		}
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+
+	// only the owner can close an account
+	authPayload := ctx.MustGet(authorizationPayloadKey).(*token.Payload)
+	if account.Owner != authPayload.Username {
+		err := errors.New("account doesn't belong to the authenticated user")
+		ctx.JSON(http.StatusUnauthorized, errorResponse(err))
+		return
+	}
+	auditBefore(ctx, account)
+
+	server.updateAccountStatus(ctx, account.ID, db.AccountStatusClosed, req.Reason)
+}
+
+// bank staff freezes any account, blocking all transfers in and out
+func (server *Server) freezeAccount(ctx *gin.Context) {
+	server.changeAccountStatus(ctx, db.AccountStatusFrozen)
+}
+
+// bank staff unfreezes a frozen account, making it active again
+func (server *Server) unfreezeAccount(ctx *gin.Context) {
+	server.changeAccountStatus(ctx, db.AccountStatusActive)
+}
+
+// validate the staff request, then move the account to the new status
+func (server *Server) changeAccountStatus(ctx *gin.Context, status string) {
+	var uri getAccountRequest
+	if err := ctx.ShouldBindUri(&uri); err != nil {
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+	var req accountStatusRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+
+	server.updateAccountStatus(ctx, uri.ID, status, req.Reason)
+}
+
+// run the status transaction and write the response
+func (server *Server) updateAccountStatus(ctx *gin.Context, accountID int64, status string, reason string) {
+	authPayload := ctx.MustGet(authorizationPayloadKey).(*token.Payload)
+	arg := db.UpdateAccountStatusTxParams{
+		AccountID: accountID,
+		Status:    status,
+		Reason:    reason,
+		ChangedBy: authPayload.Username,
+	}
+
+	result, err := server.store.UpdateAccountStatusTx(ctx, arg)
+	if err != nil {
+		switch {
+		case errors.Is(err, db.ErrRecordNotFound):
+			ctx.JSON(http.StatusNotFound, errorResponse(err))
+		case errors.Is(err, db.ErrInvalidStatusTransition), errors.Is(err, db.ErrAccountBalanceNotZero):
+			ctx.JSON(http.StatusConflict, errorResponse(err))
+		default:
+			ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		}
+		return
+	}
+
+	ctx.JSON(http.StatusOK, result)
+}
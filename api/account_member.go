@@ -0,0 +1,277 @@
+package api
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+
+	db "github.com/XiaozhouCui/go-bank/db/sqlc"
+	"github.com/XiaozhouCui/go-bank/token"
+	"github.com/gin-gonic/gin"
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+type inviteAccountMemberRequest struct {
+	Username string `json:"username" binding:"required,alphanum"`
+	Role     string `json:"role" binding:"required,oneof=co-owner spender viewer"`
+	// largest amount a spender can send per day, only for spenders
+	SpendLimit int64 `json:"spend_limit" binding:"required_if=Role spender,excluded_unless=Role spender,omitempty,gt=0"`
+}
+
+// the owner or a co-owner invites a user to share an account, the access starts once they accept
+func (server *Server) inviteAccountMember(ctx *gin.Context) {
+	var uri getAccountRequest
+	if err := ctx.ShouldBindUri(&uri); err != nil {
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+	var req inviteAccountMemberRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+
+	account, valid := server.memberAccount(ctx, uri.ID, db.AccountRoleCanManage)
+	if !valid {
+		return
+	}
+	if req.Username == account.Owner {
+		err := errors.New("the owner of an account cannot be invited to it")
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+
+	if _, err := server.store.GetUser(ctx, req.Username); err != nil {
+		if errors.Is(err, db.ErrRecordNotFound) {
+			ctx.JSON(http.StatusNotFound, errorResponse(err))
+			return
+		}
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+
+	authPayload := ctx.MustGet(authorizationPayloadKey).(*token.Payload)
+	member, err := server.store.CreateAccountMember(ctx, db.CreateAccountMemberParams{
+		AccountID:  account.ID,
+		Username:   req.Username,
+		Role:       req.Role,
+		SpendLimit: pgtype.Int8{Int64: req.SpendLimit, Valid: req.SpendLimit > 0},
+		InvitedBy:  authPayload.Username,
+	})
+	if err != nil {
+		// a user is a member of an account once, remove them to change their role
+		if db.ErrorCode(err) == db.UniqueViolation {
+			ctx.JSON(http.StatusForbidden, errorResponse(err))
+			return
+		}
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+
+	ctx.JSON(http.StatusOK, member)
+}
+
+// every member of an account lists its members, invited or not
+func (server *Server) listAccountMembers(ctx *gin.Context) {
+	var uri getAccountRequest
+	if err := ctx.ShouldBindUri(&uri); err != nil {
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+
+	account, valid := server.memberAccount(ctx, uri.ID, db.AccountRoleCanView)
+	if !valid {
+		return
+	}
+
+	members, err := server.store.ListAccountMembers(ctx, account.ID)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+
+	ctx.JSON(http.StatusOK, members)
+}
+
+// the invited user accepts to share an account
+func (server *Server) acceptAccountMember(ctx *gin.Context) {
+	var uri getAccountRequest
+	if err := ctx.ShouldBindUri(&uri); err != nil {
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+
+	authPayload := ctx.MustGet(authorizationPayloadKey).(*token.Payload)
+	arg := db.GetAccountMemberParams{
+		AccountID: uri.ID,
+		Username:  authPayload.Username,
+	}
+	member, err := server.store.GetAccountMember(ctx, arg)
+	if err != nil {
+		if errors.Is(err, db.ErrRecordNotFound) {
+			ctx.JSON(http.StatusNotFound, errorResponse(err))
+			return
+		}
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+	if member.AcceptedAt.Valid {
+		err := fmt.Errorf("invitation to account [%d] already accepted", uri.ID)
+		ctx.JSON(http.StatusConflict, errorResponse(err))
+		return
+	}
+	auditBefore(ctx, member)
+
+	member, err = server.store.AcceptAccountMember(ctx, db.AcceptAccountMemberParams(arg))
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+
+	ctx.JSON(http.StatusOK, member)
+}
+
+type removeAccountMemberRequest struct {
+	ID       int64  `uri:"id" binding:"required,min=1"`
+	Username string `uri:"username" binding:"required,alphanum"`
+}
+
+// the owner or a co-owner removes a member of an account, or a member leaves it.
+// an invitation is declined the same way
+func (server *Server) removeAccountMember(ctx *gin.Context) {
+	var uri removeAccountMemberRequest
+	if err := ctx.ShouldBindUri(&uri); err != nil {
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+
+	authPayload := ctx.MustGet(authorizationPayloadKey).(*token.Payload)
+	if uri.Username != authPayload.Username {
+		if _, valid := server.memberAccount(ctx, uri.ID, db.AccountRoleCanManage); !valid {
+			return
+		}
+	}
+
+	arg := db.GetAccountMemberParams{
+		AccountID: uri.ID,
+		Username:  uri.Username,
+	}
+	member, err := server.store.GetAccountMember(ctx, arg)
+	if err != nil {
+		if errors.Is(err, db.ErrRecordNotFound) {
+			ctx.JSON(http.StatusNotFound, errorResponse(err))
+			return
+		}
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+	auditBefore(ctx, member)
+
+	if err := server.store.DeleteAccountMember(ctx, db.DeleteAccountMemberParams(arg)); err != nil {
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{})
+}
+
+// memberAccount gets an account and checks that the current user has a role allowed by the check,
+// it writes the error response and returns false otherwise
+func (server *Server) memberAccount(ctx *gin.Context, id int64, allowed func(role string) bool) (db.Account, bool) {
+	account, err := server.store.GetAccount(ctx, id)
+	if err != nil {
+		if errors.Is(err, db.ErrRecordNotFound) {
+			ctx.JSON(http.StatusNotFound, errorResponse(err))
+			return account, false
+		}
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return account, false
+	}
+
+	_, valid := server.authorizeAccount(ctx, account, allowed)
+	return account, valid
+}
+
+// authorizeAccount checks that the current user has a role allowed by the check on an account,
+// it writes the error response and returns false otherwise
+func (server *Server) authorizeAccount(ctx *gin.Context, account db.Account, allowed func(role string) bool) (db.AccountMember, bool) {
+	authPayload := ctx.MustGet(authorizationPayloadKey).(*token.Payload)
+	member, status, err := server.checkAccountAccess(ctx, account, authPayload.Username, allowed)
+	if err != nil {
+		ctx.JSON(status, errorResponse(err))
+		return member, false
+	}
+	return member, true
+}
+
+// checkAccountAccess checks that a user has a role allowed by the check on an account, without answering the request,
+// and returns the status code to answer with if they do not
+func (server *Server) checkAccountAccess(ctx context.Context, account db.Account, username string, allowed func(role string) bool) (db.AccountMember, int, error) {
+	member, err := server.accountMember(ctx, account, username)
+	if err != nil {
+		if errors.Is(err, db.ErrRecordNotFound) {
+			err := errors.New("account doesn't belong to the authenticated user")
+			return member, http.StatusUnauthorized, err
+		}
+		return member, http.StatusInternalServerError, err
+	}
+	if !allowed(member.Role) {
+		err := fmt.Errorf("a %s of account [%d] is not allowed to do this", member.Role, account.ID)
+		return member, http.StatusForbidden, err
+	}
+	return member, http.StatusOK, nil
+}
+
+// accountMember returns the membership of a user in an account: the owner is a member of their own account,
+// other users once they have accepted an invitation. It returns ErrRecordNotFound otherwise
+func (server *Server) accountMember(ctx context.Context, account db.Account, username string) (db.AccountMember, error) {
+	if account.Owner == username {
+		return db.AccountMember{
+			AccountID: account.ID,
+			Username:  username,
+			Role:      db.AccountOwner,
+		}, nil
+	}
+
+	member, err := server.store.GetAccountMember(ctx, db.GetAccountMemberParams{
+		AccountID: account.ID,
+		Username:  username,
+	})
+	if err != nil {
+		return member, err
+	}
+	if !member.AcceptedAt.Valid {
+		return member, fmt.Errorf("invitation to account [%d] not accepted: %w", account.ID, db.ErrRecordNotFound)
+	}
+	return member, nil
+}
+
+// checkSpendLimit checks that a member can send an amount from the account today. The spend limit is a daily limit:
+// the transfers the member sent from the account since the start of the day count towards it, and so does pending,
+// the amount of the earlier legs of the same batch. The store checks it again when the money moves
+func (server *Server) checkSpendLimit(ctx context.Context, member db.AccountMember, pending int64, amount int64) (int, error) {
+	if !member.SpendLimit.Valid {
+		return http.StatusOK, nil
+	}
+	if amount > member.SpendLimit.Int64 {
+		err := fmt.Errorf("amount %d is over the spend limit of %d on account [%d]", amount, member.SpendLimit.Int64, member.AccountID)
+		return http.StatusForbidden, err
+	}
+
+	now := time.Now().UTC()
+	totals, err := server.store.GetOutgoingTransferTotalsBySender(ctx, db.GetOutgoingTransferTotalsBySenderParams{
+		AccountID: member.AccountID,
+		SentBy:    pgtype.Text{String: member.Username, Valid: true},
+		Since:     time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, time.UTC),
+	})
+	if err != nil {
+		return http.StatusInternalServerError, err
+	}
+	if spent := totals.TotalAmount + pending; spent+amount > member.SpendLimit.Int64 {
+		err := fmt.Errorf("%d already sent today from account [%d], the daily spend limit is %d", spent, member.AccountID, member.SpendLimit.Int64)
+		return http.StatusForbidden, err
+	}
+	return http.StatusOK, nil
+}
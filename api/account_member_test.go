@@ -0,0 +1,609 @@
+package api
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	mockdb "github.com/XiaozhouCui/go-bank/db/mock"
+	db "github.com/XiaozhouCui/go-bank/db/sqlc"
+	"github.com/XiaozhouCui/go-bank/db/util"
+	"github.com/gin-gonic/gin"
+	"github.com/golang/mock/gomock"
+	"github.com/jackc/pgx/v5/pgtype"
+	"github.com/stretchr/testify/require"
+)
+
+func TestInviteAccountMemberAPI(t *testing.T) {
+	owner, _ := randomUser(t)
+	invitee, _ := randomUser(t)
+	viewer, _ := randomUser(t)
+	account := randomAccount(owner.Username)
+
+	spender := randomAccountMember(account, invitee.Username, db.AccountSpender, 500)
+	spender.AcceptedAt = pgtype.Timestamptz{}
+	viewerMember := randomAccountMember(account, viewer.Username, db.AccountViewer, 0)
+
+	testCases := []struct {
+		name          string
+		username      string
+		body          gin.H
+		buildStubs    func(store *mockdb.MockStore)
+		checkResponse func(recorder *httptest.ResponseRecorder)
+	}{
+		{
+			name:     "OK",
+			username: owner.Username,
+			body:     gin.H{"username": invitee.Username, "role": db.AccountSpender, "spend_limit": 500},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Eq(account.ID)).Times(1).Return(account, nil)
+				store.EXPECT().GetUser(gomock.Any(), gomock.Eq(invitee.Username)).Times(1).Return(invitee, nil)
+
+				arg := db.CreateAccountMemberParams{
+					AccountID:  account.ID,
+					Username:   invitee.Username,
+					Role:       db.AccountSpender,
+					SpendLimit: pgtype.Int8{Int64: 500, Valid: true},
+					InvitedBy:  owner.Username,
+				}
+				store.EXPECT().CreateAccountMember(gomock.Any(), gomock.Eq(arg)).Times(1).Return(spender, nil)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+
+				var member db.AccountMember
+				require.NoError(t, json.NewDecoder(recorder.Body).Decode(&member))
+				require.Equal(t, spender.Username, member.Username)
+				require.False(t, member.AcceptedAt.Valid)
+			},
+		},
+		{
+			name:     "SpenderWithoutLimit",
+			username: owner.Username,
+			body:     gin.H{"username": invitee.Username, "role": db.AccountSpender},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().CreateAccountMember(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusBadRequest, recorder.Code)
+			},
+		},
+		{
+			name:     "ViewerWithLimit",
+			username: owner.Username,
+			body:     gin.H{"username": invitee.Username, "role": db.AccountViewer, "spend_limit": 500},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().CreateAccountMember(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusBadRequest, recorder.Code)
+			},
+		},
+		{
+			name:     "InvalidRole",
+			username: owner.Username,
+			body:     gin.H{"username": invitee.Username, "role": db.AccountOwner},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().CreateAccountMember(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusBadRequest, recorder.Code)
+			},
+		},
+		{
+			name:     "InviteOwner",
+			username: owner.Username,
+			body:     gin.H{"username": owner.Username, "role": db.AccountViewer},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Eq(account.ID)).Times(1).Return(account, nil)
+				store.EXPECT().CreateAccountMember(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusBadRequest, recorder.Code)
+			},
+		},
+		{
+			name:     "Viewer",
+			username: viewer.Username,
+			body:     gin.H{"username": invitee.Username, "role": db.AccountViewer},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Eq(account.ID)).Times(1).Return(account, nil)
+				store.EXPECT().
+					GetAccountMember(gomock.Any(), gomock.Eq(db.GetAccountMemberParams{AccountID: account.ID, Username: viewer.Username})).
+					Times(1).
+					Return(viewerMember, nil)
+				store.EXPECT().CreateAccountMember(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusForbidden, recorder.Code)
+			},
+		},
+		{
+			name:     "NotMember",
+			username: "unauthorized_user",
+			body:     gin.H{"username": invitee.Username, "role": db.AccountViewer},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Eq(account.ID)).Times(1).Return(account, nil)
+				store.EXPECT().GetAccountMember(gomock.Any(), gomock.Any()).Times(1).Return(db.AccountMember{}, db.ErrRecordNotFound)
+				store.EXPECT().CreateAccountMember(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusUnauthorized, recorder.Code)
+			},
+		},
+		{
+			name:     "UserNotFound",
+			username: owner.Username,
+			body:     gin.H{"username": invitee.Username, "role": db.AccountViewer},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Eq(account.ID)).Times(1).Return(account, nil)
+				store.EXPECT().GetUser(gomock.Any(), gomock.Eq(invitee.Username)).Times(1).Return(db.User{}, db.ErrRecordNotFound)
+				store.EXPECT().CreateAccountMember(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusNotFound, recorder.Code)
+			},
+		},
+		{
+			name:     "AlreadyMember",
+			username: owner.Username,
+			body:     gin.H{"username": invitee.Username, "role": db.AccountViewer},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Eq(account.ID)).Times(1).Return(account, nil)
+				store.EXPECT().GetUser(gomock.Any(), gomock.Eq(invitee.Username)).Times(1).Return(invitee, nil)
+				store.EXPECT().CreateAccountMember(gomock.Any(), gomock.Any()).Times(1).Return(db.AccountMember{}, db.ErrUniqueViolation)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusForbidden, recorder.Code)
+			},
+		},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			store := mockdb.NewMockStore(ctrl)
+			tc.buildStubs(store)
+
+			server := newTestServer(t, store)
+			recorder := httptest.NewRecorder()
+
+			data, err := json.Marshal(tc.body)
+			require.NoError(t, err)
+
+			url := fmt.Sprintf("/accounts/%d/members", account.ID)
+			request, err := http.NewRequest(http.MethodPost, url, bytes.NewReader(data))
+			require.NoError(t, err)
+
+			addAuthorization(t, request, server.tokenMaker, authorizationTypeBearer, tc.username, util.DepositorRole, time.Minute)
+			server.router.ServeHTTP(recorder, request)
+			tc.checkResponse(recorder)
+		})
+	}
+}
+
+func TestAcceptAccountMemberAPI(t *testing.T) {
+	owner, _ := randomUser(t)
+	invitee, _ := randomUser(t)
+	account := randomAccount(owner.Username)
+
+	invited := randomAccountMember(account, invitee.Username, db.AccountViewer, 0)
+	invited.AcceptedAt = pgtype.Timestamptz{}
+	accepted := invited
+	accepted.AcceptedAt = pgtype.Timestamptz{Time: time.Now().UTC().Truncate(time.Second), Valid: true}
+
+	arg := db.GetAccountMemberParams{AccountID: account.ID, Username: invitee.Username}
+
+	testCases := []struct {
+		name          string
+		buildStubs    func(store *mockdb.MockStore)
+		checkResponse func(recorder *httptest.ResponseRecorder)
+	}{
+		{
+			name: "OK",
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetAccountMember(gomock.Any(), gomock.Eq(arg)).Times(1).Return(invited, nil)
+				store.EXPECT().AcceptAccountMember(gomock.Any(), gomock.Eq(db.AcceptAccountMemberParams(arg))).Times(1).Return(accepted, nil)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+
+				var member db.AccountMember
+				require.NoError(t, json.NewDecoder(recorder.Body).Decode(&member))
+				require.True(t, member.AcceptedAt.Valid)
+			},
+		},
+		{
+			name: "NotInvited",
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetAccountMember(gomock.Any(), gomock.Eq(arg)).Times(1).Return(db.AccountMember{}, db.ErrRecordNotFound)
+				store.EXPECT().AcceptAccountMember(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusNotFound, recorder.Code)
+			},
+		},
+		{
+			name: "AlreadyAccepted",
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetAccountMember(gomock.Any(), gomock.Eq(arg)).Times(1).Return(accepted, nil)
+				store.EXPECT().AcceptAccountMember(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusConflict, recorder.Code)
+			},
+		},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			store := mockdb.NewMockStore(ctrl)
+			tc.buildStubs(store)
+
+			server := newTestServer(t, store)
+			recorder := httptest.NewRecorder()
+
+			url := fmt.Sprintf("/accounts/%d/members/accept", account.ID)
+			request, err := http.NewRequest(http.MethodPost, url, nil)
+			require.NoError(t, err)
+
+			addAuthorization(t, request, server.tokenMaker, authorizationTypeBearer, invitee.Username, util.DepositorRole, time.Minute)
+			server.router.ServeHTTP(recorder, request)
+			tc.checkResponse(recorder)
+		})
+	}
+}
+
+func TestRemoveAccountMemberAPI(t *testing.T) {
+	owner, _ := randomUser(t)
+	user1, _ := randomUser(t)
+	user2, _ := randomUser(t)
+	account := randomAccount(owner.Username)
+
+	member1 := randomAccountMember(account, user1.Username, db.AccountViewer, 0)
+	member2 := randomAccountMember(account, user2.Username, db.AccountSpender, 100)
+
+	arg := db.GetAccountMemberParams{AccountID: account.ID, Username: user2.Username}
+
+	testCases := []struct {
+		name          string
+		username      string
+		buildStubs    func(store *mockdb.MockStore)
+		checkResponse func(recorder *httptest.ResponseRecorder)
+	}{
+		{
+			name:     "ByOwner",
+			username: owner.Username,
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Eq(account.ID)).Times(1).Return(account, nil)
+				store.EXPECT().GetAccountMember(gomock.Any(), gomock.Eq(arg)).Times(1).Return(member2, nil)
+				store.EXPECT().DeleteAccountMember(gomock.Any(), gomock.Eq(db.DeleteAccountMemberParams(arg))).Times(1).Return(nil)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+			},
+		},
+		{
+			name:     "Leave",
+			username: user2.Username,
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Any()).Times(0)
+				store.EXPECT().GetAccountMember(gomock.Any(), gomock.Eq(arg)).Times(1).Return(member2, nil)
+				store.EXPECT().DeleteAccountMember(gomock.Any(), gomock.Eq(db.DeleteAccountMemberParams(arg))).Times(1).Return(nil)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+			},
+		},
+		{
+			name:     "ByViewer",
+			username: user1.Username,
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Eq(account.ID)).Times(1).Return(account, nil)
+				store.EXPECT().
+					GetAccountMember(gomock.Any(), gomock.Eq(db.GetAccountMemberParams{AccountID: account.ID, Username: user1.Username})).
+					Times(1).
+					Return(member1, nil)
+				store.EXPECT().DeleteAccountMember(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusForbidden, recorder.Code)
+			},
+		},
+		{
+			name:     "NotFound",
+			username: owner.Username,
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Eq(account.ID)).Times(1).Return(account, nil)
+				store.EXPECT().GetAccountMember(gomock.Any(), gomock.Eq(arg)).Times(1).Return(db.AccountMember{}, db.ErrRecordNotFound)
+				store.EXPECT().DeleteAccountMember(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusNotFound, recorder.Code)
+			},
+		},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			store := mockdb.NewMockStore(ctrl)
+			tc.buildStubs(store)
+
+			server := newTestServer(t, store)
+			recorder := httptest.NewRecorder()
+
+			url := fmt.Sprintf("/accounts/%d/members/%s", account.ID, user2.Username)
+			request, err := http.NewRequest(http.MethodDelete, url, nil)
+			require.NoError(t, err)
+
+			addAuthorization(t, request, server.tokenMaker, authorizationTypeBearer, tc.username, util.DepositorRole, time.Minute)
+			server.router.ServeHTTP(recorder, request)
+			tc.checkResponse(recorder)
+		})
+	}
+}
+
+func TestListAccountMembersAPI(t *testing.T) {
+	owner, _ := randomUser(t)
+	viewer, _ := randomUser(t)
+	account := randomAccount(owner.Username)
+
+	members := []db.AccountMember{randomAccountMember(account, viewer.Username, db.AccountViewer, 0)}
+
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	store := mockdb.NewMockStore(ctrl)
+	store.EXPECT().GetAccount(gomock.Any(), gomock.Eq(account.ID)).Times(1).Return(account, nil)
+	store.EXPECT().
+		GetAccountMember(gomock.Any(), gomock.Eq(db.GetAccountMemberParams{AccountID: account.ID, Username: viewer.Username})).
+		Times(1).
+		Return(members[0], nil)
+	store.EXPECT().ListAccountMembers(gomock.Any(), gomock.Eq(account.ID)).Times(1).Return(members, nil)
+
+	server := newTestServer(t, store)
+	recorder := httptest.NewRecorder()
+
+	url := fmt.Sprintf("/accounts/%d/members", account.ID)
+	request, err := http.NewRequest(http.MethodGet, url, nil)
+	require.NoError(t, err)
+
+	addAuthorization(t, request, server.tokenMaker, authorizationTypeBearer, viewer.Username, util.DepositorRole, time.Minute)
+	server.router.ServeHTTP(recorder, request)
+	require.Equal(t, http.StatusOK, recorder.Code)
+
+	var gotMembers []db.AccountMember
+	require.NoError(t, json.NewDecoder(recorder.Body).Decode(&gotMembers))
+	require.Equal(t, members, gotMembers)
+}
+
+// members of a joint account use it through the same endpoints as its owner, within their role
+func TestJointAccountAPI(t *testing.T) {
+	owner, _ := randomUser(t)
+	member, _ := randomUser(t)
+	recipient, _ := randomUser(t)
+
+	account := randomAccount(owner.Username)
+	toAccount := randomAccount(recipient.Username)
+	account.Currency = util.USD
+	toAccount.Currency = util.USD
+
+	memberArg := db.GetAccountMemberParams{AccountID: account.ID, Username: member.Username}
+
+	testCases := []struct {
+		name          string
+		method        string
+		url           string
+		body          gin.H
+		buildStubs    func(store *mockdb.MockStore)
+		checkResponse func(recorder *httptest.ResponseRecorder)
+	}{
+		{
+			name:   "ViewerGetsAccount",
+			method: http.MethodGet,
+			url:    fmt.Sprintf("/accounts/%d", account.ID),
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Eq(account.ID)).Times(1).Return(account, nil)
+				store.EXPECT().GetAccountMember(gomock.Any(), gomock.Eq(memberArg)).Times(1).
+					Return(randomAccountMember(account, member.Username, db.AccountViewer, 0), nil)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+				requireBodyMatchAccount(t, recorder.Body, account)
+			},
+		},
+		{
+			name:   "InvitedGetsAccount",
+			method: http.MethodGet,
+			url:    fmt.Sprintf("/accounts/%d", account.ID),
+			buildStubs: func(store *mockdb.MockStore) {
+				invited := randomAccountMember(account, member.Username, db.AccountCoOwner, 0)
+				invited.AcceptedAt = pgtype.Timestamptz{}
+
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Eq(account.ID)).Times(1).Return(account, nil)
+				store.EXPECT().GetAccountMember(gomock.Any(), gomock.Eq(memberArg)).Times(1).Return(invited, nil)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusUnauthorized, recorder.Code)
+			},
+		},
+		{
+			name:   "ViewerTransfers",
+			method: http.MethodPost,
+			url:    "/transfers",
+			body:   gin.H{"from_account_id": account.ID, "to_account_id": toAccount.ID, "amount": 50, "currency": util.USD},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Eq(account.ID)).Times(1).Return(account, nil)
+				store.EXPECT().GetAccountMember(gomock.Any(), gomock.Eq(memberArg)).Times(1).
+					Return(randomAccountMember(account, member.Username, db.AccountViewer, 0), nil)
+				store.EXPECT().TransferTx(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusForbidden, recorder.Code)
+			},
+		},
+		{
+			name:   "SpenderTransfers",
+			method: http.MethodPost,
+			url:    "/transfers",
+			body:   gin.H{"from_account_id": account.ID, "to_account_id": toAccount.ID, "amount": 50, "currency": util.USD},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Eq(account.ID)).Times(1).Return(account, nil)
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Eq(toAccount.ID)).Times(1).Return(toAccount, nil)
+				store.EXPECT().GetAccountMember(gomock.Any(), gomock.Eq(memberArg)).Times(1).
+					Return(randomAccountMember(account, member.Username, db.AccountSpender, 50), nil)
+				store.EXPECT().GetOutgoingTransferTotalsBySender(gomock.Any(), gomock.Any()).Times(1).
+					Return(db.GetOutgoingTransferTotalsBySenderRow{}, nil)
+
+				arg := db.TransferTxParams{
+					FromAccountID: account.ID,
+					ToAccountID:   toAccount.ID,
+					Amount:        50,
+					SentBy:        member.Username,
+				}
+				store.EXPECT().TransferTx(gomock.Any(), gomock.Eq(arg)).Times(1)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+			},
+		},
+		{
+			name:   "SpenderOverDailyLimit",
+			method: http.MethodPost,
+			url:    "/transfers",
+			body:   gin.H{"from_account_id": account.ID, "to_account_id": toAccount.ID, "amount": 30, "currency": util.USD},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Eq(account.ID)).Times(1).Return(account, nil)
+				store.EXPECT().GetAccountMember(gomock.Any(), gomock.Eq(memberArg)).Times(1).
+					Return(randomAccountMember(account, member.Username, db.AccountSpender, 50), nil)
+
+				// a first transfer of 30 was sent today, a second one goes over the limit of 50
+				store.EXPECT().GetOutgoingTransferTotalsBySender(gomock.Any(), gomock.Any()).Times(1).
+					DoAndReturn(func(_ context.Context, arg db.GetOutgoingTransferTotalsBySenderParams) (db.GetOutgoingTransferTotalsBySenderRow, error) {
+						require.Equal(t, account.ID, arg.AccountID)
+						require.Equal(t, member.Username, arg.SentBy.String)
+						require.True(t, arg.Since.Before(time.Now()))
+						return db.GetOutgoingTransferTotalsBySenderRow{TotalAmount: 30, TransferCount: 1}, nil
+					})
+				store.EXPECT().TransferTx(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusForbidden, recorder.Code)
+			},
+		},
+		{
+			name:   "SpenderBatchOverDailyLimit",
+			method: http.MethodPost,
+			url:    "/transfers/batch",
+			body: gin.H{"legs": []gin.H{
+				{"from_account_id": account.ID, "to_account_id": toAccount.ID, "amount": 30, "currency": util.USD},
+				{"from_account_id": account.ID, "to_account_id": toAccount.ID, "amount": 30, "currency": util.USD},
+			}},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Eq(account.ID)).Times(1).Return(account, nil)
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Eq(toAccount.ID)).Times(1).Return(toAccount, nil)
+				store.EXPECT().GetAccountMember(gomock.Any(), gomock.Eq(memberArg)).Times(2).
+					Return(randomAccountMember(account, member.Username, db.AccountSpender, 50), nil)
+
+				// each leg fits under the limit of 50 alone, the second one counts the first
+				store.EXPECT().GetOutgoingTransferTotalsBySender(gomock.Any(), gomock.Any()).Times(2).
+					Return(db.GetOutgoingTransferTotalsBySenderRow{}, nil)
+				store.EXPECT().BatchTransferTx(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusForbidden, recorder.Code)
+
+				rsp := requireBodyBatchTransfer(t, recorder.Body)
+				require.Equal(t, legRolledBack, rsp.Legs[0].Status)
+				require.Equal(t, legFailed, rsp.Legs[1].Status)
+				require.Contains(t, rsp.Legs[1].Error, "daily spend limit")
+			},
+		},
+		{
+			name:   "SpenderOverLimit",
+			method: http.MethodPost,
+			url:    "/transfers",
+			body:   gin.H{"from_account_id": account.ID, "to_account_id": toAccount.ID, "amount": 51, "currency": util.USD},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Eq(account.ID)).Times(1).Return(account, nil)
+				store.EXPECT().GetAccountMember(gomock.Any(), gomock.Eq(memberArg)).Times(1).
+					Return(randomAccountMember(account, member.Username, db.AccountSpender, 50), nil)
+				store.EXPECT().TransferTx(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusForbidden, recorder.Code)
+			},
+		},
+		{
+			name:   "CoOwnerCannotClose",
+			method: http.MethodPost,
+			url:    fmt.Sprintf("/accounts/%d/close", account.ID),
+			body:   gin.H{"reason": "moving banks"},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Eq(account.ID)).Times(1).Return(account, nil)
+				store.EXPECT().UpdateAccountStatusTx(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusUnauthorized, recorder.Code)
+			},
+		},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			store := mockdb.NewMockStore(ctrl)
+			tc.buildStubs(store)
+
+			server := newTestServer(t, store)
+			recorder := httptest.NewRecorder()
+
+			var body bytes.Buffer
+			if tc.body != nil {
+				require.NoError(t, json.NewEncoder(&body).Encode(tc.body))
+			}
+
+			request, err := http.NewRequest(tc.method, tc.url, &body)
+			require.NoError(t, err)
+
+			addAuthorization(t, request, server.tokenMaker, authorizationTypeBearer, member.Username, util.DepositorRole, time.Minute)
+			server.router.ServeHTTP(recorder, request)
+			tc.checkResponse(recorder)
+		})
+	}
+}
+
+func randomAccountMember(account db.Account, username, role string, spendLimit int64) db.AccountMember {
+	return db.AccountMember{
+		AccountID:  account.ID,
+		Username:   username,
+		Role:       role,
+		SpendLimit: pgtype.Int8{Int64: spendLimit, Valid: spendLimit > 0},
+		InvitedBy:  account.Owner,
+		AcceptedAt: pgtype.Timestamptz{Time: time.Now().UTC().Truncate(time.Second), Valid: true},
+		CreatedAt:  time.Now().UTC().Truncate(time.Second),
+	}
+}
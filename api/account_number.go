@@ -0,0 +1,92 @@
+package api
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+
+	db "github.com/XiaozhouCui/go-bank/db/sqlc"
+	"github.com/XiaozhouCui/go-bank/db/util"
+	"github.com/gin-gonic/gin"
+)
+
+type lookupAccountNumberRequest struct {
+	Number string `uri:"number" binding:"required,account_number"`
+}
+
+// accountNumberResponse is what a sender sees of an account before a transfer to its number
+type accountNumberResponse struct {
+	Number    string `json:"number"`
+	Currency  string `json:"currency"`
+	OwnerName string `json:"owner_name"` // masked, like "J*** S****"
+}
+
+// lookupAccountNumber resolves an account number, so the sender can confirm the recipient before a transfer.
+// Only the initials of the owner are shown
+func (server *Server) lookupAccountNumber(ctx *gin.Context) {
+	var req lookupAccountNumberRequest
+	if err := ctx.ShouldBindUri(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+
+	account, status, err := server.accountByNumber(ctx, req.Number)
+	if err != nil {
+		ctx.JSON(status, errorResponse(err))
+		return
+	}
+
+	owner, err := server.store.GetUser(ctx, account.Owner)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+
+	ctx.JSON(http.StatusOK, accountNumberResponse{
+		Number:    account.Number,
+		Currency:  account.Currency,
+		OwnerName: maskName(owner.FullName),
+	})
+}
+
+// accountByNumber loads the customer account of a number, typed with or without spaces and dashes.
+// It returns the status code to answer with if there is none, internal accounts have no public number
+func (server *Server) accountByNumber(ctx *gin.Context, number string) (db.Account, int, error) {
+	account, err := server.store.GetAccountByNumber(ctx, util.NormalizeAccountNumber(number))
+	if err != nil {
+		if errors.Is(err, db.ErrRecordNotFound) {
+			return account, http.StatusNotFound, fmt.Errorf("account number %s: %w", number, err)
+		}
+		return account, http.StatusInternalServerError, err
+	}
+	if account.Type == util.Internal {
+		return account, http.StatusNotFound, fmt.Errorf("account number %s: %w", number, db.ErrRecordNotFound)
+	}
+	return account, http.StatusOK, nil
+}
+
+// recipientTransfer turns a transfer to a payee or an account number into a transfer to an account ID,
+// see payeeTransfer. It returns the status code to answer with if the recipient is invalid
+func (server *Server) recipientTransfer(ctx *gin.Context, req transferRequest, username string) (transferRequest, int, error) {
+	if req.ToAccountNumber == "" {
+		return server.payeeTransfer(ctx, req, username)
+	}
+
+	account, status, err := server.accountByNumber(ctx, req.ToAccountNumber)
+	if err != nil {
+		return req, status, err
+	}
+	req.ToAccountID = account.ID
+	return req, http.StatusOK, nil
+}
+
+// maskName keeps the first letter of each word of a name
+func maskName(name string) string {
+	words := strings.Fields(name)
+	for i, word := range words {
+		letters := []rune(word)
+		words[i] = string(letters[0]) + strings.Repeat("*", len(letters)-1)
+	}
+	return strings.Join(words, " ")
+}
@@ -0,0 +1,371 @@
+package api
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+
+	db "github.com/XiaozhouCui/go-bank/db/sqlc"
+	"github.com/XiaozhouCui/go-bank/db/util"
+	"github.com/XiaozhouCui/go-bank/token"
+	"github.com/gin-gonic/gin"
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+type setApprovalPolicyRequest struct {
+	// transfers of at least this amount need approvals
+	Threshold         int64    `json:"threshold" binding:"required,gt=0"`
+	RequiredApprovals int32    `json:"required_approvals" binding:"required,min=1"`
+	Approvers         []string `json:"approvers" binding:"required,min=1,unique,dive,alphanum"`
+}
+
+// the owner or a co-owner sets the approval policy of an account, replacing the previous one.
+// transfers already waiting for approvals keep the policy they were requested with.
+// A first policy, or one at least as strict as the current one, applies at once: a looser one
+// needs the approvals of the current policy, see createPolicyChange
+func (server *Server) setApprovalPolicy(ctx *gin.Context) {
+	var uri getAccountRequest
+	if err := ctx.ShouldBindUri(&uri); err != nil {
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+	var req setApprovalPolicyRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+
+	if int(req.RequiredApprovals) > len(req.Approvers) {
+		err := fmt.Errorf("required_approvals cannot be more than the %d approvers", len(req.Approvers))
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+
+	account, valid := server.memberAccount(ctx, uri.ID, db.AccountRoleCanManage)
+	if !valid {
+		return
+	}
+
+	// approvers are the members who can spend from the account
+	for _, approver := range req.Approvers {
+		member, err := server.accountMember(ctx, account, approver)
+		if err != nil {
+			if errors.Is(err, db.ErrRecordNotFound) {
+				err := fmt.Errorf("approver %s is not a member of account [%d]", approver, account.ID)
+				ctx.JSON(http.StatusBadRequest, errorResponse(err))
+				return
+			}
+			ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+			return
+		}
+		if !db.AccountRoleCanSpend(member.Role) {
+			err := fmt.Errorf("approver %s is a %s of account [%d]", approver, member.Role, account.ID)
+			ctx.JSON(http.StatusBadRequest, errorResponse(err))
+			return
+		}
+	}
+
+	current, err := server.store.GetApprovalPolicy(ctx, account.ID)
+	if err != nil && !errors.Is(err, db.ErrRecordNotFound) {
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+	if err == nil && !isStricterPolicy(current, req) {
+		auditBefore(ctx, current)
+		server.createPolicyChange(ctx, current, db.CreatePolicyChangeParams{
+			NewThreshold:         pgtype.Int8{Int64: req.Threshold, Valid: true},
+			NewRequiredApprovals: pgtype.Int4{Int32: req.RequiredApprovals, Valid: true},
+			NewApprovers:         req.Approvers,
+		})
+		return
+	}
+
+	authPayload := ctx.MustGet(authorizationPayloadKey).(*token.Payload)
+	policy, err := server.store.SetApprovalPolicy(ctx, db.SetApprovalPolicyParams{
+		AccountID:         account.ID,
+		Threshold:         req.Threshold,
+		RequiredApprovals: req.RequiredApprovals,
+		Approvers:         req.Approvers,
+		UpdatedBy:         authPayload.Username,
+	})
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+
+	ctx.JSON(http.StatusOK, policy)
+}
+
+// every member of an account can get its approval policy
+func (server *Server) getApprovalPolicy(ctx *gin.Context) {
+	var uri getAccountRequest
+	if err := ctx.ShouldBindUri(&uri); err != nil {
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+
+	account, valid := server.memberAccount(ctx, uri.ID, db.AccountRoleCanView)
+	if !valid {
+		return
+	}
+
+	policy, valid := server.accountApprovalPolicy(ctx, account.ID)
+	if !valid {
+		return
+	}
+
+	ctx.JSON(http.StatusOK, policy)
+}
+
+// the owner or a co-owner asks to remove the approval policy of an account, transfers no longer need approvals
+// once the approvers of the policy agree, see createPolicyChange
+func (server *Server) deleteApprovalPolicy(ctx *gin.Context) {
+	var uri getAccountRequest
+	if err := ctx.ShouldBindUri(&uri); err != nil {
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+
+	account, valid := server.memberAccount(ctx, uri.ID, db.AccountRoleCanManage)
+	if !valid {
+		return
+	}
+
+	policy, valid := server.accountApprovalPolicy(ctx, account.ID)
+	if !valid {
+		return
+	}
+	auditBefore(ctx, policy)
+
+	server.createPolicyChange(ctx, policy, db.CreatePolicyChangeParams{})
+}
+
+func (server *Server) accountApprovalPolicy(ctx *gin.Context, accountID int64) (db.ApprovalPolicy, bool) {
+	policy, err := server.store.GetApprovalPolicy(ctx, accountID)
+	if err != nil {
+		if errors.Is(err, db.ErrRecordNotFound) {
+			ctx.JSON(http.StatusNotFound, errorResponse(err))
+			return policy, false
+		}
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return policy, false
+	}
+	return policy, true
+}
+
+// createPendingTransfer requests a transfer that needs approvals, it is made once enough approvers approve it
+func (server *Server) createPendingTransfer(ctx *gin.Context, req transferRequest, policy db.ApprovalPolicy) {
+	authPayload := ctx.MustGet(authorizationPayloadKey).(*token.Payload)
+
+	// the requester does not approve their own transfer
+	approvers := 0
+	for _, approver := range policy.Approvers {
+		if approver != authPayload.Username {
+			approvers++
+		}
+	}
+	if approvers < int(policy.RequiredApprovals) {
+		err := fmt.Errorf("account [%d] does not have %d approvers other than the requester", policy.AccountID, policy.RequiredApprovals)
+		ctx.JSON(http.StatusForbidden, errorResponse(err))
+		return
+	}
+
+	pending, err := server.store.CreatePendingTransfer(ctx, db.CreatePendingTransferParams{
+		FromAccountID:     req.FromAccountID,
+		ToAccountID:       req.ToAccountID,
+		Amount:            req.Amount,
+		RequestedBy:       authPayload.Username,
+		RequiredApprovals: policy.RequiredApprovals,
+		Approvers:         policy.Approvers,
+		Description:       req.Description,
+		Reference:         req.Reference,
+		Tags:              util.NormalizeTags(req.Tags),
+	})
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+
+	auditTarget(ctx, "pending-transfers", pending.ID)
+	ctx.JSON(http.StatusAccepted, pending)
+}
+
+type pendingTransferResponse struct {
+	PendingTransfer db.PendingTransfer    `json:"pending_transfer"`
+	Approvals       []db.TransferApproval `json:"approvals"`
+}
+
+type getPendingTransferRequest struct {
+	ID int64 `uri:"id" binding:"required,min=1"`
+}
+
+// the members of the from account and the approvers can get a pending transfer with its decisions so far
+func (server *Server) getPendingTransfer(ctx *gin.Context) {
+	var req getPendingTransferRequest
+	if err := ctx.ShouldBindUri(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+
+	pending, err := server.store.GetPendingTransfer(ctx, req.ID)
+	if err != nil {
+		if errors.Is(err, db.ErrRecordNotFound) {
+			ctx.JSON(http.StatusNotFound, errorResponse(err))
+			return
+		}
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+
+	authPayload := ctx.MustGet(authorizationPayloadKey).(*token.Payload)
+	if !db.CanApproveTransfer(pending, authPayload.Username) {
+		if _, valid := server.memberAccount(ctx, pending.FromAccountID, db.AccountRoleCanView); !valid {
+			return
+		}
+	}
+
+	approvals, err := server.store.ListTransferApprovals(ctx, pending.ID)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+
+	ctx.JSON(http.StatusOK, pendingTransferResponse{
+		PendingTransfer: pending,
+		Approvals:       approvals,
+	})
+}
+
+type listPendingTransfersRequest struct {
+	Status   string `form:"status" binding:"omitempty,oneof=pending executed rejected"`
+	PageID   int32  `form:"page_id" binding:"required,min=1"`
+	PageSize int32  `form:"page_size" binding:"required,min=5,max=10"`
+}
+
+// every member of an account lists the transfers from it that need approvals, newest first
+func (server *Server) listPendingTransfers(ctx *gin.Context) {
+	var uri getAccountRequest
+	if err := ctx.ShouldBindUri(&uri); err != nil {
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+	var req listPendingTransfersRequest
+	if err := ctx.ShouldBindQuery(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+
+	account, valid := server.memberAccount(ctx, uri.ID, db.AccountRoleCanView)
+	if !valid {
+		return
+	}
+
+	pendings, err := server.store.ListPendingTransfers(ctx, db.ListPendingTransfersParams{
+		FromAccountID: account.ID,
+		Status:        pgtype.Text{String: req.Status, Valid: req.Status != ""},
+		Limit:         req.PageSize,
+		Offset:        (req.PageID - 1) * req.PageSize,
+	})
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+
+	ctx.JSON(http.StatusOK, pendings)
+}
+
+// an approver approves a pending transfer, the approval that reaches the quorum makes the transfer
+func (server *Server) approvePendingTransfer(ctx *gin.Context) {
+	server.decidePendingTransfer(ctx, db.ApprovalApproved)
+}
+
+// an approver rejects a pending transfer, it will not be made
+func (server *Server) rejectPendingTransfer(ctx *gin.Context) {
+	server.decidePendingTransfer(ctx, db.ApprovalRejected)
+}
+
+func (server *Server) decidePendingTransfer(ctx *gin.Context, decision string) {
+	var uri getPendingTransferRequest
+	if err := ctx.ShouldBindUri(&uri); err != nil {
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+
+	pending, err := server.store.GetPendingTransfer(ctx, uri.ID)
+	if err != nil {
+		if errors.Is(err, db.ErrRecordNotFound) {
+			ctx.JSON(http.StatusNotFound, errorResponse(err))
+			return
+		}
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+
+	// an approver who no longer can spend from the account cannot approve its transfers either
+	if _, valid := server.memberAccount(ctx, pending.FromAccountID, db.AccountRoleCanSpend); !valid {
+		return
+	}
+	auditBefore(ctx, pending)
+
+	authPayload := ctx.MustGet(authorizationPayloadKey).(*token.Payload)
+	result, err := server.store.DecidePendingTransferTx(ctx, db.DecidePendingTransferTxParams{
+		ID:       pending.ID,
+		Approver: authPayload.Username,
+		Decision: decision,
+	})
+	if err != nil {
+		// an approver decides once
+		if errors.Is(err, db.ErrPendingTransferNotPending) || db.ErrorCode(err) == db.UniqueViolation {
+			ctx.JSON(http.StatusConflict, errorResponse(err))
+			return
+		}
+		// the requester can no longer send the transfer, it has been rejected
+		if errors.Is(err, db.ErrNotApprover) || errors.Is(err, db.ErrSenderNotAllowed) {
+			ctx.JSON(http.StatusForbidden, errorResponse(err))
+			return
+		}
+		ctx.JSON(transferErrorStatus(err), errorResponse(err))
+		return
+	}
+
+	ctx.JSON(http.StatusOK, result)
+}
+
+// transferApprovalPolicy returns the approval policy of an account and true if a transfer of an amount from it
+// needs approvals, or false if the account has no policy or the amount is under its threshold
+func (server *Server) transferApprovalPolicy(ctx context.Context, accountID int64, amount int64) (db.ApprovalPolicy, bool, error) {
+	policy, err := server.store.GetApprovalPolicy(ctx, accountID)
+	if err != nil {
+		if errors.Is(err, db.ErrRecordNotFound) {
+			return policy, false, nil
+		}
+		return policy, false, err
+	}
+	return policy, amount >= policy.Threshold, nil
+}
+
+// checkNoApproval checks that a transfer made without waiting for approvals does not need them,
+// and returns the status code to answer with if it does. Only POST /transfers waits for approvals,
+// every other way to send money checks this instead; payment imports check it in the bulk package
+func (server *Server) checkNoApproval(ctx context.Context, accountID int64, amount int64) (int, error) {
+	policy, needed, err := server.transferApprovalPolicy(ctx, accountID, amount)
+	if err != nil {
+		return http.StatusInternalServerError, err
+	}
+	if needed {
+		err := fmt.Errorf("transfers of %d or more from account [%d] need approvals", policy.Threshold, accountID)
+		return http.StatusForbidden, err
+	}
+	return http.StatusOK, nil
+}
+
+// noApproval checks that a transfer does not need approvals like checkNoApproval,
+// it writes the error response and returns false otherwise
+func (server *Server) noApproval(ctx *gin.Context, accountID int64, amount int64) bool {
+	if status, err := server.checkNoApproval(ctx, accountID, amount); err != nil {
+		ctx.JSON(status, errorResponse(err))
+		return false
+	}
+	return true
+}
@@ -0,0 +1,657 @@
+package api
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	mockdb "github.com/XiaozhouCui/go-bank/db/mock"
+	db "github.com/XiaozhouCui/go-bank/db/sqlc"
+	"github.com/XiaozhouCui/go-bank/db/util"
+	"github.com/gin-gonic/gin"
+	"github.com/golang/mock/gomock"
+	"github.com/jackc/pgx/v5/pgtype"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSetApprovalPolicyAPI(t *testing.T) {
+	owner, _ := randomUser(t)
+	coOwner, _ := randomUser(t)
+	viewer, _ := randomUser(t)
+	account := randomAccount(owner.Username)
+
+	coOwnerArg := db.GetAccountMemberParams{AccountID: account.ID, Username: coOwner.Username}
+	viewerArg := db.GetAccountMemberParams{AccountID: account.ID, Username: viewer.Username}
+
+	current := db.ApprovalPolicy{
+		AccountID:         account.ID,
+		Threshold:         1000,
+		RequiredApprovals: 1,
+		Approvers:         []string{owner.Username, coOwner.Username},
+		UpdatedBy:         owner.Username,
+	}
+
+	testCases := []struct {
+		name          string
+		username      string
+		body          gin.H
+		buildStubs    func(store *mockdb.MockStore)
+		checkResponse func(recorder *httptest.ResponseRecorder)
+	}{
+		{
+			name:     "OK",
+			username: owner.Username,
+			body:     gin.H{"threshold": 1000, "required_approvals": 1, "approvers": []string{owner.Username, coOwner.Username}},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Eq(account.ID)).Times(1).Return(account, nil)
+				store.EXPECT().GetAccountMember(gomock.Any(), gomock.Eq(coOwnerArg)).Times(1).
+					Return(randomAccountMember(account, coOwner.Username, db.AccountCoOwner, 0), nil)
+
+				arg := db.SetApprovalPolicyParams{
+					AccountID:         account.ID,
+					Threshold:         1000,
+					RequiredApprovals: 1,
+					Approvers:         []string{owner.Username, coOwner.Username},
+					UpdatedBy:         owner.Username,
+				}
+				store.EXPECT().SetApprovalPolicy(gomock.Any(), gomock.Eq(arg)).Times(1).
+					Return(db.ApprovalPolicy{AccountID: account.ID, Threshold: 1000, RequiredApprovals: 1, Approvers: arg.Approvers, UpdatedBy: owner.Username}, nil)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+
+				var policy db.ApprovalPolicy
+				require.NoError(t, json.NewDecoder(recorder.Body).Decode(&policy))
+				require.Equal(t, int64(1000), policy.Threshold)
+				require.Equal(t, []string{owner.Username, coOwner.Username}, policy.Approvers)
+			},
+		},
+		{
+			name:     "Tighten",
+			username: owner.Username,
+			body:     gin.H{"threshold": 500, "required_approvals": 1, "approvers": []string{coOwner.Username}},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Eq(account.ID)).Times(1).Return(account, nil)
+				store.EXPECT().GetAccountMember(gomock.Any(), gomock.Eq(coOwnerArg)).Times(1).
+					Return(randomAccountMember(account, coOwner.Username, db.AccountCoOwner, 0), nil)
+				store.EXPECT().GetApprovalPolicy(gomock.Any(), gomock.Eq(account.ID)).Times(1).Return(current, nil)
+
+				arg := db.SetApprovalPolicyParams{
+					AccountID:         account.ID,
+					Threshold:         500,
+					RequiredApprovals: 1,
+					Approvers:         []string{coOwner.Username},
+					UpdatedBy:         owner.Username,
+				}
+				store.EXPECT().SetApprovalPolicy(gomock.Any(), gomock.Eq(arg)).Times(1).
+					Return(db.ApprovalPolicy{AccountID: account.ID, Threshold: 500, RequiredApprovals: 1, Approvers: arg.Approvers}, nil)
+				store.EXPECT().CreatePolicyChange(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				// a stricter policy applies at once
+				require.Equal(t, http.StatusOK, recorder.Code)
+			},
+		},
+		{
+			name:     "Loosen",
+			username: owner.Username,
+			body:     gin.H{"threshold": 5000, "required_approvals": 1, "approvers": []string{owner.Username, coOwner.Username}},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Eq(account.ID)).Times(1).Return(account, nil)
+				store.EXPECT().GetAccountMember(gomock.Any(), gomock.Eq(coOwnerArg)).Times(1).
+					Return(randomAccountMember(account, coOwner.Username, db.AccountCoOwner, 0), nil)
+				store.EXPECT().GetApprovalPolicy(gomock.Any(), gomock.Eq(account.ID)).Times(1).Return(current, nil)
+				store.EXPECT().SetApprovalPolicy(gomock.Any(), gomock.Any()).Times(0)
+
+				arg := db.CreatePolicyChangeParams{
+					AccountID:            account.ID,
+					NewThreshold:         pgtype.Int8{Int64: 5000, Valid: true},
+					NewRequiredApprovals: pgtype.Int4{Int32: 1, Valid: true},
+					NewApprovers:         []string{owner.Username, coOwner.Username},
+					RequestedBy:          owner.Username,
+					RequiredApprovals:    current.RequiredApprovals,
+					Approvers:            current.Approvers,
+				}
+				store.EXPECT().CreatePolicyChange(gomock.Any(), gomock.Eq(arg)).Times(1).
+					Return(db.PolicyChange{ID: 1, AccountID: account.ID, NewThreshold: arg.NewThreshold, Status: db.PolicyChangePending}, nil)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				// a looser policy waits for the approvers of the current one
+				require.Equal(t, http.StatusAccepted, recorder.Code)
+
+				var change db.PolicyChange
+				require.NoError(t, json.NewDecoder(recorder.Body).Decode(&change))
+				require.Equal(t, db.PolicyChangePending, change.Status)
+				require.Equal(t, int64(5000), change.NewThreshold.Int64)
+			},
+		},
+		{
+			name:     "LoosenWithoutOtherApprovers",
+			username: owner.Username,
+			body:     gin.H{"threshold": 1000, "required_approvals": 1, "approvers": []string{owner.Username, coOwner.Username}},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Eq(account.ID)).Times(1).Return(account, nil)
+				store.EXPECT().GetAccountMember(gomock.Any(), gomock.Eq(coOwnerArg)).Times(1).
+					Return(randomAccountMember(account, coOwner.Username, db.AccountCoOwner, 0), nil)
+
+				// adding an approver is looser too, and only the requester approves today
+				alone := current
+				alone.Approvers = []string{owner.Username}
+				store.EXPECT().GetApprovalPolicy(gomock.Any(), gomock.Eq(account.ID)).Times(1).Return(alone, nil)
+				store.EXPECT().SetApprovalPolicy(gomock.Any(), gomock.Any()).Times(0)
+				store.EXPECT().CreatePolicyChange(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusForbidden, recorder.Code)
+			},
+		},
+		{
+			name:     "TooManyRequiredApprovals",
+			username: owner.Username,
+			body:     gin.H{"threshold": 1000, "required_approvals": 2, "approvers": []string{coOwner.Username}},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Any()).Times(0)
+				store.EXPECT().SetApprovalPolicy(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusBadRequest, recorder.Code)
+			},
+		},
+		{
+			name:     "DuplicateApprovers",
+			username: owner.Username,
+			body:     gin.H{"threshold": 1000, "required_approvals": 2, "approvers": []string{coOwner.Username, coOwner.Username}},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().SetApprovalPolicy(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusBadRequest, recorder.Code)
+			},
+		},
+		{
+			name:     "ApproverNotMember",
+			username: owner.Username,
+			body:     gin.H{"threshold": 1000, "required_approvals": 1, "approvers": []string{coOwner.Username}},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Eq(account.ID)).Times(1).Return(account, nil)
+				store.EXPECT().GetAccountMember(gomock.Any(), gomock.Eq(coOwnerArg)).Times(1).Return(db.AccountMember{}, db.ErrRecordNotFound)
+				store.EXPECT().SetApprovalPolicy(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusBadRequest, recorder.Code)
+			},
+		},
+		{
+			name:     "ViewerApprover",
+			username: owner.Username,
+			body:     gin.H{"threshold": 1000, "required_approvals": 1, "approvers": []string{viewer.Username}},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Eq(account.ID)).Times(1).Return(account, nil)
+				store.EXPECT().GetAccountMember(gomock.Any(), gomock.Eq(viewerArg)).Times(1).
+					Return(randomAccountMember(account, viewer.Username, db.AccountViewer, 0), nil)
+				store.EXPECT().SetApprovalPolicy(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusBadRequest, recorder.Code)
+			},
+		},
+		{
+			name:     "ViewerSetsPolicy",
+			username: viewer.Username,
+			body:     gin.H{"threshold": 1000, "required_approvals": 1, "approvers": []string{owner.Username}},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Eq(account.ID)).Times(1).Return(account, nil)
+				store.EXPECT().GetAccountMember(gomock.Any(), gomock.Eq(viewerArg)).Times(1).
+					Return(randomAccountMember(account, viewer.Username, db.AccountViewer, 0), nil)
+				store.EXPECT().SetApprovalPolicy(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusForbidden, recorder.Code)
+			},
+		},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			store := mockdb.NewMockStore(ctrl)
+			tc.buildStubs(store)
+
+			server := newTestServer(t, store)
+			recorder := httptest.NewRecorder()
+
+			data, err := json.Marshal(tc.body)
+			require.NoError(t, err)
+
+			url := fmt.Sprintf("/accounts/%d/approval-policy", account.ID)
+			request, err := http.NewRequest(http.MethodPut, url, bytes.NewReader(data))
+			require.NoError(t, err)
+
+			addAuthorization(t, request, server.tokenMaker, authorizationTypeBearer, tc.username, util.DepositorRole, time.Minute)
+			server.router.ServeHTTP(recorder, request)
+			tc.checkResponse(recorder)
+		})
+	}
+}
+
+func TestDeleteApprovalPolicyAPI(t *testing.T) {
+	owner, _ := randomUser(t)
+	approver, _ := randomUser(t)
+	viewer, _ := randomUser(t)
+	account := randomAccount(owner.Username)
+
+	policy := db.ApprovalPolicy{
+		AccountID:         account.ID,
+		Threshold:         1000,
+		RequiredApprovals: 1,
+		Approvers:         []string{owner.Username, approver.Username},
+		UpdatedBy:         owner.Username,
+	}
+	viewerArg := db.GetAccountMemberParams{AccountID: account.ID, Username: viewer.Username}
+
+	testCases := []struct {
+		name          string
+		username      string
+		buildStubs    func(store *mockdb.MockStore)
+		checkResponse func(recorder *httptest.ResponseRecorder)
+	}{
+		{
+			name:     "NeedsApproval",
+			username: owner.Username,
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Eq(account.ID)).Times(1).Return(account, nil)
+				store.EXPECT().GetApprovalPolicy(gomock.Any(), gomock.Eq(account.ID)).Times(1).Return(policy, nil)
+				store.EXPECT().DeleteApprovalPolicy(gomock.Any(), gomock.Any()).Times(0)
+
+				arg := db.CreatePolicyChangeParams{
+					AccountID:         account.ID,
+					RequestedBy:       owner.Username,
+					RequiredApprovals: policy.RequiredApprovals,
+					Approvers:         policy.Approvers,
+				}
+				store.EXPECT().CreatePolicyChange(gomock.Any(), gomock.Eq(arg)).Times(1).
+					Return(db.PolicyChange{ID: 1, AccountID: account.ID, Status: db.PolicyChangePending}, nil)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusAccepted, recorder.Code)
+
+				var change db.PolicyChange
+				require.NoError(t, json.NewDecoder(recorder.Body).Decode(&change))
+				require.False(t, change.NewThreshold.Valid)
+			},
+		},
+		{
+			name:     "NoPolicy",
+			username: owner.Username,
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Eq(account.ID)).Times(1).Return(account, nil)
+				store.EXPECT().CreatePolicyChange(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusNotFound, recorder.Code)
+			},
+		},
+		{
+			name:     "Viewer",
+			username: viewer.Username,
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Eq(account.ID)).Times(1).Return(account, nil)
+				store.EXPECT().GetAccountMember(gomock.Any(), gomock.Eq(viewerArg)).Times(1).
+					Return(randomAccountMember(account, viewer.Username, db.AccountViewer, 0), nil)
+				store.EXPECT().CreatePolicyChange(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusForbidden, recorder.Code)
+			},
+		},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			store := mockdb.NewMockStore(ctrl)
+			tc.buildStubs(store)
+
+			server := newTestServer(t, store)
+			recorder := httptest.NewRecorder()
+
+			url := fmt.Sprintf("/accounts/%d/approval-policy", account.ID)
+			request, err := http.NewRequest(http.MethodDelete, url, nil)
+			require.NoError(t, err)
+
+			addAuthorization(t, request, server.tokenMaker, authorizationTypeBearer, tc.username, util.DepositorRole, time.Minute)
+			server.router.ServeHTTP(recorder, request)
+			tc.checkResponse(recorder)
+		})
+	}
+}
+
+func TestPendingTransferAPI(t *testing.T) {
+	owner, _ := randomUser(t)
+	approver, _ := randomUser(t)
+	recipient, _ := randomUser(t)
+
+	account := randomAccount(owner.Username)
+	toAccount := randomAccount(recipient.Username)
+	account.Currency = util.USD
+	toAccount.Currency = util.USD
+
+	policy := db.ApprovalPolicy{
+		AccountID:         account.ID,
+		Threshold:         1000,
+		RequiredApprovals: 1,
+		Approvers:         []string{owner.Username, approver.Username},
+		UpdatedBy:         owner.Username,
+	}
+	pending := db.PendingTransfer{
+		ID:                util.RandomInt(1, 1000),
+		FromAccountID:     account.ID,
+		ToAccountID:       toAccount.ID,
+		Amount:            1000,
+		RequestedBy:       owner.Username,
+		RequiredApprovals: 1,
+		Approvers:         policy.Approvers,
+		Status:            db.PendingTransferPending,
+	}
+	approverArg := db.GetAccountMemberParams{AccountID: account.ID, Username: approver.Username}
+
+	testCases := []struct {
+		name          string
+		username      string
+		method        string
+		url           string
+		body          gin.H
+		buildStubs    func(store *mockdb.MockStore)
+		checkResponse func(recorder *httptest.ResponseRecorder)
+	}{
+		{
+			name:     "TransferNeedsApproval",
+			username: owner.Username,
+			method:   http.MethodPost,
+			url:      "/transfers",
+			body: gin.H{
+				"from_account_id": account.ID,
+				"to_account_id":   toAccount.ID,
+				"amount":          1000,
+				"currency":        util.USD,
+				"description":     " Office\nchairs ",
+				"reference":       "PO-7",
+				"tags":            []string{"Office", "office "},
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Eq(account.ID)).Times(1).Return(account, nil)
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Eq(toAccount.ID)).Times(1).Return(toAccount, nil)
+				store.EXPECT().GetApprovalPolicy(gomock.Any(), gomock.Eq(account.ID)).Times(1).Return(policy, nil)
+
+				arg := db.CreatePendingTransferParams{
+					FromAccountID:     account.ID,
+					ToAccountID:       toAccount.ID,
+					Amount:            1000,
+					RequestedBy:       owner.Username,
+					RequiredApprovals: 1,
+					Approvers:         policy.Approvers,
+					Description:       "Office chairs",
+					Reference:         "PO-7",
+					Tags:              []string{"office"},
+				}
+				store.EXPECT().CreatePendingTransfer(gomock.Any(), gomock.Eq(arg)).Times(1).Return(pending, nil)
+				store.EXPECT().TransferTx(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusAccepted, recorder.Code)
+
+				var got db.PendingTransfer
+				require.NoError(t, json.NewDecoder(recorder.Body).Decode(&got))
+				require.Equal(t, pending.ID, got.ID)
+				require.Equal(t, db.PendingTransferPending, got.Status)
+			},
+		},
+		{
+			name:     "TransferUnderThreshold",
+			username: owner.Username,
+			method:   http.MethodPost,
+			url:      "/transfers",
+			body:     gin.H{"from_account_id": account.ID, "to_account_id": toAccount.ID, "amount": 999, "currency": util.USD},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Eq(account.ID)).Times(1).Return(account, nil)
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Eq(toAccount.ID)).Times(1).Return(toAccount, nil)
+				store.EXPECT().GetApprovalPolicy(gomock.Any(), gomock.Eq(account.ID)).Times(1).Return(policy, nil)
+				store.EXPECT().CreatePendingTransfer(gomock.Any(), gomock.Any()).Times(0)
+				store.EXPECT().TransferTx(gomock.Any(), gomock.Any()).Times(1)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+			},
+		},
+		{
+			name:     "NotEnoughApprovers",
+			username: owner.Username,
+			method:   http.MethodPost,
+			url:      "/transfers",
+			body:     gin.H{"from_account_id": account.ID, "to_account_id": toAccount.ID, "amount": 1000, "currency": util.USD},
+			buildStubs: func(store *mockdb.MockStore) {
+				// the owner is the only approver, and does not approve their own transfer
+				onlyOwner := policy
+				onlyOwner.Approvers = []string{owner.Username}
+
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Eq(account.ID)).Times(1).Return(account, nil)
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Eq(toAccount.ID)).Times(1).Return(toAccount, nil)
+				store.EXPECT().GetApprovalPolicy(gomock.Any(), gomock.Eq(account.ID)).Times(1).Return(onlyOwner, nil)
+				store.EXPECT().CreatePendingTransfer(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusForbidden, recorder.Code)
+			},
+		},
+		{
+			name:     "HoldNeedsApproval",
+			username: owner.Username,
+			method:   http.MethodPost,
+			url:      "/holds",
+			body:     gin.H{"account_id": account.ID, "to_account_id": toAccount.ID, "amount": 1000, "currency": util.USD},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Eq(account.ID)).Times(1).Return(account, nil)
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Eq(toAccount.ID)).Times(1).Return(toAccount, nil)
+				store.EXPECT().GetApprovalPolicy(gomock.Any(), gomock.Eq(account.ID)).Times(1).Return(policy, nil)
+				store.EXPECT().PlaceHoldTx(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusForbidden, recorder.Code)
+			},
+		},
+		{
+			name:     "GetPendingTransfer",
+			username: approver.Username,
+			method:   http.MethodGet,
+			url:      fmt.Sprintf("/pending-transfers/%d", pending.ID),
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetPendingTransfer(gomock.Any(), gomock.Eq(pending.ID)).Times(1).Return(pending, nil)
+				store.EXPECT().ListTransferApprovals(gomock.Any(), gomock.Eq(pending.ID)).Times(1).Return([]db.TransferApproval{}, nil)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+
+				var rsp pendingTransferResponse
+				require.NoError(t, json.NewDecoder(recorder.Body).Decode(&rsp))
+				require.Equal(t, pending.ID, rsp.PendingTransfer.ID)
+				require.Empty(t, rsp.Approvals)
+			},
+		},
+		{
+			name:     "Approve",
+			username: approver.Username,
+			method:   http.MethodPost,
+			url:      fmt.Sprintf("/pending-transfers/%d/approve", pending.ID),
+			buildStubs: func(store *mockdb.MockStore) {
+				executed := pending
+				executed.Status = db.PendingTransferExecuted
+				executed.TransferID = pgtype.Int8{Int64: 1, Valid: true}
+
+				store.EXPECT().GetPendingTransfer(gomock.Any(), gomock.Eq(pending.ID)).Times(1).Return(pending, nil)
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Eq(account.ID)).Times(1).Return(account, nil)
+				store.EXPECT().GetAccountMember(gomock.Any(), gomock.Eq(approverArg)).Times(1).
+					Return(randomAccountMember(account, approver.Username, db.AccountCoOwner, 0), nil)
+
+				arg := db.DecidePendingTransferTxParams{
+					ID:       pending.ID,
+					Approver: approver.Username,
+					Decision: db.ApprovalApproved,
+				}
+				store.EXPECT().DecidePendingTransferTx(gomock.Any(), gomock.Eq(arg)).Times(1).
+					Return(db.DecidePendingTransferTxResult{PendingTransfer: executed}, nil)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+
+				var result db.DecidePendingTransferTxResult
+				require.NoError(t, json.NewDecoder(recorder.Body).Decode(&result))
+				require.Equal(t, db.PendingTransferExecuted, result.PendingTransfer.Status)
+			},
+		},
+		{
+			name:     "Reject",
+			username: approver.Username,
+			method:   http.MethodPost,
+			url:      fmt.Sprintf("/pending-transfers/%d/reject", pending.ID),
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetPendingTransfer(gomock.Any(), gomock.Eq(pending.ID)).Times(1).Return(pending, nil)
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Eq(account.ID)).Times(1).Return(account, nil)
+				store.EXPECT().GetAccountMember(gomock.Any(), gomock.Eq(approverArg)).Times(1).
+					Return(randomAccountMember(account, approver.Username, db.AccountCoOwner, 0), nil)
+
+				arg := db.DecidePendingTransferTxParams{
+					ID:       pending.ID,
+					Approver: approver.Username,
+					Decision: db.ApprovalRejected,
+				}
+				store.EXPECT().DecidePendingTransferTx(gomock.Any(), gomock.Eq(arg)).Times(1)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+			},
+		},
+		{
+			name:     "RequesterApproves",
+			username: owner.Username,
+			method:   http.MethodPost,
+			url:      fmt.Sprintf("/pending-transfers/%d/approve", pending.ID),
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetPendingTransfer(gomock.Any(), gomock.Eq(pending.ID)).Times(1).Return(pending, nil)
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Eq(account.ID)).Times(1).Return(account, nil)
+				store.EXPECT().DecidePendingTransferTx(gomock.Any(), gomock.Any()).Times(1).
+					Return(db.DecidePendingTransferTxResult{}, db.ErrNotApprover)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusForbidden, recorder.Code)
+			},
+		},
+		{
+			name:     "NotPending",
+			username: approver.Username,
+			method:   http.MethodPost,
+			url:      fmt.Sprintf("/pending-transfers/%d/approve", pending.ID),
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetPendingTransfer(gomock.Any(), gomock.Eq(pending.ID)).Times(1).Return(pending, nil)
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Eq(account.ID)).Times(1).Return(account, nil)
+				store.EXPECT().GetAccountMember(gomock.Any(), gomock.Eq(approverArg)).Times(1).
+					Return(randomAccountMember(account, approver.Username, db.AccountCoOwner, 0), nil)
+				store.EXPECT().DecidePendingTransferTx(gomock.Any(), gomock.Any()).Times(1).
+					Return(db.DecidePendingTransferTxResult{}, db.ErrPendingTransferNotPending)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusConflict, recorder.Code)
+			},
+		},
+		{
+			name:     "InsufficientFunds",
+			username: approver.Username,
+			method:   http.MethodPost,
+			url:      fmt.Sprintf("/pending-transfers/%d/approve", pending.ID),
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetPendingTransfer(gomock.Any(), gomock.Eq(pending.ID)).Times(1).Return(pending, nil)
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Eq(account.ID)).Times(1).Return(account, nil)
+				store.EXPECT().GetAccountMember(gomock.Any(), gomock.Eq(approverArg)).Times(1).
+					Return(randomAccountMember(account, approver.Username, db.AccountCoOwner, 0), nil)
+				store.EXPECT().DecidePendingTransferTx(gomock.Any(), gomock.Any()).Times(1).
+					Return(db.DecidePendingTransferTxResult{}, db.ErrInsufficientFunds)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusForbidden, recorder.Code)
+			},
+		},
+		{
+			name:     "RemovedRequester",
+			username: approver.Username,
+			method:   http.MethodPost,
+			url:      fmt.Sprintf("/pending-transfers/%d/approve", pending.ID),
+			buildStubs: func(store *mockdb.MockStore) {
+				rejected := pending
+				rejected.Status = db.PendingTransferRejected
+
+				store.EXPECT().GetPendingTransfer(gomock.Any(), gomock.Eq(pending.ID)).Times(1).Return(pending, nil)
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Eq(account.ID)).Times(1).Return(account, nil)
+				store.EXPECT().GetAccountMember(gomock.Any(), gomock.Eq(approverArg)).Times(1).
+					Return(randomAccountMember(account, approver.Username, db.AccountCoOwner, 0), nil)
+				store.EXPECT().DecidePendingTransferTx(gomock.Any(), gomock.Any()).Times(1).
+					Return(db.DecidePendingTransferTxResult{PendingTransfer: rejected}, db.ErrSenderNotAllowed)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusForbidden, recorder.Code)
+			},
+		},
+		{
+			name:     "RemovedApprover",
+			username: approver.Username,
+			method:   http.MethodPost,
+			url:      fmt.Sprintf("/pending-transfers/%d/approve", pending.ID),
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetPendingTransfer(gomock.Any(), gomock.Eq(pending.ID)).Times(1).Return(pending, nil)
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Eq(account.ID)).Times(1).Return(account, nil)
+				store.EXPECT().GetAccountMember(gomock.Any(), gomock.Eq(approverArg)).Times(1).Return(db.AccountMember{}, db.ErrRecordNotFound)
+				store.EXPECT().DecidePendingTransferTx(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusUnauthorized, recorder.Code)
+			},
+		},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			store := mockdb.NewMockStore(ctrl)
+			tc.buildStubs(store)
+
+			server := newTestServer(t, store)
+			recorder := httptest.NewRecorder()
+
+			var body bytes.Buffer
+			if tc.body != nil {
+				require.NoError(t, json.NewEncoder(&body).Encode(tc.body))
+			}
+
+			request, err := http.NewRequest(tc.method, tc.url, &body)
+			require.NoError(t, err)
+
+			addAuthorization(t, request, server.tokenMaker, authorizationTypeBearer, tc.username, util.DepositorRole, time.Minute)
+			server.router.ServeHTTP(recorder, request)
+			tc.checkResponse(recorder)
+		})
+	}
+}
@@ -0,0 +1,255 @@
+package api
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/XiaozhouCui/go-bank/audit"
+	db "github.com/XiaozhouCui/go-bank/db/sqlc"
+	"github.com/XiaozhouCui/go-bank/token"
+	"github.com/gin-gonic/gin"
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+const (
+	auditKey    = "audit"
+	adminPrefix = "/admin"
+)
+
+// routes that change nothing, though their method is not GET
+var unauditedActions = map[string]bool{
+	"POST /users/login":     true,
+	"POST /transfers/quote": true,
+}
+
+// auditRecord is what the handler of a request tells the audit middleware about its target
+type auditRecord struct {
+	targetType string
+	targetID   string
+	before     interface{}
+}
+
+// attempts to append an entry to the audit log, every writer waits for the lock of the log
+const auditLogAttempts = 3
+
+// auditWriter holds the response back until the request is audited, and keeps its body,
+// the state of the target after the request
+type auditWriter struct {
+	gin.ResponseWriter
+	body bytes.Buffer
+}
+
+func (writer *auditWriter) Write(data []byte) (int, error) {
+	return writer.body.Write(data)
+}
+
+func (writer *auditWriter) WriteString(s string) (int, error) {
+	return writer.body.WriteString(s)
+}
+
+// audit middleware appends every successful request that is not a GET to the audit log, after its handler,
+// with the JSON response as the state of the target after it. Reads of the admin routes are audited too,
+// without their response: staff looking at customer data is on record.
+// The target is the one of the route, like the account of /accounts/:id/close, unless the handler
+// names it with auditTarget. Handlers changing an existing target give its prior state with auditBefore.
+// The response is only sent once the request is audited: if the audit log cannot be written,
+// the client gets a 500 saying the request may have been processed, and the failure is logged.
+func (server *Server) auditMiddleware() gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		action := ctx.Request.Method + " " + ctx.FullPath()
+		read := ctx.Request.Method == http.MethodGet
+		if (read && !strings.HasPrefix(ctx.FullPath(), adminPrefix)) || ctx.FullPath() == "" || unauditedActions[action] {
+			ctx.Next()
+			return
+		}
+
+		writer := &auditWriter{ResponseWriter: ctx.Writer}
+		ctx.Writer = writer
+		record := &auditRecord{}
+		ctx.Set(auditKey, record)
+		ctx.Next()
+		ctx.Writer = writer.ResponseWriter
+
+		status := writer.Status()
+		if status < 200 || status >= 300 {
+			writer.ResponseWriter.Write(writer.body.Bytes())
+			return
+		}
+
+		var after []byte
+		if !read {
+			after = writer.body.Bytes()
+		}
+		arg, err := newAuditLogParams(ctx, action, record, after)
+		if err == nil {
+			err = server.addAuditLog(ctx, arg)
+		}
+		if err != nil {
+			log.Printf("cannot write the audit log of %s request %s: %v", action, ctx.GetString(requestIDKey), err)
+			// a response without a body may be sent already
+			if !writer.Written() {
+				err = fmt.Errorf("request %s could not be audited, it may have been processed: %w", ctx.GetString(requestIDKey), err)
+				ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+				return
+			}
+		}
+
+		writer.ResponseWriter.Write(writer.body.Bytes())
+	}
+}
+
+// addAuditLog appends an entry to the audit log, trying again if it fails
+func (server *Server) addAuditLog(ctx *gin.Context, arg db.AddAuditLogTxParams) error {
+	var err error
+	for attempt := 1; attempt <= auditLogAttempts; attempt++ {
+		if _, err = server.store.AddAuditLogTx(ctx, arg); err == nil {
+			return nil
+		}
+	}
+	return err
+}
+
+func newAuditLogParams(ctx *gin.Context, action string, record *auditRecord, body []byte) (db.AddAuditLogTxParams, error) {
+	arg := db.AddAuditLogTxParams{
+		Action:     action,
+		TargetType: record.targetType,
+		TargetID:   record.targetID,
+		RequestID:  ctx.GetString(requestIDKey),
+		ClientIp:   ctx.ClientIP(),
+		Status:     int64(ctx.Writer.Status()),
+	}
+	if payload, ok := ctx.Get(authorizationPayloadKey); ok {
+		arg.Actor = payload.(*token.Payload).Username
+		arg.ActorRole = payload.(*token.Payload).Role
+	}
+	if arg.TargetType == "" {
+		path := strings.TrimPrefix(ctx.FullPath(), adminPrefix)
+		arg.TargetType = strings.Split(strings.TrimPrefix(path, "/"), "/")[0]
+		arg.TargetID = ctx.Param("id")
+		if arg.TargetID == "" {
+			arg.TargetID = ctx.Param("username")
+		}
+	}
+
+	if record.before != nil {
+		before, err := json.Marshal(record.before)
+		if err != nil {
+			return arg, err
+		}
+		arg.Before = before
+	}
+	if json.Valid(body) {
+		arg.After = body
+	}
+	return arg, nil
+}
+
+// auditTarget names the target of the request in the audit log, for handlers creating it
+func auditTarget(ctx *gin.Context, targetType string, targetID interface{}) {
+	if record, ok := ctx.Value(auditKey).(*auditRecord); ok {
+		record.targetType = targetType
+		record.targetID = fmt.Sprint(targetID)
+	}
+}
+
+// auditBefore records the state of the target before the request in the audit log, for handlers changing it
+func auditBefore(ctx *gin.Context, before interface{}) {
+	if record, ok := ctx.Value(auditKey).(*auditRecord); ok {
+		record.before = before
+	}
+}
+
+// auditLogResponse is an audit log entry with its snapshots as JSON
+type auditLogResponse struct {
+	ID         int64           `json:"id"`
+	Actor      string          `json:"actor"`
+	ActorRole  string          `json:"actor_role"`
+	Action     string          `json:"action"`
+	TargetType string          `json:"target_type"`
+	TargetID   string          `json:"target_id"`
+	RequestID  string          `json:"request_id"`
+	ClientIp   string          `json:"client_ip"`
+	Status     int64           `json:"status"`
+	Before     json.RawMessage `json:"before"`
+	After      json.RawMessage `json:"after"`
+	CreatedAt  time.Time       `json:"created_at"`
+	PrevHash   string          `json:"prev_hash"`
+	Hash       string          `json:"hash"`
+}
+
+func newAuditLogResponse(entry db.AuditLog) auditLogResponse {
+	return auditLogResponse{
+		ID:         entry.ID,
+		Actor:      entry.Actor,
+		ActorRole:  entry.ActorRole,
+		Action:     entry.Action,
+		TargetType: entry.TargetType,
+		TargetID:   entry.TargetID,
+		RequestID:  entry.RequestID,
+		ClientIp:   entry.ClientIp,
+		Status:     entry.Status,
+		Before:     entry.Before,
+		After:      entry.After,
+		CreatedAt:  entry.CreatedAt,
+		PrevHash:   entry.PrevHash,
+		Hash:       entry.Hash,
+	}
+}
+
+type listAuditLogRequest struct {
+	Actor      string    `form:"actor"`
+	Action     string    `form:"action"`
+	TargetType string    `form:"target_type"`
+	TargetID   string    `form:"target_id"`
+	Since      time.Time `form:"since" time_format:"2006-01-02T15:04:05Z07:00"`
+	Until      time.Time `form:"until" time_format:"2006-01-02T15:04:05Z07:00"`
+	PageID     int32     `form:"page_id" binding:"required,min=1"`
+	PageSize   int32     `form:"page_size" binding:"required,min=5,max=10"`
+}
+
+// listAuditLog lists the audit log for staff, most recent first. Every filter is optional,
+// since and until are RFC 3339 times
+func (server *Server) listAuditLog(ctx *gin.Context) {
+	var req listAuditLogRequest
+	if err := ctx.ShouldBindQuery(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+
+	entries, err := server.store.ListAuditLog(ctx, db.ListAuditLogParams{
+		Actor:      pgtype.Text{String: req.Actor, Valid: req.Actor != ""},
+		Action:     pgtype.Text{String: req.Action, Valid: req.Action != ""},
+		TargetType: pgtype.Text{String: req.TargetType, Valid: req.TargetType != ""},
+		TargetID:   pgtype.Text{String: req.TargetID, Valid: req.TargetID != ""},
+		Since:      pgtype.Timestamptz{Time: req.Since, Valid: !req.Since.IsZero()},
+		Until:      pgtype.Timestamptz{Time: req.Until, Valid: !req.Until.IsZero()},
+		Limit:      req.PageSize,
+		Offset:     (req.PageID - 1) * req.PageSize,
+	})
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+
+	rsp := make([]auditLogResponse, len(entries))
+	for i, entry := range entries {
+		rsp[i] = newAuditLogResponse(entry)
+	}
+	ctx.JSON(http.StatusOK, rsp)
+}
+
+// verifyAuditLog recomputes the hash chain of the audit log, and reports the first entry that breaks it
+func (server *Server) verifyAuditLog(ctx *gin.Context) {
+	report, err := audit.Verify(ctx, server.store)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+
+	ctx.JSON(http.StatusOK, report)
+}
@@ -0,0 +1,445 @@
+package api
+
+import (
+	"bytes"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+	"time"
+
+	mockdb "github.com/XiaozhouCui/go-bank/db/mock"
+	db "github.com/XiaozhouCui/go-bank/db/sqlc"
+	"github.com/XiaozhouCui/go-bank/db/util"
+	"github.com/XiaozhouCui/go-bank/ledger"
+	"github.com/XiaozhouCui/go-bank/stream"
+	"github.com/XiaozhouCui/go-bank/token"
+	"github.com/gin-gonic/gin"
+	"github.com/golang/mock/gomock"
+	"github.com/jackc/pgx/v5/pgtype"
+	"github.com/stretchr/testify/require"
+)
+
+// newAuditTestServer is newTestServer without the catch-all audit log stub, to check the calls to it
+func newAuditTestServer(t *testing.T, store db.Store) *Server {
+	config := util.Config{
+		TokenSymmetricKey:   util.RandomString(32),
+		AccessTokenDuration: time.Minute,
+	}
+
+	server, err := NewServer(config, store, ledger.NewVerifier(store), stream.NewHub())
+	require.NoError(t, err)
+
+	return server
+}
+
+func TestAuditMiddleware(t *testing.T) {
+	user, _ := randomUser(t)
+	account := randomAccount(user.Username)
+	account.Balance = 0
+
+	closedAccount := account
+	closedAccount.Status = db.AccountStatusClosed
+
+	requestID := "req-" + util.RandomString(8)
+
+	testCases := []struct {
+		name          string
+		method        string
+		url           string
+		body          gin.H
+		setupRequest  func(t *testing.T, request *http.Request, tokenMaker token.Maker)
+		buildStubs    func(store *mockdb.MockStore)
+		checkResponse func(recorder *httptest.ResponseRecorder)
+	}{
+		{
+			name:   "OK",
+			method: http.MethodPost,
+			url:    fmt.Sprintf("/accounts/%d/close", account.ID),
+			body:   gin.H{"reason": "moving to another bank"},
+			setupRequest: func(t *testing.T, request *http.Request, tokenMaker token.Maker) {
+				addAuthorization(t, request, tokenMaker, authorizationTypeBearer, user.Username, util.DepositorRole, time.Minute)
+				request.Header.Set(requestIDHeaderKey, requestID)
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Eq(account.ID)).Times(1).Return(account, nil)
+				store.EXPECT().
+					UpdateAccountStatusTx(gomock.Any(), gomock.Any()).
+					Times(1).
+					Return(db.UpdateAccountStatusTxResult{Account: closedAccount}, nil)
+				store.EXPECT().
+					AddAuditLogTx(gomock.Any(), gomock.Any()).
+					Times(1).
+					DoAndReturn(func(_ interface{}, arg db.AddAuditLogTxParams) (db.AuditLog, error) {
+						require.Equal(t, user.Username, arg.Actor)
+						require.Equal(t, util.DepositorRole, arg.ActorRole)
+						require.Equal(t, "POST /accounts/:id/close", arg.Action)
+						require.Equal(t, "accounts", arg.TargetType)
+						require.Equal(t, fmt.Sprint(account.ID), arg.TargetID)
+						require.Equal(t, requestID, arg.RequestID)
+						require.Equal(t, int64(http.StatusOK), arg.Status)
+
+						var before db.Account
+						require.NoError(t, json.Unmarshal(arg.Before, &before))
+						require.Equal(t, db.AccountStatusActive, before.Status)
+
+						var after db.UpdateAccountStatusTxResult
+						require.NoError(t, json.Unmarshal(arg.After, &after))
+						require.Equal(t, db.AccountStatusClosed, after.Account.Status)
+						return db.AuditLog{}, nil
+					})
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+				require.Equal(t, requestID, recorder.Header().Get(requestIDHeaderKey))
+			},
+		},
+		{
+			name:   "NewRequestID",
+			method: http.MethodPost,
+			url:    fmt.Sprintf("/accounts/%d/close", account.ID),
+			body:   gin.H{"reason": "moving to another bank"},
+			setupRequest: func(t *testing.T, request *http.Request, tokenMaker token.Maker) {
+				addAuthorization(t, request, tokenMaker, authorizationTypeBearer, user.Username, util.DepositorRole, time.Minute)
+				request.Header.Set(requestIDHeaderKey, "not a valid id")
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Eq(account.ID)).Times(1).Return(account, nil)
+				store.EXPECT().
+					UpdateAccountStatusTx(gomock.Any(), gomock.Any()).
+					Times(1).
+					Return(db.UpdateAccountStatusTxResult{Account: closedAccount}, nil)
+				store.EXPECT().
+					AddAuditLogTx(gomock.Any(), gomock.Any()).
+					Times(1).
+					DoAndReturn(func(_ interface{}, arg db.AddAuditLogTxParams) (db.AuditLog, error) {
+						require.NotEqual(t, "not a valid id", arg.RequestID)
+						require.Regexp(t, validRequestID, arg.RequestID)
+						return db.AuditLog{}, nil
+					})
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+				require.NotEqual(t, "not a valid id", recorder.Header().Get(requestIDHeaderKey))
+			},
+		},
+		{
+			name:   "FailedRequest",
+			method: http.MethodPost,
+			url:    fmt.Sprintf("/accounts/%d/close", account.ID),
+			body:   gin.H{"reason": "moving to another bank"},
+			setupRequest: func(t *testing.T, request *http.Request, tokenMaker token.Maker) {
+				addAuthorization(t, request, tokenMaker, authorizationTypeBearer, "unauthorized_user", util.DepositorRole, time.Minute)
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Eq(account.ID)).Times(1).Return(account, nil)
+				store.EXPECT().AddAuditLogTx(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusUnauthorized, recorder.Code)
+			},
+		},
+		{
+			name:   "ReadOnlyRequest",
+			method: http.MethodGet,
+			url:    fmt.Sprintf("/accounts/%d", account.ID),
+			setupRequest: func(t *testing.T, request *http.Request, tokenMaker token.Maker) {
+				addAuthorization(t, request, tokenMaker, authorizationTypeBearer, user.Username, util.DepositorRole, time.Minute)
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Eq(account.ID)).Times(1).Return(account, nil)
+				store.EXPECT().AddAuditLogTx(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+			},
+		},
+		{
+			name:   "AdminRead",
+			method: http.MethodGet,
+			url:    fmt.Sprintf("/admin/accounts/%d", account.ID),
+			setupRequest: func(t *testing.T, request *http.Request, tokenMaker token.Maker) {
+				addAuthorization(t, request, tokenMaker, authorizationTypeBearer, "helpdesk", util.SupportRole, time.Minute)
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Eq(account.ID)).Times(1).Return(account, nil)
+				store.EXPECT().
+					AddAuditLogTx(gomock.Any(), gomock.Any()).
+					Times(1).
+					DoAndReturn(func(_ interface{}, arg db.AddAuditLogTxParams) (db.AuditLog, error) {
+						require.Equal(t, "helpdesk", arg.Actor)
+						require.Equal(t, "GET /admin/accounts/:id", arg.Action)
+						require.Equal(t, "accounts", arg.TargetType)
+						require.Equal(t, fmt.Sprint(account.ID), arg.TargetID)
+						// the customer data that was read is not copied to the audit log
+						require.Nil(t, arg.After)
+						return db.AuditLog{}, nil
+					})
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+			},
+		},
+		{
+			name:   "AuditLogError",
+			method: http.MethodPost,
+			url:    fmt.Sprintf("/accounts/%d/close", account.ID),
+			body:   gin.H{"reason": "moving to another bank"},
+			setupRequest: func(t *testing.T, request *http.Request, tokenMaker token.Maker) {
+				addAuthorization(t, request, tokenMaker, authorizationTypeBearer, user.Username, util.DepositorRole, time.Minute)
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Eq(account.ID)).Times(1).Return(account, nil)
+				store.EXPECT().
+					UpdateAccountStatusTx(gomock.Any(), gomock.Any()).
+					Times(1).
+					Return(db.UpdateAccountStatusTxResult{Account: closedAccount}, nil)
+				store.EXPECT().AddAuditLogTx(gomock.Any(), gomock.Any()).Times(auditLogAttempts).Return(db.AuditLog{}, sql.ErrConnDone)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				// the account is closed already, but the client is not told it is until it is on record
+				require.Equal(t, http.StatusInternalServerError, recorder.Code)
+				require.Contains(t, recorder.Body.String(), "could not be audited")
+				require.NotContains(t, recorder.Body.String(), `"account"`)
+			},
+		},
+		{
+			name:   "AuditLogRetried",
+			method: http.MethodPost,
+			url:    fmt.Sprintf("/accounts/%d/close", account.ID),
+			body:   gin.H{"reason": "moving to another bank"},
+			setupRequest: func(t *testing.T, request *http.Request, tokenMaker token.Maker) {
+				addAuthorization(t, request, tokenMaker, authorizationTypeBearer, user.Username, util.DepositorRole, time.Minute)
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Eq(account.ID)).Times(1).Return(account, nil)
+				store.EXPECT().
+					UpdateAccountStatusTx(gomock.Any(), gomock.Any()).
+					Times(1).
+					Return(db.UpdateAccountStatusTxResult{Account: closedAccount}, nil)
+				gomock.InOrder(
+					store.EXPECT().AddAuditLogTx(gomock.Any(), gomock.Any()).Times(1).Return(db.AuditLog{}, sql.ErrConnDone),
+					store.EXPECT().AddAuditLogTx(gomock.Any(), gomock.Any()).Times(1).Return(db.AuditLog{}, nil),
+				)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+
+				var rsp db.UpdateAccountStatusTxResult
+				require.NoError(t, json.Unmarshal(recorder.Body.Bytes(), &rsp))
+				require.Equal(t, db.AccountStatusClosed, rsp.Account.Status)
+			},
+		},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			store := mockdb.NewMockStore(ctrl)
+			tc.buildStubs(store)
+
+			server := newAuditTestServer(t, store)
+			recorder := httptest.NewRecorder()
+
+			var body bytes.Buffer
+			if tc.body != nil {
+				require.NoError(t, json.NewEncoder(&body).Encode(tc.body))
+			}
+			request, err := http.NewRequest(tc.method, tc.url, &body)
+			require.NoError(t, err)
+
+			tc.setupRequest(t, request, server.tokenMaker)
+			server.router.ServeHTTP(recorder, request)
+			tc.checkResponse(recorder)
+		})
+	}
+}
+
+func TestListAuditLogAPI(t *testing.T) {
+	staff := "banker"
+	since := time.Now().Add(-time.Hour).Truncate(time.Second)
+
+	entries := []db.AuditLog{
+		{
+			ID:         2,
+			Actor:      util.RandomOwner(),
+			ActorRole:  util.DepositorRole,
+			Action:     "POST /accounts",
+			TargetType: "accounts",
+			TargetID:   "7",
+			After:      []byte(`{"id":7}`),
+			CreatedAt:  time.Now(),
+			Hash:       util.RandomString(64),
+		},
+	}
+
+	testCases := []struct {
+		name          string
+		query         url.Values
+		setupAuth     func(t *testing.T, request *http.Request, tokenMaker token.Maker)
+		buildStubs    func(store *mockdb.MockStore)
+		checkResponse func(recorder *httptest.ResponseRecorder)
+	}{
+		{
+			name: "OK",
+			query: url.Values{
+				"action":    {"POST /accounts"},
+				"since":     {since.Format(time.RFC3339)},
+				"page_id":   {"1"},
+				"page_size": {"5"},
+			},
+			setupAuth: func(t *testing.T, request *http.Request, tokenMaker token.Maker) {
+				addAuthorization(t, request, tokenMaker, authorizationTypeBearer, staff, util.BankerRole, time.Minute)
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				arg := db.ListAuditLogParams{
+					Action: pgtype.Text{String: "POST /accounts", Valid: true},
+					Since:  pgtype.Timestamptz{Time: since, Valid: true},
+					Limit:  5,
+					Offset: 0,
+				}
+				store.EXPECT().
+					ListAuditLog(gomock.Any(), gomock.Any()).
+					Times(1).
+					DoAndReturn(func(_ interface{}, got db.ListAuditLogParams) ([]db.AuditLog, error) {
+						require.True(t, got.Since.Time.Equal(arg.Since.Time))
+						got.Since.Time = arg.Since.Time
+						require.Equal(t, arg, got)
+						return entries, nil
+					})
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+
+				var rsp []auditLogResponse
+				require.NoError(t, json.Unmarshal(recorder.Body.Bytes(), &rsp))
+				require.Len(t, rsp, 1)
+				require.Equal(t, entries[0].Hash, rsp[0].Hash)
+				require.JSONEq(t, `{"id":7}`, string(rsp[0].After))
+				require.Equal(t, "null", string(rsp[0].Before))
+			},
+		},
+		{
+			name:  "Forbidden",
+			query: url.Values{"page_id": {"1"}, "page_size": {"5"}},
+			setupAuth: func(t *testing.T, request *http.Request, tokenMaker token.Maker) {
+				addAuthorization(t, request, tokenMaker, authorizationTypeBearer, "customer", util.DepositorRole, time.Minute)
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().ListAuditLog(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusForbidden, recorder.Code)
+			},
+		},
+		{
+			name:  "InvalidSince",
+			query: url.Values{"since": {"yesterday"}, "page_id": {"1"}, "page_size": {"5"}},
+			setupAuth: func(t *testing.T, request *http.Request, tokenMaker token.Maker) {
+				addAuthorization(t, request, tokenMaker, authorizationTypeBearer, staff, util.BankerRole, time.Minute)
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().ListAuditLog(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusBadRequest, recorder.Code)
+			},
+		},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			store := mockdb.NewMockStore(ctrl)
+			tc.buildStubs(store)
+
+			server := newTestServer(t, store)
+			recorder := httptest.NewRecorder()
+
+			request, err := http.NewRequest(http.MethodGet, "/audit-log?"+tc.query.Encode(), nil)
+			require.NoError(t, err)
+
+			tc.setupAuth(t, request, server.tokenMaker)
+			server.router.ServeHTTP(recorder, request)
+			tc.checkResponse(recorder)
+		})
+	}
+}
+
+func TestVerifyAuditLogAPI(t *testing.T) {
+	first := db.AuditLog{ID: 1, Actor: util.RandomOwner(), Action: "POST /users", CreatedAt: time.Now().UTC()}
+	hash, err := db.AuditLogHash(first)
+	require.NoError(t, err)
+	first.Hash = hash
+
+	second := db.AuditLog{ID: 2, Actor: util.RandomOwner(), Action: "POST /accounts", CreatedAt: time.Now().UTC(), PrevHash: first.Hash}
+	hash, err = db.AuditLogHash(second)
+	require.NoError(t, err)
+	second.Hash = hash
+
+	tampered := second
+	tampered.Actor = util.RandomOwner()
+
+	testCases := []struct {
+		name          string
+		entries       []db.AuditLog
+		checkResponse func(recorder *httptest.ResponseRecorder)
+	}{
+		{
+			name:    "OK",
+			entries: []db.AuditLog{first, second},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+				require.JSONEq(t, `{"ok":true,"entries":2}`, recorder.Body.String())
+			},
+		},
+		{
+			name:    "Tampered",
+			entries: []db.AuditLog{first, tampered},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+
+				var rsp struct {
+					OK       bool  `json:"ok"`
+					BrokenAt int64 `json:"broken_at"`
+				}
+				require.NoError(t, json.Unmarshal(recorder.Body.Bytes(), &rsp))
+				require.False(t, rsp.OK)
+				require.Equal(t, tampered.ID, rsp.BrokenAt)
+			},
+		},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			store := mockdb.NewMockStore(ctrl)
+			store.EXPECT().ListAuditLogAfter(gomock.Any(), gomock.Any()).Times(1).Return(tc.entries, nil)
+
+			server := newTestServer(t, store)
+			recorder := httptest.NewRecorder()
+
+			request, err := http.NewRequest(http.MethodGet, "/audit-log/verify", nil)
+			require.NoError(t, err)
+
+			addAuthorization(t, request, server.tokenMaker, authorizationTypeBearer, "banker", util.BankerRole, time.Minute)
+			server.router.ServeHTTP(recorder, request)
+			tc.checkResponse(recorder)
+		})
+	}
+}
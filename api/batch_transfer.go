@@ -0,0 +1,203 @@
+package api
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+
+	db "github.com/XiaozhouCui/go-bank/db/sqlc"
+	"github.com/XiaozhouCui/go-bank/token"
+	"github.com/gin-gonic/gin"
+)
+
+// outcomes of a leg of a batch transfer
+const (
+	legSucceeded  = "succeeded"
+	legFailed     = "failed"
+	legRolledBack = "rolled_back" // valid, but not made because another leg failed
+)
+
+type batchTransferRequest struct {
+	Legs []transferRequest `json:"legs" binding:"required,min=1,max=1000,dive"`
+	// commit the legs that succeed and report the ones that fail, instead of all or nothing
+	BestEffort bool `json:"best_effort"`
+}
+
+type batchTransferLegResponse struct {
+	Index  int                  `json:"index"`
+	Status string               `json:"status"`
+	Error  string               `json:"error,omitempty"`
+	Result *db.TransferTxResult `json:"result,omitempty"`
+}
+
+type batchTransferResponse struct {
+	BestEffort bool                       `json:"best_effort"`
+	Succeeded  int                        `json:"succeeded"`
+	Failed     int                        `json:"failed"`
+	Legs       []batchTransferLegResponse `json:"legs"`
+}
+
+// createBatchTransfer moves money from the current user's accounts to many recipients in a single db transaction,
+// e.g. a payroll. Each leg is validated like a single transfer.
+func (server *Server) createBatchTransfer(ctx *gin.Context) {
+	var req batchTransferRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+
+	authPayload := ctx.MustGet(authorizationPayloadKey).(*token.Payload)
+
+	rsp := batchTransferResponse{
+		BestEffort: req.BestEffort,
+		Legs:       make([]batchTransferLegResponse, len(req.Legs)),
+	}
+
+	// the accounts are loaded once, a payroll sends every leg from the same account
+	accounts := make(map[int64]db.Account)
+	failedStatus := 0
+	arg := db.BatchTransferTxParams{BestEffort: req.BestEffort}
+	indexes := make([]int, 0, len(req.Legs)) // index in the request of each transfer sent to the store
+	sent := make(map[int64]int64)            // amount of the legs sent to the store, by to account
+	spent := make(map[int64]int64)           // amount of the legs sent to the store, by from account
+
+	now := time.Now()
+	for i, leg := range req.Legs {
+		rsp.Legs[i].Index = i
+
+		leg, status, err := server.recipientTransfer(ctx, sanitizeTransfer(leg), authPayload.Username)
+		if err == nil {
+			// the legs before it in the batch count towards the spend limit of a member
+			status, err = server.checkBatchLeg(ctx, accounts, leg, authPayload.Username, spent[leg.FromAccountID])
+		}
+		if err == nil {
+			// the legs before it in the batch count towards the cooling-off amount of a payee
+			status, err = server.checkCoolingOff(ctx, authPayload.Username, leg.ToAccountID, sent[leg.ToAccountID]+leg.Amount, now)
+		}
+		if err != nil {
+			if status == http.StatusInternalServerError {
+				ctx.JSON(status, errorResponse(err))
+				return
+			}
+			rsp.Legs[i].Status = legFailed
+			rsp.Legs[i].Error = err.Error()
+			if failedStatus == 0 {
+				failedStatus = status
+			}
+			continue
+		}
+
+		arg.Transfers = append(arg.Transfers, db.TransferTxParams{
+			FromAccountID: leg.FromAccountID,
+			ToAccountID:   leg.ToAccountID,
+			Amount:        leg.Amount,
+			Description:   leg.Description,
+			Reference:     leg.Reference,
+			Tags:          leg.Tags,
+			SentBy:        authPayload.Username,
+		})
+		indexes = append(indexes, i)
+		sent[leg.ToAccountID] += leg.Amount
+		spent[leg.FromAccountID] += leg.Amount
+	}
+
+	// all or nothing: an invalid leg rejects the whole batch before touching the db
+	if failedStatus != 0 && !req.BestEffort {
+		for i := range rsp.Legs {
+			if rsp.Legs[i].Status == "" {
+				rsp.Legs[i].Status = legRolledBack
+			}
+		}
+		rsp.Failed = len(req.Legs) - len(indexes)
+		ctx.JSON(failedStatus, rsp)
+		return
+	}
+
+	if len(arg.Transfers) > 0 {
+		result, err := server.store.BatchTransferTx(ctx, arg)
+		if err != nil && (req.BestEffort || transferErrorStatus(err) == http.StatusInternalServerError) {
+			ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+			return
+		}
+
+		for j, leg := range result.Legs {
+			legRsp := &rsp.Legs[indexes[j]]
+			switch {
+			case leg.Err != nil:
+				legRsp.Status = legFailed
+				legRsp.Error = leg.Err.Error()
+			case err != nil:
+				legRsp.Status = legRolledBack
+			default:
+				legRsp.Status = legSucceeded
+				legRsp.Result = &result.Legs[j].Result
+			}
+		}
+
+		if err != nil {
+			failedStatus = transferErrorStatus(err)
+		}
+	}
+
+	for _, leg := range rsp.Legs {
+		if leg.Status == legSucceeded {
+			rsp.Succeeded++
+		} else if leg.Status == legFailed {
+			rsp.Failed++
+		}
+	}
+
+	if failedStatus != 0 && !req.BestEffort {
+		ctx.JSON(failedStatus, rsp)
+		return
+	}
+	ctx.JSON(http.StatusOK, rsp)
+}
+
+// checkBatchLeg validates a leg of a batch like validTransfer, without answering the request,
+// and returns the status code a single transfer would get if the leg is invalid.
+// spent is the amount of the earlier legs of the batch from the same account
+func (server *Server) checkBatchLeg(ctx *gin.Context, accounts map[int64]db.Account, leg transferRequest, username string, spent int64) (int, error) {
+	fromAccount, status, err := server.batchAccount(ctx, accounts, leg.FromAccountID)
+	if err != nil {
+		return status, err
+	}
+	member, status, err := server.checkAccountAccess(ctx, fromAccount, username, db.AccountRoleCanSpend)
+	if err != nil {
+		return status, err
+	}
+	if status, err := server.checkSpendLimit(ctx, member, spent, leg.Amount); err != nil {
+		return status, err
+	}
+	if status, err := checkTransferAccount(fromAccount, leg.Currency); err != nil {
+		return status, err
+	}
+	if status, err := server.checkNoApproval(ctx, fromAccount.ID, leg.Amount); err != nil {
+		return status, err
+	}
+
+	toAccount, status, err := server.batchAccount(ctx, accounts, leg.ToAccountID)
+	if err != nil {
+		return status, err
+	}
+	return checkTransferAccount(toAccount, leg.Currency)
+}
+
+// batchAccount gets an account of a batch, each account is only loaded once
+func (server *Server) batchAccount(ctx *gin.Context, accounts map[int64]db.Account, id int64) (db.Account, int, error) {
+	if account, ok := accounts[id]; ok {
+		return account, http.StatusOK, nil
+	}
+
+	account, err := server.store.GetAccount(ctx, id)
+	if err != nil {
+		if errors.Is(err, db.ErrRecordNotFound) {
+			return account, http.StatusNotFound, fmt.Errorf("account [%d]: %w", id, err)
+		}
+		return account, http.StatusInternalServerError, err
+	}
+
+	accounts[id] = account
+	return account, http.StatusOK, nil
+}
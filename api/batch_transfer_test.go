@@ -0,0 +1,261 @@
+package api
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	mockdb "github.com/XiaozhouCui/go-bank/db/mock"
+	db "github.com/XiaozhouCui/go-bank/db/sqlc"
+	"github.com/XiaozhouCui/go-bank/db/util"
+	"github.com/XiaozhouCui/go-bank/token"
+	"github.com/gin-gonic/gin"
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/require"
+)
+
+func TestBatchTransferAPI(t *testing.T) {
+	user1, _ := randomUser(t)
+	user2, _ := randomUser(t)
+
+	payer := randomAccount(user1.Username)
+	payee1 := randomAccount(user2.Username)
+	payee2 := randomAccount(user2.Username)
+	payer.Currency = util.USD
+	payee1.Currency = util.USD
+	payee2.Currency = util.EUR
+
+	leg1 := gin.H{"from_account_id": payer.ID, "to_account_id": payee1.ID, "amount": 10, "currency": util.USD}
+	// payee2 is in another currency
+	leg2 := gin.H{"from_account_id": payer.ID, "to_account_id": payee2.ID, "amount": 20, "currency": util.USD}
+
+	getAccounts := func(store *mockdb.MockStore) {
+		store.EXPECT().GetAccount(gomock.Any(), gomock.Eq(payer.ID)).Times(1).Return(payer, nil)
+		store.EXPECT().GetAccount(gomock.Any(), gomock.Eq(payee1.ID)).Times(1).Return(payee1, nil)
+		store.EXPECT().GetAccount(gomock.Any(), gomock.Eq(payee2.ID)).Times(1).Return(payee2, nil)
+	}
+
+	testCases := []struct {
+		name          string
+		body          gin.H
+		setupAuth     func(t *testing.T, request *http.Request, tokenMaker token.Maker)
+		buildStubs    func(store *mockdb.MockStore)
+		checkResponse func(recoder *httptest.ResponseRecorder)
+	}{
+		{
+			name: "OK",
+			body: gin.H{"legs": []gin.H{leg1, leg1}},
+			setupAuth: func(t *testing.T, request *http.Request, tokenMaker token.Maker) {
+				addAuthorization(t, request, tokenMaker, authorizationTypeBearer, user1.Username, util.DepositorRole, time.Minute)
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				// each account is loaded once
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Eq(payer.ID)).Times(1).Return(payer, nil)
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Eq(payee1.ID)).Times(1).Return(payee1, nil)
+
+				transfer := db.TransferTxParams{FromAccountID: payer.ID, ToAccountID: payee1.ID, Amount: 10, SentBy: user1.Username}
+				arg := db.BatchTransferTxParams{Transfers: []db.TransferTxParams{transfer, transfer}}
+				store.EXPECT().
+					BatchTransferTx(gomock.Any(), gomock.Eq(arg)).
+					Times(1).
+					Return(db.BatchTransferTxResult{Legs: make([]db.BatchTransferLeg, 2)}, nil)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+
+				rsp := requireBodyBatchTransfer(t, recorder.Body)
+				require.Equal(t, 2, rsp.Succeeded)
+				require.Zero(t, rsp.Failed)
+			},
+		},
+		{
+			name: "InvalidLegRejectsBatch",
+			body: gin.H{"legs": []gin.H{leg1, leg2}},
+			setupAuth: func(t *testing.T, request *http.Request, tokenMaker token.Maker) {
+				addAuthorization(t, request, tokenMaker, authorizationTypeBearer, user1.Username, util.DepositorRole, time.Minute)
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				getAccounts(store)
+				store.EXPECT().BatchTransferTx(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusBadRequest, recorder.Code)
+
+				rsp := requireBodyBatchTransfer(t, recorder.Body)
+				require.Equal(t, legRolledBack, rsp.Legs[0].Status)
+				require.Equal(t, legFailed, rsp.Legs[1].Status)
+				require.NotEmpty(t, rsp.Legs[1].Error)
+			},
+		},
+		{
+			name: "BestEffort",
+			body: gin.H{"legs": []gin.H{leg1, leg2}, "best_effort": true},
+			setupAuth: func(t *testing.T, request *http.Request, tokenMaker token.Maker) {
+				addAuthorization(t, request, tokenMaker, authorizationTypeBearer, user1.Username, util.DepositorRole, time.Minute)
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				getAccounts(store)
+
+				// only the valid leg reaches the store
+				arg := db.BatchTransferTxParams{
+					Transfers:  []db.TransferTxParams{{FromAccountID: payer.ID, ToAccountID: payee1.ID, Amount: 10, SentBy: user1.Username}},
+					BestEffort: true,
+				}
+				store.EXPECT().
+					BatchTransferTx(gomock.Any(), gomock.Eq(arg)).
+					Times(1).
+					Return(db.BatchTransferTxResult{Legs: make([]db.BatchTransferLeg, 1)}, nil)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+
+				rsp := requireBodyBatchTransfer(t, recorder.Body)
+				require.Equal(t, 1, rsp.Succeeded)
+				require.Equal(t, 1, rsp.Failed)
+				require.Equal(t, legSucceeded, rsp.Legs[0].Status)
+				require.Equal(t, legFailed, rsp.Legs[1].Status)
+			},
+		},
+		{
+			name: "TransferLimitExceeded",
+			body: gin.H{"legs": []gin.H{leg1, leg1}},
+			setupAuth: func(t *testing.T, request *http.Request, tokenMaker token.Maker) {
+				addAuthorization(t, request, tokenMaker, authorizationTypeBearer, user1.Username, util.DepositorRole, time.Minute)
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Eq(payer.ID)).Times(1).Return(payer, nil)
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Eq(payee1.ID)).Times(1).Return(payee1, nil)
+
+				legs := make([]db.BatchTransferLeg, 2)
+				legs[1].Err = db.ErrTransferLimitExceeded
+				store.EXPECT().
+					BatchTransferTx(gomock.Any(), gomock.Any()).
+					Times(1).
+					Return(db.BatchTransferTxResult{Legs: legs}, fmt.Errorf("transfer 1 of the batch: %w", db.ErrTransferLimitExceeded))
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusForbidden, recorder.Code)
+
+				rsp := requireBodyBatchTransfer(t, recorder.Body)
+				require.Zero(t, rsp.Succeeded)
+				require.Equal(t, legRolledBack, rsp.Legs[0].Status)
+				require.Equal(t, legFailed, rsp.Legs[1].Status)
+			},
+		},
+		{
+			name: "UnauthorizedUser",
+			body: gin.H{"legs": []gin.H{leg1}},
+			setupAuth: func(t *testing.T, request *http.Request, tokenMaker token.Maker) {
+				addAuthorization(t, request, tokenMaker, authorizationTypeBearer, user2.Username, util.DepositorRole, time.Minute)
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Eq(payer.ID)).Times(1).Return(payer, nil)
+				store.EXPECT().GetAccountMember(gomock.Any(), gomock.Any()).Times(1).Return(db.AccountMember{}, db.ErrRecordNotFound)
+				store.EXPECT().BatchTransferTx(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusUnauthorized, recorder.Code)
+			},
+		},
+		{
+			name: "NoLegs",
+			body: gin.H{"legs": []gin.H{}},
+			setupAuth: func(t *testing.T, request *http.Request, tokenMaker token.Maker) {
+				addAuthorization(t, request, tokenMaker, authorizationTypeBearer, user1.Username, util.DepositorRole, time.Minute)
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().BatchTransferTx(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusBadRequest, recorder.Code)
+			},
+		},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			store := mockdb.NewMockStore(ctrl)
+			tc.buildStubs(store)
+
+			server := newTestServer(t, store)
+			recorder := httptest.NewRecorder()
+
+			data, err := json.Marshal(tc.body)
+			require.NoError(t, err)
+
+			url := "/transfers/batch"
+			request, err := http.NewRequest(http.MethodPost, url, bytes.NewReader(data))
+			require.NoError(t, err)
+
+			tc.setupAuth(t, request, server.tokenMaker)
+			server.router.ServeHTTP(recorder, request)
+			tc.checkResponse(recorder)
+		})
+	}
+}
+
+func TestBatchTransferToPayeeCoolingOffAPI(t *testing.T) {
+	user1, _ := randomUser(t)
+	user2, _ := randomUser(t)
+
+	payer := randomAccount(user1.Username)
+	payeeAccount := randomAccount(user2.Username)
+	payer.Currency = util.USD
+	payeeAccount.Currency = util.USD
+
+	payee := randomPayee(user1.Username, payeeAccount)
+	payee.CreatedAt = time.Now().Add(-time.Minute)
+
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	store := mockdb.NewMockStore(ctrl)
+	store.EXPECT().GetAccount(gomock.Any(), gomock.Eq(payer.ID)).Times(1).Return(payer, nil)
+	store.EXPECT().GetAccount(gomock.Any(), gomock.Eq(payeeAccount.ID)).Times(1).Return(payeeAccount, nil)
+	store.EXPECT().GetPayeeByAccount(gomock.Any(), gomock.Any()).Times(2).Return(payee, nil)
+	store.EXPECT().SumPayeeTransfers(gomock.Any(), gomock.Any()).Times(2).Return(int64(0), nil)
+
+	// each leg is within the cooling-off amount, not both of them
+	arg := db.BatchTransferTxParams{
+		Transfers:  []db.TransferTxParams{{FromAccountID: payer.ID, ToAccountID: payeeAccount.ID, Amount: 600, SentBy: user1.Username}},
+		BestEffort: true,
+	}
+	store.EXPECT().
+		BatchTransferTx(gomock.Any(), gomock.Eq(arg)).
+		Times(1).
+		Return(db.BatchTransferTxResult{Legs: make([]db.BatchTransferLeg, 1)}, nil)
+
+	server := newPayeeTestServer(t, store)
+	recorder := httptest.NewRecorder()
+
+	leg := gin.H{"from_account_id": payer.ID, "to_account_id": payeeAccount.ID, "amount": 600, "currency": util.USD}
+	data, err := json.Marshal(gin.H{"legs": []gin.H{leg, leg}, "best_effort": true})
+	require.NoError(t, err)
+
+	request, err := http.NewRequest(http.MethodPost, "/transfers/batch", bytes.NewReader(data))
+	require.NoError(t, err)
+
+	addAuthorization(t, request, server.tokenMaker, authorizationTypeBearer, user1.Username, util.DepositorRole, time.Minute)
+	server.router.ServeHTTP(recorder, request)
+	require.Equal(t, http.StatusOK, recorder.Code)
+
+	rsp := requireBodyBatchTransfer(t, recorder.Body)
+	require.Equal(t, legSucceeded, rsp.Legs[0].Status)
+	require.Equal(t, legFailed, rsp.Legs[1].Status)
+}
+
+func requireBodyBatchTransfer(t *testing.T, body *bytes.Buffer) batchTransferResponse {
+	var rsp batchTransferResponse
+	err := json.NewDecoder(body).Decode(&rsp)
+	require.NoError(t, err)
+	return rsp
+}
@@ -0,0 +1,102 @@
+package api
+
+import (
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/XiaozhouCui/go-bank/camt"
+	"github.com/XiaozhouCui/go-bank/statement"
+	"github.com/gin-gonic/gin"
+)
+
+// booking days are calendar days in UTC
+const dayLayout = "2006-01-02"
+
+type getCamtStatementRequest struct {
+	Date string `form:"date" binding:"required,datetime=2006-01-02"`
+}
+
+// the owner or bank staff export the end of day statement of an account as ISO 20022 camt.053 XML,
+// for a day that has ended (e.g. /accounts/1/camt053?date=2023-01-31)
+func (server *Server) getCamtStatement(ctx *gin.Context) {
+	var uri getAccountRequest
+	if err := ctx.ShouldBindUri(&uri); err != nil {
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+	var req getCamtStatementRequest
+	if err := ctx.ShouldBindQuery(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+	start, err := time.Parse(dayLayout, req.Date)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+	end := start.AddDate(0, 0, 1)
+
+	account, ok := server.statementAccount(ctx, uri.ID)
+	if !ok {
+		return
+	}
+
+	now := time.Now()
+	if end.After(now) {
+		err := fmt.Errorf("the statement for %s is not available until the day has ended, use the intraday report", req.Date)
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+	if !end.After(account.CreatedAt) {
+		err := fmt.Errorf("the account was opened after %s", req.Date)
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+
+	s, err := statement.BuildPeriod(ctx, server.store, account, start, end)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+	message, err := camt.EndOfDayStatement(s, now)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+
+	ctx.Header("Content-Disposition", fmt.Sprintf(`attachment; filename="camt053-%d-%s.xml"`, account.ID, req.Date))
+	ctx.Data(http.StatusOK, "application/xml", message)
+}
+
+// the owner or bank staff export the activity of an account since the start of the day
+// as an ISO 20022 camt.052 intraday report
+func (server *Server) getCamtReport(ctx *gin.Context) {
+	var uri getAccountRequest
+	if err := ctx.ShouldBindUri(&uri); err != nil {
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+
+	account, ok := server.statementAccount(ctx, uri.ID)
+	if !ok {
+		return
+	}
+
+	now := time.Now().UTC()
+	start := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, time.UTC)
+
+	s, err := statement.BuildPeriod(ctx, server.store, account, start, now)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+	message, err := camt.IntradayReport(s, now)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+
+	ctx.Header("Content-Disposition", fmt.Sprintf(`attachment; filename="camt052-%d-%s.xml"`, account.ID, now.Format("20060102150405")))
+	ctx.Data(http.StatusOK, "application/xml", message)
+}
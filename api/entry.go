@@ -0,0 +1,114 @@
+package api
+
+import (
+	"errors"
+	"net/http"
+	"strings"
+
+	db "github.com/XiaozhouCui/go-bank/db/sqlc"
+	"github.com/XiaozhouCui/go-bank/db/util"
+	"github.com/gin-gonic/gin"
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+type listEntriesRequest struct {
+	Tag       string `form:"tag" binding:"omitempty,tag"`
+	Reference string `form:"reference" binding:"omitempty,reference"`
+	// case insensitive search in the description of the transfers
+	Search   string `form:"search" binding:"max=140"`
+	PageID   int32  `form:"page_id" binding:"required,min=1"`
+	PageSize int32  `form:"page_size" binding:"required,min=5,max=10"`
+}
+
+// every member of an account lists its entries, with the description and reference of their transfers,
+// optionally filtered by tag, reference or a search in the description
+func (server *Server) listAccountEntries(ctx *gin.Context) {
+	var uri getAccountRequest
+	if err := ctx.ShouldBindUri(&uri); err != nil {
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+	var req listEntriesRequest
+	if err := ctx.ShouldBindQuery(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+
+	if _, valid := server.memberAccount(ctx, uri.ID, db.AccountRoleCanView); !valid {
+		return
+	}
+
+	tag := util.NormalizeTag(req.Tag)
+	reference := strings.TrimSpace(req.Reference)
+	search := util.SanitizeDescription(req.Search)
+
+	entries, err := server.store.ListAccountEntries(ctx, db.ListAccountEntriesParams{
+		AccountID: uri.ID,
+		Tag:       pgtype.Text{String: tag, Valid: tag != ""},
+		Reference: pgtype.Text{String: reference, Valid: reference != ""},
+		Search:    pgtype.Text{String: search, Valid: search != ""},
+		Limit:     req.PageSize,
+		Offset:    (req.PageID - 1) * req.PageSize,
+	})
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+
+	ctx.JSON(http.StatusOK, entries)
+}
+
+type entryRequest struct {
+	ID      int64 `uri:"id" binding:"required,min=1"`
+	EntryID int64 `uri:"entry_id" binding:"required,min=1"`
+}
+
+type setEntryTagsRequest struct {
+	// replaces the tags of the entry, an empty list removes them
+	Tags []string `json:"tags" binding:"required,max=10,dive,tag"`
+}
+
+// the owner or a member who can spend from the account tags one of its entries
+func (server *Server) setEntryTags(ctx *gin.Context) {
+	var uri entryRequest
+	if err := ctx.ShouldBindUri(&uri); err != nil {
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+	var req setEntryTagsRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+
+	if _, valid := server.memberAccount(ctx, uri.ID, db.AccountRoleCanSpend); !valid {
+		return
+	}
+
+	entry, err := server.store.GetEntry(ctx, uri.EntryID)
+	if err != nil {
+		if errors.Is(err, db.ErrRecordNotFound) {
+			ctx.JSON(http.StatusNotFound, errorResponse(err))
+			return
+		}
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+	// an entry of another account is not found in this one
+	if entry.AccountID != uri.ID {
+		ctx.JSON(http.StatusNotFound, errorResponse(db.ErrRecordNotFound))
+		return
+	}
+	auditBefore(ctx, entry)
+
+	entry, err = server.store.SetEntryTags(ctx, db.SetEntryTagsParams{
+		ID:   entry.ID,
+		Tags: util.NormalizeTags(req.Tags),
+	})
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+
+	ctx.JSON(http.StatusOK, entry)
+}
@@ -0,0 +1,109 @@
+package api
+
+import (
+	"errors"
+	"net/http"
+
+	db "github.com/XiaozhouCui/go-bank/db/sqlc"
+	"github.com/gin-gonic/gin"
+)
+
+type feeTierRequest struct {
+	MinAmount int64 `json:"min_amount" binding:"min=0"`
+	FlatFee   int64 `json:"flat_fee" binding:"min=0"`
+	RateBps   int64 `json:"rate_bps" binding:"min=0,max=10000"`
+}
+
+type createFeeScheduleRequest struct {
+	Currency string `json:"currency" binding:"required,currency"`
+	// the schedule applies to transfers sent from accounts of this type
+	AccountType string `json:"account_type" binding:"required,account_type"`
+	// "fee_kind" validator is registered in server.go
+	Kind    string `json:"kind" binding:"required,fee_kind"`
+	FlatFee int64  `json:"flat_fee" binding:"min=0"`
+	// rate in basis points, 50 is 0.50% of the amount
+	RateBps int64 `json:"rate_bps" binding:"min=0,max=10000"`
+	MinFee  int64 `json:"min_fee" binding:"min=0"`
+	// 0 means no cap
+	MaxFee int64            `json:"max_fee" binding:"min=0"`
+	Tiers  []feeTierRequest `json:"tiers" binding:"dive"`
+}
+
+// bank staff creates the fee schedule of a currency and account type
+func (server *Server) createFeeSchedule(ctx *gin.Context) {
+	var req createFeeScheduleRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+
+	if req.Kind == db.FeeKindTiered && len(req.Tiers) == 0 {
+		err := errors.New("a tiered fee schedule needs at least one tier")
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+	if req.MaxFee > 0 && req.MaxFee < req.MinFee {
+		err := errors.New("max_fee cannot be less than min_fee")
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+
+	arg := db.CreateFeeScheduleTxParams{
+		CreateFeeScheduleParams: db.CreateFeeScheduleParams{
+			Currency:    req.Currency,
+			AccountType: req.AccountType,
+			Kind:        req.Kind,
+			FlatFee:     req.FlatFee,
+			RateBps:     req.RateBps,
+			MinFee:      req.MinFee,
+			MaxFee:      req.MaxFee,
+		},
+	}
+	for _, tier := range req.Tiers {
+		arg.Tiers = append(arg.Tiers, db.CreateFeeTierParams{
+			MinAmount: tier.MinAmount,
+			FlatFee:   tier.FlatFee,
+			RateBps:   tier.RateBps,
+		})
+	}
+
+	result, err := server.store.CreateFeeScheduleTx(ctx, arg)
+	if err != nil {
+		// one schedule per currency and account type, tier amounts are unique within a schedule
+		if db.ErrorCode(err) == db.UniqueViolation {
+			ctx.JSON(http.StatusForbidden, errorResponse(err))
+			return
+		}
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+
+	auditTarget(ctx, "fee-schedules", result.Schedule.ID)
+	ctx.JSON(http.StatusOK, result)
+}
+
+type listFeeSchedulesRequest struct {
+	PageID   int32 `form:"page_id" binding:"required,min=1"`
+	PageSize int32 `form:"page_size" binding:"required,min=5,max=10"`
+}
+
+// bank staff lists the fee schedules
+func (server *Server) listFeeSchedules(ctx *gin.Context) {
+	var req listFeeSchedulesRequest
+	if err := ctx.ShouldBindQuery(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+
+	arg := db.ListFeeSchedulesParams{
+		Limit:  req.PageSize,
+		Offset: (req.PageID - 1) * req.PageSize,
+	}
+	schedules, err := server.store.ListFeeSchedules(ctx, arg)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+
+	ctx.JSON(http.StatusOK, schedules)
+}
@@ -0,0 +1,217 @@
+package api
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	mockdb "github.com/XiaozhouCui/go-bank/db/mock"
+	db "github.com/XiaozhouCui/go-bank/db/sqlc"
+	"github.com/XiaozhouCui/go-bank/db/util"
+	"github.com/XiaozhouCui/go-bank/token"
+	"github.com/gin-gonic/gin"
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCreateFeeScheduleAPI(t *testing.T) {
+	staff := "banker"
+
+	testCases := []struct {
+		name          string
+		body          gin.H
+		setupAuth     func(t *testing.T, request *http.Request, tokenMaker token.Maker)
+		buildStubs    func(store *mockdb.MockStore)
+		checkResponse func(recoder *httptest.ResponseRecorder)
+	}{
+		{
+			name: "Percentage",
+			body: gin.H{
+				"currency":     util.USD,
+				"account_type": util.Checking,
+				"kind":         db.FeeKindPercentage,
+				"rate_bps":     50,
+				"min_fee":      10,
+				"max_fee":      500,
+			},
+			setupAuth: func(t *testing.T, request *http.Request, tokenMaker token.Maker) {
+				addAuthorization(t, request, tokenMaker, authorizationTypeBearer, staff, util.BankerRole, time.Minute)
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				arg := db.CreateFeeScheduleTxParams{
+					CreateFeeScheduleParams: db.CreateFeeScheduleParams{
+						Currency:    util.USD,
+						AccountType: util.Checking,
+						Kind:        db.FeeKindPercentage,
+						RateBps:     50,
+						MinFee:      10,
+						MaxFee:      500,
+					},
+				}
+				store.EXPECT().
+					CreateFeeScheduleTx(gomock.Any(), gomock.Eq(arg)).
+					Times(1).
+					Return(db.CreateFeeScheduleTxResult{Schedule: db.FeeSchedule{ID: 1}}, nil)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+			},
+		},
+		{
+			name: "Tiered",
+			body: gin.H{
+				"currency":     util.EUR,
+				"account_type": util.Savings,
+				"kind":         db.FeeKindTiered,
+				"tiers": []gin.H{
+					{"min_amount": 0, "flat_fee": 10},
+					{"min_amount": 10000, "rate_bps": 20},
+				},
+			},
+			setupAuth: func(t *testing.T, request *http.Request, tokenMaker token.Maker) {
+				addAuthorization(t, request, tokenMaker, authorizationTypeBearer, staff, util.BankerRole, time.Minute)
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				arg := db.CreateFeeScheduleTxParams{
+					CreateFeeScheduleParams: db.CreateFeeScheduleParams{
+						Currency:    util.EUR,
+						AccountType: util.Savings,
+						Kind:        db.FeeKindTiered,
+					},
+					Tiers: []db.CreateFeeTierParams{
+						{MinAmount: 0, FlatFee: 10},
+						{MinAmount: 10000, RateBps: 20},
+					},
+				}
+				store.EXPECT().
+					CreateFeeScheduleTx(gomock.Any(), gomock.Eq(arg)).
+					Times(1).
+					Return(db.CreateFeeScheduleTxResult{}, nil)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+			},
+		},
+		{
+			name: "TieredWithoutTiers",
+			body: gin.H{
+				"currency":     util.EUR,
+				"account_type": util.Savings,
+				"kind":         db.FeeKindTiered,
+			},
+			setupAuth: func(t *testing.T, request *http.Request, tokenMaker token.Maker) {
+				addAuthorization(t, request, tokenMaker, authorizationTypeBearer, staff, util.BankerRole, time.Minute)
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().CreateFeeScheduleTx(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusBadRequest, recorder.Code)
+			},
+		},
+		{
+			name: "InvalidKind",
+			body: gin.H{
+				"currency":     util.USD,
+				"account_type": util.Checking,
+				"kind":         "monthly",
+			},
+			setupAuth: func(t *testing.T, request *http.Request, tokenMaker token.Maker) {
+				addAuthorization(t, request, tokenMaker, authorizationTypeBearer, staff, util.BankerRole, time.Minute)
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().CreateFeeScheduleTx(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusBadRequest, recorder.Code)
+			},
+		},
+		{
+			name: "MaxFeeBelowMinFee",
+			body: gin.H{
+				"currency":     util.USD,
+				"account_type": util.Checking,
+				"kind":         db.FeeKindPercentage,
+				"rate_bps":     50,
+				"min_fee":      100,
+				"max_fee":      10,
+			},
+			setupAuth: func(t *testing.T, request *http.Request, tokenMaker token.Maker) {
+				addAuthorization(t, request, tokenMaker, authorizationTypeBearer, staff, util.BankerRole, time.Minute)
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().CreateFeeScheduleTx(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusBadRequest, recorder.Code)
+			},
+		},
+		{
+			name: "DuplicateSchedule",
+			body: gin.H{
+				"currency":     util.USD,
+				"account_type": util.Checking,
+				"kind":         db.FeeKindFlat,
+				"flat_fee":     25,
+			},
+			setupAuth: func(t *testing.T, request *http.Request, tokenMaker token.Maker) {
+				addAuthorization(t, request, tokenMaker, authorizationTypeBearer, staff, util.BankerRole, time.Minute)
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					CreateFeeScheduleTx(gomock.Any(), gomock.Any()).
+					Times(1).
+					Return(db.CreateFeeScheduleTxResult{}, db.ErrUniqueViolation)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusForbidden, recorder.Code)
+			},
+		},
+		{
+			name: "DepositorForbidden",
+			body: gin.H{
+				"currency":     util.USD,
+				"account_type": util.Checking,
+				"kind":         db.FeeKindFlat,
+				"flat_fee":     25,
+			},
+			setupAuth: func(t *testing.T, request *http.Request, tokenMaker token.Maker) {
+				addAuthorization(t, request, tokenMaker, authorizationTypeBearer, "user", util.DepositorRole, time.Minute)
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().CreateFeeScheduleTx(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusForbidden, recorder.Code)
+			},
+		},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			store := mockdb.NewMockStore(ctrl)
+			tc.buildStubs(store)
+
+			server := newTestServer(t, store)
+			recorder := httptest.NewRecorder()
+
+			data, err := json.Marshal(tc.body)
+			require.NoError(t, err)
+
+			request, err := http.NewRequest(http.MethodPost, "/fee-schedules", bytes.NewReader(data))
+			require.NoError(t, err)
+
+			tc.setupAuth(t, request, server.tokenMaker)
+			server.router.ServeHTTP(recorder, request)
+			tc.checkResponse(recorder)
+		})
+	}
+}
@@ -0,0 +1,48 @@
+package api
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+type healthResponse struct {
+	Status string `json:"status"`
+	// ok, failed, or unknown until the ledger has been checked
+	Ledger              string     `json:"ledger"`
+	LedgerCheckedAt     *time.Time `json:"ledger_checked_at,omitempty"`
+	LedgerDiscrepancies int64      `json:"ledger_discrepancies"`
+}
+
+// health answers 503 when the last ledger check found discrepancies, so they are noticed by monitoring
+func (server *Server) health(ctx *gin.Context) {
+	rsp := healthResponse{
+		Status: "ok",
+		Ledger: "unknown",
+	}
+
+	report, checked := server.ledger.Last()
+	if checked {
+		rsp.Ledger = "ok"
+		rsp.LedgerCheckedAt = &report.CheckedAt
+		rsp.LedgerDiscrepancies = report.Discrepancies()
+		if !report.OK {
+			rsp.Status = "degraded"
+			rsp.Ledger = "failed"
+			ctx.JSON(http.StatusServiceUnavailable, rsp)
+			return
+		}
+	}
+
+	ctx.JSON(http.StatusOK, rsp)
+}
+
+// metrics exposes the ledger check in the Prometheus text format
+func (server *Server) metrics(ctx *gin.Context) {
+	ctx.Header("Content-Type", "text/plain; version=0.0.4")
+	ctx.Status(http.StatusOK)
+	if err := server.ledger.WriteMetrics(ctx.Writer); err != nil {
+		ctx.Error(err)
+	}
+}
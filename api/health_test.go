@@ -0,0 +1,91 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	mockdb "github.com/XiaozhouCui/go-bank/db/mock"
+	db "github.com/XiaozhouCui/go-bank/db/sqlc"
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/require"
+)
+
+func TestHealthAPI(t *testing.T) {
+	testCases := []struct {
+		name          string
+		mismatches    []db.ListAccountBalanceMismatchesRow
+		checkLedger   bool
+		checkResponse func(recoder *httptest.ResponseRecorder)
+	}{
+		{
+			name: "NotChecked",
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+
+				var rsp healthResponse
+				err := json.NewDecoder(recorder.Body).Decode(&rsp)
+				require.NoError(t, err)
+				require.Equal(t, "unknown", rsp.Ledger)
+			},
+		},
+		{
+			name:        "LedgerOK",
+			mismatches:  []db.ListAccountBalanceMismatchesRow{},
+			checkLedger: true,
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+
+				var rsp healthResponse
+				err := json.NewDecoder(recorder.Body).Decode(&rsp)
+				require.NoError(t, err)
+				require.Equal(t, "ok", rsp.Ledger)
+				require.NotNil(t, rsp.LedgerCheckedAt)
+			},
+		},
+		{
+			name:        "LedgerFailed",
+			mismatches:  []db.ListAccountBalanceMismatchesRow{{AccountID: 1, Balance: 10, MismatchCount: 1}},
+			checkLedger: true,
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusServiceUnavailable, recorder.Code)
+
+				var rsp healthResponse
+				err := json.NewDecoder(recorder.Body).Decode(&rsp)
+				require.NoError(t, err)
+				require.Equal(t, "failed", rsp.Ledger)
+				require.Equal(t, int64(1), rsp.LedgerDiscrepancies)
+			},
+		},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			store := mockdb.NewMockStore(ctrl)
+			server := newTestServer(t, store)
+
+			if tc.checkLedger {
+				store.EXPECT().ListAccountBalanceMismatches(gomock.Any(), gomock.Any()).Times(1).Return(tc.mismatches, nil)
+				store.EXPECT().ListUnbalancedTransfers(gomock.Any(), gomock.Any()).Times(1).Return([]db.ListUnbalancedTransfersRow{}, nil)
+				store.EXPECT().ListCurrencyTotals(gomock.Any()).Times(1).Return([]db.ListCurrencyTotalsRow{}, nil)
+
+				_, err := server.ledger.Check(context.Background())
+				require.NoError(t, err)
+			}
+
+			recorder := httptest.NewRecorder()
+			request, err := http.NewRequest(http.MethodGet, "/health", nil)
+			require.NoError(t, err)
+
+			server.router.ServeHTTP(recorder, request)
+			tc.checkResponse(recorder)
+		})
+	}
+}
@@ -0,0 +1,275 @@
+package api
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"time"
+
+	db "github.com/XiaozhouCui/go-bank/db/sqlc"
+	"github.com/XiaozhouCui/go-bank/token"
+	"github.com/gin-gonic/gin"
+)
+
+const (
+	defaultHoldDuration = 7 * 24 * time.Hour  // a hold without expires_at expires after a week
+	maxHoldDuration     = 30 * 24 * time.Hour // a hold cannot reserve funds for longer
+)
+
+type placeHoldRequest struct {
+	AccountID   int64  `json:"account_id" binding:"required,min=1"`
+	ToAccountID int64  `json:"to_account_id" binding:"required,min=1"`
+	Amount      int64  `json:"amount" binding:"required,gt=0"`
+	Currency    string `json:"currency" binding:"required,currency"`
+	// defaults to defaultHoldDuration from now
+	ExpiresAt time.Time `json:"expires_at"`
+}
+
+// owner reserves funds of their account for a payment to another account, captured or released later
+func (server *Server) placeHold(ctx *gin.Context) {
+	var req placeHoldRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+
+	now := time.Now()
+	if req.ExpiresAt.IsZero() {
+		req.ExpiresAt = now.Add(defaultHoldDuration)
+	}
+	if !req.ExpiresAt.After(now) || req.ExpiresAt.After(now.Add(maxHoldDuration)) {
+		err := fmt.Errorf("expires_at must be in the next %v", maxHoldDuration)
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+
+	// the same checks as a transfer made now
+	_, valid := server.validTransfer(ctx, transferRequest{
+		FromAccountID: req.AccountID,
+		ToAccountID:   req.ToAccountID,
+		Amount:        req.Amount,
+		Currency:      req.Currency,
+	})
+	if !valid {
+		return
+	}
+	if !server.noApproval(ctx, req.AccountID, req.Amount) {
+		return
+	}
+
+	authPayload := ctx.MustGet(authorizationPayloadKey).(*token.Payload)
+
+	result, err := server.store.PlaceHoldTx(ctx, db.PlaceHoldTxParams{
+		AccountID:   req.AccountID,
+		ToAccountID: req.ToAccountID,
+		Amount:      req.Amount,
+		CreatedBy:   authPayload.Username,
+		ExpiresAt:   req.ExpiresAt.UTC(),
+	})
+	if err != nil {
+		ctx.JSON(transferErrorStatus(err), errorResponse(err))
+		return
+	}
+
+	auditTarget(ctx, "holds", result.Hold.ID)
+	ctx.JSON(http.StatusOK, result)
+}
+
+type getHoldRequest struct {
+	ID int64 `uri:"id" binding:"required,min=1"`
+}
+
+func (server *Server) getHold(ctx *gin.Context) {
+	var req getHoldRequest
+	if err := ctx.ShouldBindUri(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+
+	hold, valid := server.involvedHold(ctx, req.ID)
+	if !valid {
+		return
+	}
+
+	ctx.JSON(http.StatusOK, hold)
+}
+
+type listHoldsRequest struct {
+	PageID   int32 `form:"page_id" binding:"required,min=1"`
+	PageSize int32 `form:"page_size" binding:"required,min=5,max=10"`
+}
+
+// owner or member lists the holds placed on an account
+func (server *Server) listAccountHolds(ctx *gin.Context) {
+	var uri getAccountRequest
+	if err := ctx.ShouldBindUri(&uri); err != nil {
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+	var req listHoldsRequest
+	if err := ctx.ShouldBindQuery(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+
+	account, err := server.store.GetAccount(ctx, uri.ID)
+	if err != nil {
+		if errors.Is(err, db.ErrRecordNotFound) {
+			ctx.JSON(http.StatusNotFound, errorResponse(err))
+			return
+		}
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+
+	if _, valid := server.authorizeAccount(ctx, account, db.AccountRoleCanView); !valid {
+		return
+	}
+
+	holds, err := server.store.ListHolds(ctx, db.ListHoldsParams{
+		AccountID: uri.ID,
+		Limit:     req.PageSize,
+		Offset:    (req.PageID - 1) * req.PageSize,
+	})
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+
+	ctx.JSON(http.StatusOK, holds)
+}
+
+type captureHoldRequest struct {
+	// omit it to capture the whole hold
+	Amount int64 `json:"amount" binding:"omitempty,gt=0"`
+}
+
+// the payee captures a hold, fully or partially.
+// the captured amount becomes a transfer, the rest of the hold is released
+func (server *Server) captureHold(ctx *gin.Context) {
+	var uri getHoldRequest
+	if err := ctx.ShouldBindUri(&uri); err != nil {
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+	var req captureHoldRequest
+	// the body is optional
+	if err := ctx.ShouldBindJSON(&req); err != nil && !errors.Is(err, io.EOF) {
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+
+	hold, valid := server.payeeHold(ctx, uri.ID)
+	if !valid {
+		return
+	}
+	auditBefore(ctx, hold)
+
+	if req.Amount == 0 {
+		req.Amount = hold.Amount
+	}
+
+	result, err := server.store.CaptureHoldTx(ctx, db.CaptureHoldTxParams{
+		ID:     hold.ID,
+		Amount: req.Amount,
+		Now:    time.Now(),
+	})
+	if err != nil {
+		server.holdError(ctx, err)
+		return
+	}
+
+	ctx.JSON(http.StatusOK, result)
+}
+
+// the payee releases a hold, its funds are available again to the held account
+func (server *Server) releaseHold(ctx *gin.Context) {
+	var uri getHoldRequest
+	if err := ctx.ShouldBindUri(&uri); err != nil {
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+
+	hold, valid := server.payeeHold(ctx, uri.ID)
+	if !valid {
+		return
+	}
+	auditBefore(ctx, hold)
+
+	result, err := server.store.ReleaseHoldTx(ctx, db.ReleaseHoldTxParams{
+		ID:  hold.ID,
+		Now: time.Now(),
+	})
+	if err != nil {
+		server.holdError(ctx, err)
+		return
+	}
+
+	ctx.JSON(http.StatusOK, result)
+}
+
+func (server *Server) holdError(ctx *gin.Context, err error) {
+	switch {
+	case errors.Is(err, db.ErrHoldNotPending):
+		ctx.JSON(http.StatusConflict, errorResponse(err))
+	case errors.Is(err, db.ErrCaptureExceedsHold):
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+	default:
+		ctx.JSON(transferErrorStatus(err), errorResponse(err))
+	}
+}
+
+// get a hold and check that the current user is a member of the held account or the payee account
+func (server *Server) involvedHold(ctx *gin.Context, id int64) (db.Hold, bool) {
+	hold, valid := server.findHold(ctx, id)
+	if !valid {
+		return hold, false
+	}
+
+	authPayload := ctx.MustGet(authorizationPayloadKey).(*token.Payload)
+	for _, accountID := range []int64{hold.AccountID, hold.ToAccountID} {
+		account, err := server.store.GetAccount(ctx, accountID)
+		if err != nil {
+			ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+			return hold, false
+		}
+		_, status, err := server.checkAccountAccess(ctx, account, authPayload.Username, db.AccountRoleCanView)
+		if err == nil {
+			return hold, true
+		}
+		if status == http.StatusInternalServerError {
+			ctx.JSON(status, errorResponse(err))
+			return hold, false
+		}
+	}
+
+	err := errors.New("hold doesn't involve an account of the authenticated user")
+	ctx.JSON(http.StatusUnauthorized, errorResponse(err))
+	return hold, false
+}
+
+// get a hold and check that the current user can receive money on the payee account.
+// only the payee captures or releases a hold, the payer's reservation stands until then or until it expires
+func (server *Server) payeeHold(ctx *gin.Context, id int64) (db.Hold, bool) {
+	hold, valid := server.findHold(ctx, id)
+	if !valid {
+		return hold, false
+	}
+
+	_, valid = server.memberAccount(ctx, hold.ToAccountID, db.AccountRoleCanSpend)
+	return hold, valid
+}
+
+func (server *Server) findHold(ctx *gin.Context, id int64) (db.Hold, bool) {
+	hold, err := server.store.GetHold(ctx, id)
+	if err != nil {
+		if errors.Is(err, db.ErrRecordNotFound) {
+			ctx.JSON(http.StatusNotFound, errorResponse(err))
+			return hold, false
+		}
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return hold, false
+	}
+	return hold, true
+}
@@ -0,0 +1,184 @@
+package api
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+
+	"github.com/XiaozhouCui/go-bank/bulk"
+	db "github.com/XiaozhouCui/go-bank/db/sqlc"
+	"github.com/XiaozhouCui/go-bank/token"
+	"github.com/gin-gonic/gin"
+)
+
+// a pain.001 file of bulk.MaxInstructions transactions is well under this size
+const maxImportFileSize = 2 << 20
+
+type createImportRequest struct {
+	Format string `form:"format" binding:"required,oneof=pain.001 csv"`
+}
+
+type importJobResponse struct {
+	Job   db.ImportJob       `json:"job"`
+	Lines []db.ImportJobLine `json:"lines"`
+}
+
+// createImport uploads a payment file as a multipart form with the file and its format,
+// and answers with the validation report of every instruction. This is a dry run, no money moves
+// until the job is executed, and a job with invalid instructions is rejected as a whole.
+func (server *Server) createImport(ctx *gin.Context) {
+	var req createImportRequest
+	if err := ctx.ShouldBind(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+	header, err := ctx.FormFile("file")
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+	if header.Size > maxImportFileSize {
+		err := fmt.Errorf("the payment file cannot be larger than %d bytes", maxImportFileSize)
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+
+	file, err := header.Open()
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+	defer file.Close()
+	data, err := io.ReadAll(file)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+
+	instructions, err := bulk.Parse(req.Format, data)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+	if len(instructions) == 0 {
+		err := errors.New("the payment file has no instructions")
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+
+	authPayload := ctx.MustGet(authorizationPayloadKey).(*token.Payload)
+	result, err := bulk.Import(ctx, server.store, authPayload.Username, req.Format, instructions)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+
+	auditTarget(ctx, "imports", result.Job.ID)
+	ctx.JSON(http.StatusOK, importJobResponse{Job: result.Job, Lines: result.Lines})
+}
+
+type getImportRequest struct {
+	ID int64 `uri:"id" binding:"required,min=1"`
+}
+
+// current user can only get their own import jobs, with the status of every line
+func (server *Server) getImport(ctx *gin.Context) {
+	var req getImportRequest
+	if err := ctx.ShouldBindUri(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+
+	job, valid := server.ownedImportJob(ctx, req.ID)
+	if !valid {
+		return
+	}
+
+	lines, err := server.store.ListImportJobLines(ctx, job.ID)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+
+	ctx.JSON(http.StatusOK, importJobResponse{Job: job, Lines: lines})
+}
+
+type listImportsRequest struct {
+	PageID   int32 `form:"page_id" binding:"required,min=1"`
+	PageSize int32 `form:"page_size" binding:"required,min=5,max=10"`
+}
+
+// current user can only list their own import jobs
+func (server *Server) listImports(ctx *gin.Context) {
+	var req listImportsRequest
+	if err := ctx.ShouldBindQuery(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+
+	authPayload := ctx.MustGet(authorizationPayloadKey).(*token.Payload)
+	arg := db.ListImportJobsParams{
+		Owner:  authPayload.Username,
+		Limit:  req.PageSize,
+		Offset: (req.PageID - 1) * req.PageSize,
+	}
+	jobs, err := server.store.ListImportJobs(ctx, arg)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+
+	ctx.JSON(http.StatusOK, jobs)
+}
+
+// executeImport pays the valid lines of a validated import job, and answers with the status of every line.
+// Executing a job again resumes it if it was interrupted
+func (server *Server) executeImport(ctx *gin.Context) {
+	var req getImportRequest
+	if err := ctx.ShouldBindUri(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+
+	job, valid := server.ownedImportJob(ctx, req.ID)
+	if !valid {
+		return
+	}
+	auditBefore(ctx, job)
+
+	job, lines, err := bulk.Execute(ctx, server.store, job)
+	if err != nil {
+		if errors.Is(err, db.ErrImportJobNotExecutable) {
+			ctx.JSON(http.StatusConflict, errorResponse(err))
+			return
+		}
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+
+	ctx.JSON(http.StatusOK, importJobResponse{Job: job, Lines: lines})
+}
+
+// ownedImportJob loads an import job of the authenticated user,
+// it writes the error response and returns false otherwise
+func (server *Server) ownedImportJob(ctx *gin.Context, id int64) (db.ImportJob, bool) {
+	job, err := server.store.GetImportJob(ctx, id)
+	if err != nil {
+		if errors.Is(err, db.ErrRecordNotFound) {
+			ctx.JSON(http.StatusNotFound, errorResponse(err))
+			return job, false
+		}
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return job, false
+	}
+
+	authPayload := ctx.MustGet(authorizationPayloadKey).(*token.Payload)
+	if job.Owner != authPayload.Username {
+		err := errors.New("import job doesn't belong to the authenticated user")
+		ctx.JSON(http.StatusUnauthorized, errorResponse(err))
+		return job, false
+	}
+
+	return job, true
+}
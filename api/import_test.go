@@ -0,0 +1,285 @@
+package api
+
+import (
+	"bytes"
+	"fmt"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/XiaozhouCui/go-bank/bulk"
+	mockdb "github.com/XiaozhouCui/go-bank/db/mock"
+	db "github.com/XiaozhouCui/go-bank/db/sqlc"
+	"github.com/XiaozhouCui/go-bank/db/util"
+	"github.com/XiaozhouCui/go-bank/token"
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCreateImportAPI(t *testing.T) {
+	user, _ := randomUser(t)
+	account1 := randomAccount(user.Username)
+	account2 := randomAccount(util.RandomOwner())
+	account2.ID = account1.ID + 1
+	account2.Currency = account1.Currency
+
+	file := fmt.Sprintf("%s\n%d,%d,12.50,%s,INV-001\n", bulk.CSVHeader, account1.ID, account2.ID, account1.Currency)
+	job := db.ImportJob{ID: 1, Owner: user.Username, Format: db.ImportFormatCSV, Status: db.ImportJobValidated, LineCount: 1}
+
+	testCases := []struct {
+		name          string
+		format        string
+		file          string
+		setupAuth     func(t *testing.T, request *http.Request, tokenMaker token.Maker)
+		buildStubs    func(store *mockdb.MockStore)
+		checkResponse func(recoder *httptest.ResponseRecorder)
+	}{
+		{
+			name:   "OK",
+			format: db.ImportFormatCSV,
+			file:   file,
+			setupAuth: func(t *testing.T, request *http.Request, tokenMaker token.Maker) {
+				addAuthorization(t, request, tokenMaker, authorizationTypeBearer, user.Username, util.DepositorRole, time.Minute)
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Eq(account1.ID)).Times(1).Return(account1, nil)
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Eq(account2.ID)).Times(1).Return(account2, nil)
+
+				arg := db.CreateImportJobTxParams{
+					Owner:  user.Username,
+					Format: db.ImportFormatCSV,
+					Lines: []db.CreateImportJobLineParams{{
+						Line:          1,
+						FromAccountID: account1.ID,
+						ToAccountID:   account2.ID,
+						Amount:        1250,
+						Currency:      account1.Currency,
+						Reference:     "INV-001",
+						Status:        db.ImportLineValid,
+					}},
+				}
+				store.EXPECT().
+					CreateImportJobTx(gomock.Any(), gomock.Eq(arg)).
+					Times(1).
+					Return(db.CreateImportJobTxResult{Job: job}, nil)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+				require.Contains(t, recorder.Body.String(), `"status":"validated"`)
+			},
+		},
+		{
+			name:   "InvalidFormat",
+			format: "xlsx",
+			file:   file,
+			setupAuth: func(t *testing.T, request *http.Request, tokenMaker token.Maker) {
+				addAuthorization(t, request, tokenMaker, authorizationTypeBearer, user.Username, util.DepositorRole, time.Minute)
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().CreateImportJobTx(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusBadRequest, recorder.Code)
+			},
+		},
+		{
+			name:   "InvalidFile",
+			format: db.ImportFormatCSV,
+			file:   "from,to\n1,2\n",
+			setupAuth: func(t *testing.T, request *http.Request, tokenMaker token.Maker) {
+				addAuthorization(t, request, tokenMaker, authorizationTypeBearer, user.Username, util.DepositorRole, time.Minute)
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().CreateImportJobTx(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusBadRequest, recorder.Code)
+			},
+		},
+		{
+			name:   "EmptyFile",
+			format: db.ImportFormatCSV,
+			file:   bulk.CSVHeader + "\n",
+			setupAuth: func(t *testing.T, request *http.Request, tokenMaker token.Maker) {
+				addAuthorization(t, request, tokenMaker, authorizationTypeBearer, user.Username, util.DepositorRole, time.Minute)
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().CreateImportJobTx(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusBadRequest, recorder.Code)
+			},
+		},
+		{
+			name:   "NoAuthorization",
+			format: db.ImportFormatCSV,
+			file:   file,
+			setupAuth: func(t *testing.T, request *http.Request, tokenMaker token.Maker) {
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().CreateImportJobTx(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusUnauthorized, recorder.Code)
+			},
+		},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			store := mockdb.NewMockStore(ctrl)
+			tc.buildStubs(store)
+
+			server := newTestServer(t, store)
+			recorder := httptest.NewRecorder()
+
+			var body bytes.Buffer
+			writer := multipart.NewWriter(&body)
+			require.NoError(t, writer.WriteField("format", tc.format))
+			part, err := writer.CreateFormFile("file", "payments.csv")
+			require.NoError(t, err)
+			_, err = part.Write([]byte(tc.file))
+			require.NoError(t, err)
+			require.NoError(t, writer.Close())
+
+			request, err := http.NewRequest(http.MethodPost, "/imports", &body)
+			require.NoError(t, err)
+			request.Header.Set("Content-Type", writer.FormDataContentType())
+
+			tc.setupAuth(t, request, server.tokenMaker)
+			server.router.ServeHTTP(recorder, request)
+			tc.checkResponse(recorder)
+		})
+	}
+}
+
+func TestExecuteImportAPI(t *testing.T) {
+	user, _ := randomUser(t)
+	job := db.ImportJob{ID: 1, Owner: user.Username, Format: db.ImportFormatCSV, Status: db.ImportJobValidated, LineCount: 1}
+	account := randomAccount(user.Username)
+	line := db.ImportJobLine{ID: 10, JobID: job.ID, Line: 1, FromAccountID: account.ID, Amount: 100, Status: db.ImportLineValid}
+
+	testCases := []struct {
+		name          string
+		setupAuth     func(t *testing.T, request *http.Request, tokenMaker token.Maker)
+		buildStubs    func(store *mockdb.MockStore)
+		checkResponse func(recoder *httptest.ResponseRecorder)
+	}{
+		{
+			name: "OK",
+			setupAuth: func(t *testing.T, request *http.Request, tokenMaker token.Maker) {
+				addAuthorization(t, request, tokenMaker, authorizationTypeBearer, user.Username, util.DepositorRole, time.Minute)
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetImportJob(gomock.Any(), gomock.Eq(job.ID)).Times(1).Return(job, nil)
+				store.EXPECT().StartImportJob(gomock.Any(), gomock.Eq(job.ID)).Times(1).Return(job, nil)
+				store.EXPECT().ListImportJobLines(gomock.Any(), gomock.Eq(job.ID)).Times(2).Return([]db.ImportJobLine{line}, nil)
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Eq(account.ID)).Times(1).Return(account, nil)
+				store.EXPECT().ExecuteImportLineTx(gomock.Any(), gomock.Eq(line.ID)).Times(1).Return(db.ExecuteImportLineTxResult{}, nil)
+
+				completed := job
+				completed.Status = db.ImportJobCompleted
+				store.EXPECT().CompleteImportJob(gomock.Any(), gomock.Eq(job.ID)).Times(1).Return(completed, nil)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+				require.Contains(t, recorder.Body.String(), `"status":"completed"`)
+			},
+		},
+		{
+			name: "NotExecutable",
+			setupAuth: func(t *testing.T, request *http.Request, tokenMaker token.Maker) {
+				addAuthorization(t, request, tokenMaker, authorizationTypeBearer, user.Username, util.DepositorRole, time.Minute)
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				rejected := job
+				rejected.Status = db.ImportJobRejected
+				store.EXPECT().GetImportJob(gomock.Any(), gomock.Eq(job.ID)).Times(1).Return(rejected, nil)
+				store.EXPECT().StartImportJob(gomock.Any(), gomock.Eq(job.ID)).Times(1).Return(db.ImportJob{}, db.ErrRecordNotFound)
+				store.EXPECT().ExecuteImportLineTx(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusConflict, recorder.Code)
+			},
+		},
+		{
+			name: "UnauthorizedUser",
+			setupAuth: func(t *testing.T, request *http.Request, tokenMaker token.Maker) {
+				addAuthorization(t, request, tokenMaker, authorizationTypeBearer, "unauthorized_user", util.DepositorRole, time.Minute)
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetImportJob(gomock.Any(), gomock.Eq(job.ID)).Times(1).Return(job, nil)
+				store.EXPECT().StartImportJob(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusUnauthorized, recorder.Code)
+			},
+		},
+		{
+			name: "NotFound",
+			setupAuth: func(t *testing.T, request *http.Request, tokenMaker token.Maker) {
+				addAuthorization(t, request, tokenMaker, authorizationTypeBearer, user.Username, util.DepositorRole, time.Minute)
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetImportJob(gomock.Any(), gomock.Eq(job.ID)).Times(1).Return(db.ImportJob{}, db.ErrRecordNotFound)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusNotFound, recorder.Code)
+			},
+		},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			store := mockdb.NewMockStore(ctrl)
+			tc.buildStubs(store)
+
+			server := newTestServer(t, store)
+			recorder := httptest.NewRecorder()
+
+			url := fmt.Sprintf("/imports/%d/execute", job.ID)
+			request, err := http.NewRequest(http.MethodPost, url, nil)
+			require.NoError(t, err)
+
+			tc.setupAuth(t, request, server.tokenMaker)
+			server.router.ServeHTTP(recorder, request)
+			tc.checkResponse(recorder)
+		})
+	}
+}
+
+func TestGetImportAPI(t *testing.T) {
+	user, _ := randomUser(t)
+	job := db.ImportJob{ID: 1, Owner: user.Username, Format: db.ImportFormatPain001, Status: db.ImportJobRejected, LineCount: 1, InvalidCount: 1}
+	line := db.ImportJobLine{ID: 10, JobID: job.ID, Line: 1, Status: db.ImportLineInvalid, Error: "to account [2] not found"}
+
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	store := mockdb.NewMockStore(ctrl)
+	store.EXPECT().GetImportJob(gomock.Any(), gomock.Eq(job.ID)).Times(1).Return(job, nil)
+	store.EXPECT().ListImportJobLines(gomock.Any(), gomock.Eq(job.ID)).Times(1).Return([]db.ImportJobLine{line}, nil)
+
+	server := newTestServer(t, store)
+	recorder := httptest.NewRecorder()
+
+	request, err := http.NewRequest(http.MethodGet, fmt.Sprintf("/imports/%d", job.ID), nil)
+	require.NoError(t, err)
+
+	addAuthorization(t, request, server.tokenMaker, authorizationTypeBearer, user.Username, util.DepositorRole, time.Minute)
+	server.router.ServeHTTP(recorder, request)
+	require.Equal(t, http.StatusOK, recorder.Code)
+	require.Contains(t, recorder.Body.String(), "to account [2] not found")
+}
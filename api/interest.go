@@ -0,0 +1,129 @@
+package api
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+
+	db "github.com/XiaozhouCui/go-bank/db/sqlc"
+	"github.com/XiaozhouCui/go-bank/db/util"
+	"github.com/gin-gonic/gin"
+)
+
+type createInterestPlanRequest struct {
+	Name string `json:"name" binding:"required,max=64"`
+	// rate in basis points, 250 is 2.50% a year
+	AnnualRateBps int64 `json:"annual_rate_bps" binding:"required,min=1,max=10000"`
+	// "day_count" validator is registered in server.go
+	DayCount string `json:"day_count" binding:"required,day_count"`
+}
+
+// bank staff creates a new interest rate plan
+func (server *Server) createInterestPlan(ctx *gin.Context) {
+	var req createInterestPlanRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+
+	arg := db.CreateInterestPlanParams{
+		Name:          req.Name,
+		AnnualRateBps: req.AnnualRateBps,
+		DayCount:      req.DayCount,
+	}
+	plan, err := server.store.CreateInterestPlan(ctx, arg)
+	if err != nil {
+		// plan names are unique
+		if db.ErrorCode(err) == db.UniqueViolation {
+			ctx.JSON(http.StatusForbidden, errorResponse(err))
+			return
+		}
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+
+	auditTarget(ctx, "interest-plans", plan.ID)
+	ctx.JSON(http.StatusOK, plan)
+}
+
+type listInterestPlansRequest struct {
+	PageID   int32 `form:"page_id" binding:"required,min=1"`
+	PageSize int32 `form:"page_size" binding:"required,min=5,max=10"`
+}
+
+// any logged-in user can see the available interest rate plans
+func (server *Server) listInterestPlans(ctx *gin.Context) {
+	var req listInterestPlansRequest
+	if err := ctx.ShouldBindQuery(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+
+	arg := db.ListInterestPlansParams{
+		Limit:  req.PageSize,
+		Offset: (req.PageID - 1) * req.PageSize,
+	}
+	plans, err := server.store.ListInterestPlans(ctx, arg)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+
+	ctx.JSON(http.StatusOK, plans)
+}
+
+type setAccountInterestPlanRequest struct {
+	PlanID int64 `json:"plan_id" binding:"required,min=1"`
+}
+
+// bank staff attaches an interest rate plan to a savings account, replacing its current plan
+func (server *Server) setAccountInterestPlan(ctx *gin.Context) {
+	var uri getAccountRequest
+	if err := ctx.ShouldBindUri(&uri); err != nil {
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+	var req setAccountInterestPlanRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+
+	account, err := server.store.GetAccount(ctx, uri.ID)
+	if err != nil {
+		if errors.Is(err, db.ErrRecordNotFound) {
+			ctx.JSON(http.StatusNotFound, errorResponse(err))
+			return
+		}
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+
+	// only savings accounts earn interest
+	if account.Type != util.Savings {
+		err := fmt.Errorf("account [%d] is a %s account, not a savings account", account.ID, account.Type)
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+
+	if _, err := server.store.GetInterestPlan(ctx, req.PlanID); err != nil {
+		if errors.Is(err, db.ErrRecordNotFound) {
+			ctx.JSON(http.StatusNotFound, errorResponse(err))
+			return
+		}
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+
+	arg := db.SetAccountInterestPlanParams{
+		AccountID: account.ID,
+		PlanID:    req.PlanID,
+	}
+	accountPlan, err := server.store.SetAccountInterestPlan(ctx, arg)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+
+	ctx.JSON(http.StatusOK, accountPlan)
+}
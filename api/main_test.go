@@ -5,19 +5,30 @@ import (
 	"testing"
 	"time"
 
+	mockdb "github.com/XiaozhouCui/go-bank/db/mock"
 	db "github.com/XiaozhouCui/go-bank/db/sqlc"
 	"github.com/XiaozhouCui/go-bank/db/util"
+	"github.com/XiaozhouCui/go-bank/ledger"
+	"github.com/XiaozhouCui/go-bank/stream"
 	"github.com/gin-gonic/gin"
+	"github.com/golang/mock/gomock"
 	"github.com/stretchr/testify/require"
 )
 
 func newTestServer(t *testing.T, store db.Store) *Server {
+	// successful requests append to the audit log, which is tested on its own in audit_test.go
+	if mockStore, ok := store.(*mockdb.MockStore); ok {
+		mockStore.EXPECT().AddAuditLogTx(gomock.Any(), gomock.Any()).AnyTimes()
+		// accounts have no approval policy unless a test stubs one before creating the server, see approval_test.go
+		mockStore.EXPECT().GetApprovalPolicy(gomock.Any(), gomock.Any()).AnyTimes().Return(db.ApprovalPolicy{}, db.ErrRecordNotFound)
+	}
+
 	config := util.Config{
 		TokenSymmetricKey:   util.RandomString(32),
 		AccessTokenDuration: time.Minute,
 	}
 
-	server, err := NewServer(config, store)
+	server, err := NewServer(config, store, ledger.NewVerifier(store), stream.NewHub())
 	require.NoError(t, err)
 
 	return server
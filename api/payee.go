@@ -0,0 +1,265 @@
+package api
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+
+	db "github.com/XiaozhouCui/go-bank/db/sqlc"
+	"github.com/XiaozhouCui/go-bank/token"
+	"github.com/gin-gonic/gin"
+)
+
+type createPayeeRequest struct {
+	Nickname  string `json:"nickname" binding:"required,max=64"`
+	AccountID int64  `json:"account_id" binding:"required,min=1"`
+	Currency  string `json:"currency" binding:"required,currency"`
+}
+
+// payeeResponse is a payee with the end of its cooling-off period
+type payeeResponse struct {
+	db.Payee
+	// until then, the payee cannot receive more than the cooling-off amount in total
+	CoolingOffUntil time.Time `json:"cooling_off_until"`
+}
+
+func (server *Server) newPayeeResponse(payee db.Payee) payeeResponse {
+	return payeeResponse{
+		Payee:           payee,
+		CoolingOffUntil: payee.CreatedAt.Add(server.config.PayeeCoolingOffPeriod),
+	}
+}
+
+// createPayee adds an account to the address book of the authenticated user, under a nickname.
+// The account must exist and be in the given currency
+func (server *Server) createPayee(ctx *gin.Context) {
+	var req createPayeeRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+
+	if _, valid := server.validAccount(ctx, req.AccountID, req.Currency); !valid {
+		return
+	}
+
+	authPayload := ctx.MustGet(authorizationPayloadKey).(*token.Payload)
+	payee, err := server.store.CreatePayee(ctx, db.CreatePayeeParams{
+		Owner:     authPayload.Username,
+		Nickname:  req.Nickname,
+		AccountID: req.AccountID,
+		Currency:  req.Currency,
+	})
+	if err != nil {
+		// nicknames and accounts are unique in an address book
+		if db.ErrorCode(err) == db.UniqueViolation {
+			ctx.JSON(http.StatusForbidden, errorResponse(err))
+			return
+		}
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+
+	auditTarget(ctx, "payees", payee.ID)
+	ctx.JSON(http.StatusOK, server.newPayeeResponse(payee))
+}
+
+type getPayeeRequest struct {
+	ID int64 `uri:"id" binding:"required,min=1"`
+}
+
+// current user can only get their own payees
+func (server *Server) getPayee(ctx *gin.Context) {
+	var req getPayeeRequest
+	if err := ctx.ShouldBindUri(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+
+	payee, valid := server.ownedPayee(ctx, req.ID)
+	if !valid {
+		return
+	}
+
+	ctx.JSON(http.StatusOK, server.newPayeeResponse(payee))
+}
+
+type listPayeesRequest struct {
+	PageID   int32 `form:"page_id" binding:"required,min=1"`
+	PageSize int32 `form:"page_size" binding:"required,min=5,max=10"`
+}
+
+// current user can only list their own payees
+func (server *Server) listPayees(ctx *gin.Context) {
+	var req listPayeesRequest
+	if err := ctx.ShouldBindQuery(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+
+	authPayload := ctx.MustGet(authorizationPayloadKey).(*token.Payload)
+	payees, err := server.store.ListPayees(ctx, db.ListPayeesParams{
+		Owner:  authPayload.Username,
+		Limit:  req.PageSize,
+		Offset: (req.PageID - 1) * req.PageSize,
+	})
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+
+	rsp := make([]payeeResponse, len(payees))
+	for i, payee := range payees {
+		rsp[i] = server.newPayeeResponse(payee)
+	}
+	ctx.JSON(http.StatusOK, rsp)
+}
+
+type updatePayeeRequest struct {
+	Nickname string `json:"nickname" binding:"required,max=64"`
+}
+
+// updatePayee renames a payee. Its account cannot change, a new account is a new payee with its own cooling-off period
+func (server *Server) updatePayee(ctx *gin.Context) {
+	var uri getPayeeRequest
+	if err := ctx.ShouldBindUri(&uri); err != nil {
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+	var req updatePayeeRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+
+	payee, valid := server.ownedPayee(ctx, uri.ID)
+	if !valid {
+		return
+	}
+	auditBefore(ctx, payee)
+
+	payee, err := server.store.UpdatePayee(ctx, db.UpdatePayeeParams{
+		ID:       payee.ID,
+		Nickname: req.Nickname,
+	})
+	if err != nil {
+		if db.ErrorCode(err) == db.UniqueViolation {
+			ctx.JSON(http.StatusForbidden, errorResponse(err))
+			return
+		}
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+
+	ctx.JSON(http.StatusOK, server.newPayeeResponse(payee))
+}
+
+// deletePayee removes a payee from the address book, the transfers made to it are kept
+func (server *Server) deletePayee(ctx *gin.Context) {
+	var req getPayeeRequest
+	if err := ctx.ShouldBindUri(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+
+	payee, valid := server.ownedPayee(ctx, req.ID)
+	if !valid {
+		return
+	}
+	auditBefore(ctx, payee)
+
+	if err := server.store.DeletePayee(ctx, payee.ID); err != nil {
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+
+	ctx.JSON(http.StatusOK, server.newPayeeResponse(payee))
+}
+
+// ownedPayee loads a payee of the authenticated user, it writes the error response and returns false otherwise
+func (server *Server) ownedPayee(ctx *gin.Context, id int64) (db.Payee, bool) {
+	payee, err := server.store.GetPayee(ctx, id)
+	if err != nil {
+		if errors.Is(err, db.ErrRecordNotFound) {
+			ctx.JSON(http.StatusNotFound, errorResponse(err))
+			return payee, false
+		}
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return payee, false
+	}
+
+	authPayload := ctx.MustGet(authorizationPayloadKey).(*token.Payload)
+	if payee.Owner != authPayload.Username {
+		err := errors.New("payee doesn't belong to the authenticated user")
+		ctx.JSON(http.StatusUnauthorized, errorResponse(err))
+		return payee, false
+	}
+
+	return payee, true
+}
+
+// payeeTransfer turns a transfer to a payee of the user into a transfer to the payee's account.
+// A transfer to an account ID is returned as is. It returns the status code to answer with
+// if the payee is unknown, not the user's, or in another currency
+func (server *Server) payeeTransfer(ctx *gin.Context, req transferRequest, username string) (transferRequest, int, error) {
+	if req.PayeeID == 0 {
+		return req, http.StatusOK, nil
+	}
+
+	payee, err := server.store.GetPayee(ctx, req.PayeeID)
+	if err != nil {
+		if errors.Is(err, db.ErrRecordNotFound) {
+			return req, http.StatusNotFound, fmt.Errorf("payee [%d]: %w", req.PayeeID, err)
+		}
+		return req, http.StatusInternalServerError, err
+	}
+	if payee.Owner != username {
+		return req, http.StatusUnauthorized, fmt.Errorf("payee [%d] doesn't belong to the current user", payee.ID)
+	}
+	if payee.Currency != req.Currency {
+		return req, http.StatusBadRequest, fmt.Errorf("payee [%d] currency mismatch: %s vs %s", payee.ID, payee.Currency, req.Currency)
+	}
+
+	req.ToAccountID = payee.AccountID
+	return req, http.StatusOK, nil
+}
+
+// checkCoolingOff checks that the user can send amount to an account at now, and returns the status code
+// to answer with if they cannot: while the account is a payee of the user cooling off, the transfers to it,
+// and the funds on hold for it, cannot add up to more than the cooling-off amount. It applies however the
+// account is given, by payee, account ID or account number
+func (server *Server) checkCoolingOff(ctx *gin.Context, username string, toAccountID, amount int64, now time.Time) (int, error) {
+	if server.config.PayeeCoolingOffPeriod == 0 {
+		return http.StatusOK, nil
+	}
+
+	payee, err := server.store.GetPayeeByAccount(ctx, db.GetPayeeByAccountParams{
+		Owner:     username,
+		AccountID: toAccountID,
+	})
+	if err != nil {
+		if errors.Is(err, db.ErrRecordNotFound) {
+			return http.StatusOK, nil
+		}
+		return http.StatusInternalServerError, err
+	}
+	coolingOffUntil := payee.CreatedAt.Add(server.config.PayeeCoolingOffPeriod)
+	if !now.Before(coolingOffUntil) {
+		return http.StatusOK, nil
+	}
+
+	sent, err := server.store.SumPayeeTransfers(ctx, db.SumPayeeTransfersParams{
+		Owner:       username,
+		ToAccountID: toAccountID,
+		Since:       payee.CreatedAt,
+	})
+	if err != nil {
+		return http.StatusInternalServerError, err
+	}
+	if sent+amount > server.config.PayeeCoolingOffAmount {
+		return http.StatusForbidden, fmt.Errorf("payee [%d] can receive at most %d in total until %s, %d already sent",
+			payee.ID, server.config.PayeeCoolingOffAmount, coolingOffUntil.Format(time.RFC3339), sent)
+	}
+	return http.StatusOK, nil
+}
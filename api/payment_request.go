@@ -0,0 +1,308 @@
+package api
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+
+	db "github.com/XiaozhouCui/go-bank/db/sqlc"
+	"github.com/XiaozhouCui/go-bank/token"
+	"github.com/gin-gonic/gin"
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+const (
+	defaultPaymentRequestDuration = 7 * 24 * time.Hour  // a request without expires_at expires after a week
+	maxPaymentRequestDuration     = 30 * 24 * time.Hour // a request cannot wait longer for its payer
+)
+
+type createPaymentRequestRequest struct {
+	Payer       string `json:"payer" binding:"required,alphanum"`
+	ToAccountID int64  `json:"to_account_id" binding:"required,min=1"`
+	Amount      int64  `json:"amount" binding:"required,gt=0"`
+	Currency    string `json:"currency" binding:"required,currency"`
+	Message     string `json:"message" binding:"max=140"`
+	// defaults to defaultPaymentRequestDuration from now
+	ExpiresAt time.Time `json:"expires_at"`
+}
+
+// requester asks another user to pay an amount into one of their accounts
+func (server *Server) createPaymentRequest(ctx *gin.Context) {
+	var req createPaymentRequestRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+
+	now := time.Now()
+	if req.ExpiresAt.IsZero() {
+		req.ExpiresAt = now.Add(defaultPaymentRequestDuration)
+	}
+	if !req.ExpiresAt.After(now) || req.ExpiresAt.After(now.Add(maxPaymentRequestDuration)) {
+		err := fmt.Errorf("expires_at must be in the next %v", maxPaymentRequestDuration)
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+
+	authPayload := ctx.MustGet(authorizationPayloadKey).(*token.Payload)
+	if req.Payer == authPayload.Username {
+		err := errors.New("cannot request a payment from yourself")
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+
+	// the requester is paid into their own account, which must be able to receive the currency
+	account, valid := server.validAccount(ctx, req.ToAccountID, req.Currency)
+	if !valid {
+		return
+	}
+	if account.Owner != authPayload.Username {
+		err := fmt.Errorf("to account [%d] is not owned by the current user", account.ID)
+		ctx.JSON(http.StatusUnauthorized, errorResponse(err))
+		return
+	}
+
+	if _, err := server.store.GetUser(ctx, req.Payer); err != nil {
+		if errors.Is(err, db.ErrRecordNotFound) {
+			ctx.JSON(http.StatusNotFound, errorResponse(err))
+			return
+		}
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+
+	request, err := server.store.CreatePaymentRequest(ctx, db.CreatePaymentRequestParams{
+		Requester:   authPayload.Username,
+		Payer:       req.Payer,
+		ToAccountID: req.ToAccountID,
+		Amount:      req.Amount,
+		Currency:    req.Currency,
+		Message:     req.Message,
+		ExpiresAt:   req.ExpiresAt.UTC(),
+	})
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+
+	auditTarget(ctx, "payment-requests", request.ID)
+	ctx.JSON(http.StatusOK, request)
+}
+
+type getPaymentRequestRequest struct {
+	ID int64 `uri:"id" binding:"required,min=1"`
+}
+
+// the requester and the payer can get a payment request
+func (server *Server) getPaymentRequest(ctx *gin.Context) {
+	var req getPaymentRequestRequest
+	if err := ctx.ShouldBindUri(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+
+	request, valid := server.involvedPaymentRequest(ctx, req.ID)
+	if !valid {
+		return
+	}
+
+	ctx.JSON(http.StatusOK, request)
+}
+
+type listPaymentRequestsRequest struct {
+	// incoming requests are to be paid by the current user, outgoing ones were sent by them
+	Direction string `form:"direction" binding:"required,oneof=incoming outgoing"`
+	Status    string `form:"status" binding:"omitempty,oneof=pending paid declined expired cancelled"`
+	PageID    int32  `form:"page_id" binding:"required,min=1"`
+	PageSize  int32  `form:"page_size" binding:"required,min=5,max=10"`
+}
+
+// current user lists the payment requests they received or sent, newest first
+func (server *Server) listPaymentRequests(ctx *gin.Context) {
+	var req listPaymentRequestsRequest
+	if err := ctx.ShouldBindQuery(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+
+	authPayload := ctx.MustGet(authorizationPayloadKey).(*token.Payload)
+	status := pgtype.Text{String: req.Status, Valid: req.Status != ""}
+	offset := (req.PageID - 1) * req.PageSize
+
+	var requests []db.PaymentRequest
+	var err error
+	if req.Direction == "incoming" {
+		requests, err = server.store.ListIncomingPaymentRequests(ctx, db.ListIncomingPaymentRequestsParams{
+			Payer:  authPayload.Username,
+			Status: status,
+			Limit:  req.PageSize,
+			Offset: offset,
+		})
+	} else {
+		requests, err = server.store.ListOutgoingPaymentRequests(ctx, db.ListOutgoingPaymentRequestsParams{
+			Requester: authPayload.Username,
+			Status:    status,
+			Limit:     req.PageSize,
+			Offset:    offset,
+		})
+	}
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+
+	ctx.JSON(http.StatusOK, requests)
+}
+
+type payPaymentRequestRequest struct {
+	FromAccountID int64 `json:"from_account_id" binding:"required,min=1"`
+}
+
+// the payer pays a pending request from one of their accounts, the payment is a transfer to the requester
+func (server *Server) payPaymentRequest(ctx *gin.Context) {
+	var uri getPaymentRequestRequest
+	if err := ctx.ShouldBindUri(&uri); err != nil {
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+	var req payPaymentRequestRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+
+	request, valid := server.payerPaymentRequest(ctx, uri.ID)
+	if !valid {
+		return
+	}
+	auditBefore(ctx, request)
+
+	// the same checks as a transfer made now
+	_, valid = server.validTransfer(ctx, transferRequest{
+		FromAccountID: req.FromAccountID,
+		ToAccountID:   request.ToAccountID,
+		Amount:        request.Amount,
+		Currency:      request.Currency,
+	})
+	if !valid {
+		return
+	}
+	if !server.noApproval(ctx, req.FromAccountID, request.Amount) {
+		return
+	}
+
+	authPayload := ctx.MustGet(authorizationPayloadKey).(*token.Payload)
+	result, err := server.store.PayPaymentRequestTx(ctx, db.PayPaymentRequestTxParams{
+		ID:            request.ID,
+		FromAccountID: req.FromAccountID,
+		Payer:         authPayload.Username,
+		Now:           time.Now(),
+	})
+	if err != nil {
+		paymentRequestError(ctx, err)
+		return
+	}
+
+	ctx.JSON(http.StatusOK, result)
+}
+
+// the payer declines a pending request
+func (server *Server) declinePaymentRequest(ctx *gin.Context) {
+	var uri getPaymentRequestRequest
+	if err := ctx.ShouldBindUri(&uri); err != nil {
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+
+	request, valid := server.payerPaymentRequest(ctx, uri.ID)
+	if !valid {
+		return
+	}
+
+	server.closePaymentRequest(ctx, request, db.PaymentRequestDeclined)
+}
+
+// the requester cancels a pending request
+func (server *Server) cancelPaymentRequest(ctx *gin.Context) {
+	var uri getPaymentRequestRequest
+	if err := ctx.ShouldBindUri(&uri); err != nil {
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+
+	request, valid := server.involvedPaymentRequest(ctx, uri.ID)
+	if !valid {
+		return
+	}
+
+	authPayload := ctx.MustGet(authorizationPayloadKey).(*token.Payload)
+	if request.Requester != authPayload.Username {
+		err := errors.New("only the requester can cancel a payment request")
+		ctx.JSON(http.StatusForbidden, errorResponse(err))
+		return
+	}
+
+	server.closePaymentRequest(ctx, request, db.PaymentRequestCancelled)
+}
+
+func (server *Server) closePaymentRequest(ctx *gin.Context, request db.PaymentRequest, status string) {
+	auditBefore(ctx, request)
+
+	request, err := server.store.ClosePaymentRequestTx(ctx, db.ClosePaymentRequestTxParams{
+		ID:     request.ID,
+		Status: status,
+		Now:    time.Now(),
+	})
+	if err != nil {
+		paymentRequestError(ctx, err)
+		return
+	}
+
+	ctx.JSON(http.StatusOK, request)
+}
+
+func paymentRequestError(ctx *gin.Context, err error) {
+	if errors.Is(err, db.ErrPaymentRequestNotPending) {
+		ctx.JSON(http.StatusConflict, errorResponse(err))
+		return
+	}
+	ctx.JSON(transferErrorStatus(err), errorResponse(err))
+}
+
+// get a payment request and check that the current user is its payer
+func (server *Server) payerPaymentRequest(ctx *gin.Context, id int64) (db.PaymentRequest, bool) {
+	request, valid := server.involvedPaymentRequest(ctx, id)
+	if !valid {
+		return request, false
+	}
+
+	authPayload := ctx.MustGet(authorizationPayloadKey).(*token.Payload)
+	if request.Payer != authPayload.Username {
+		err := errors.New("only the payer can pay or decline a payment request")
+		ctx.JSON(http.StatusForbidden, errorResponse(err))
+		return request, false
+	}
+	return request, true
+}
+
+// get a payment request and check that the current user is its requester or payer
+func (server *Server) involvedPaymentRequest(ctx *gin.Context, id int64) (db.PaymentRequest, bool) {
+	request, err := server.store.GetPaymentRequest(ctx, id)
+	if err != nil {
+		if errors.Is(err, db.ErrRecordNotFound) {
+			ctx.JSON(http.StatusNotFound, errorResponse(err))
+			return request, false
+		}
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return request, false
+	}
+
+	authPayload := ctx.MustGet(authorizationPayloadKey).(*token.Payload)
+	if request.Requester != authPayload.Username && request.Payer != authPayload.Username {
+		err := errors.New("payment request doesn't involve the authenticated user")
+		ctx.JSON(http.StatusUnauthorized, errorResponse(err))
+		return request, false
+	}
+	return request, true
+}
@@ -0,0 +1,204 @@
+package api
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+
+	db "github.com/XiaozhouCui/go-bank/db/sqlc"
+	"github.com/XiaozhouCui/go-bank/token"
+	"github.com/gin-gonic/gin"
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+// isStricterPolicy reports whether a new policy is at least as strict as the current one:
+// it covers the same transfers or more, needs as many approvals or more, and only from approvers
+// the current policy already trusts
+func isStricterPolicy(current db.ApprovalPolicy, req setApprovalPolicyRequest) bool {
+	if req.Threshold > current.Threshold || req.RequiredApprovals < current.RequiredApprovals {
+		return false
+	}
+	approvers := make(map[string]bool, len(current.Approvers))
+	for _, approver := range current.Approvers {
+		approvers[approver] = true
+	}
+	for _, approver := range req.Approvers {
+		if !approvers[approver] {
+			return false
+		}
+	}
+	return true
+}
+
+// createPolicyChange requests a change loosening or removing the current policy of an account, arg holding
+// the new policy or nothing to remove it. A single owner or co-owner cannot lower the bar set for large transfers
+// on their own: the change is applied once enough approvers of the current policy approve it
+func (server *Server) createPolicyChange(ctx *gin.Context, policy db.ApprovalPolicy, arg db.CreatePolicyChangeParams) {
+	authPayload := ctx.MustGet(authorizationPayloadKey).(*token.Payload)
+
+	// the requester does not approve their own change
+	approvers := 0
+	for _, approver := range policy.Approvers {
+		if approver != authPayload.Username {
+			approvers++
+		}
+	}
+	if approvers < int(policy.RequiredApprovals) {
+		err := fmt.Errorf("account [%d] does not have %d approvers other than the requester", policy.AccountID, policy.RequiredApprovals)
+		ctx.JSON(http.StatusForbidden, errorResponse(err))
+		return
+	}
+
+	arg.AccountID = policy.AccountID
+	arg.RequestedBy = authPayload.Username
+	arg.RequiredApprovals = policy.RequiredApprovals
+	arg.Approvers = policy.Approvers
+	change, err := server.store.CreatePolicyChange(ctx, arg)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+
+	auditTarget(ctx, "policy-changes", change.ID)
+	ctx.JSON(http.StatusAccepted, change)
+}
+
+type policyChangeResponse struct {
+	PolicyChange db.PolicyChange           `json:"policy_change"`
+	Approvals    []db.PolicyChangeApproval `json:"approvals"`
+}
+
+type getPolicyChangeRequest struct {
+	ID int64 `uri:"id" binding:"required,min=1"`
+}
+
+// the members of the account and the approvers can get a policy change with its decisions so far
+func (server *Server) getPolicyChange(ctx *gin.Context) {
+	var req getPolicyChangeRequest
+	if err := ctx.ShouldBindUri(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+
+	change, err := server.store.GetPolicyChange(ctx, req.ID)
+	if err != nil {
+		if errors.Is(err, db.ErrRecordNotFound) {
+			ctx.JSON(http.StatusNotFound, errorResponse(err))
+			return
+		}
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+
+	authPayload := ctx.MustGet(authorizationPayloadKey).(*token.Payload)
+	if !db.CanApprovePolicyChange(change, authPayload.Username) {
+		if _, valid := server.memberAccount(ctx, change.AccountID, db.AccountRoleCanView); !valid {
+			return
+		}
+	}
+
+	approvals, err := server.store.ListPolicyChangeApprovals(ctx, change.ID)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+
+	ctx.JSON(http.StatusOK, policyChangeResponse{
+		PolicyChange: change,
+		Approvals:    approvals,
+	})
+}
+
+type listPolicyChangesRequest struct {
+	Status   string `form:"status" binding:"omitempty,oneof=pending executed rejected"`
+	PageID   int32  `form:"page_id" binding:"required,min=1"`
+	PageSize int32  `form:"page_size" binding:"required,min=5,max=10"`
+}
+
+// every member of an account lists the changes of its approval policy that need approvals, newest first
+func (server *Server) listPolicyChanges(ctx *gin.Context) {
+	var uri getAccountRequest
+	if err := ctx.ShouldBindUri(&uri); err != nil {
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+	var req listPolicyChangesRequest
+	if err := ctx.ShouldBindQuery(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+
+	account, valid := server.memberAccount(ctx, uri.ID, db.AccountRoleCanView)
+	if !valid {
+		return
+	}
+
+	changes, err := server.store.ListPolicyChanges(ctx, db.ListPolicyChangesParams{
+		AccountID: account.ID,
+		Status:    pgtype.Text{String: req.Status, Valid: req.Status != ""},
+		Limit:     req.PageSize,
+		Offset:    (req.PageID - 1) * req.PageSize,
+	})
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+
+	ctx.JSON(http.StatusOK, changes)
+}
+
+// an approver approves a policy change, the approval that reaches the quorum applies it
+func (server *Server) approvePolicyChange(ctx *gin.Context) {
+	server.decidePolicyChange(ctx, db.ApprovalApproved)
+}
+
+// an approver rejects a policy change, the policy stays as it is
+func (server *Server) rejectPolicyChange(ctx *gin.Context) {
+	server.decidePolicyChange(ctx, db.ApprovalRejected)
+}
+
+func (server *Server) decidePolicyChange(ctx *gin.Context, decision string) {
+	var uri getPolicyChangeRequest
+	if err := ctx.ShouldBindUri(&uri); err != nil {
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+
+	change, err := server.store.GetPolicyChange(ctx, uri.ID)
+	if err != nil {
+		if errors.Is(err, db.ErrRecordNotFound) {
+			ctx.JSON(http.StatusNotFound, errorResponse(err))
+			return
+		}
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+
+	// an approver who no longer can spend from the account cannot approve changes of its policy either
+	if _, valid := server.memberAccount(ctx, change.AccountID, db.AccountRoleCanSpend); !valid {
+		return
+	}
+	auditBefore(ctx, change)
+
+	authPayload := ctx.MustGet(authorizationPayloadKey).(*token.Payload)
+	result, err := server.store.DecidePolicyChangeTx(ctx, db.DecidePolicyChangeTxParams{
+		ID:       change.ID,
+		Approver: authPayload.Username,
+		Decision: decision,
+	})
+	if err != nil {
+		// an approver decides once
+		if errors.Is(err, db.ErrPolicyChangeNotPending) || db.ErrorCode(err) == db.UniqueViolation {
+			ctx.JSON(http.StatusConflict, errorResponse(err))
+			return
+		}
+		if errors.Is(err, db.ErrNotApprover) {
+			ctx.JSON(http.StatusForbidden, errorResponse(err))
+			return
+		}
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+
+	ctx.JSON(http.StatusOK, result)
+}
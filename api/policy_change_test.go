@@ -0,0 +1,231 @@
+package api
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	mockdb "github.com/XiaozhouCui/go-bank/db/mock"
+	db "github.com/XiaozhouCui/go-bank/db/sqlc"
+	"github.com/XiaozhouCui/go-bank/db/util"
+	"github.com/golang/mock/gomock"
+	"github.com/jackc/pgx/v5/pgtype"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPolicyChangeAPI(t *testing.T) {
+	owner, _ := randomUser(t)
+	approver, _ := randomUser(t)
+	other, _ := randomUser(t)
+	account := randomAccount(owner.Username)
+
+	// the owner asks to raise the threshold, the other approver of the current policy decides
+	change := db.PolicyChange{
+		ID:                   util.RandomInt(1, 1000),
+		AccountID:            account.ID,
+		NewThreshold:         pgtype.Int8{Int64: 5000, Valid: true},
+		NewRequiredApprovals: pgtype.Int4{Int32: 1, Valid: true},
+		NewApprovers:         []string{owner.Username, approver.Username},
+		RequestedBy:          owner.Username,
+		RequiredApprovals:    1,
+		Approvers:            []string{owner.Username, approver.Username},
+		Status:               db.PolicyChangePending,
+	}
+	approverArg := db.GetAccountMemberParams{AccountID: account.ID, Username: approver.Username}
+	otherArg := db.GetAccountMemberParams{AccountID: account.ID, Username: other.Username}
+
+	testCases := []struct {
+		name          string
+		username      string
+		method        string
+		url           string
+		buildStubs    func(store *mockdb.MockStore)
+		checkResponse func(recorder *httptest.ResponseRecorder)
+	}{
+		{
+			name:     "Get",
+			username: approver.Username,
+			method:   http.MethodGet,
+			url:      fmt.Sprintf("/policy-changes/%d", change.ID),
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetPolicyChange(gomock.Any(), gomock.Eq(change.ID)).Times(1).Return(change, nil)
+				store.EXPECT().ListPolicyChangeApprovals(gomock.Any(), gomock.Eq(change.ID)).Times(1).Return([]db.PolicyChangeApproval{}, nil)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+
+				var rsp policyChangeResponse
+				require.NoError(t, json.NewDecoder(recorder.Body).Decode(&rsp))
+				require.Equal(t, change.ID, rsp.PolicyChange.ID)
+				require.Empty(t, rsp.Approvals)
+			},
+		},
+		{
+			name:     "GetNotMember",
+			username: other.Username,
+			method:   http.MethodGet,
+			url:      fmt.Sprintf("/policy-changes/%d", change.ID),
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetPolicyChange(gomock.Any(), gomock.Eq(change.ID)).Times(1).Return(change, nil)
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Eq(account.ID)).Times(1).Return(account, nil)
+				store.EXPECT().GetAccountMember(gomock.Any(), gomock.Eq(otherArg)).Times(1).Return(db.AccountMember{}, db.ErrRecordNotFound)
+				store.EXPECT().ListPolicyChangeApprovals(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusUnauthorized, recorder.Code)
+			},
+		},
+		{
+			name:     "List",
+			username: owner.Username,
+			method:   http.MethodGet,
+			url:      fmt.Sprintf("/accounts/%d/policy-changes?status=pending&page_id=1&page_size=5", account.ID),
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Eq(account.ID)).Times(1).Return(account, nil)
+
+				arg := db.ListPolicyChangesParams{
+					AccountID: account.ID,
+					Status:    pgtype.Text{String: db.PolicyChangePending, Valid: true},
+					Limit:     5,
+					Offset:    0,
+				}
+				store.EXPECT().ListPolicyChanges(gomock.Any(), gomock.Eq(arg)).Times(1).Return([]db.PolicyChange{change}, nil)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+
+				var changes []db.PolicyChange
+				require.NoError(t, json.NewDecoder(recorder.Body).Decode(&changes))
+				require.Len(t, changes, 1)
+			},
+		},
+		{
+			name:     "Approve",
+			username: approver.Username,
+			method:   http.MethodPost,
+			url:      fmt.Sprintf("/policy-changes/%d/approve", change.ID),
+			buildStubs: func(store *mockdb.MockStore) {
+				executed := change
+				executed.Status = db.PolicyChangeExecuted
+
+				store.EXPECT().GetPolicyChange(gomock.Any(), gomock.Eq(change.ID)).Times(1).Return(change, nil)
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Eq(account.ID)).Times(1).Return(account, nil)
+				store.EXPECT().GetAccountMember(gomock.Any(), gomock.Eq(approverArg)).Times(1).
+					Return(randomAccountMember(account, approver.Username, db.AccountCoOwner, 0), nil)
+
+				arg := db.DecidePolicyChangeTxParams{
+					ID:       change.ID,
+					Approver: approver.Username,
+					Decision: db.ApprovalApproved,
+				}
+				store.EXPECT().DecidePolicyChangeTx(gomock.Any(), gomock.Eq(arg)).Times(1).
+					Return(db.DecidePolicyChangeTxResult{
+						PolicyChange: executed,
+						Policy:       db.ApprovalPolicy{AccountID: account.ID, Threshold: 5000, RequiredApprovals: 1},
+					}, nil)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+
+				var result db.DecidePolicyChangeTxResult
+				require.NoError(t, json.NewDecoder(recorder.Body).Decode(&result))
+				require.Equal(t, db.PolicyChangeExecuted, result.PolicyChange.Status)
+				require.Equal(t, int64(5000), result.Policy.Threshold)
+			},
+		},
+		{
+			name:     "Reject",
+			username: approver.Username,
+			method:   http.MethodPost,
+			url:      fmt.Sprintf("/policy-changes/%d/reject", change.ID),
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetPolicyChange(gomock.Any(), gomock.Eq(change.ID)).Times(1).Return(change, nil)
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Eq(account.ID)).Times(1).Return(account, nil)
+				store.EXPECT().GetAccountMember(gomock.Any(), gomock.Eq(approverArg)).Times(1).
+					Return(randomAccountMember(account, approver.Username, db.AccountCoOwner, 0), nil)
+
+				arg := db.DecidePolicyChangeTxParams{
+					ID:       change.ID,
+					Approver: approver.Username,
+					Decision: db.ApprovalRejected,
+				}
+				store.EXPECT().DecidePolicyChangeTx(gomock.Any(), gomock.Eq(arg)).Times(1)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+			},
+		},
+		{
+			name:     "RequesterApproves",
+			username: owner.Username,
+			method:   http.MethodPost,
+			url:      fmt.Sprintf("/policy-changes/%d/approve", change.ID),
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetPolicyChange(gomock.Any(), gomock.Eq(change.ID)).Times(1).Return(change, nil)
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Eq(account.ID)).Times(1).Return(account, nil)
+				store.EXPECT().DecidePolicyChangeTx(gomock.Any(), gomock.Any()).Times(1).
+					Return(db.DecidePolicyChangeTxResult{}, db.ErrNotApprover)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusForbidden, recorder.Code)
+			},
+		},
+		{
+			name:     "NotPending",
+			username: approver.Username,
+			method:   http.MethodPost,
+			url:      fmt.Sprintf("/policy-changes/%d/approve", change.ID),
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetPolicyChange(gomock.Any(), gomock.Eq(change.ID)).Times(1).Return(change, nil)
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Eq(account.ID)).Times(1).Return(account, nil)
+				store.EXPECT().GetAccountMember(gomock.Any(), gomock.Eq(approverArg)).Times(1).
+					Return(randomAccountMember(account, approver.Username, db.AccountCoOwner, 0), nil)
+				store.EXPECT().DecidePolicyChangeTx(gomock.Any(), gomock.Any()).Times(1).
+					Return(db.DecidePolicyChangeTxResult{}, db.ErrPolicyChangeNotPending)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusConflict, recorder.Code)
+			},
+		},
+		{
+			name:     "RemovedApprover",
+			username: approver.Username,
+			method:   http.MethodPost,
+			url:      fmt.Sprintf("/policy-changes/%d/approve", change.ID),
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetPolicyChange(gomock.Any(), gomock.Eq(change.ID)).Times(1).Return(change, nil)
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Eq(account.ID)).Times(1).Return(account, nil)
+				store.EXPECT().GetAccountMember(gomock.Any(), gomock.Eq(approverArg)).Times(1).Return(db.AccountMember{}, db.ErrRecordNotFound)
+				store.EXPECT().DecidePolicyChangeTx(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusUnauthorized, recorder.Code)
+			},
+		},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			store := mockdb.NewMockStore(ctrl)
+			tc.buildStubs(store)
+
+			server := newTestServer(t, store)
+			recorder := httptest.NewRecorder()
+
+			request, err := http.NewRequest(tc.method, tc.url, nil)
+			require.NoError(t, err)
+
+			addAuthorization(t, request, server.tokenMaker, authorizationTypeBearer, tc.username, util.DepositorRole, time.Minute)
+			server.router.ServeHTTP(recorder, request)
+			tc.checkResponse(recorder)
+		})
+	}
+}
@@ -0,0 +1,289 @@
+package api
+
+import (
+	"errors"
+	"net/http"
+	"time"
+
+	db "github.com/XiaozhouCui/go-bank/db/sqlc"
+	"github.com/XiaozhouCui/go-bank/token"
+	"github.com/gin-gonic/gin"
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+// retries of a failed run when the request does not set max_retries
+const defaultMaxRetries = 3
+
+type createScheduledTransferRequest struct {
+	FromAccountID int64     `json:"from_account_id" binding:"required,min=1"`
+	ToAccountID   int64     `json:"to_account_id" binding:"required,min=1"`
+	Amount        int64     `json:"amount" binding:"required,gt=0"`
+	Currency      string    `json:"currency" binding:"required,currency"`
+	StartAt       time.Time `json:"start_at" binding:"required"`
+	// "schedule" validator is registered in server.go, omit it for a one-off transfer
+	Schedule      string `json:"schedule" binding:"omitempty,schedule"`
+	FailurePolicy string `json:"failure_policy" binding:"omitempty,oneof=retry skip"`
+	MaxRetries    int64  `json:"max_retries" binding:"omitempty,min=1,max=10"`
+}
+
+// customer schedules a transfer from one of their accounts, once or on a recurring schedule
+func (server *Server) createScheduledTransfer(ctx *gin.Context) {
+	var req createScheduledTransferRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+
+	if !req.StartAt.After(time.Now()) {
+		err := errors.New("start_at must be in the future")
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+
+	// the same checks as a transfer made now
+	_, valid := server.validTransfer(ctx, transferRequest{
+		FromAccountID: req.FromAccountID,
+		ToAccountID:   req.ToAccountID,
+		Amount:        req.Amount,
+		Currency:      req.Currency,
+	})
+	if !valid {
+		return
+	}
+	if !server.noApproval(ctx, req.FromAccountID, req.Amount) {
+		return
+	}
+
+	if req.FailurePolicy == "" {
+		req.FailurePolicy = db.FailurePolicyRetry
+	}
+	if req.MaxRetries == 0 {
+		req.MaxRetries = defaultMaxRetries
+	}
+
+	authPayload := ctx.MustGet(authorizationPayloadKey).(*token.Payload)
+
+	arg := db.CreateScheduledTransferParams{
+		Owner:         authPayload.Username,
+		FromAccountID: req.FromAccountID,
+		ToAccountID:   req.ToAccountID,
+		Amount:        req.Amount,
+		Currency:      req.Currency,
+		Schedule:      req.Schedule,
+		StartAt:       req.StartAt.UTC(),
+		NextRunAt:     pgtype.Timestamptz{Time: req.StartAt.UTC(), Valid: true},
+		FailurePolicy: req.FailurePolicy,
+		MaxRetries:    req.MaxRetries,
+	}
+	scheduled, err := server.store.CreateScheduledTransfer(ctx, arg)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+
+	auditTarget(ctx, "scheduled-transfers", scheduled.ID)
+	ctx.JSON(http.StatusOK, scheduled)
+}
+
+type getScheduledTransferRequest struct {
+	ID int64 `uri:"id" binding:"required,min=1"`
+}
+
+func (server *Server) getScheduledTransfer(ctx *gin.Context) {
+	var req getScheduledTransferRequest
+	if err := ctx.ShouldBindUri(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+
+	scheduled, valid := server.ownedScheduledTransfer(ctx, req.ID)
+	if !valid {
+		return
+	}
+
+	ctx.JSON(http.StatusOK, scheduled)
+}
+
+type listScheduledTransfersRequest struct {
+	PageID   int32 `form:"page_id" binding:"required,min=1"`
+	PageSize int32 `form:"page_size" binding:"required,min=5,max=10"`
+}
+
+// current user can only list their own scheduled transfers
+func (server *Server) listScheduledTransfers(ctx *gin.Context) {
+	var req listScheduledTransfersRequest
+	if err := ctx.ShouldBindQuery(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+
+	authPayload := ctx.MustGet(authorizationPayloadKey).(*token.Payload)
+	arg := db.ListScheduledTransfersParams{
+		Owner:  authPayload.Username,
+		Limit:  req.PageSize,
+		Offset: (req.PageID - 1) * req.PageSize,
+	}
+	scheduled, err := server.store.ListScheduledTransfers(ctx, arg)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+
+	ctx.JSON(http.StatusOK, scheduled)
+}
+
+type updateScheduledTransferRequest struct {
+	Amount        int64  `json:"amount" binding:"required,gt=0"`
+	FailurePolicy string `json:"failure_policy" binding:"required,oneof=retry skip"`
+	MaxRetries    int64  `json:"max_retries" binding:"min=0,max=10"`
+	Paused        bool   `json:"paused"`
+}
+
+// owner changes the amount or failure policy, or pauses and resumes a scheduled transfer.
+// the accounts and the schedule cannot change, cancel it and create a new one instead
+func (server *Server) updateScheduledTransfer(ctx *gin.Context) {
+	var uri getScheduledTransferRequest
+	if err := ctx.ShouldBindUri(&uri); err != nil {
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+	var req updateScheduledTransferRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+
+	scheduled, valid := server.ownedScheduledTransfer(ctx, uri.ID)
+	if !valid {
+		return
+	}
+	// the owner must still be allowed to send the new amount from the account, as a transfer made now
+	fromAccount, err := server.store.GetAccount(ctx, scheduled.FromAccountID)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+	member, valid := server.authorizeAccount(ctx, fromAccount, db.AccountRoleCanSpend)
+	if !valid {
+		return
+	}
+	if status, err := server.checkSpendLimit(ctx, member, 0, req.Amount); err != nil {
+		ctx.JSON(status, errorResponse(err))
+		return
+	}
+	// a larger amount may need approvals, which scheduled transfers cannot wait for
+	if !server.noApproval(ctx, scheduled.FromAccountID, req.Amount) {
+		return
+	}
+	auditBefore(ctx, scheduled)
+
+	status := db.ScheduledTransferActive
+	if req.Paused {
+		status = db.ScheduledTransferPaused
+	}
+
+	server.changeScheduledTransfer(ctx, db.UpdateScheduledTransferTxParams{
+		ID:            uri.ID,
+		Amount:        req.Amount,
+		FailurePolicy: req.FailurePolicy,
+		MaxRetries:    req.MaxRetries,
+		Status:        status,
+		Now:           time.Now(),
+	})
+}
+
+// owner cancels a scheduled transfer, its past runs are kept
+func (server *Server) cancelScheduledTransfer(ctx *gin.Context) {
+	var uri getScheduledTransferRequest
+	if err := ctx.ShouldBindUri(&uri); err != nil {
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+
+	scheduled, valid := server.ownedScheduledTransfer(ctx, uri.ID)
+	if !valid {
+		return
+	}
+	auditBefore(ctx, scheduled)
+
+	server.changeScheduledTransfer(ctx, db.UpdateScheduledTransferTxParams{
+		ID:            scheduled.ID,
+		Amount:        scheduled.Amount,
+		FailurePolicy: scheduled.FailurePolicy,
+		MaxRetries:    scheduled.MaxRetries,
+		Status:        db.ScheduledTransferCancelled,
+		Now:           time.Now(),
+	})
+}
+
+func (server *Server) changeScheduledTransfer(ctx *gin.Context, arg db.UpdateScheduledTransferTxParams) {
+	scheduled, err := server.store.UpdateScheduledTransferTx(ctx, arg)
+	if err != nil {
+		if errors.Is(err, db.ErrScheduledTransferEnded) {
+			ctx.JSON(http.StatusConflict, errorResponse(err))
+			return
+		}
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+
+	ctx.JSON(http.StatusOK, scheduled)
+}
+
+type listScheduledTransferRunsRequest struct {
+	PageID   int32 `form:"page_id" binding:"required,min=1"`
+	PageSize int32 `form:"page_size" binding:"required,min=5,max=10"`
+}
+
+// owner sees the outcome of each run of a scheduled transfer
+func (server *Server) listScheduledTransferRuns(ctx *gin.Context) {
+	var uri getScheduledTransferRequest
+	if err := ctx.ShouldBindUri(&uri); err != nil {
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+	var req listScheduledTransferRunsRequest
+	if err := ctx.ShouldBindQuery(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+
+	if _, valid := server.ownedScheduledTransfer(ctx, uri.ID); !valid {
+		return
+	}
+
+	arg := db.ListScheduledTransferRunsParams{
+		ScheduledTransferID: uri.ID,
+		Limit:               req.PageSize,
+		Offset:              (req.PageID - 1) * req.PageSize,
+	}
+	runs, err := server.store.ListScheduledTransferRuns(ctx, arg)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+
+	ctx.JSON(http.StatusOK, runs)
+}
+
+// get a scheduled transfer and check that it belongs to the current user
+func (server *Server) ownedScheduledTransfer(ctx *gin.Context, id int64) (db.ScheduledTransfer, bool) {
+	scheduled, err := server.store.GetScheduledTransfer(ctx, id)
+	if err != nil {
+		if errors.Is(err, db.ErrRecordNotFound) {
+			ctx.JSON(http.StatusNotFound, errorResponse(err))
+			return scheduled, false
+		}
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return scheduled, false
+	}
+
+	authPayload := ctx.MustGet(authorizationPayloadKey).(*token.Payload)
+	if scheduled.Owner != authPayload.Username {
+		err := errors.New("scheduled transfer doesn't belong to the authenticated user")
+		ctx.JSON(http.StatusUnauthorized, errorResponse(err))
+		return scheduled, false
+	}
+
+	return scheduled, true
+}
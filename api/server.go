@@ -5,6 +5,8 @@ import (
 
 	db "github.com/XiaozhouCui/go-bank/db/sqlc"
 	"github.com/XiaozhouCui/go-bank/db/util"
+	"github.com/XiaozhouCui/go-bank/ledger"
+	"github.com/XiaozhouCui/go-bank/stream"
 	"github.com/XiaozhouCui/go-bank/token"
 	"github.com/gin-gonic/gin"
 	"github.com/gin-gonic/gin/binding"
@@ -17,9 +19,13 @@ type Server struct {
 	store      db.Store
 	tokenMaker token.Maker
 	router     *gin.Engine
+	ledger     *ledger.Verifier
+	hub        *stream.Hub
 }
 
-func NewServer(config util.Config, store db.Store) (*Server, error) {
+// NewServer creates a new HTTP server. Its health reflects the last check of the ledger verifier,
+// and it streams the entries published to hub.
+func NewServer(config util.Config, store db.Store, verifier *ledger.Verifier, hub *stream.Hub) (*Server, error) {
 	// Add TokenMaker (Paseto / JWT)
 	tokenMaker, err := token.NewPasetoMaker(config.TokenSymmetricKey)
 	// tokenMaker, err := token.NewJWTMaker(config.TokenSymmetricKey)
@@ -31,11 +37,11 @@ func NewServer(config util.Config, store db.Store) (*Server, error) {
 		config:     config,
 		store:      store,
 		tokenMaker: tokenMaker,
+		ledger:     verifier,
+		hub:        hub,
 	}
 
-	if v, ok := binding.Validator.Engine().(*validator.Validate); ok {
-		v.RegisterValidation("currency", validCurrency)
-	}
+	RegisterValidators()
 
 	// add routes to server.router
 	server.setupRouter()
@@ -43,19 +49,142 @@ func NewServer(config util.Config, store db.Store) (*Server, error) {
 	return server, nil
 }
 
+// RegisterValidators registers the custom validators of the API with gin,
+// for the packages that validate their input the same way, like bulk.
+func RegisterValidators() {
+	if v, ok := binding.Validator.Engine().(*validator.Validate); ok {
+		v.RegisterValidation("currency", validCurrency)
+		v.RegisterValidation("account_type", validAccountType)
+		v.RegisterValidation("day_count", validDayCount)
+		v.RegisterValidation("fee_kind", validFeeKind)
+		v.RegisterValidation("schedule", validSchedule)
+		v.RegisterValidation("webhook_url", validWebhookURL)
+		v.RegisterValidation("webhook_event", validWebhookEvent)
+		v.RegisterValidation("account_number", validAccountNumber)
+		v.RegisterValidation("reference", validReference)
+		v.RegisterValidation("tag", validTag)
+	}
+}
+
 func (server *Server) setupRouter() {
 	router := gin.Default()
+	router.Use(requestIDMiddleware(), server.auditMiddleware())
 
 	router.POST("/users", server.createUser)
 	router.POST("/users/login", server.loginUser)
 
+	router.GET("/health", server.health)
+	router.GET("/metrics", server.metrics)
+
 	authRoutes := router.Group("/").Use(authMiddleware(server.tokenMaker))
 
 	authRoutes.POST("/accounts", server.createAccount)
 	authRoutes.GET("/accounts/:id", server.getAccount)
 	authRoutes.GET("/accounts", server.listAccount)
+	authRoutes.GET("/accounts/:id/balance", server.getAccountBalance)
+	authRoutes.GET("/accounts/:id/statements", server.getStatement)
+	authRoutes.GET("/accounts/:id/camt053", server.getCamtStatement)
+	authRoutes.GET("/accounts/:id/camt052", server.getCamtReport)
+	authRoutes.GET("/accounts/:id/events", server.streamAccountEvents)
+	authRoutes.GET("/accounts/:id/ws", server.streamAccountWebSocket)
+
+	authRoutes.POST("/accounts/:id/close", server.closeAccount)
+	authRoutes.POST("/accounts/:id/members", server.inviteAccountMember)
+	authRoutes.GET("/accounts/:id/members", server.listAccountMembers)
+	authRoutes.POST("/accounts/:id/members/accept", server.acceptAccountMember)
+	authRoutes.DELETE("/accounts/:id/members/:username", server.removeAccountMember)
+	authRoutes.GET("/accounts/:id/holds", server.listAccountHolds)
+	authRoutes.PUT("/accounts/:id/approval-policy", server.setApprovalPolicy)
+	authRoutes.GET("/accounts/:id/approval-policy", server.getApprovalPolicy)
+	authRoutes.DELETE("/accounts/:id/approval-policy", server.deleteApprovalPolicy)
+	authRoutes.GET("/accounts/:id/pending-transfers", server.listPendingTransfers)
+	authRoutes.GET("/accounts/:id/policy-changes", server.listPolicyChanges)
+	authRoutes.GET("/accounts/:id/entries", server.listAccountEntries)
+	authRoutes.PUT("/accounts/:id/entries/:entry_id/tags", server.setEntryTags)
+
+	authRoutes.GET("/account-numbers/:number", server.lookupAccountNumber)
 
 	authRoutes.POST("/transfers", server.createTransfer)
+	authRoutes.POST("/transfers/quote", server.quoteTransfer)
+	authRoutes.POST("/transfers/batch", server.createBatchTransfer)
+
+	authRoutes.GET("/pending-transfers/:id", server.getPendingTransfer)
+	authRoutes.POST("/pending-transfers/:id/approve", server.approvePendingTransfer)
+	authRoutes.POST("/pending-transfers/:id/reject", server.rejectPendingTransfer)
+	authRoutes.GET("/policy-changes/:id", server.getPolicyChange)
+	authRoutes.POST("/policy-changes/:id/approve", server.approvePolicyChange)
+	authRoutes.POST("/policy-changes/:id/reject", server.rejectPolicyChange)
+
+	authRoutes.GET("/interest-plans", server.listInterestPlans)
+
+	authRoutes.POST("/scheduled-transfers", server.createScheduledTransfer)
+	authRoutes.GET("/scheduled-transfers/:id", server.getScheduledTransfer)
+	authRoutes.GET("/scheduled-transfers", server.listScheduledTransfers)
+	authRoutes.PUT("/scheduled-transfers/:id", server.updateScheduledTransfer)
+	authRoutes.DELETE("/scheduled-transfers/:id", server.cancelScheduledTransfer)
+	authRoutes.GET("/scheduled-transfers/:id/runs", server.listScheduledTransferRuns)
+
+	authRoutes.POST("/holds", server.placeHold)
+	authRoutes.GET("/holds/:id", server.getHold)
+	authRoutes.POST("/holds/:id/capture", server.captureHold)
+	authRoutes.POST("/holds/:id/release", server.releaseHold)
+
+	authRoutes.POST("/imports", server.createImport)
+	authRoutes.GET("/imports/:id", server.getImport)
+	authRoutes.GET("/imports", server.listImports)
+	authRoutes.POST("/imports/:id/execute", server.executeImport)
+
+	authRoutes.POST("/payees", server.createPayee)
+	authRoutes.GET("/payees/:id", server.getPayee)
+	authRoutes.GET("/payees", server.listPayees)
+	authRoutes.PUT("/payees/:id", server.updatePayee)
+	authRoutes.DELETE("/payees/:id", server.deletePayee)
+
+	authRoutes.POST("/payment-requests", server.createPaymentRequest)
+	authRoutes.GET("/payment-requests/:id", server.getPaymentRequest)
+	authRoutes.GET("/payment-requests", server.listPaymentRequests)
+	authRoutes.POST("/payment-requests/:id/pay", server.payPaymentRequest)
+	authRoutes.POST("/payment-requests/:id/decline", server.declinePaymentRequest)
+	authRoutes.POST("/payment-requests/:id/cancel", server.cancelPaymentRequest)
+
+	authRoutes.POST("/webhooks", server.createWebhook)
+	authRoutes.GET("/webhooks/:id", server.getWebhook)
+	authRoutes.GET("/webhooks", server.listWebhooks)
+	authRoutes.DELETE("/webhooks/:id", server.deleteWebhook)
+	authRoutes.GET("/webhooks/:id/deliveries", server.listWebhookDeliveries)
+	authRoutes.GET("/webhooks/:id/deliveries/:delivery_id", server.getWebhookDelivery)
+	authRoutes.POST("/webhooks/:id/deliveries/:delivery_id/retry", server.retryWebhookDelivery)
+
+	// routes for bank staff only
+	staffRoutes := router.Group("/").Use(authMiddleware(server.tokenMaker), roleMiddleware(util.BankerRole))
+
+	staffRoutes.POST("/accounts/:id/freeze", server.freezeAccount)
+	staffRoutes.POST("/accounts/:id/unfreeze", server.unfreezeAccount)
+	staffRoutes.POST("/accounts/:id/interest-plan", server.setAccountInterestPlan)
+
+	staffRoutes.POST("/interest-plans", server.createInterestPlan)
+
+	staffRoutes.POST("/fee-schedules", server.createFeeSchedule)
+	staffRoutes.GET("/fee-schedules", server.listFeeSchedules)
+
+	staffRoutes.PUT("/transfer-limits/default", server.updateDefaultTransferLimit)
+	staffRoutes.PUT("/users/:username/transfer-limits", server.setUserTransferLimit)
+	staffRoutes.DELETE("/users/:username/transfer-limits", server.deleteUserTransferLimit)
+	staffRoutes.PUT("/accounts/:id/transfer-limits", server.setAccountTransferLimit)
+	staffRoutes.DELETE("/accounts/:id/transfer-limits", server.deleteAccountTransferLimit)
+
+	staffRoutes.GET("/audit-log", server.listAuditLog)
+	staffRoutes.GET("/audit-log/verify", server.verifyAuditLog)
+
+	// routes for customer support only
+	adminRoutes := router.Group("/admin").Use(authMiddleware(server.tokenMaker), roleMiddleware(util.SupportRole))
+
+	adminRoutes.GET("/users", server.searchUsers)
+	adminRoutes.GET("/users/:username/accounts", server.listUserAccounts)
+	adminRoutes.GET("/accounts/:id", server.getAdminAccount)
+	adminRoutes.GET("/accounts/:id/entries", server.listAdminAccountEntries)
+	adminRoutes.POST("/accounts/:id/adjustments", server.createManualAdjustment)
+	adminRoutes.GET("/accounts/:id/adjustments", server.listManualAdjustments)
 
 	server.router = router
 }
@@ -0,0 +1,95 @@
+package api
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+
+	db "github.com/XiaozhouCui/go-bank/db/sqlc"
+	"github.com/XiaozhouCui/go-bank/db/util"
+	"github.com/XiaozhouCui/go-bank/statement"
+	"github.com/XiaozhouCui/go-bank/token"
+	"github.com/gin-gonic/gin"
+)
+
+type getStatementRequest struct {
+	Month  string `form:"month" binding:"required,datetime=2006-01"`
+	Format string `form:"format" binding:"omitempty,oneof=pdf csv"` // defaults to pdf
+}
+
+// the owner or bank staff download the statement of an account for a month that has ended
+// (e.g. /accounts/1/statements?month=2023-01&format=csv).
+// it is generated the first time it is asked for, and stored for later downloads
+func (server *Server) getStatement(ctx *gin.Context) {
+	var uri getAccountRequest
+	if err := ctx.ShouldBindUri(&uri); err != nil {
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+	var req getStatementRequest
+	if err := ctx.ShouldBindQuery(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+	month, err := time.Parse(statement.MonthLayout, req.Month)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+	start, end := statement.Period(month)
+
+	account, ok := server.statementAccount(ctx, uri.ID)
+	if !ok {
+		return
+	}
+
+	// a stored statement never changes, so the month must be over
+	if end.After(time.Now()) {
+		err := fmt.Errorf("the statement for %s is not available until the month has ended", req.Month)
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+	if !end.After(account.CreatedAt) {
+		err := fmt.Errorf("the account was opened after %s", req.Month)
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+
+	stored, err := statement.Load(ctx, server.store, account, start)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+
+	filename := fmt.Sprintf("statement-%d-%s", account.ID, req.Month)
+	if req.Format == "csv" {
+		ctx.Header("Content-Disposition", fmt.Sprintf(`attachment; filename="%s.csv"`, filename))
+		ctx.Data(http.StatusOK, "text/csv", stored.Csv)
+		return
+	}
+	ctx.Header("Content-Disposition", fmt.Sprintf(`attachment; filename="%s.pdf"`, filename))
+	ctx.Data(http.StatusOK, "application/pdf", stored.Pdf)
+}
+
+// statementAccount loads an account whose statements the authenticated user can read:
+// their own or shared with them, or any account for bankers, who act as auditors.
+// it writes the error response and returns false otherwise
+func (server *Server) statementAccount(ctx *gin.Context, id int64) (db.Account, bool) {
+	account, err := server.store.GetAccount(ctx, id)
+	if err != nil {
+		if errors.Is(err, db.ErrRecordNotFound) {
+			ctx.JSON(http.StatusNotFound, errorResponse(err))
+			return account, false
+		}
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return account, false
+	}
+
+	authPayload := ctx.MustGet(authorizationPayloadKey).(*token.Payload)
+	if authPayload.Role == util.BankerRole {
+		return account, true
+	}
+	_, valid := server.authorizeAccount(ctx, account, db.AccountRoleCanView)
+	return account, valid
+}
@@ -0,0 +1,142 @@
+package api
+
+import (
+	"errors"
+	"io"
+	"net/http"
+	"time"
+
+	db "github.com/XiaozhouCui/go-bank/db/sqlc"
+	"github.com/gin-gonic/gin"
+	"golang.org/x/net/websocket"
+)
+
+// an idle event stream gets a comment this often, so proxies do not close it
+const streamHeartbeat = 15 * time.Second
+
+// account stream event types
+const (
+	balanceEvent = "balance" // first event of a stream, with the current balance
+	entryEvent   = "entry"   // a new entry, with the balance right after it
+)
+
+// accountEvent is pushed to the clients streaming an account
+type accountEvent struct {
+	Type    string    `json:"type"`
+	Balance int64     `json:"balance"`
+	Entry   *db.Entry `json:"entry,omitempty"`
+}
+
+func newEntryEvent(entry db.Entry) accountEvent {
+	return accountEvent{
+		Type:    entryEvent,
+		Balance: entry.BalanceAfter,
+		Entry:   &entry,
+	}
+}
+
+type streamAccountRequest struct {
+	ID int64 `uri:"id" binding:"required,min=1"`
+}
+
+// streamAccountEvents streams the balance and the new entries of an account of the current user
+// as server-sent events. The stream ends if the client falls too far behind, it reconnects to catch up.
+func (server *Server) streamAccountEvents(ctx *gin.Context) {
+	first, entries, unsubscribe, valid := server.subscribeAccount(ctx)
+	if !valid {
+		return
+	}
+	defer unsubscribe()
+
+	ctx.Header("Cache-Control", "no-cache")
+	// tell nginx not to buffer the stream
+	ctx.Header("X-Accel-Buffering", "no")
+	ctx.SSEvent(first.Type, first)
+	ctx.Writer.Flush()
+
+	heartbeat := time.NewTicker(streamHeartbeat)
+	defer heartbeat.Stop()
+
+	for {
+		select {
+		case <-ctx.Request.Context().Done():
+			return
+		case entry, ok := <-entries:
+			if !ok {
+				return
+			}
+			ctx.SSEvent(entryEvent, newEntryEvent(entry))
+		case <-heartbeat.C:
+			ctx.Writer.WriteString(": heartbeat\n\n")
+		}
+		ctx.Writer.Flush()
+	}
+}
+
+// streamAccountWebSocket streams the same events as streamAccountEvents over a WebSocket, one JSON text message each.
+// The client is not expected to send anything.
+func (server *Server) streamAccountWebSocket(ctx *gin.Context) {
+	first, entries, unsubscribe, valid := server.subscribeAccount(ctx)
+	if !valid {
+		return
+	}
+	defer unsubscribe()
+
+	// the client authenticates with its token, so the origin is not checked
+	websocket.Server{Handler: func(conn *websocket.Conn) {
+		closed := make(chan struct{})
+		go func() {
+			// reading notices when the client goes away
+			io.Copy(io.Discard, conn)
+			close(closed)
+		}()
+
+		if err := websocket.JSON.Send(conn, first); err != nil {
+			return
+		}
+		for {
+			select {
+			case <-closed:
+				return
+			case entry, ok := <-entries:
+				if !ok {
+					return
+				}
+				if err := websocket.JSON.Send(conn, newEntryEvent(entry)); err != nil {
+					return
+				}
+			}
+		}
+	}}.ServeHTTP(ctx.Writer, ctx.Request)
+}
+
+// subscribeAccount subscribes to the entries of an account the authenticated user can view, and returns its first event
+// with the balance read after subscribing, so no entry is missed. Call unsubscribe once done.
+// It writes the error response and returns false otherwise
+func (server *Server) subscribeAccount(ctx *gin.Context) (first accountEvent, entries <-chan db.Entry, unsubscribe func(), valid bool) {
+	var req streamAccountRequest
+	if err := ctx.ShouldBindUri(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+
+	entries, unsubscribe = server.hub.Subscribe(req.ID)
+	account, err := server.store.GetAccount(ctx, req.ID)
+	if err != nil {
+		unsubscribe()
+		if errors.Is(err, db.ErrRecordNotFound) {
+			ctx.JSON(http.StatusNotFound, errorResponse(err))
+			return
+		}
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+
+	if _, allowed := server.authorizeAccount(ctx, account, db.AccountRoleCanView); !allowed {
+		unsubscribe()
+		return
+	}
+
+	first = accountEvent{Type: balanceEvent, Balance: account.Balance}
+	return first, entries, unsubscribe, true
+}
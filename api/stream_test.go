@@ -0,0 +1,183 @@
+package api
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	mockdb "github.com/XiaozhouCui/go-bank/db/mock"
+	db "github.com/XiaozhouCui/go-bank/db/sqlc"
+	"github.com/XiaozhouCui/go-bank/db/util"
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/net/websocket"
+)
+
+// readServerSentEvent reads the next event of a stream, skipping comments
+func readServerSentEvent(t *testing.T, reader *bufio.Reader) (string, accountEvent) {
+	var name string
+	var event accountEvent
+	for {
+		line, err := reader.ReadString('\n')
+		require.NoError(t, err)
+		line = strings.TrimSuffix(line, "\n")
+
+		switch {
+		case strings.HasPrefix(line, "event:"):
+			name = strings.TrimPrefix(line, "event:")
+		case strings.HasPrefix(line, "data:"):
+			require.NoError(t, json.Unmarshal([]byte(strings.TrimPrefix(line, "data:")), &event))
+		case line == "" && name != "":
+			return name, event
+		}
+	}
+}
+
+func TestStreamAccountEventsAPI(t *testing.T) {
+	user, _ := randomUser(t)
+	account := randomAccount(user.Username)
+
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	store := mockdb.NewMockStore(ctrl)
+	store.EXPECT().GetAccount(gomock.Any(), gomock.Eq(account.ID)).Times(1).Return(account, nil)
+
+	server := newTestServer(t, store)
+	httpServer := httptest.NewServer(server.router)
+	defer httpServer.Close()
+
+	url := fmt.Sprintf("%s/accounts/%d/events", httpServer.URL, account.ID)
+	request, err := http.NewRequest(http.MethodGet, url, nil)
+	require.NoError(t, err)
+	addAuthorization(t, request, server.tokenMaker, authorizationTypeBearer, user.Username, util.DepositorRole, time.Minute)
+
+	response, err := http.DefaultClient.Do(request)
+	require.NoError(t, err)
+	defer response.Body.Close()
+	require.Equal(t, http.StatusOK, response.StatusCode)
+	require.Equal(t, "text/event-stream", response.Header.Get("Content-Type"))
+
+	reader := bufio.NewReader(response.Body)
+	name, event := readServerSentEvent(t, reader)
+	require.Equal(t, balanceEvent, name)
+	require.Equal(t, account.Balance, event.Balance)
+	require.Nil(t, event.Entry)
+
+	// the stream is subscribed once the balance is sent, entries of other accounts are not streamed
+	server.hub.Publish(db.Entry{ID: 1, AccountID: account.ID + 1, Amount: 99, BalanceAfter: 99})
+	server.hub.Publish(db.Entry{ID: 2, AccountID: account.ID, Amount: -10, BalanceAfter: account.Balance - 10})
+
+	name, event = readServerSentEvent(t, reader)
+	require.Equal(t, entryEvent, name)
+	require.Equal(t, account.Balance-10, event.Balance)
+	require.Equal(t, int64(2), event.Entry.ID)
+	require.Equal(t, int64(-10), event.Entry.Amount)
+}
+
+func TestStreamAccountEventsErrorsAPI(t *testing.T) {
+	user, _ := randomUser(t)
+	account := randomAccount(user.Username)
+
+	testCases := []struct {
+		name          string
+		username      string
+		buildStubs    func(store *mockdb.MockStore)
+		checkResponse func(recoder *httptest.ResponseRecorder)
+	}{
+		{
+			name:     "UnauthorizedUser",
+			username: "unauthorized_user",
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Eq(account.ID)).Times(1).Return(account, nil)
+				store.EXPECT().GetAccountMember(gomock.Any(), gomock.Any()).Times(1).Return(db.AccountMember{}, db.ErrRecordNotFound)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusUnauthorized, recorder.Code)
+			},
+		},
+		{
+			name:     "NotFound",
+			username: user.Username,
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Eq(account.ID)).Times(1).Return(db.Account{}, db.ErrRecordNotFound)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusNotFound, recorder.Code)
+			},
+		},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			store := mockdb.NewMockStore(ctrl)
+			tc.buildStubs(store)
+
+			server := newTestServer(t, store)
+			recorder := httptest.NewRecorder()
+
+			url := fmt.Sprintf("/accounts/%d/events", account.ID)
+			request, err := http.NewRequest(http.MethodGet, url, nil)
+			require.NoError(t, err)
+
+			addAuthorization(t, request, server.tokenMaker, authorizationTypeBearer, tc.username, util.DepositorRole, time.Minute)
+			server.router.ServeHTTP(recorder, request)
+			tc.checkResponse(recorder)
+
+			// the failed stream does not stay subscribed
+			require.Zero(t, server.hub.Subscribers(account.ID))
+		})
+	}
+}
+
+func TestStreamAccountWebSocketAPI(t *testing.T) {
+	user, _ := randomUser(t)
+	account := randomAccount(user.Username)
+
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	store := mockdb.NewMockStore(ctrl)
+	store.EXPECT().GetAccount(gomock.Any(), gomock.Eq(account.ID)).Times(1).Return(account, nil)
+
+	server := newTestServer(t, store)
+	httpServer := httptest.NewServer(server.router)
+	defer httpServer.Close()
+
+	url := fmt.Sprintf("ws%s/accounts/%d/ws", strings.TrimPrefix(httpServer.URL, "http"), account.ID)
+	config, err := websocket.NewConfig(url, httpServer.URL)
+	require.NoError(t, err)
+	accessToken, err := server.tokenMaker.CreateToken(user.Username, util.DepositorRole, time.Minute)
+	require.NoError(t, err)
+	config.Header.Set(authorizationHeaderKey, authorizationTypeBearer+" "+accessToken)
+
+	conn, err := websocket.DialConfig(config)
+	require.NoError(t, err)
+
+	var event accountEvent
+	require.NoError(t, websocket.JSON.Receive(conn, &event))
+	require.Equal(t, balanceEvent, event.Type)
+	require.Equal(t, account.Balance, event.Balance)
+
+	server.hub.Publish(db.Entry{ID: 3, AccountID: account.ID, Amount: 25, BalanceAfter: account.Balance + 25})
+	require.NoError(t, websocket.JSON.Receive(conn, &event))
+	require.Equal(t, entryEvent, event.Type)
+	require.Equal(t, account.Balance+25, event.Balance)
+	require.Equal(t, int64(3), event.Entry.ID)
+
+	// the server unsubscribes once the client is gone
+	require.NoError(t, conn.Close())
+	require.Eventually(t, func() bool {
+		return server.hub.Subscribers(account.ID) == 0
+	}, time.Second, 10*time.Millisecond)
+}
@@ -1,11 +1,14 @@
 package api
 
 import (
-	"database/sql"
+	"errors"
 	"fmt"
 	"net/http"
+	"strings"
+	"time"
 
 	db "github.com/XiaozhouCui/go-bank/db/sqlc"
+	"github.com/XiaozhouCui/go-bank/db/util"
 	"github.com/XiaozhouCui/go-bank/token"
 	"github.com/gin-gonic/gin"
 )
@@ -13,10 +16,18 @@ import (
 type transferRequest struct {
 	// binding is for validation
 	// "currency" validator is registered in server.go, to replace binding "oneof=USD EUR CAD"
-	FromAccountID int64  `json:"from_account_id" binding:"required,min=1"`
-	ToAccountID   int64  `json:"to_account_id" binding:"required,min=1"`
-	Amount        int64  `json:"amount" binding:"required,gt=0"`
-	Currency      string `json:"currency" binding:"required,currency"`
+	FromAccountID int64 `json:"from_account_id" binding:"required,min=1"`
+	// the recipient is either an account ID, a payee of the current user or an account number
+	ToAccountID     int64  `json:"to_account_id" binding:"required_without_all=PayeeID ToAccountNumber,excluded_with=PayeeID ToAccountNumber,omitempty,min=1"`
+	PayeeID         int64  `json:"payee_id" binding:"excluded_with=ToAccountNumber,omitempty,min=1"`
+	ToAccountNumber string `json:"to_account_number" binding:"omitempty,account_number"`
+	Amount          int64  `json:"amount" binding:"required,gt=0"`
+	Currency        string `json:"currency" binding:"required,currency"`
+	// free text shown to both sides, and an end to end reference passed on unchanged to the recipient
+	Description string `json:"description" binding:"max=140"`
+	Reference   string `json:"reference" binding:"omitempty,reference"`
+	// labels of the sender on their side of the transfer, see validTag
+	Tags []string `json:"tags" binding:"max=10,dive,tag"`
 }
 
 func (server *Server) createTransfer(ctx *gin.Context) {
@@ -27,50 +38,155 @@ func (server *Server) createTransfer(ctx *gin.Context) {
 		return
 	}
 
-	// validate currency for FromAccount
-	fromAccount, valid := server.validAccount(ctx, req.FromAccountID, req.Currency)
+	req = sanitizeTransfer(req)
+
+	req, valid := server.validRecipient(ctx, req)
 	if !valid {
 		return
 	}
-
-	authPayload := ctx.MustGet(authorizationPayloadKey).(*token.Payload)
-
-	if fromAccount.Owner != authPayload.Username {
-		err := fmt.Errorf("from account [%d] is not owned by the current user", fromAccount.ID)
-		ctx.JSON(http.StatusUnauthorized, errorResponse(err))
+	if _, valid := server.validTransfer(ctx, req); !valid {
 		return
 	}
 
-	// validate currency for ToAccount
-	_, valid = server.validAccount(ctx, req.ToAccountID, req.Currency)
-	if !valid {
+	// large transfers from an account with an approval policy wait for its approvers
+	policy, needed, err := server.transferApprovalPolicy(ctx, req.FromAccountID, req.Amount)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+	if needed {
+		server.createPendingTransfer(ctx, req, policy)
 		return
 	}
 
 	// if req is valid, create transfer in db
+	authPayload := ctx.MustGet(authorizationPayloadKey).(*token.Payload)
 	arg := db.TransferTxParams{
 		FromAccountID: req.FromAccountID,
 		ToAccountID:   req.ToAccountID,
 		Amount:        req.Amount,
+		Description:   req.Description,
+		Reference:     req.Reference,
+		Tags:          req.Tags,
+		SentBy:        authPayload.Username,
 	}
 
 	// create money transfer transaction
 	result, err := server.store.TransferTx(ctx, arg)
 	if err != nil {
-		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		ctx.JSON(transferErrorStatus(err), errorResponse(err))
 		return
 	}
 
+	auditTarget(ctx, "transfers", result.Transfer.ID)
+
 	// return the result
 	ctx.JSON(http.StatusOK, result)
 }
 
+type transferQuoteResponse struct {
+	FromAccountID int64  `json:"from_account_id"`
+	ToAccountID   int64  `json:"to_account_id"`
+	Amount        int64  `json:"amount"`
+	Fee           int64  `json:"fee"`
+	Total         int64  `json:"total"` // amount plus fee, taken from the from account
+	Currency      string `json:"currency"`
+}
+
+// quoteTransfer previews the fee of a transfer without moving any money
+func (server *Server) quoteTransfer(ctx *gin.Context) {
+	var req transferRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+
+	req, valid := server.validRecipient(ctx, req)
+	if !valid {
+		return
+	}
+	fromAccount, valid := server.validTransfer(ctx, req)
+	if !valid {
+		return
+	}
+
+	fee, err := server.store.QuoteTransferFee(ctx, fromAccount, req.Amount)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+
+	rsp := transferQuoteResponse{
+		FromAccountID: req.FromAccountID,
+		ToAccountID:   req.ToAccountID,
+		Amount:        req.Amount,
+		Fee:           fee,
+		Total:         req.Amount + fee,
+		Currency:      req.Currency,
+	}
+	ctx.JSON(http.StatusOK, rsp)
+}
+
+// sanitizeTransfer cleans up the description, reference and tags of a transfer once they are validated,
+// before they are stored
+func sanitizeTransfer(req transferRequest) transferRequest {
+	req.Description = util.SanitizeDescription(req.Description)
+	req.Reference = strings.TrimSpace(req.Reference)
+	if len(req.Tags) > 0 {
+		req.Tags = util.NormalizeTags(req.Tags)
+	}
+	return req
+}
+
+// resolve the payee or account number of a transfer to its account, see recipientTransfer
+func (server *Server) validRecipient(ctx *gin.Context, req transferRequest) (transferRequest, bool) {
+	authPayload := ctx.MustGet(authorizationPayloadKey).(*token.Payload)
+	req, status, err := server.recipientTransfer(ctx, req, authPayload.Username)
+	if err != nil {
+		ctx.JSON(status, errorResponse(err))
+		return req, false
+	}
+	return req, true
+}
+
+// validate both accounts of a transfer, and that the current user can spend the amount from the from account,
+// and send it to the to account if it is a payee cooling off
+func (server *Server) validTransfer(ctx *gin.Context, req transferRequest) (db.Account, bool) {
+	// validate currency for FromAccount
+	fromAccount, valid := server.validAccount(ctx, req.FromAccountID, req.Currency)
+	if !valid {
+		return fromAccount, false
+	}
+
+	// the owner, a co-owner or a spender within their limit
+	member, valid := server.authorizeAccount(ctx, fromAccount, db.AccountRoleCanSpend)
+	if !valid {
+		return fromAccount, false
+	}
+	if status, err := server.checkSpendLimit(ctx, member, 0, req.Amount); err != nil {
+		ctx.JSON(status, errorResponse(err))
+		return fromAccount, false
+	}
+
+	// validate currency for ToAccount
+	if _, valid = server.validAccount(ctx, req.ToAccountID, req.Currency); !valid {
+		return fromAccount, false
+	}
+
+	authPayload := ctx.MustGet(authorizationPayloadKey).(*token.Payload)
+	if status, err := server.checkCoolingOff(ctx, authPayload.Username, req.ToAccountID, req.Amount, time.Now()); err != nil {
+		ctx.JSON(status, errorResponse(err))
+		return fromAccount, false
+	}
+	return fromAccount, true
+}
+
 // validate transfer currency against account currency
 func (server *Server) validAccount(ctx *gin.Context, accountId int64, currency string) (db.Account, bool) {
 	account, err := server.store.GetAccount(ctx, accountId)
 	if err != nil {
 		// 2 error scenarios: 404 and 500
-		if err == sql.ErrNoRows {
+		if errors.Is(err, db.ErrRecordNotFound) {
 			ctx.JSON(http.StatusNotFound, errorResponse(err))
 			return account, false
 		}
@@ -78,12 +194,41 @@ func (server *Server) validAccount(ctx *gin.Context, accountId int64, currency s
 		return account, false
 	}
 
-	// validate currency
-	if account.Currency != currency {
-		err := fmt.Errorf("account [%d] currency mismatch: %s vs %s", account.ID, account.Currency, currency)
-		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+	if status, err := checkTransferAccount(account, currency); err != nil {
+		ctx.JSON(status, errorResponse(err))
 		return account, false
 	}
 
 	return account, true
 }
+
+// checkTransferAccount checks that an account can take part in a transfer in a currency,
+// and returns the status code to answer with if it cannot
+func checkTransferAccount(account db.Account, currency string) (int, error) {
+	// frozen and closed accounts cannot send or receive money
+	if account.Status != db.AccountStatusActive {
+		return http.StatusForbidden, fmt.Errorf("account [%d] is %s", account.ID, account.Status)
+	}
+
+	// validate currency
+	if account.Currency != currency {
+		return http.StatusBadRequest, fmt.Errorf("account [%d] currency mismatch: %s vs %s", account.ID, account.Currency, currency)
+	}
+
+	return http.StatusOK, nil
+}
+
+// transferErrorStatus returns the status code to answer with when the store fails to make a transfer
+func transferErrorStatus(err error) int {
+	// an account may be frozen or closed after it was validated, the transfer may go over
+	// the limits, spend limit or available balance of the sender, or a policy may have been set since
+	if errors.Is(err, db.ErrAccountNotActive) || errors.Is(err, db.ErrTransferLimitExceeded) ||
+		errors.Is(err, db.ErrSenderNotAllowed) || errors.Is(err, db.ErrInsufficientFunds) ||
+		errors.Is(err, db.ErrApprovalRequired) {
+		return http.StatusForbidden
+	}
+	if errors.Is(err, db.ErrRecordNotFound) {
+		return http.StatusNotFound
+	}
+	return http.StatusInternalServerError
+}
@@ -0,0 +1,162 @@
+package api
+
+import (
+	"errors"
+	"net/http"
+
+	db "github.com/XiaozhouCui/go-bank/db/sqlc"
+	"github.com/XiaozhouCui/go-bank/token"
+	"github.com/gin-gonic/gin"
+)
+
+// a limit of 0 means no limit
+type transferLimitRequest struct {
+	MaxAmount     int64 `json:"max_amount" binding:"min=0"`
+	DailyAmount   int64 `json:"daily_amount" binding:"min=0"`
+	MonthlyAmount int64 `json:"monthly_amount" binding:"min=0"`
+	DailyCount    int64 `json:"daily_count" binding:"min=0"`
+}
+
+type userTransferLimitRequest struct {
+	Username string `uri:"username" binding:"required,alphanum"`
+}
+
+// bank staff changes the limits of all users without limits of their own
+func (server *Server) updateDefaultTransferLimit(ctx *gin.Context) {
+	var req transferLimitRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+
+	authPayload := ctx.MustGet(authorizationPayloadKey).(*token.Payload)
+
+	arg := db.UpdateDefaultTransferLimitParams{
+		MaxAmount:     req.MaxAmount,
+		DailyAmount:   req.DailyAmount,
+		MonthlyAmount: req.MonthlyAmount,
+		DailyCount:    req.DailyCount,
+		UpdatedBy:     authPayload.Username,
+	}
+	limit, err := server.store.UpdateDefaultTransferLimit(ctx, arg)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+
+	ctx.JSON(http.StatusOK, limit)
+}
+
+// bank staff overrides the default limits for a customer
+func (server *Server) setUserTransferLimit(ctx *gin.Context) {
+	var uri userTransferLimitRequest
+	if err := ctx.ShouldBindUri(&uri); err != nil {
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+	var req transferLimitRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+
+	if _, err := server.store.GetUser(ctx, uri.Username); err != nil {
+		if errors.Is(err, db.ErrRecordNotFound) {
+			ctx.JSON(http.StatusNotFound, errorResponse(err))
+			return
+		}
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+
+	authPayload := ctx.MustGet(authorizationPayloadKey).(*token.Payload)
+
+	arg := db.SetUserTransferLimitParams{
+		Username:      uri.Username,
+		MaxAmount:     req.MaxAmount,
+		DailyAmount:   req.DailyAmount,
+		MonthlyAmount: req.MonthlyAmount,
+		DailyCount:    req.DailyCount,
+		UpdatedBy:     authPayload.Username,
+	}
+	limit, err := server.store.SetUserTransferLimit(ctx, arg)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+
+	ctx.JSON(http.StatusOK, limit)
+}
+
+// bank staff removes the override, the customer gets the default limits again
+func (server *Server) deleteUserTransferLimit(ctx *gin.Context) {
+	var uri userTransferLimitRequest
+	if err := ctx.ShouldBindUri(&uri); err != nil {
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+
+	if err := server.store.DeleteUserTransferLimit(ctx, uri.Username); err != nil {
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+
+	ctx.Status(http.StatusNoContent)
+}
+
+// bank staff limits a single account, on top of the limits of its owner
+func (server *Server) setAccountTransferLimit(ctx *gin.Context) {
+	var uri getAccountRequest
+	if err := ctx.ShouldBindUri(&uri); err != nil {
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+	var req transferLimitRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+
+	if _, err := server.store.GetAccount(ctx, uri.ID); err != nil {
+		if errors.Is(err, db.ErrRecordNotFound) {
+			ctx.JSON(http.StatusNotFound, errorResponse(err))
+			return
+		}
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+
+	authPayload := ctx.MustGet(authorizationPayloadKey).(*token.Payload)
+
+	arg := db.SetAccountTransferLimitParams{
+		AccountID:     uri.ID,
+		MaxAmount:     req.MaxAmount,
+		DailyAmount:   req.DailyAmount,
+		MonthlyAmount: req.MonthlyAmount,
+		DailyCount:    req.DailyCount,
+		UpdatedBy:     authPayload.Username,
+	}
+	limit, err := server.store.SetAccountTransferLimit(ctx, arg)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+
+	ctx.JSON(http.StatusOK, limit)
+}
+
+// bank staff removes the limits of a single account
+func (server *Server) deleteAccountTransferLimit(ctx *gin.Context) {
+	var uri getAccountRequest
+	if err := ctx.ShouldBindUri(&uri); err != nil {
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+
+	if err := server.store.DeleteAccountTransferLimit(ctx, uri.ID); err != nil {
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+
+	ctx.Status(http.StatusNoContent)
+}
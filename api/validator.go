@@ -1,7 +1,12 @@
 package api
 
 import (
+	"strings"
+
+	db "github.com/XiaozhouCui/go-bank/db/sqlc"
 	"github.com/XiaozhouCui/go-bank/db/util"
+	"github.com/XiaozhouCui/go-bank/interest"
+	"github.com/XiaozhouCui/go-bank/webhook"
 	"github.com/go-playground/validator/v10"
 )
 
@@ -14,3 +19,68 @@ var validCurrency validator.Func = func(fieldLevel validator.FieldLevel) bool {
 	// if "ok" is false, then currency is not a string, return false
 	return false
 }
+
+var validAccountType validator.Func = func(fieldLevel validator.FieldLevel) bool {
+	if accountType, ok := fieldLevel.Field().Interface().(string); ok {
+		return util.IsSupportedAccountType(accountType)
+	}
+	return false
+}
+
+var validDayCount validator.Func = func(fieldLevel validator.FieldLevel) bool {
+	if dayCount, ok := fieldLevel.Field().Interface().(string); ok {
+		return interest.IsSupportedDayCount(dayCount)
+	}
+	return false
+}
+
+var validFeeKind validator.Func = func(fieldLevel validator.FieldLevel) bool {
+	if kind, ok := fieldLevel.Field().Interface().(string); ok {
+		return db.IsSupportedFeeKind(kind)
+	}
+	return false
+}
+
+var validSchedule validator.Func = func(fieldLevel validator.FieldLevel) bool {
+	if schedule, ok := fieldLevel.Field().Interface().(string); ok {
+		_, err := db.ParseRecurrence(schedule)
+		return err == nil
+	}
+	return false
+}
+
+var validWebhookURL validator.Func = func(fieldLevel validator.FieldLevel) bool {
+	if url, ok := fieldLevel.Field().Interface().(string); ok {
+		return webhook.IsValidURL(url)
+	}
+	return false
+}
+
+var validWebhookEvent validator.Func = func(fieldLevel validator.FieldLevel) bool {
+	if eventType, ok := fieldLevel.Field().Interface().(string); ok {
+		return webhook.IsSupportedEventType(eventType)
+	}
+	return false
+}
+
+var validAccountNumber validator.Func = func(fieldLevel validator.FieldLevel) bool {
+	if number, ok := fieldLevel.Field().Interface().(string); ok {
+		return util.IsValidAccountNumber(util.NormalizeAccountNumber(number))
+	}
+	return false
+}
+
+var validReference validator.Func = func(fieldLevel validator.FieldLevel) bool {
+	if reference, ok := fieldLevel.Field().Interface().(string); ok {
+		return util.IsValidReference(strings.TrimSpace(reference))
+	}
+	return false
+}
+
+// a tag is valid once normalized, so "Rent " is the tag "rent"
+var validTag validator.Func = func(fieldLevel validator.FieldLevel) bool {
+	if tag, ok := fieldLevel.Field().Interface().(string); ok {
+		return util.IsValidTag(util.NormalizeTag(tag))
+	}
+	return false
+}
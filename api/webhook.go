@@ -0,0 +1,322 @@
+package api
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"time"
+
+	db "github.com/XiaozhouCui/go-bank/db/sqlc"
+	"github.com/XiaozhouCui/go-bank/token"
+	"github.com/gin-gonic/gin"
+)
+
+type createWebhookRequest struct {
+	Url        string   `json:"url" binding:"required,webhook_url"`
+	EventTypes []string `json:"event_types" binding:"required,min=1,unique,dive,webhook_event"`
+	// signs the deliveries, it is never sent back
+	Secret string `json:"secret" binding:"required,min=16,max=256"`
+}
+
+// webhookResponse is a webhook subscription without its secret
+type webhookResponse struct {
+	ID         int64     `json:"id"`
+	Url        string    `json:"url"`
+	EventTypes []string  `json:"event_types"`
+	Active     bool      `json:"active"`
+	CreatedAt  time.Time `json:"created_at"`
+}
+
+func newWebhookResponse(subscription db.WebhookSubscription) webhookResponse {
+	return webhookResponse{
+		ID:         subscription.ID,
+		Url:        subscription.Url,
+		EventTypes: subscription.EventTypes,
+		Active:     subscription.Active,
+		CreatedAt:  subscription.CreatedAt,
+	}
+}
+
+// webhookDeliveryResponse is a webhook delivery with its payload as JSON
+type webhookDeliveryResponse struct {
+	ID             int64                       `json:"id"`
+	EventType      string                      `json:"event_type"`
+	Payload        json.RawMessage             `json:"payload"`
+	Status         string                      `json:"status"`
+	Attempts       int64                       `json:"attempts"`
+	NextAttemptAt  time.Time                   `json:"next_attempt_at"`
+	LastStatusCode int64                       `json:"last_status_code"`
+	LastError      string                      `json:"last_error"`
+	DeliveredAt    *time.Time                  `json:"delivered_at"`
+	CreatedAt      time.Time                   `json:"created_at"`
+	Log            []db.WebhookDeliveryAttempt `json:"log,omitempty"`
+}
+
+func newWebhookDeliveryResponse(delivery db.WebhookDelivery) webhookDeliveryResponse {
+	rsp := webhookDeliveryResponse{
+		ID:             delivery.ID,
+		EventType:      delivery.EventType,
+		Payload:        delivery.Payload,
+		Status:         delivery.Status,
+		Attempts:       delivery.Attempts,
+		NextAttemptAt:  delivery.NextAttemptAt,
+		LastStatusCode: delivery.LastStatusCode,
+		LastError:      delivery.LastError,
+		CreatedAt:      delivery.CreatedAt,
+	}
+	if delivery.DeliveredAt.Valid {
+		rsp.DeliveredAt = &delivery.DeliveredAt.Time
+	}
+	return rsp
+}
+
+// createWebhook subscribes the authenticated user to events of their accounts,
+// which are posted to an https URL and signed with the secret
+func (server *Server) createWebhook(ctx *gin.Context) {
+	var req createWebhookRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+
+	authPayload := ctx.MustGet(authorizationPayloadKey).(*token.Payload)
+	subscription, err := server.store.CreateWebhookSubscription(ctx, db.CreateWebhookSubscriptionParams{
+		Owner:      authPayload.Username,
+		Url:        req.Url,
+		EventTypes: req.EventTypes,
+		Secret:     req.Secret,
+	})
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+
+	auditTarget(ctx, "webhooks", subscription.ID)
+	ctx.JSON(http.StatusOK, newWebhookResponse(subscription))
+}
+
+type getWebhookRequest struct {
+	ID int64 `uri:"id" binding:"required,min=1"`
+}
+
+// current user can only get their own webhook subscriptions
+func (server *Server) getWebhook(ctx *gin.Context) {
+	var req getWebhookRequest
+	if err := ctx.ShouldBindUri(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+
+	subscription, valid := server.ownedWebhook(ctx, req.ID)
+	if !valid {
+		return
+	}
+
+	ctx.JSON(http.StatusOK, newWebhookResponse(subscription))
+}
+
+type listWebhooksRequest struct {
+	PageID   int32 `form:"page_id" binding:"required,min=1"`
+	PageSize int32 `form:"page_size" binding:"required,min=5,max=10"`
+}
+
+// current user can only list their own webhook subscriptions
+func (server *Server) listWebhooks(ctx *gin.Context) {
+	var req listWebhooksRequest
+	if err := ctx.ShouldBindQuery(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+
+	authPayload := ctx.MustGet(authorizationPayloadKey).(*token.Payload)
+	subscriptions, err := server.store.ListWebhookSubscriptions(ctx, db.ListWebhookSubscriptionsParams{
+		Owner:  authPayload.Username,
+		Limit:  req.PageSize,
+		Offset: (req.PageID - 1) * req.PageSize,
+	})
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+
+	rsp := make([]webhookResponse, len(subscriptions))
+	for i, subscription := range subscriptions {
+		rsp[i] = newWebhookResponse(subscription)
+	}
+	ctx.JSON(http.StatusOK, rsp)
+}
+
+// deleteWebhook deactivates a webhook subscription: nothing is delivered to it anymore,
+// and its deliveries are kept for the log
+func (server *Server) deleteWebhook(ctx *gin.Context) {
+	var req getWebhookRequest
+	if err := ctx.ShouldBindUri(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+
+	subscription, valid := server.ownedWebhook(ctx, req.ID)
+	if !valid {
+		return
+	}
+	auditBefore(ctx, newWebhookResponse(subscription))
+
+	subscription, err := server.store.DeactivateWebhookSubscription(ctx, subscription.ID)
+	if err != nil {
+		if errors.Is(err, db.ErrRecordNotFound) {
+			err := errors.New("webhook subscription is already deleted")
+			ctx.JSON(http.StatusConflict, errorResponse(err))
+			return
+		}
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+
+	ctx.JSON(http.StatusOK, newWebhookResponse(subscription))
+}
+
+type listWebhookDeliveriesRequest struct {
+	PageID   int32 `form:"page_id" binding:"required,min=1"`
+	PageSize int32 `form:"page_size" binding:"required,min=5,max=10"`
+}
+
+// listWebhookDeliveries lists the deliveries of a webhook subscription of the current user,
+// with the outcome of their last attempt
+func (server *Server) listWebhookDeliveries(ctx *gin.Context) {
+	var uri getWebhookRequest
+	if err := ctx.ShouldBindUri(&uri); err != nil {
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+	var req listWebhookDeliveriesRequest
+	if err := ctx.ShouldBindQuery(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+
+	subscription, valid := server.ownedWebhook(ctx, uri.ID)
+	if !valid {
+		return
+	}
+
+	deliveries, err := server.store.ListWebhookDeliveries(ctx, db.ListWebhookDeliveriesParams{
+		SubscriptionID: subscription.ID,
+		Limit:          req.PageSize,
+		Offset:         (req.PageID - 1) * req.PageSize,
+	})
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+
+	rsp := make([]webhookDeliveryResponse, len(deliveries))
+	for i, delivery := range deliveries {
+		rsp[i] = newWebhookDeliveryResponse(delivery)
+	}
+	ctx.JSON(http.StatusOK, rsp)
+}
+
+type getWebhookDeliveryRequest struct {
+	ID         int64 `uri:"id" binding:"required,min=1"`
+	DeliveryID int64 `uri:"delivery_id" binding:"required,min=1"`
+}
+
+// getWebhookDelivery gets a delivery of a webhook subscription of the current user,
+// with the log of every attempt
+func (server *Server) getWebhookDelivery(ctx *gin.Context) {
+	var req getWebhookDeliveryRequest
+	if err := ctx.ShouldBindUri(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+
+	delivery, valid := server.ownedWebhookDelivery(ctx, req.ID, req.DeliveryID)
+	if !valid {
+		return
+	}
+
+	attempts, err := server.store.ListWebhookDeliveryAttempts(ctx, delivery.ID)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+
+	rsp := newWebhookDeliveryResponse(delivery)
+	rsp.Log = attempts
+	ctx.JSON(http.StatusOK, rsp)
+}
+
+// retryWebhookDelivery brings a dead delivery back to pending, to be attempted again from scratch
+func (server *Server) retryWebhookDelivery(ctx *gin.Context) {
+	var req getWebhookDeliveryRequest
+	if err := ctx.ShouldBindUri(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+
+	delivery, valid := server.ownedWebhookDelivery(ctx, req.ID, req.DeliveryID)
+	if !valid {
+		return
+	}
+	auditBefore(ctx, newWebhookDeliveryResponse(delivery))
+
+	delivery, err := server.store.RetryWebhookDelivery(ctx, delivery.ID)
+	if err != nil {
+		if errors.Is(err, db.ErrRecordNotFound) {
+			err := errors.New("only dead webhook deliveries can be retried")
+			ctx.JSON(http.StatusConflict, errorResponse(err))
+			return
+		}
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+
+	ctx.JSON(http.StatusOK, newWebhookDeliveryResponse(delivery))
+}
+
+// ownedWebhook loads a webhook subscription of the authenticated user,
+// it writes the error response and returns false otherwise
+func (server *Server) ownedWebhook(ctx *gin.Context, id int64) (db.WebhookSubscription, bool) {
+	subscription, err := server.store.GetWebhookSubscription(ctx, id)
+	if err != nil {
+		if errors.Is(err, db.ErrRecordNotFound) {
+			ctx.JSON(http.StatusNotFound, errorResponse(err))
+			return subscription, false
+		}
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return subscription, false
+	}
+
+	authPayload := ctx.MustGet(authorizationPayloadKey).(*token.Payload)
+	if subscription.Owner != authPayload.Username {
+		err := errors.New("webhook subscription doesn't belong to the authenticated user")
+		ctx.JSON(http.StatusUnauthorized, errorResponse(err))
+		return subscription, false
+	}
+
+	return subscription, true
+}
+
+// ownedWebhookDelivery loads a delivery of a webhook subscription of the authenticated user,
+// it writes the error response and returns false otherwise
+func (server *Server) ownedWebhookDelivery(ctx *gin.Context, subscriptionID, id int64) (db.WebhookDelivery, bool) {
+	subscription, valid := server.ownedWebhook(ctx, subscriptionID)
+	if !valid {
+		return db.WebhookDelivery{}, false
+	}
+
+	delivery, err := server.store.GetWebhookDelivery(ctx, id)
+	if err == nil && delivery.SubscriptionID != subscription.ID {
+		err = db.ErrRecordNotFound
+	}
+	if err != nil {
+		if errors.Is(err, db.ErrRecordNotFound) {
+			ctx.JSON(http.StatusNotFound, errorResponse(err))
+			return delivery, false
+		}
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return delivery, false
+	}
+
+	return delivery, true
+}
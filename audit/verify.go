@@ -0,0 +1,75 @@
+// Package audit checks the hash chain of the audit log.
+//
+// Each entry of the audit log holds the hash of the previous one, and its own hash covers that link,
+// see db.AuditLogHash. Changing, inserting or removing an entry anywhere but at the end breaks the chain
+// from that entry on, unless every later hash is recomputed as well.
+package audit
+
+import (
+	"context"
+	"fmt"
+
+	db "github.com/XiaozhouCui/go-bank/db/sqlc"
+)
+
+// number of entries loaded from the db at a time
+const batchSize = 1000
+
+// Report is the result of a verification of the audit log.
+type Report struct {
+	OK      bool  `json:"ok"`
+	Entries int64 `json:"entries"` // entries checked, up to the first broken one
+	// first entry whose hash or link does not match, and why
+	BrokenAt int64  `json:"broken_at,omitempty"`
+	Reason   string `json:"reason,omitempty"`
+}
+
+// Verify walks the audit log in order and recomputes the hash chain. It stops at the first broken entry.
+func Verify(ctx context.Context, store db.Store) (Report, error) {
+	report := Report{OK: true}
+
+	var prevHash string
+	var afterID int64
+	for {
+		entries, err := store.ListAuditLogAfter(ctx, db.ListAuditLogAfterParams{
+			AfterID: afterID,
+			Limit:   batchSize,
+		})
+		if err != nil {
+			return report, err
+		}
+
+		for _, entry := range entries {
+			if reason, err := check(entry, prevHash); err != nil {
+				return report, err
+			} else if reason != "" {
+				report.OK = false
+				report.BrokenAt = entry.ID
+				report.Reason = reason
+				return report, nil
+			}
+			report.Entries++
+			prevHash = entry.Hash
+			afterID = entry.ID
+		}
+
+		if len(entries) < batchSize {
+			return report, nil
+		}
+	}
+}
+
+// check returns why an entry breaks the chain after prevHash, or an empty string if it does not.
+func check(entry db.AuditLog, prevHash string) (string, error) {
+	if entry.PrevHash != prevHash {
+		return fmt.Sprintf("prev_hash %q does not match the hash of the previous entry %q", entry.PrevHash, prevHash), nil
+	}
+	hash, err := db.AuditLogHash(entry)
+	if err != nil {
+		return "", fmt.Errorf("cannot hash audit log entry [%d]: %w", entry.ID, err)
+	}
+	if entry.Hash != hash {
+		return fmt.Sprintf("hash %q does not match its content, expected %q", entry.Hash, hash), nil
+	}
+	return "", nil
+}
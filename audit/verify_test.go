@@ -0,0 +1,125 @@
+package audit
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	mockdb "github.com/XiaozhouCui/go-bank/db/mock"
+	db "github.com/XiaozhouCui/go-bank/db/sqlc"
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/require"
+)
+
+// chain builds n audit log entries linked by their hashes
+func chain(t *testing.T, n int) []db.AuditLog {
+	entries := make([]db.AuditLog, n)
+	var prevHash string
+	for i := range entries {
+		entry := db.AuditLog{
+			ID:         int64(i + 1),
+			Actor:      "alice",
+			ActorRole:  "depositor",
+			Action:     "POST /accounts",
+			TargetType: "accounts",
+			TargetID:   "1",
+			RequestID:  "request",
+			ClientIp:   "127.0.0.1",
+			Status:     200,
+			After:      []byte(`{"id": 1}`),
+			CreatedAt:  time.Date(2023, time.March, 1, 12, 0, i, 0, time.UTC),
+			PrevHash:   prevHash,
+		}
+		hash, err := db.AuditLogHash(entry)
+		require.NoError(t, err)
+		entry.Hash = hash
+		entries[i] = entry
+		prevHash = hash
+	}
+	return entries
+}
+
+func verify(t *testing.T, entries []db.AuditLog) Report {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	store := mockdb.NewMockStore(ctrl)
+	store.EXPECT().
+		ListAuditLogAfter(gomock.Any(), gomock.Eq(db.ListAuditLogAfterParams{AfterID: 0, Limit: batchSize})).
+		Times(1).
+		Return(entries, nil)
+
+	report, err := Verify(context.Background(), store)
+	require.NoError(t, err)
+	return report
+}
+
+func TestVerify(t *testing.T) {
+	report := verify(t, chain(t, 3))
+	require.True(t, report.OK)
+	require.Equal(t, int64(3), report.Entries)
+
+	// the snapshots are hashed compacted, whatever their formatting
+	entries := chain(t, 2)
+	entries[0].After = []byte(`{"id":1}`)
+	require.True(t, verify(t, entries).OK)
+}
+
+func TestVerifyTampered(t *testing.T) {
+	testCases := []struct {
+		name     string
+		tamper   func(entries []db.AuditLog) []db.AuditLog
+		brokenAt int64
+		entries  int64
+	}{
+		{
+			name: "ChangedEntry",
+			tamper: func(entries []db.AuditLog) []db.AuditLog {
+				entries[1].Actor = "mallory"
+				return entries
+			},
+			brokenAt: 2,
+			entries:  1,
+		},
+		{
+			name: "ChangedSnapshot",
+			tamper: func(entries []db.AuditLog) []db.AuditLog {
+				entries[0].After = []byte(`{"id": 2}`)
+				return entries
+			},
+			brokenAt: 1,
+			entries:  0,
+		},
+		{
+			name: "RehashedEntry",
+			tamper: func(entries []db.AuditLog) []db.AuditLog {
+				// the next entry still links to the original hash
+				entries[0].Status = 500
+				entries[0].Hash, _ = db.AuditLogHash(entries[0])
+				return entries
+			},
+			brokenAt: 2,
+			entries:  1,
+		},
+		{
+			name: "RemovedEntry",
+			tamper: func(entries []db.AuditLog) []db.AuditLog {
+				return append(entries[:1], entries[2:]...)
+			},
+			brokenAt: 3,
+			entries:  1,
+		},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+
+		t.Run(tc.name, func(t *testing.T) {
+			report := verify(t, tc.tamper(chain(t, 3)))
+			require.False(t, report.OK)
+			require.Equal(t, tc.brokenAt, report.BrokenAt)
+			require.Equal(t, tc.entries, report.Entries)
+			require.NotEmpty(t, report.Reason)
+		})
+	}
+}
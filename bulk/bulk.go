@@ -0,0 +1,178 @@
+// Package bulk imports payment files, so treasury teams can upload many transfers at once
+// instead of calling POST /transfers for each of them. A file is either an ISO 20022 pain.001
+// customer credit transfer initiation or a CSV file. It is validated and stored as an import job,
+// a dry run that moves no money, and its lines are paid when the job is executed.
+package bulk
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+
+	db "github.com/XiaozhouCui/go-bank/db/sqlc"
+	"github.com/gin-gonic/gin/binding"
+)
+
+// MaxInstructions is the largest number of instructions in a file, the same as a batch transfer.
+const MaxInstructions = 1000
+
+// ErrTooManyInstructions is returned by the parsers when a file has more than MaxInstructions.
+var ErrTooManyInstructions = fmt.Errorf("a payment file cannot have more than %d instructions", MaxInstructions)
+
+// Instruction is one payment of a file. The fields are checked with the validators of the API,
+// "currency" and "reference" among them, which must be registered with api.RegisterValidators.
+type Instruction struct {
+	Line          int64  `json:"line"` // position in the file, from 1
+	FromAccountID int64  `json:"from_account_id" binding:"required,min=1"`
+	ToAccountID   int64  `json:"to_account_id" binding:"required,min=1"`
+	Amount        int64  `json:"amount" binding:"required,gt=0"`
+	Currency      string `json:"currency" binding:"required,currency"`
+	Reference     string `json:"reference" binding:"omitempty,reference"` // end to end reference, as the reference of a transfer
+	// why the instruction cannot be paid, empty if it is valid
+	Error string `json:"error,omitempty"`
+}
+
+// Parse reads a payment file of the given format, see ParsePain001 and ParseCSV.
+func Parse(format string, data []byte) ([]Instruction, error) {
+	switch format {
+	case db.ImportFormatPain001:
+		return ParsePain001(data)
+	case db.ImportFormatCSV:
+		return ParseCSV(data)
+	}
+	return nil, fmt.Errorf("unsupported payment file format %q", format)
+}
+
+// Import validates the instructions of a file uploaded by owner and stores them as an import job.
+// Nothing is paid: the job is a dry run report until it is executed, and it is rejected if any instruction is invalid.
+func Import(ctx context.Context, store db.Store, owner, format string, instructions []Instruction) (db.CreateImportJobTxResult, error) {
+	if err := Validate(ctx, store, owner, instructions); err != nil {
+		return db.CreateImportJobTxResult{}, err
+	}
+
+	arg := db.CreateImportJobTxParams{
+		Owner:  owner,
+		Format: format,
+		Lines:  make([]db.CreateImportJobLineParams, len(instructions)),
+	}
+	for i, instruction := range instructions {
+		status := db.ImportLineValid
+		if instruction.Error != "" {
+			status = db.ImportLineInvalid
+		}
+		arg.Lines[i] = db.CreateImportJobLineParams{
+			Line:          instruction.Line,
+			FromAccountID: instruction.FromAccountID,
+			ToAccountID:   instruction.ToAccountID,
+			Amount:        instruction.Amount,
+			Currency:      instruction.Currency,
+			Reference:     instruction.Reference,
+			Status:        status,
+			Error:         instruction.Error,
+		}
+	}
+	return store.CreateImportJobTx(ctx, arg)
+}
+
+// Validate checks every instruction like a single transfer sent by owner: the fields, then that owner
+// can send the amount from the from account, and that both accounts are active and in the currency of the instruction.
+// The problems are recorded in the Error of each instruction, the error returned is a failure of the store.
+func Validate(ctx context.Context, store db.Store, owner string, instructions []Instruction) error {
+	// the accounts are loaded once, a payment file usually sends every instruction from the same account
+	senders := newSenders(store, owner)
+
+	for i := range instructions {
+		instruction := &instructions[i]
+		if instruction.Error != "" {
+			continue
+		}
+		// trimmed like the reference of POST /transfers
+		instruction.Reference = strings.TrimSpace(instruction.Reference)
+		if err := binding.Validator.ValidateStruct(instruction); err != nil {
+			instruction.Error = err.Error()
+			continue
+		}
+
+		fromAccount, err := loadAccount(ctx, store, senders.accounts, instruction.FromAccountID)
+		if err != nil {
+			return err
+		}
+		toAccount, err := loadAccount(ctx, store, senders.accounts, instruction.ToAccountID)
+		if err != nil {
+			return err
+		}
+
+		if fromAccount == nil {
+			instruction.Error = fmt.Sprintf("from account [%d] not found", instruction.FromAccountID)
+			continue
+		}
+		instruction.Error, err = senders.check(ctx, *fromAccount, instruction.Amount)
+		if err != nil {
+			return err
+		}
+
+		switch {
+		case instruction.Error != "":
+		case toAccount == nil:
+			instruction.Error = fmt.Sprintf("to account [%d] not found", instruction.ToAccountID)
+		default:
+			instruction.Error = checkAccount(*fromAccount, instruction.Currency)
+			if instruction.Error == "" {
+				instruction.Error = checkAccount(*toAccount, instruction.Currency)
+			}
+		}
+	}
+	return nil
+}
+
+// loadAccount gets an account of a file, each account is only loaded once. It returns nil if the account does not exist.
+func loadAccount(ctx context.Context, store db.Store, accounts map[int64]*db.Account, id int64) (*db.Account, error) {
+	if account, ok := accounts[id]; ok {
+		return account, nil
+	}
+
+	account, err := store.GetAccount(ctx, id)
+	if err != nil {
+		if errors.Is(err, db.ErrRecordNotFound) {
+			accounts[id] = nil
+			return nil, nil
+		}
+		return nil, err
+	}
+
+	accounts[id] = &account
+	return &account, nil
+}
+
+// checkAccount returns why money in currency cannot move to or from the account, or an empty string
+func checkAccount(account db.Account, currency string) string {
+	if account.Status != db.AccountStatusActive {
+		return fmt.Sprintf("account [%d] is %s", account.ID, account.Status)
+	}
+	if account.Currency != currency {
+		return fmt.Sprintf("account [%d] currency mismatch: %s vs %s", account.ID, account.Currency, currency)
+	}
+	return ""
+}
+
+// amounts are decimal, with up to 2 digits after the point
+var amountPattern = regexp.MustCompile(`^[0-9]+(\.[0-9]{1,2})?$`)
+
+// parseAmount turns a decimal amount such as 12.3 into the minor units of its currency, 1230
+func parseAmount(s string) (int64, error) {
+	s = strings.TrimSpace(s)
+	if !amountPattern.MatchString(s) {
+		return 0, fmt.Errorf("invalid amount %q", s)
+	}
+
+	units, cents, _ := strings.Cut(s, ".")
+	cents = (cents + "00")[:2]
+	value, err := strconv.ParseInt(units+cents, 10, 64)
+	if err != nil {
+		return 0, fmt.Errorf("invalid amount %q", s)
+	}
+	return value, nil
+}
@@ -0,0 +1,192 @@
+package bulk
+
+import (
+	"context"
+	"database/sql"
+	"testing"
+	"time"
+
+	mockdb "github.com/XiaozhouCui/go-bank/db/mock"
+	db "github.com/XiaozhouCui/go-bank/db/sqlc"
+	"github.com/golang/mock/gomock"
+	"github.com/jackc/pgx/v5/pgtype"
+	"github.com/stretchr/testify/require"
+)
+
+func TestImport(t *testing.T) {
+	owned := db.Account{ID: 1, Owner: "alice", Currency: "USD", Status: db.AccountStatusActive}
+	payee := db.Account{ID: 2, Owner: "bob", Currency: "USD", Status: db.AccountStatusActive}
+	frozen := db.Account{ID: 3, Owner: "carol", Currency: "USD", Status: db.AccountStatusFrozen}
+	euros := db.Account{ID: 4, Owner: "dave", Currency: "EUR", Status: db.AccountStatusActive}
+	other := db.Account{ID: 5, Owner: "erin", Currency: "USD", Status: db.AccountStatusActive}
+	shared := db.Account{ID: 7, Owner: "frank", Currency: "USD", Status: db.AccountStatusActive}
+	limited := db.Account{ID: 8, Owner: "grace", Currency: "USD", Status: db.AccountStatusActive}
+	viewed := db.Account{ID: 9, Owner: "heidi", Currency: "USD", Status: db.AccountStatusActive}
+	approved := db.Account{ID: 10, Owner: "alice", Currency: "USD", Status: db.AccountStatusActive}
+
+	instructions := []Instruction{
+		{Line: 1, FromAccountID: 1, ToAccountID: 2, Amount: 100, Currency: "USD", Reference: "OK"},
+		{Line: 2, FromAccountID: 1, ToAccountID: 2, Amount: 100, Currency: "XXX"},
+		{Line: 3, FromAccountID: 5, ToAccountID: 2, Amount: 100, Currency: "USD"},
+		{Line: 4, FromAccountID: 1, ToAccountID: 6, Amount: 100, Currency: "USD"},
+		{Line: 5, FromAccountID: 1, ToAccountID: 3, Amount: 100, Currency: "USD"},
+		{Line: 6, FromAccountID: 1, ToAccountID: 4, Amount: 100, Currency: "USD"},
+		{Line: 7, Error: "expected 5 fields, got 3"},
+		{Line: 8, FromAccountID: 7, ToAccountID: 2, Amount: 100, Currency: "USD"},
+		{Line: 9, FromAccountID: 8, ToAccountID: 2, Amount: 50, Currency: "USD"},
+		{Line: 10, FromAccountID: 8, ToAccountID: 2, Amount: 100, Currency: "USD"},
+		{Line: 11, FromAccountID: 9, ToAccountID: 2, Amount: 100, Currency: "USD"},
+		{Line: 12, FromAccountID: 10, ToAccountID: 2, Amount: 100, Currency: "USD"},
+		{Line: 13, FromAccountID: 10, ToAccountID: 2, Amount: 500, Currency: "USD"},
+		{Line: 14, FromAccountID: 1, ToAccountID: 2, Amount: 100, Currency: "USD", Reference: " INV-42 "},
+		{Line: 15, FromAccountID: 1, ToAccountID: 2, Amount: 100, Currency: "USD", Reference: "INV#42"},
+	}
+
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	store := mockdb.NewMockStore(ctrl)
+	// each account and membership is loaded once
+	for _, account := range []db.Account{owned, payee, frozen, euros, other, shared, limited, viewed, approved} {
+		store.EXPECT().GetAccount(gomock.Any(), gomock.Eq(account.ID)).Times(1).Return(account, nil)
+	}
+	store.EXPECT().GetAccount(gomock.Any(), gomock.Eq(int64(6))).Times(1).Return(db.Account{}, db.ErrRecordNotFound)
+
+	accepted := pgtype.Timestamptz{Time: time.Now(), Valid: true}
+	members := map[int64]db.AccountMember{
+		shared.ID:  {AccountID: shared.ID, Username: "alice", Role: db.AccountCoOwner, AcceptedAt: accepted},
+		limited.ID: {AccountID: limited.ID, Username: "alice", Role: db.AccountSpender, SpendLimit: pgtype.Int8{Int64: 50, Valid: true}, AcceptedAt: accepted},
+		viewed.ID:  {AccountID: viewed.ID, Username: "alice", Role: db.AccountViewer, AcceptedAt: accepted},
+	}
+	for id, member := range members {
+		arg := db.GetAccountMemberParams{AccountID: id, Username: "alice"}
+		store.EXPECT().GetAccountMember(gomock.Any(), gomock.Eq(arg)).Times(1).Return(member, nil)
+	}
+	// an invitation that is not accepted yet gives no access
+	store.EXPECT().GetAccountMember(gomock.Any(), gomock.Eq(db.GetAccountMemberParams{AccountID: other.ID, Username: "alice"})).
+		Times(1).
+		Return(db.AccountMember{AccountID: other.ID, Username: "alice", Role: db.AccountCoOwner}, nil)
+	store.EXPECT().GetApprovalPolicy(gomock.Any(), gomock.Eq(approved.ID)).Times(1).Return(db.ApprovalPolicy{AccountID: approved.ID, Threshold: 500}, nil)
+	store.EXPECT().GetApprovalPolicy(gomock.Any(), gomock.Any()).AnyTimes().Return(db.ApprovalPolicy{}, db.ErrRecordNotFound)
+
+	var arg db.CreateImportJobTxParams
+	store.EXPECT().
+		CreateImportJobTx(gomock.Any(), gomock.Any()).
+		Times(1).
+		DoAndReturn(func(_ context.Context, a db.CreateImportJobTxParams) (db.CreateImportJobTxResult, error) {
+			arg = a
+			return db.CreateImportJobTxResult{}, nil
+		})
+
+	_, err := Import(context.Background(), store, "alice", db.ImportFormatCSV, instructions)
+	require.NoError(t, err)
+
+	require.Equal(t, "alice", arg.Owner)
+	require.Equal(t, db.ImportFormatCSV, arg.Format)
+	require.Len(t, arg.Lines, len(instructions))
+
+	require.Equal(t, db.ImportLineValid, arg.Lines[0].Status)
+	require.Equal(t, "OK", arg.Lines[0].Reference)
+	require.Empty(t, arg.Lines[0].Error)
+
+	// the currency is checked by the "currency" validator
+	require.Contains(t, arg.Lines[1].Error, "'currency' tag")
+	require.Equal(t, "from account [5] is not owned by or shared with alice", arg.Lines[2].Error)
+	require.Equal(t, "to account [6] not found", arg.Lines[3].Error)
+	require.Equal(t, "account [3] is frozen", arg.Lines[4].Error)
+	require.Equal(t, "account [4] currency mismatch: EUR vs USD", arg.Lines[5].Error)
+	require.Equal(t, "expected 5 fields, got 3", arg.Lines[6].Error)
+	for _, line := range arg.Lines[1:7] {
+		require.Equal(t, db.ImportLineInvalid, line.Status)
+		require.Equal(t, int64(0), line.JobID)
+	}
+
+	// co-owners and spenders send from accounts shared with them, spenders up to their spend limit
+	require.Equal(t, db.ImportLineValid, arg.Lines[7].Status)
+	require.Equal(t, db.ImportLineValid, arg.Lines[8].Status)
+	require.Equal(t, "amount 100 is over the spend limit of 50 on account [8]", arg.Lines[9].Error)
+	require.Equal(t, "a viewer of account [9] cannot send money from it", arg.Lines[10].Error)
+	// an import cannot wait for approvals
+	require.Equal(t, db.ImportLineValid, arg.Lines[11].Status)
+	require.Equal(t, "transfers of 500 or more from account [10] need approvals", arg.Lines[12].Error)
+
+	// references are trimmed and checked like the reference of a transfer
+	require.Equal(t, db.ImportLineValid, arg.Lines[13].Status)
+	require.Equal(t, "INV-42", arg.Lines[13].Reference)
+	require.Contains(t, arg.Lines[14].Error, "'reference' tag")
+}
+
+func TestImportStoreError(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	store := mockdb.NewMockStore(ctrl)
+	store.EXPECT().GetAccount(gomock.Any(), gomock.Any()).Times(1).Return(db.Account{}, sql.ErrConnDone)
+	store.EXPECT().CreateImportJobTx(gomock.Any(), gomock.Any()).Times(0)
+
+	instructions := []Instruction{{Line: 1, FromAccountID: 1, ToAccountID: 2, Amount: 100, Currency: "USD"}}
+	_, err := Import(context.Background(), store, "alice", db.ImportFormatCSV, instructions)
+	require.ErrorIs(t, err, sql.ErrConnDone)
+}
+
+func TestExecute(t *testing.T) {
+	job := db.ImportJob{ID: 1, Owner: "alice", Status: db.ImportJobValidated}
+	owned := db.Account{ID: 1, Owner: "alice", Currency: "USD", Status: db.AccountStatusActive}
+	removed := db.Account{ID: 3, Owner: "carol", Currency: "USD", Status: db.AccountStatusActive}
+	lines := []db.ImportJobLine{
+		{ID: 10, JobID: 1, Line: 1, FromAccountID: 1, ToAccountID: 2, Amount: 100, Status: db.ImportLineValid},
+		{ID: 11, JobID: 1, Line: 2, FromAccountID: 1, ToAccountID: 2, Amount: 100, Status: db.ImportLineValid},
+		{ID: 12, JobID: 1, Line: 3, FromAccountID: 1, ToAccountID: 2, Amount: 100, Status: db.ImportLineValid},
+		{ID: 13, JobID: 1, Line: 4, FromAccountID: 1, ToAccountID: 2, Amount: 100, Status: db.ImportLineSucceeded},
+		{ID: 14, JobID: 1, Line: 5, FromAccountID: 3, ToAccountID: 2, Amount: 100, Status: db.ImportLineValid},
+	}
+
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	store := mockdb.NewMockStore(ctrl)
+	store.EXPECT().GetAccount(gomock.Any(), gomock.Eq(owned.ID)).Times(1).Return(owned, nil)
+	store.EXPECT().GetAccount(gomock.Any(), gomock.Eq(removed.ID)).Times(1).Return(removed, nil)
+	// alice was a member of account 3 when the file was imported
+	store.EXPECT().GetAccountMember(gomock.Any(), gomock.Any()).Times(1).Return(db.AccountMember{}, db.ErrRecordNotFound)
+	store.EXPECT().GetApprovalPolicy(gomock.Any(), gomock.Eq(owned.ID)).Times(1).Return(db.ApprovalPolicy{}, db.ErrRecordNotFound)
+	gomock.InOrder(
+		store.EXPECT().StartImportJob(gomock.Any(), gomock.Eq(job.ID)).Times(1).Return(db.ImportJob{ID: 1, Status: db.ImportJobExecuting}, nil),
+		store.EXPECT().ListImportJobLines(gomock.Any(), gomock.Eq(job.ID)).Times(1).Return(lines, nil),
+		store.EXPECT().CompleteImportJob(gomock.Any(), gomock.Eq(job.ID)).Times(1).Return(db.ImportJob{ID: 1, Status: db.ImportJobCompleted}, nil),
+		store.EXPECT().ListImportJobLines(gomock.Any(), gomock.Eq(job.ID)).Times(1).Return(lines, nil),
+	)
+
+	// 10 is paid, 11 has already been paid by another execution, 12 fails, 13 is not paid again
+	store.EXPECT().ExecuteImportLineTx(gomock.Any(), gomock.Eq(int64(10))).Times(1).Return(db.ExecuteImportLineTxResult{}, nil)
+	store.EXPECT().ExecuteImportLineTx(gomock.Any(), gomock.Eq(int64(11))).Times(1).Return(db.ExecuteImportLineTxResult{}, db.ErrImportLineNotPending)
+	store.EXPECT().ExecuteImportLineTx(gomock.Any(), gomock.Eq(int64(12))).Times(1).Return(db.ExecuteImportLineTxResult{}, db.ErrInsufficientFunds)
+	store.EXPECT().ExecuteImportLineTx(gomock.Any(), gomock.Eq(int64(13))).Times(0)
+	store.EXPECT().
+		FailImportJobLine(gomock.Any(), gomock.Eq(db.FailImportJobLineParams{ID: 12, Error: db.ErrInsufficientFunds.Error()})).
+		Times(1).
+		Return(db.ImportJobLine{}, nil)
+	store.EXPECT().ExecuteImportLineTx(gomock.Any(), gomock.Eq(int64(14))).Times(0)
+	store.EXPECT().
+		FailImportJobLine(gomock.Any(), gomock.Eq(db.FailImportJobLineParams{ID: 14, Error: "from account [3] is not owned by or shared with alice"})).
+		Times(1).
+		Return(db.ImportJobLine{}, nil)
+
+	completed, _, err := Execute(context.Background(), store, job)
+	require.NoError(t, err)
+	require.Equal(t, db.ImportJobCompleted, completed.Status)
+}
+
+func TestExecuteNotExecutable(t *testing.T) {
+	job := db.ImportJob{ID: 1, Owner: "alice", Status: db.ImportJobRejected}
+
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	store := mockdb.NewMockStore(ctrl)
+	store.EXPECT().StartImportJob(gomock.Any(), gomock.Eq(job.ID)).Times(1).Return(db.ImportJob{}, db.ErrRecordNotFound)
+	store.EXPECT().ListImportJobLines(gomock.Any(), gomock.Any()).Times(0)
+
+	_, _, err := Execute(context.Background(), store, job)
+	require.ErrorIs(t, err, db.ErrImportJobNotExecutable)
+}
@@ -0,0 +1,78 @@
+package bulk
+
+import (
+	"bytes"
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+)
+
+// CSVHeader is the first line of a CSV payment file, naming its columns.
+const CSVHeader = "from_account_id,to_account_id,amount,currency,reference"
+
+var csvColumns = strings.Split(CSVHeader, ",")
+
+// ParseCSV reads a CSV payment file: a CSVHeader line, then one instruction per line, numbered from 1.
+// Amounts are decimal with up to 2 digits after the point (12.50 is 1250 cents) and the reference can be empty:
+//
+//	from_account_id,to_account_id,amount,currency,reference
+//	1,2,12.50,USD,INV-001
+func ParseCSV(data []byte) ([]Instruction, error) {
+	// spreadsheets often save CSV files with a byte order mark
+	data = bytes.TrimPrefix(data, []byte("\xef\xbb\xbf"))
+	reader := csv.NewReader(bytes.NewReader(data))
+	reader.FieldsPerRecord = -1 // a line with a wrong number of fields is an invalid instruction
+	reader.TrimLeadingSpace = true
+
+	header, err := reader.Read()
+	if err != nil {
+		if errors.Is(err, io.EOF) {
+			return nil, errors.New("invalid CSV file: it is empty")
+		}
+		return nil, fmt.Errorf("invalid CSV file: %w", err)
+	}
+	if strings.Join(header, ",") != CSVHeader {
+		return nil, fmt.Errorf("invalid CSV file: the header must be %s", CSVHeader)
+	}
+
+	var instructions []Instruction
+	for {
+		record, err := reader.Read()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("invalid CSV file: %w", err)
+		}
+		if len(instructions) == MaxInstructions {
+			return nil, ErrTooManyInstructions
+		}
+
+		instructions = append(instructions, parseCSVRecord(int64(len(instructions)+1), record))
+	}
+	return instructions, nil
+}
+
+// parseCSVRecord reads the fields of a line, a field that cannot be read makes the instruction invalid
+func parseCSVRecord(line int64, record []string) Instruction {
+	instruction := Instruction{Line: line}
+	if len(record) != len(csvColumns) {
+		instruction.Error = fmt.Sprintf("expected %d fields, got %d", len(csvColumns), len(record))
+		return instruction
+	}
+
+	var err error
+	if instruction.FromAccountID, err = strconv.ParseInt(strings.TrimSpace(record[0]), 10, 64); err != nil {
+		instruction.Error = fmt.Sprintf("invalid from_account_id %q", record[0])
+	} else if instruction.ToAccountID, err = strconv.ParseInt(strings.TrimSpace(record[1]), 10, 64); err != nil {
+		instruction.Error = fmt.Sprintf("invalid to_account_id %q", record[1])
+	} else if instruction.Amount, err = parseAmount(record[2]); err != nil {
+		instruction.Error = err.Error()
+	}
+	instruction.Currency = strings.TrimSpace(record[3])
+	instruction.Reference = strings.TrimSpace(record[4])
+	return instruction
+}
@@ -0,0 +1,48 @@
+package bulk
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseCSV(t *testing.T) {
+	data := "\xef\xbb\xbf" + CSVHeader + "\n" +
+		"1,2,12.5,USD,INV-001\n" +
+		"1, 3, 100 ,USD,\n" +
+		"\n" +
+		"x,3,1.00,USD,INV-003\n" +
+		"1,3,1.001,USD,INV-004\n" +
+		"1,3,1.00\n"
+
+	instructions, err := ParseCSV([]byte(data))
+	require.NoError(t, err)
+	require.Equal(t, []Instruction{
+		{Line: 1, FromAccountID: 1, ToAccountID: 2, Amount: 1250, Currency: "USD", Reference: "INV-001"},
+		{Line: 2, FromAccountID: 1, ToAccountID: 3, Amount: 10000, Currency: "USD"},
+		{Line: 3, Currency: "USD", Reference: "INV-003", Error: `invalid from_account_id "x"`},
+		{Line: 4, FromAccountID: 1, ToAccountID: 3, Currency: "USD", Reference: "INV-004", Error: `invalid amount "1.001"`},
+		{Line: 5, Error: "expected 5 fields, got 3"},
+	}, instructions)
+}
+
+func TestParseCSVHeader(t *testing.T) {
+	_, err := ParseCSV([]byte("from,to,amount\n1,2,3\n"))
+	require.ErrorContains(t, err, "the header must be")
+
+	_, err = ParseCSV(nil)
+	require.ErrorContains(t, err, "empty")
+}
+
+func TestParseCSVTooManyInstructions(t *testing.T) {
+	var data strings.Builder
+	data.WriteString(CSVHeader + "\n")
+	for i := 0; i <= MaxInstructions; i++ {
+		fmt.Fprintf(&data, "1,2,1.00,USD,%d\n", i)
+	}
+
+	_, err := ParseCSV([]byte(data.String()))
+	require.ErrorIs(t, err, ErrTooManyInstructions)
+}
@@ -0,0 +1,99 @@
+package bulk
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	db "github.com/XiaozhouCui/go-bank/db/sqlc"
+)
+
+// Execute pays the valid lines of an import job one by one, each in its own transaction, and completes the job.
+// Each line is checked again like at import, as the owner of the job may no longer be allowed to send it.
+// A line that cannot be paid is marked as failed with its error, and the other lines go on.
+// An interrupted execution can be resumed by executing the job again: each line is paid at most once,
+// even if the job is executed twice at the same time.
+// ErrImportJobNotExecutable is returned if the job is rejected or already completed.
+func Execute(ctx context.Context, store db.Store, job db.ImportJob) (db.ImportJob, []db.ImportJobLine, error) {
+	started, err := store.StartImportJob(ctx, job.ID)
+	if err != nil {
+		if errors.Is(err, db.ErrRecordNotFound) {
+			// the status may have changed since the job was loaded
+			err = fmt.Errorf("import job [%d] is %s: %w", job.ID, job.Status, db.ErrImportJobNotExecutable)
+		}
+		return job, nil, err
+	}
+
+	lines, err := store.ListImportJobLines(ctx, job.ID)
+	if err != nil {
+		return started, nil, err
+	}
+
+	// the user may have lost access to an account since the file was imported
+	senders := newSenders(store, job.Owner)
+	for _, line := range lines {
+		if line.Status != db.ImportLineValid {
+			continue
+		}
+		reason, err := checkLine(ctx, senders, line)
+		if err != nil {
+			return started, nil, err
+		}
+		if reason != "" {
+			err = failLine(ctx, store, line.ID, reason)
+		} else {
+			err = executeLine(ctx, store, line.ID)
+		}
+		if err != nil {
+			return started, nil, fmt.Errorf("cannot record line %d of import job [%d]: %w", line.Line, job.ID, err)
+		}
+	}
+
+	completed, err := store.CompleteImportJob(ctx, job.ID)
+	if errors.Is(err, db.ErrRecordNotFound) {
+		// completed by another execution in the meantime
+		completed, err = store.GetImportJob(ctx, job.ID)
+	}
+	if err != nil {
+		return started, nil, err
+	}
+
+	// the lines as they were paid, including by another execution
+	lines, err = store.ListImportJobLines(ctx, job.ID)
+	return completed, lines, err
+}
+
+// executeLine pays one line, and records its failure if the transfer could not be made.
+func executeLine(ctx context.Context, store db.Store, id int64) error {
+	_, err := store.ExecuteImportLineTx(ctx, id)
+	// already paid or failed by another execution
+	if err == nil || errors.Is(err, db.ErrImportLineNotPending) {
+		return nil
+	}
+
+	return failLine(ctx, store, id, err.Error())
+}
+
+// failLine marks a line as failed, unless it has been paid or failed by another execution.
+func failLine(ctx context.Context, store db.Store, id int64, reason string) error {
+	_, err := store.FailImportJobLine(ctx, db.FailImportJobLineParams{
+		ID:    id,
+		Error: reason,
+	})
+	if err != nil && !errors.Is(err, db.ErrRecordNotFound) {
+		return err
+	}
+	return nil
+}
+
+// checkLine returns why the owner of the job cannot send a line anymore, or an empty string.
+func checkLine(ctx context.Context, senders *senders, line db.ImportJobLine) (string, error) {
+	fromAccount, err := loadAccount(ctx, senders.store, senders.accounts, line.FromAccountID)
+	if err != nil {
+		return "", err
+	}
+	if fromAccount == nil {
+		return fmt.Sprintf("from account [%d] not found", line.FromAccountID), nil
+	}
+	return senders.check(ctx, *fromAccount, line.Amount)
+}
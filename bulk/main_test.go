@@ -0,0 +1,25 @@
+package bulk
+
+import (
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/XiaozhouCui/go-bank/db/util"
+	"github.com/gin-gonic/gin/binding"
+	"github.com/go-playground/validator/v10"
+)
+
+// TestMain is the entry point for all tests in bulk.
+func TestMain(m *testing.M) {
+	// the api package registers its validators with api.RegisterValidators, but cannot be imported here
+	if v, ok := binding.Validator.Engine().(*validator.Validate); ok {
+		v.RegisterValidation("currency", func(fieldLevel validator.FieldLevel) bool {
+			return util.IsSupportedCurrency(fieldLevel.Field().String())
+		})
+		v.RegisterValidation("reference", func(fieldLevel validator.FieldLevel) bool {
+			return util.IsValidReference(strings.TrimSpace(fieldLevel.Field().String()))
+		})
+	}
+	os.Exit(m.Run())
+}
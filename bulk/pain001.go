@@ -0,0 +1,122 @@
+package bulk
+
+import (
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// Pain001Namespace is the XML namespace prefix of the supported pain.001 messages,
+// any version of the customer credit transfer initiation is read.
+const Pain001Namespace = "urn:iso:std:iso:20022:tech:xsd:pain.001.001."
+
+type pain001Document struct {
+	XMLName    xml.Name           `xml:"Document"`
+	Initiation *pain001Initiation `xml:"CstmrCdtTrfInitn"`
+}
+
+type pain001Initiation struct {
+	NumberOfTransactions string           `xml:"GrpHdr>NbOfTxs"`
+	ControlSum           string           `xml:"GrpHdr>CtrlSum"`
+	PaymentInformation   []pain001Payment `xml:"PmtInf"`
+}
+
+// pain001Payment is a PmtInf block: the transactions paid from one debtor account
+type pain001Payment struct {
+	DebtorAccount string               `xml:"DbtrAcct>Id>Othr>Id"`
+	Transactions  []pain001Transaction `xml:"CdtTrfTxInf"`
+}
+
+type pain001Transaction struct {
+	EndToEndID      string        `xml:"PmtId>EndToEndId"`
+	Amount          pain001Amount `xml:"Amt>InstdAmt"`
+	CreditorAccount string        `xml:"CdtrAcct>Id>Othr>Id"`
+}
+
+type pain001Amount struct {
+	Currency string `xml:"Ccy,attr"`
+	Value    string `xml:",chardata"`
+}
+
+// ParsePain001 reads an ISO 20022 pain.001 customer credit transfer initiation.
+// Each CdtTrfTxInf is an instruction from the debtor account of its PmtInf, numbered across the file from 1.
+// Accounts are identified by their ID in the Othr element of the account ID, as in the camt exports,
+// and the EndToEndId is the reference, unless it is NOTPROVIDED.
+// The number of transactions and the control sum of the group header must match the file.
+func ParsePain001(data []byte) ([]Instruction, error) {
+	var doc pain001Document
+	if err := xml.Unmarshal(data, &doc); err != nil {
+		return nil, fmt.Errorf("invalid pain.001 file: %w", err)
+	}
+	if !strings.HasPrefix(doc.XMLName.Space, Pain001Namespace) || doc.Initiation == nil {
+		return nil, errors.New("invalid pain.001 file: not a customer credit transfer initiation")
+	}
+
+	var instructions []Instruction
+	var sum int64
+	complete := true // every amount could be read
+	for _, payment := range doc.Initiation.PaymentInformation {
+		for _, transaction := range payment.Transactions {
+			if len(instructions) == MaxInstructions {
+				return nil, ErrTooManyInstructions
+			}
+
+			instruction := Instruction{
+				Line:      int64(len(instructions) + 1),
+				Currency:  transaction.Amount.Currency,
+				Reference: strings.TrimSpace(transaction.EndToEndID),
+			}
+			if instruction.Reference == "NOTPROVIDED" {
+				instruction.Reference = ""
+			}
+
+			amount, amountErr := parseAmount(transaction.Amount.Value)
+			fromAccountID, fromErr := parseAccountID(payment.DebtorAccount)
+			toAccountID, toErr := parseAccountID(transaction.CreditorAccount)
+			switch {
+			case fromErr != nil:
+				instruction.Error = "debtor account: " + fromErr.Error()
+			case toErr != nil:
+				instruction.Error = "creditor account: " + toErr.Error()
+			case amountErr != nil:
+				instruction.Error = amountErr.Error()
+			}
+			instruction.FromAccountID, instruction.ToAccountID, instruction.Amount = fromAccountID, toAccountID, amount
+			if amountErr != nil {
+				complete = false
+			}
+			sum += amount
+
+			instructions = append(instructions, instruction)
+		}
+	}
+
+	count, err := strconv.Atoi(strings.TrimSpace(doc.Initiation.NumberOfTransactions))
+	if err != nil || count != len(instructions) {
+		return nil, fmt.Errorf("invalid pain.001 file: NbOfTxs is %q but the file has %d transactions",
+			doc.Initiation.NumberOfTransactions, len(instructions))
+	}
+	if doc.Initiation.ControlSum != "" && complete {
+		controlSum, err := parseAmount(doc.Initiation.ControlSum)
+		if err != nil || controlSum != sum {
+			return nil, fmt.Errorf("invalid pain.001 file: CtrlSum is %q but the amounts add up to a different sum",
+				doc.Initiation.ControlSum)
+		}
+	}
+	return instructions, nil
+}
+
+// parseAccountID reads the account ID of an Othr element, IBANs are not supported
+func parseAccountID(s string) (int64, error) {
+	s = strings.TrimSpace(s)
+	if s == "" {
+		return 0, errors.New("missing Othr/Id, accounts are identified by their ID")
+	}
+	id, err := strconv.ParseInt(s, 10, 64)
+	if err != nil {
+		return 0, fmt.Errorf("invalid account ID %q", s)
+	}
+	return id, nil
+}
@@ -0,0 +1,83 @@
+package bulk
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestParsePain001(t *testing.T) {
+	data, err := os.ReadFile(filepath.Join("testdata", "pain001.xml"))
+	require.NoError(t, err)
+
+	instructions, err := ParsePain001(data)
+	require.NoError(t, err)
+	require.Equal(t, []Instruction{
+		{Line: 1, FromAccountID: 7, ToAccountID: 8, Amount: 100050, Currency: "USD", Reference: "SALARY-BOB"},
+		{Line: 2, FromAccountID: 7, ToAccountID: 9, Amount: 50000, Currency: "USD"},
+		{
+			Line: 3, ToAccountID: 10, Amount: 25000, Currency: "EUR", Reference: "BONUS-DAVE",
+			Error: "debtor account: missing Othr/Id, accounts are identified by their ID",
+		},
+	}, instructions)
+}
+
+func TestParsePain001GroupHeader(t *testing.T) {
+	data, err := os.ReadFile(filepath.Join("testdata", "pain001.xml"))
+	require.NoError(t, err)
+
+	testCases := []struct {
+		name    string
+		old     string
+		new     string
+		message string
+	}{
+		{
+			name:    "NumberOfTransactions",
+			old:     "<NbOfTxs>3</NbOfTxs>",
+			new:     "<NbOfTxs>4</NbOfTxs>",
+			message: "NbOfTxs",
+		},
+		{
+			name:    "ControlSum",
+			old:     "<CtrlSum>1750.50</CtrlSum>",
+			new:     "<CtrlSum>1750.00</CtrlSum>",
+			message: "CtrlSum",
+		},
+		{
+			name:    "Namespace",
+			old:     "pain.001.001.03",
+			new:     "pain.008.001.02",
+			message: "not a customer credit transfer initiation",
+		},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+
+		t.Run(tc.name, func(t *testing.T) {
+			_, err := ParsePain001([]byte(strings.Replace(string(data), tc.old, tc.new, 1)))
+			require.ErrorContains(t, err, tc.message)
+		})
+	}
+}
+
+func TestParsePain001InvalidAmount(t *testing.T) {
+	data, err := os.ReadFile(filepath.Join("testdata", "pain001.xml"))
+	require.NoError(t, err)
+
+	// a line with an unreadable amount is invalid, the control sum cannot be checked
+	instructions, err := ParsePain001([]byte(strings.Replace(string(data), "1000.50", "1000.505", 1)))
+	require.NoError(t, err)
+	require.Len(t, instructions, 3)
+	require.Equal(t, `invalid amount "1000.505"`, instructions[0].Error)
+	require.Empty(t, instructions[1].Error)
+}
+
+func TestParsePain001InvalidXML(t *testing.T) {
+	_, err := ParsePain001([]byte("<Document>"))
+	require.ErrorContains(t, err, "invalid pain.001 file")
+}
@@ -0,0 +1,107 @@
+package bulk
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	db "github.com/XiaozhouCui/go-bank/db/sqlc"
+)
+
+// senders checks that a user may send the instructions of a file from their accounts,
+// as the API checks a transfer: the api package cannot be imported here.
+type senders struct {
+	store    db.Store
+	username string
+	accounts map[int64]*db.Account
+	// the membership of the user in each from account, nil if they are not a member
+	members map[int64]*db.AccountMember
+	// the approval policy of each from account, nil if it has none
+	policies map[int64]*db.ApprovalPolicy
+}
+
+func newSenders(store db.Store, username string) *senders {
+	return &senders{
+		store:    store,
+		username: username,
+		accounts: make(map[int64]*db.Account),
+		members:  make(map[int64]*db.AccountMember),
+		policies: make(map[int64]*db.ApprovalPolicy),
+	}
+}
+
+// check returns why the user cannot send amount from the account, or an empty string: they must be its owner,
+// a co-owner, or a spender within their spend limit, and the transfer cannot need approvals.
+// Only POST /transfers waits for approvals, an import cannot.
+// The error returned is a failure of the store.
+func (s *senders) check(ctx context.Context, account db.Account, amount int64) (string, error) {
+	member, err := s.member(ctx, account)
+	if err != nil {
+		return "", err
+	}
+
+	switch {
+	case member == nil:
+		return fmt.Sprintf("from account [%d] is not owned by or shared with %s", account.ID, s.username), nil
+	case !db.AccountRoleCanSpend(member.Role):
+		return fmt.Sprintf("a %s of account [%d] cannot send money from it", member.Role, account.ID), nil
+	case member.SpendLimit.Valid && amount > member.SpendLimit.Int64:
+		return fmt.Sprintf("amount %d is over the spend limit of %d on account [%d]", amount, member.SpendLimit.Int64, account.ID), nil
+	}
+
+	policy, err := s.policy(ctx, account.ID)
+	if err != nil {
+		return "", err
+	}
+	if policy != nil && amount >= policy.Threshold {
+		return fmt.Sprintf("transfers of %d or more from account [%d] need approvals", policy.Threshold, account.ID), nil
+	}
+	return "", nil
+}
+
+// policy returns the approval policy of an account, each one is only loaded once. It returns nil if there is none.
+func (s *senders) policy(ctx context.Context, accountID int64) (*db.ApprovalPolicy, error) {
+	if policy, ok := s.policies[accountID]; ok {
+		return policy, nil
+	}
+
+	var policy *db.ApprovalPolicy
+	p, err := s.store.GetApprovalPolicy(ctx, accountID)
+	if err != nil && !errors.Is(err, db.ErrRecordNotFound) {
+		return nil, err
+	}
+	if err == nil {
+		policy = &p
+	}
+
+	s.policies[accountID] = policy
+	return policy, nil
+}
+
+// member returns the membership of the user in an account, each one is only loaded once.
+// The owner is a member of their own account, other users once they have accepted an invitation.
+// It returns nil if the user is not a member.
+func (s *senders) member(ctx context.Context, account db.Account) (*db.AccountMember, error) {
+	if member, ok := s.members[account.ID]; ok {
+		return member, nil
+	}
+
+	var member *db.AccountMember
+	if account.Owner == s.username {
+		member = &db.AccountMember{AccountID: account.ID, Username: s.username, Role: db.AccountOwner}
+	} else {
+		m, err := s.store.GetAccountMember(ctx, db.GetAccountMemberParams{
+			AccountID: account.ID,
+			Username:  s.username,
+		})
+		if err != nil && !errors.Is(err, db.ErrRecordNotFound) {
+			return nil, err
+		}
+		if err == nil && m.AcceptedAt.Valid {
+			member = &m
+		}
+	}
+
+	s.members[account.ID] = member
+	return member, nil
+}
@@ -0,0 +1,351 @@
+// Package camt exports account activity as ISO 20022 cash management messages,
+// the bank statement formats ERPs import: camt.053 for end of day statements
+// and camt.052 for intraday reports. Both use version 02 of the schemas, the one most ERPs read.
+package camt
+
+import (
+	"bytes"
+	"encoding/xml"
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/XiaozhouCui/go-bank/statement"
+)
+
+// XML namespaces of the supported message versions
+const (
+	StatementNamespace = "urn:iso:std:iso:20022:tech:xsd:camt.053.001.02"
+	ReportNamespace    = "urn:iso:std:iso:20022:tech:xsd:camt.052.001.02"
+)
+
+// balance types
+const (
+	openingBooked = "OPBD"
+	closingBooked = "CLBD"
+	interimBooked = "ITBD"
+)
+
+// credit and debit indicators, amounts are always positive
+const (
+	credit = "CRDT"
+	debit  = "DBIT"
+)
+
+// isoDateTime is the ISODateTime layout, always in UTC
+const isoDateTime = "2006-01-02T15:04:05Z"
+
+type document struct {
+	XMLName   xml.Name `xml:"Document"`
+	Namespace string   `xml:"xmlns,attr"`
+	Statement *message `xml:"BkToCstmrStmt,omitempty"`
+	Report    *message `xml:"BkToCstmrAcctRpt,omitempty"`
+}
+
+type message struct {
+	GroupHeader groupHeader    `xml:"GrpHdr"`
+	Statement   *accountReport `xml:"Stmt,omitempty"`
+	Report      *accountReport `xml:"Rpt,omitempty"`
+}
+
+type groupHeader struct {
+	MessageID string `xml:"MsgId"`
+	CreatedAt string `xml:"CreDtTm"`
+}
+
+// accountReport is an AccountStatement2 in camt.053 and an AccountReport11 in camt.052,
+// they share the elements used here
+type accountReport struct {
+	ID        string         `xml:"Id"`
+	CreatedAt string         `xml:"CreDtTm"`
+	Period    dateTimePeriod `xml:"FrToDt"`
+	Account   cashAccount    `xml:"Acct"`
+	Balances  []balance      `xml:"Bal"`
+	Summary   summary        `xml:"TxsSummry"`
+	Entries   []entry        `xml:"Ntry"`
+}
+
+type dateTimePeriod struct {
+	From string `xml:"FrDtTm"`
+	To   string `xml:"ToDtTm"`
+}
+
+type cashAccount struct {
+	ID       accountID `xml:"Id"`
+	Currency string    `xml:"Ccy,omitempty"`
+	Owner    *party    `xml:"Ownr,omitempty"`
+}
+
+type accountID struct {
+	Other genericID `xml:"Othr"`
+}
+
+type genericID struct {
+	ID string `xml:"Id"`
+}
+
+type party struct {
+	Name string `xml:"Nm"`
+}
+
+type balance struct {
+	Type        balanceType `xml:"Tp"`
+	Amount      amount      `xml:"Amt"`
+	CreditDebit string      `xml:"CdtDbtInd"`
+	Date        dateChoice  `xml:"Dt"`
+}
+
+type balanceType struct {
+	Code string `xml:"CdOrPrtry>Cd"`
+}
+
+type amount struct {
+	Currency string `xml:"Ccy,attr"`
+	Value    string `xml:",chardata"`
+}
+
+// dateChoice holds either a date or a date and time
+type dateChoice struct {
+	Date     string `xml:"Dt,omitempty"`
+	DateTime string `xml:"DtTm,omitempty"`
+}
+
+type summary struct {
+	Total   totalEntries `xml:"TtlNtries"`
+	Credits entryCount   `xml:"TtlCdtNtries"`
+	Debits  entryCount   `xml:"TtlDbtNtries"`
+}
+
+type totalEntries struct {
+	Count       int    `xml:"NbOfNtries"`
+	Sum         string `xml:"Sum"`
+	Net         string `xml:"TtlNetNtryAmt"`
+	CreditDebit string `xml:"CdtDbtInd"`
+}
+
+type entryCount struct {
+	Count int    `xml:"NbOfNtries"`
+	Sum   string `xml:"Sum"`
+}
+
+type entry struct {
+	Reference         string              `xml:"NtryRef"`
+	Amount            amount              `xml:"Amt"`
+	CreditDebit       string              `xml:"CdtDbtInd"`
+	Status            string              `xml:"Sts"`
+	BookingDate       dateChoice          `xml:"BookgDt"`
+	ValueDate         dateChoice          `xml:"ValDt"`
+	ServicerReference string              `xml:"AcctSvcrRef,omitempty"`
+	TransactionCode   bankTransactionCode `xml:"BkTxCd"`
+	Details           *entryDetails       `xml:"NtryDtls,omitempty"`
+	AdditionalInfo    string              `xml:"AddtlNtryInf,omitempty"`
+}
+
+type bankTransactionCode struct {
+	Domain    string `xml:"Domn>Cd"`
+	Family    string `xml:"Domn>Fmly>Cd"`
+	SubFamily string `xml:"Domn>Fmly>SubFmlyCd"`
+}
+
+type entryDetails struct {
+	Transaction transactionDetails `xml:"TxDtls"`
+}
+
+type transactionDetails struct {
+	References     references      `xml:"Refs"`
+	RelatedParties *relatedParties `xml:"RltdPties,omitempty"`
+	Remittance     string          `xml:"RmtInf>Ustrd"`
+}
+
+type references struct {
+	EndToEndID    string `xml:"EndToEndId"`
+	TransactionID string `xml:"TxId"`
+}
+
+type relatedParties struct {
+	Debtor          *party       `xml:"Dbtr,omitempty"`
+	DebtorAccount   *cashAccount `xml:"DbtrAcct,omitempty"`
+	Creditor        *party       `xml:"Cdtr,omitempty"`
+	CreditorAccount *cashAccount `xml:"CdtrAcct,omitempty"`
+}
+
+// EndOfDayStatement renders the statement of a whole booking day as a camt.053 message,
+// with its opening and closing booked balances.
+func EndOfDayStatement(s statement.Statement, createdAt time.Time) ([]byte, error) {
+	id := fmt.Sprintf("STMT-%d-%s", s.Account.ID, s.PeriodStart.Format("20060102"))
+	report := newAccountReport(id, s, createdAt)
+	report.Balances = []balance{
+		newBalance(openingBooked, s.Account.Currency, s.OpeningBalance, dateChoice{Date: s.PeriodStart.Format("2006-01-02")}),
+		newBalance(closingBooked, s.Account.Currency, s.ClosingBalance, dateChoice{Date: s.PeriodStart.Format("2006-01-02")}),
+	}
+
+	return marshal(document{
+		Namespace: StatementNamespace,
+		Statement: &message{
+			GroupHeader: groupHeader{MessageID: id, CreatedAt: createdAt.UTC().Format(isoDateTime)},
+			Statement:   report,
+		},
+	})
+}
+
+// IntradayReport renders the activity of a booking day so far as a camt.052 message,
+// with the opening booked balance of the day and the interim booked balance at the end of the report.
+func IntradayReport(s statement.Statement, createdAt time.Time) ([]byte, error) {
+	id := fmt.Sprintf("RPT-%d-%s", s.Account.ID, s.PeriodEnd.UTC().Format("20060102150405"))
+	report := newAccountReport(id, s, createdAt)
+	report.Balances = []balance{
+		newBalance(openingBooked, s.Account.Currency, s.OpeningBalance, dateChoice{Date: s.PeriodStart.Format("2006-01-02")}),
+		newBalance(interimBooked, s.Account.Currency, s.ClosingBalance, dateChoice{DateTime: s.PeriodEnd.UTC().Format(isoDateTime)}),
+	}
+
+	return marshal(document{
+		Namespace: ReportNamespace,
+		Report: &message{
+			GroupHeader: groupHeader{MessageID: id, CreatedAt: createdAt.UTC().Format(isoDateTime)},
+			Report:      report,
+		},
+	})
+}
+
+func newAccountReport(id string, s statement.Statement, createdAt time.Time) *accountReport {
+	currency := s.Account.Currency
+	report := &accountReport{
+		ID:        id,
+		CreatedAt: createdAt.UTC().Format(isoDateTime),
+		Period: dateTimePeriod{
+			From: s.PeriodStart.UTC().Format(isoDateTime),
+			To:   s.PeriodEnd.UTC().Format(isoDateTime),
+		},
+		Account: cashAccount{
+			ID:       accountID{Other: genericID{ID: strconv.FormatInt(s.Account.ID, 10)}},
+			Currency: currency,
+			Owner:    &party{Name: s.Account.Owner},
+		},
+	}
+
+	var credits, debits int64
+	for _, line := range s.Lines {
+		if line.Amount < 0 {
+			debits -= line.Amount
+			report.Summary.Debits.Count++
+		} else {
+			credits += line.Amount
+			report.Summary.Credits.Count++
+		}
+		report.Entries = append(report.Entries, newEntry(currency, line))
+	}
+
+	net, indicator := absolute(credits - debits)
+	report.Summary.Total = totalEntries{
+		Count:       len(s.Lines),
+		Sum:         statement.FormatAmount(credits + debits),
+		Net:         net,
+		CreditDebit: indicator,
+	}
+	report.Summary.Credits.Sum = statement.FormatAmount(credits)
+	report.Summary.Debits.Sum = statement.FormatAmount(debits)
+	return report
+}
+
+func newBalance(code, currency string, value int64, date dateChoice) balance {
+	formatted, indicator := absolute(value)
+	return balance{
+		Type:        balanceType{Code: code},
+		Amount:      amount{Currency: currency, Value: formatted},
+		CreditDebit: indicator,
+		Date:        date,
+	}
+}
+
+// newEntry turns a line of the statement into an entry, referenced by the entry ID
+// and linked back to its transfer by the servicer reference and the transaction ID
+func newEntry(currency string, line statement.Line) entry {
+	value, indicator := absolute(line.Amount)
+	booked := dateChoice{DateTime: line.Date.UTC().Format(isoDateTime)}
+	result := entry{
+		Reference:       strconv.FormatInt(line.EntryID, 10),
+		Amount:          amount{Currency: currency, Value: value},
+		CreditDebit:     indicator,
+		Status:          "BOOK",
+		BookingDate:     booked,
+		ValueDate:       dateChoice{Date: line.Date.UTC().Format("2006-01-02")},
+		TransactionCode: transactionCode(line, indicator),
+		AdditionalInfo:  line.Description,
+	}
+	if line.TransferID == 0 {
+		return result
+	}
+
+	transferID := strconv.FormatInt(line.TransferID, 10)
+	result.ServicerReference = transferID
+	details := transactionDetails{
+		References: references{EndToEndID: line.Reference, TransactionID: transferID},
+		Remittance: line.Memo,
+	}
+	// a transfer sent without an end to end reference, the same value as in a pain.001 file
+	if details.References.EndToEndID == "" {
+		details.References.EndToEndID = "NOTPROVIDED"
+	}
+	// the remittance information is the description from the sender, or what the transfer is without one
+	if details.Remittance == "" {
+		details.Remittance = line.Description
+	}
+	// the fee of a transfer is paid to the bank, not to the other party of the transfer
+	if line.CounterpartyAccountID != 0 && !line.Fee {
+		counterparty := &party{Name: line.CounterpartyOwner}
+		counterpartyAccount := &cashAccount{
+			ID: accountID{Other: genericID{ID: strconv.FormatInt(line.CounterpartyAccountID, 10)}},
+		}
+		// money coming in is paid by the debtor, money going out goes to the creditor
+		if indicator == credit {
+			details.RelatedParties = &relatedParties{Debtor: counterparty, DebtorAccount: counterpartyAccount}
+		} else {
+			details.RelatedParties = &relatedParties{Creditor: counterparty, CreditorAccount: counterpartyAccount}
+		}
+	}
+	result.Details = &entryDetails{Transaction: details}
+	return result
+}
+
+// transactionCode classifies an entry with the ISO bank transaction codes:
+// transfers between accounts of the bank are book transfers, fees are charges
+// and entries without a transfer are adjustments
+func transactionCode(line statement.Line, indicator string) bankTransactionCode {
+	if line.TransferID == 0 {
+		family := "MCOP" // miscellaneous credit operations
+		if indicator == debit {
+			family = "MDOP"
+		}
+		return bankTransactionCode{Domain: "ACMT", Family: family, SubFamily: "ADJT"}
+	}
+
+	family := "RCDT" // received credit transfers
+	if indicator == debit {
+		family = "ICDT" // issued credit transfers
+	}
+	subFamily := "BOOK"
+	if line.Fee {
+		subFamily = "CHRG"
+	}
+	return bankTransactionCode{Domain: "PMNT", Family: family, SubFamily: subFamily}
+}
+
+// absolute splits an amount into its formatted absolute value and its credit or debit indicator
+func absolute(value int64) (string, string) {
+	if value < 0 {
+		return statement.FormatAmount(-value), debit
+	}
+	return statement.FormatAmount(value), credit
+}
+
+func marshal(doc document) ([]byte, error) {
+	var buf bytes.Buffer
+	buf.WriteString(xml.Header)
+	encoder := xml.NewEncoder(&buf)
+	encoder.Indent("", "  ")
+	if err := encoder.Encode(doc); err != nil {
+		return nil, err
+	}
+	buf.WriteString("\n")
+	return buf.Bytes(), nil
+}
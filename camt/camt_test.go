@@ -0,0 +1,166 @@
+package camt
+
+import (
+	"encoding/xml"
+	"flag"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"testing"
+	"time"
+
+	db "github.com/XiaozhouCui/go-bank/db/sqlc"
+	"github.com/XiaozhouCui/go-bank/statement"
+	"github.com/stretchr/testify/require"
+)
+
+// go test ./camt -update rewrites the fixtures in testdata
+var update = flag.Bool("update", false, "update the fixtures in testdata")
+
+var (
+	day       = time.Date(2023, time.January, 10, 0, 0, 0, 0, time.UTC)
+	createdAt = day.Add(26 * time.Hour)
+)
+
+func testStatement(end time.Time) statement.Statement {
+	return statement.Statement{
+		Account:        db.Account{ID: 7, Owner: "alice", Currency: "USD", Type: "checking"},
+		PeriodStart:    day,
+		PeriodEnd:      end,
+		OpeningBalance: 10000,
+		ClosingBalance: 12450,
+		Lines: []statement.Line{
+			{
+				EntryID: 1, Date: day.Add(9 * time.Hour), Amount: 5000, Balance: 15000,
+				Description: "Transfer #11 from account #8 (bob)",
+				TransferID:  11, CounterpartyAccountID: 8, CounterpartyOwner: "bob",
+			},
+			{
+				EntryID: 2, Date: day.Add(14 * time.Hour), Amount: -2500, Balance: 12500,
+				Description: "Transfer #12 to account #9 (carol): March rent",
+				TransferID:  12, CounterpartyAccountID: 9, CounterpartyOwner: "carol",
+				Memo: "March rent", Reference: "INV-42",
+			},
+			{
+				EntryID: 3, Date: day.Add(14 * time.Hour), Amount: -50, Balance: 12450, Fee: true,
+				Description: "Fee for transfer #12 to account #9 (carol)",
+				TransferID:  12, CounterpartyAccountID: 9, CounterpartyOwner: "carol",
+			},
+		},
+	}
+}
+
+// requireFixture compares a message with its fixture in testdata
+func requireFixture(t *testing.T, name string, got []byte) {
+	path := filepath.Join("testdata", name)
+	if *update {
+		require.NoError(t, os.WriteFile(path, got, 0644))
+	}
+
+	want, err := os.ReadFile(path)
+	require.NoError(t, err)
+	require.Equal(t, string(want), string(got))
+}
+
+func TestEndOfDayStatement(t *testing.T) {
+	got, err := EndOfDayStatement(testStatement(day.AddDate(0, 0, 1)), createdAt)
+	require.NoError(t, err)
+	requireFixture(t, "camt053.xml", got)
+
+	var doc struct {
+		Statement struct {
+			Balances []struct {
+				Code        string `xml:"Tp>CdOrPrtry>Cd"`
+				Amount      string `xml:"Amt"`
+				CreditDebit string `xml:"CdtDbtInd"`
+			} `xml:"Stmt>Bal"`
+			Entries []struct {
+				Reference         string `xml:"NtryRef"`
+				ServicerReference string `xml:"AcctSvcrRef"`
+				TransactionID     string `xml:"NtryDtls>TxDtls>Refs>TxId"`
+				CreditDebit       string `xml:"CdtDbtInd"`
+				Creditor          string `xml:"NtryDtls>TxDtls>RltdPties>Cdtr>Nm"`
+			} `xml:"Stmt>Ntry"`
+		} `xml:"BkToCstmrStmt"`
+	}
+	require.NoError(t, xml.Unmarshal(got, &doc))
+
+	balances := doc.Statement.Balances
+	require.Len(t, balances, 2)
+	require.Equal(t, "OPBD", balances[0].Code)
+	require.Equal(t, "100.00", balances[0].Amount)
+	require.Equal(t, "CLBD", balances[1].Code)
+	require.Equal(t, "124.50", balances[1].Amount)
+
+	// entries link back to their transfers
+	entries := doc.Statement.Entries
+	require.Len(t, entries, 3)
+	require.Equal(t, "1", entries[0].Reference)
+	require.Equal(t, "11", entries[0].ServicerReference)
+	require.Equal(t, "11", entries[0].TransactionID)
+	require.Equal(t, "CRDT", entries[0].CreditDebit)
+	require.Equal(t, "carol", entries[1].Creditor)
+	require.Equal(t, "12", entries[2].TransactionID)
+	require.Equal(t, "DBIT", entries[2].CreditDebit)
+	require.Empty(t, entries[2].Creditor) // the fee goes to the bank
+}
+
+func TestIntradayReport(t *testing.T) {
+	got, err := IntradayReport(testStatement(day.Add(15*time.Hour)), day.Add(15*time.Hour))
+	require.NoError(t, err)
+	requireFixture(t, "camt052.xml", got)
+
+	var doc struct {
+		Report struct {
+			Balances []struct {
+				Code     string `xml:"Tp>CdOrPrtry>Cd"`
+				DateTime string `xml:"Dt>DtTm"`
+			} `xml:"Rpt>Bal"`
+		} `xml:"BkToCstmrAcctRpt"`
+	}
+	require.NoError(t, xml.Unmarshal(got, &doc))
+
+	balances := doc.Report.Balances
+	require.Len(t, balances, 2)
+	require.Equal(t, "OPBD", balances[0].Code)
+	require.Equal(t, "ITBD", balances[1].Code)
+	require.Equal(t, "2023-01-10T15:00:00Z", balances[1].DateTime)
+}
+
+func TestNegativeBalance(t *testing.T) {
+	s := testStatement(day.AddDate(0, 0, 1))
+	s.OpeningBalance = -1234
+
+	got, err := EndOfDayStatement(s, createdAt)
+	require.NoError(t, err)
+
+	var doc struct {
+		Balances []struct {
+			Amount      string `xml:"Amt"`
+			CreditDebit string `xml:"CdtDbtInd"`
+		} `xml:"BkToCstmrStmt>Stmt>Bal"`
+	}
+	require.NoError(t, xml.Unmarshal(got, &doc))
+	require.Equal(t, "12.34", doc.Balances[0].Amount)
+	require.Equal(t, "DBIT", doc.Balances[0].CreditDebit)
+}
+
+// TestSchema validates the fixtures against the official ISO 20022 schemas, vendored in testdata, with xmllint
+func TestSchema(t *testing.T) {
+	xmllint, err := exec.LookPath("xmllint")
+	if err != nil {
+		t.Skip("xmllint is not installed")
+	}
+
+	fixtures := map[string]string{
+		"camt053.xml": "camt.053.001.02.xsd",
+		"camt052.xml": "camt.052.001.02.xsd",
+	}
+	for fixture, schema := range fixtures {
+		schema = filepath.Join("testdata", schema)
+		require.FileExists(t, schema, "download it from the ISO 20022 message archive at iso20022.org")
+
+		out, err := exec.Command(xmllint, "--noout", "--schema", schema, filepath.Join("testdata", fixture)).CombinedOutput()
+		require.NoError(t, err, string(out))
+	}
+}
@@ -0,0 +1,18 @@
+ALTER TABLE "accounts"
+ADD COLUMN "status" varchar NOT NULL DEFAULT 'active';
+ALTER TABLE "accounts"
+ADD CONSTRAINT "account_status_check" CHECK ("status" IN ('active', 'frozen', 'closed'));
+CREATE TABLE "account_status_changes" (
+  "id" bigserial PRIMARY KEY,
+  "account_id" bigint NOT NULL,
+  "from_status" varchar NOT NULL,
+  "to_status" varchar NOT NULL,
+  "reason" varchar NOT NULL,
+  "changed_by" varchar NOT NULL,
+  "created_at" timestamptz NOT NULL DEFAULT (now())
+);
+CREATE INDEX ON "account_status_changes" ("account_id");
+ALTER TABLE "account_status_changes"
+ADD FOREIGN KEY ("account_id") REFERENCES "accounts" ("id");
+ALTER TABLE "account_status_changes"
+ADD FOREIGN KEY ("changed_by") REFERENCES "users" ("username");
\ No newline at end of file
@@ -0,0 +1,6 @@
+ALTER TABLE IF EXISTS "accounts" DROP CONSTRAINT IF EXISTS "owner_currency_type_key";
+ALTER TABLE IF EXISTS "accounts"
+ADD CONSTRAINT "owner_currency_key" UNIQUE ("owner", "currency");
+ALTER TABLE IF EXISTS "accounts" DROP CONSTRAINT IF EXISTS "account_type_check";
+ALTER TABLE IF EXISTS "accounts" DROP COLUMN IF EXISTS "nickname";
+ALTER TABLE IF EXISTS "accounts" DROP COLUMN IF EXISTS "type";
\ No newline at end of file
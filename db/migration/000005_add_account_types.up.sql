@@ -0,0 +1,10 @@
+ALTER TABLE "accounts"
+ADD COLUMN "type" varchar NOT NULL DEFAULT 'checking';
+ALTER TABLE "accounts"
+ADD COLUMN "nickname" varchar NOT NULL DEFAULT '';
+ALTER TABLE "accounts"
+ADD CONSTRAINT "account_type_check" CHECK ("type" IN ('checking', 'savings', 'escrow'));
+-- one account per currency and type, instead of one per currency
+ALTER TABLE "accounts" DROP CONSTRAINT IF EXISTS "owner_currency_key";
+ALTER TABLE "accounts"
+ADD CONSTRAINT "owner_currency_type_key" UNIQUE ("owner", "currency", "type");
\ No newline at end of file
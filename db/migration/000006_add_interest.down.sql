@@ -0,0 +1,18 @@
+DROP TABLE IF EXISTS "interest_postings";
+DROP TABLE IF EXISTS "interest_accruals";
+DROP TABLE IF EXISTS "account_interest_plans";
+DROP TABLE IF EXISTS "interest_plans";
+DELETE FROM "system_accounts"
+WHERE purpose = 'interest_expense';
+DELETE FROM "accounts"
+WHERE owner = 'bank'
+  AND nickname = 'interest expense';
+DROP TABLE IF EXISTS "system_accounts";
+DELETE FROM "users"
+WHERE username = 'bank';
+DROP INDEX IF EXISTS "owner_currency_type_key";
+ALTER TABLE IF EXISTS "accounts"
+ADD CONSTRAINT "owner_currency_type_key" UNIQUE ("owner", "currency", "type");
+ALTER TABLE IF EXISTS "accounts" DROP CONSTRAINT IF EXISTS "account_type_check";
+ALTER TABLE IF EXISTS "accounts"
+ADD CONSTRAINT "account_type_check" CHECK ("type" IN ('checking', 'savings', 'escrow'));
\ No newline at end of file
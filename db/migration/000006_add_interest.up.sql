@@ -0,0 +1,85 @@
+-- accounts owned by the bank itself, e.g. to pay interest from.
+-- several internal accounts can share an owner and currency, so the uniqueness only applies to customer accounts
+ALTER TABLE "accounts" DROP CONSTRAINT IF EXISTS "account_type_check";
+ALTER TABLE "accounts"
+ADD CONSTRAINT "account_type_check" CHECK (
+    "type" IN ('checking', 'savings', 'escrow', 'internal')
+  );
+ALTER TABLE "accounts" DROP CONSTRAINT IF EXISTS "owner_currency_type_key";
+CREATE UNIQUE INDEX "owner_currency_type_key" ON "accounts" ("owner", "currency", "type")
+WHERE "type" <> 'internal';
+CREATE TABLE "system_accounts" (
+  "purpose" varchar NOT NULL,
+  "currency" varchar NOT NULL,
+  "account_id" bigint UNIQUE NOT NULL,
+  PRIMARY KEY ("purpose", "currency")
+);
+ALTER TABLE "system_accounts"
+ADD FOREIGN KEY ("account_id") REFERENCES "accounts" ("id");
+-- the system user cannot log in, its password hash is empty
+INSERT INTO "users" (username, hashed_password, full_name, email)
+VALUES ('bank', '', 'Simple Bank', 'system@simplebank.local');
+WITH "created" AS (
+  INSERT INTO "accounts" (owner, balance, currency, type, nickname)
+  VALUES ('bank', 0, 'USD', 'internal', 'interest expense'),
+    ('bank', 0, 'EUR', 'internal', 'interest expense'),
+    ('bank', 0, 'CAD', 'internal', 'interest expense')
+  RETURNING id,
+    currency
+)
+INSERT INTO "system_accounts" (purpose, currency, account_id)
+SELECT 'interest_expense',
+  currency,
+  id
+FROM "created";
+CREATE TABLE "interest_plans" (
+  "id" bigserial PRIMARY KEY,
+  "name" varchar UNIQUE NOT NULL,
+  "annual_rate_bps" bigint NOT NULL,
+  "day_count" varchar NOT NULL,
+  "created_at" timestamptz NOT NULL DEFAULT (now())
+);
+CREATE TABLE "account_interest_plans" (
+  "account_id" bigint PRIMARY KEY,
+  "plan_id" bigint NOT NULL,
+  "created_at" timestamptz NOT NULL DEFAULT (now())
+);
+CREATE TABLE "interest_accruals" (
+  "id" bigserial PRIMARY KEY,
+  "account_id" bigint NOT NULL,
+  "accrual_date" date NOT NULL,
+  "balance" bigint NOT NULL,
+  "annual_rate_bps" bigint NOT NULL,
+  "day_count" varchar NOT NULL,
+  "amount_micros" bigint NOT NULL,
+  "created_at" timestamptz NOT NULL DEFAULT (now())
+);
+CREATE TABLE "interest_postings" (
+  "id" bigserial PRIMARY KEY,
+  "account_id" bigint NOT NULL,
+  "period_start" date NOT NULL,
+  "period_end" date NOT NULL,
+  "accrued_micros" bigint NOT NULL,
+  "amount" bigint NOT NULL,
+  "remainder_micros" bigint NOT NULL,
+  "transfer_id" bigint,
+  "created_at" timestamptz NOT NULL DEFAULT (now())
+);
+CREATE UNIQUE INDEX ON "interest_accruals" ("account_id", "accrual_date");
+CREATE UNIQUE INDEX ON "interest_postings" ("account_id", "period_start");
+COMMENT ON COLUMN "interest_plans"."annual_rate_bps" IS 'annual rate in basis points, 250 is 2.50%';
+COMMENT ON COLUMN "interest_plans"."day_count" IS 'ACT/365, ACT/360, ACT/ACT or 30/360';
+COMMENT ON COLUMN "interest_accruals"."balance" IS 'end-of-day balance';
+COMMENT ON COLUMN "interest_accruals"."amount_micros" IS 'interest in millionths of the minor currency unit';
+COMMENT ON COLUMN "interest_postings"."amount" IS 'interest credited to the account, in the minor currency unit';
+COMMENT ON COLUMN "interest_postings"."remainder_micros" IS 'fraction too small to credit, carried to the next period';
+ALTER TABLE "account_interest_plans"
+ADD FOREIGN KEY ("account_id") REFERENCES "accounts" ("id");
+ALTER TABLE "account_interest_plans"
+ADD FOREIGN KEY ("plan_id") REFERENCES "interest_plans" ("id");
+ALTER TABLE "interest_accruals"
+ADD FOREIGN KEY ("account_id") REFERENCES "accounts" ("id");
+ALTER TABLE "interest_postings"
+ADD FOREIGN KEY ("account_id") REFERENCES "accounts" ("id");
+ALTER TABLE "interest_postings"
+ADD FOREIGN KEY ("transfer_id") REFERENCES "transfers" ("id");
\ No newline at end of file
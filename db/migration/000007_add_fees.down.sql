@@ -0,0 +1,8 @@
+DROP TABLE IF EXISTS "fee_tiers";
+DROP TABLE IF EXISTS "fee_schedules";
+DELETE FROM "system_accounts"
+WHERE purpose = 'fee_revenue';
+DELETE FROM "accounts"
+WHERE owner = 'bank'
+  AND nickname = 'fee revenue';
+ALTER TABLE IF EXISTS "transfers" DROP COLUMN IF EXISTS "fee";
\ No newline at end of file
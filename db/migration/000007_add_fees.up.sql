@@ -0,0 +1,44 @@
+ALTER TABLE "transfers"
+ADD COLUMN "fee" bigint NOT NULL DEFAULT 0;
+COMMENT ON COLUMN "transfers"."fee" IS 'paid by the sender on top of the amount';
+-- transfer fees are credited to the fee revenue account of the transfer currency
+WITH "created" AS (
+  INSERT INTO "accounts" (owner, balance, currency, type, nickname)
+  VALUES ('bank', 0, 'USD', 'internal', 'fee revenue'),
+    ('bank', 0, 'EUR', 'internal', 'fee revenue'),
+    ('bank', 0, 'CAD', 'internal', 'fee revenue')
+  RETURNING id,
+    currency
+)
+INSERT INTO "system_accounts" (purpose, currency, account_id)
+SELECT 'fee_revenue',
+  currency,
+  id
+FROM "created";
+CREATE TABLE "fee_schedules" (
+  "id" bigserial PRIMARY KEY,
+  "currency" varchar NOT NULL,
+  "account_type" varchar NOT NULL,
+  "kind" varchar NOT NULL,
+  "flat_fee" bigint NOT NULL DEFAULT 0,
+  "rate_bps" bigint NOT NULL DEFAULT 0,
+  "min_fee" bigint NOT NULL DEFAULT 0,
+  "max_fee" bigint NOT NULL DEFAULT 0,
+  "created_at" timestamptz NOT NULL DEFAULT (now()),
+  CONSTRAINT "fee_kind_check" CHECK ("kind" IN ('flat', 'percentage', 'tiered'))
+);
+CREATE TABLE "fee_tiers" (
+  "id" bigserial PRIMARY KEY,
+  "schedule_id" bigint NOT NULL,
+  "min_amount" bigint NOT NULL,
+  "flat_fee" bigint NOT NULL DEFAULT 0,
+  "rate_bps" bigint NOT NULL DEFAULT 0
+);
+CREATE UNIQUE INDEX ON "fee_schedules" ("currency", "account_type");
+CREATE UNIQUE INDEX ON "fee_tiers" ("schedule_id", "min_amount");
+COMMENT ON COLUMN "fee_schedules"."account_type" IS 'type of the sending account';
+COMMENT ON COLUMN "fee_schedules"."rate_bps" IS 'percentage of the amount in basis points, 50 is 0.50%';
+COMMENT ON COLUMN "fee_schedules"."max_fee" IS '0 means no cap';
+COMMENT ON COLUMN "fee_tiers"."min_amount" IS 'the tier applies from this amount up to the next tier';
+ALTER TABLE "fee_tiers"
+ADD FOREIGN KEY ("schedule_id") REFERENCES "fee_schedules" ("id");
\ No newline at end of file
@@ -0,0 +1,42 @@
+-- limits on outgoing transfers, a limit of 0 means no limit.
+-- the row without username and account_id holds the default limits of every user,
+-- a user row replaces the default limits for that user, an account row applies on top of the user limits
+CREATE TABLE "transfer_limits" (
+  "id" bigserial PRIMARY KEY,
+  "username" varchar,
+  "account_id" bigint,
+  "max_amount" bigint NOT NULL DEFAULT 0,
+  "daily_amount" bigint NOT NULL DEFAULT 0,
+  "monthly_amount" bigint NOT NULL DEFAULT 0,
+  "daily_count" bigint NOT NULL DEFAULT 0,
+  "updated_by" varchar NOT NULL,
+  "updated_at" timestamptz NOT NULL DEFAULT (now()),
+  CONSTRAINT "transfer_limit_scope_check" CHECK (
+    "username" IS NULL
+    OR "account_id" IS NULL
+  )
+);
+CREATE UNIQUE INDEX "transfer_limits_username_key" ON "transfer_limits" ("username")
+WHERE "username" IS NOT NULL;
+CREATE UNIQUE INDEX "transfer_limits_account_id_key" ON "transfer_limits" ("account_id")
+WHERE "account_id" IS NOT NULL;
+CREATE UNIQUE INDEX "transfer_limits_default_key" ON "transfer_limits" ((true))
+WHERE "username" IS NULL
+  AND "account_id" IS NULL;
+CREATE INDEX ON "transfers" ("from_account_id", "created_at");
+COMMENT ON COLUMN "transfer_limits"."max_amount" IS 'largest single transfer';
+COMMENT ON COLUMN "transfer_limits"."daily_amount" IS 'total sent per calendar day (UTC)';
+COMMENT ON COLUMN "transfer_limits"."monthly_amount" IS 'total sent per calendar month (UTC)';
+COMMENT ON COLUMN "transfer_limits"."daily_count" IS 'number of transfers per calendar day (UTC)';
+ALTER TABLE "transfer_limits"
+ADD FOREIGN KEY ("username") REFERENCES "users" ("username");
+ALTER TABLE "transfer_limits"
+ADD FOREIGN KEY ("account_id") REFERENCES "accounts" ("id");
+INSERT INTO "transfer_limits" (
+    max_amount,
+    daily_amount,
+    monthly_amount,
+    daily_count,
+    updated_by
+  )
+VALUES (1000000, 2500000, 10000000, 50, 'bank');
\ No newline at end of file
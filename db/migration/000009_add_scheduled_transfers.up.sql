@@ -0,0 +1,51 @@
+CREATE TABLE "scheduled_transfers" (
+  "id" bigserial PRIMARY KEY,
+  "owner" varchar NOT NULL,
+  "from_account_id" bigint NOT NULL,
+  "to_account_id" bigint NOT NULL,
+  "amount" bigint NOT NULL,
+  "currency" varchar NOT NULL,
+  "schedule" varchar NOT NULL DEFAULT '',
+  "start_at" timestamptz NOT NULL,
+  "next_run_at" timestamptz,
+  "failure_policy" varchar NOT NULL DEFAULT 'retry',
+  "max_retries" bigint NOT NULL DEFAULT 3,
+  "retry_count" bigint NOT NULL DEFAULT 0,
+  "status" varchar NOT NULL DEFAULT 'active',
+  "created_at" timestamptz NOT NULL DEFAULT (now()),
+  "updated_at" timestamptz NOT NULL DEFAULT (now()),
+  CONSTRAINT "scheduled_transfer_failure_policy_check" CHECK ("failure_policy" IN ('retry', 'skip')),
+  CONSTRAINT "scheduled_transfer_status_check" CHECK (
+    "status" IN ('active', 'paused', 'completed', 'failed', 'cancelled')
+  )
+);
+CREATE TABLE "scheduled_transfer_runs" (
+  "id" bigserial PRIMARY KEY,
+  "scheduled_transfer_id" bigint NOT NULL,
+  "scheduled_for" timestamptz NOT NULL,
+  "attempt" bigint NOT NULL,
+  "outcome" varchar NOT NULL,
+  "transfer_id" bigint,
+  "error" varchar NOT NULL DEFAULT '',
+  "created_at" timestamptz NOT NULL DEFAULT (now()),
+  CONSTRAINT "scheduled_transfer_run_outcome_check" CHECK ("outcome" IN ('succeeded', 'failed'))
+);
+CREATE INDEX ON "scheduled_transfers" ("owner");
+CREATE INDEX ON "scheduled_transfers" ("next_run_at")
+WHERE "status" = 'active';
+CREATE INDEX ON "scheduled_transfer_runs" ("scheduled_transfer_id");
+COMMENT ON COLUMN "scheduled_transfers"."schedule" IS 'recurrence rule like FREQ=MONTHLY;BYMONTHDAY=1, empty for a one-off transfer';
+COMMENT ON COLUMN "scheduled_transfers"."start_at" IS 'first occurrence, later occurrences follow the schedule from it';
+COMMENT ON COLUMN "scheduled_transfers"."next_run_at" IS 'null once there is nothing left to run';
+COMMENT ON COLUMN "scheduled_transfers"."failure_policy" IS 'retry: try again up to max_retries times, skip: wait for the next occurrence';
+COMMENT ON COLUMN "scheduled_transfer_runs"."attempt" IS 'starts at 1, increases with each retry of the same occurrence';
+ALTER TABLE "scheduled_transfers"
+ADD FOREIGN KEY ("owner") REFERENCES "users" ("username");
+ALTER TABLE "scheduled_transfers"
+ADD FOREIGN KEY ("from_account_id") REFERENCES "accounts" ("id");
+ALTER TABLE "scheduled_transfers"
+ADD FOREIGN KEY ("to_account_id") REFERENCES "accounts" ("id");
+ALTER TABLE "scheduled_transfer_runs"
+ADD FOREIGN KEY ("scheduled_transfer_id") REFERENCES "scheduled_transfers" ("id");
+ALTER TABLE "scheduled_transfer_runs"
+ADD FOREIGN KEY ("transfer_id") REFERENCES "transfers" ("id");
\ No newline at end of file
@@ -0,0 +1,37 @@
+ALTER TABLE "accounts"
+ADD COLUMN "held_amount" bigint NOT NULL DEFAULT 0;
+ALTER TABLE "accounts"
+ADD COLUMN "available_balance" bigint GENERATED ALWAYS AS ("balance" - "held_amount") STORED;
+CREATE TABLE "holds" (
+  "id" bigserial PRIMARY KEY,
+  "account_id" bigint NOT NULL,
+  "to_account_id" bigint NOT NULL,
+  "amount" bigint NOT NULL,
+  "captured_amount" bigint NOT NULL DEFAULT 0,
+  "status" varchar NOT NULL DEFAULT 'pending',
+  "transfer_id" bigint,
+  "created_by" varchar NOT NULL,
+  "expires_at" timestamptz NOT NULL,
+  "created_at" timestamptz NOT NULL DEFAULT (now()),
+  "updated_at" timestamptz NOT NULL DEFAULT (now()),
+  CONSTRAINT "hold_amount_check" CHECK ("amount" > 0),
+  CONSTRAINT "hold_status_check" CHECK (
+    "status" IN ('pending', 'captured', 'released', 'expired')
+  )
+);
+CREATE INDEX ON "holds" ("account_id");
+CREATE INDEX ON "holds" ("expires_at")
+WHERE "status" = 'pending';
+COMMENT ON COLUMN "accounts"."held_amount" IS 'funds reserved by pending holds, they still count in the balance but cannot be spent';
+COMMENT ON COLUMN "accounts"."available_balance" IS 'balance minus held_amount';
+COMMENT ON COLUMN "holds"."to_account_id" IS 'account credited when the hold is captured';
+COMMENT ON COLUMN "holds"."captured_amount" IS 'can be less than amount, the rest is released';
+COMMENT ON COLUMN "holds"."transfer_id" IS 'transfer made by the capture';
+ALTER TABLE "holds"
+ADD FOREIGN KEY ("account_id") REFERENCES "accounts" ("id");
+ALTER TABLE "holds"
+ADD FOREIGN KEY ("to_account_id") REFERENCES "accounts" ("id");
+ALTER TABLE "holds"
+ADD FOREIGN KEY ("transfer_id") REFERENCES "transfers" ("id");
+ALTER TABLE "holds"
+ADD FOREIGN KEY ("created_by") REFERENCES "users" ("username");
\ No newline at end of file
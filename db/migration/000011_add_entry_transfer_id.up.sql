@@ -0,0 +1,36 @@
+ALTER TABLE "entries"
+ADD COLUMN "transfer_id" bigint;
+CREATE INDEX ON "entries" ("transfer_id");
+COMMENT ON COLUMN "entries"."transfer_id" IS 'transfer that made the entry, null for entries made before it was recorded';
+ALTER TABLE "entries"
+ADD FOREIGN KEY ("transfer_id") REFERENCES "transfers" ("id");
+-- link the existing entries: a transfer and its entries are created in the same db transaction,
+-- so they share the same created_at. entries matching more than one transfer are left alone
+UPDATE "entries"
+SET "transfer_id" = "matches"."transfer_id"
+FROM (
+    SELECT "entries"."id" AS "entry_id",
+      min("transfers"."id") AS "transfer_id"
+    FROM "entries"
+      JOIN "transfers" ON "transfers"."created_at" = "entries"."created_at"
+    WHERE (
+        "entries"."account_id" = "transfers"."from_account_id"
+        AND "entries"."amount" IN (- "transfers"."amount", - "transfers"."fee")
+      )
+      OR (
+        "entries"."account_id" = "transfers"."to_account_id"
+        AND "entries"."amount" = "transfers"."amount"
+      )
+      OR (
+        "transfers"."fee" > 0
+        AND "entries"."amount" = "transfers"."fee"
+        AND "entries"."account_id" IN (
+          SELECT "account_id"
+          FROM "system_accounts"
+          WHERE "purpose" = 'fee_revenue'
+        )
+      )
+    GROUP BY "entries"."id"
+    HAVING count(*) = 1
+  ) AS "matches"
+WHERE "entries"."id" = "matches"."entry_id";
\ No newline at end of file
@@ -0,0 +1,24 @@
+ALTER TABLE "entries"
+ADD COLUMN "balance_after" bigint;
+-- backfill from the current balances: the balance after an entry is the current balance
+-- minus every later entry of the account, so accounts opened with a balance are right too
+UPDATE "entries"
+SET "balance_after" = "running"."balance_after"
+FROM (
+    SELECT "entries"."id",
+      "accounts"."balance" - COALESCE(
+        sum("entries"."amount") OVER (
+          PARTITION BY "entries"."account_id"
+          ORDER BY "entries"."id" DESC ROWS BETWEEN UNBOUNDED PRECEDING AND 1 PRECEDING
+        ),
+        0
+      ) AS "balance_after"
+    FROM "entries"
+      JOIN "accounts" ON "accounts"."id" = "entries"."account_id"
+  ) AS "running"
+WHERE "entries"."id" = "running"."id";
+ALTER TABLE "entries"
+ALTER COLUMN "balance_after"
+SET NOT NULL;
+CREATE INDEX ON "entries" ("account_id", "created_at");
+COMMENT ON COLUMN "entries"."balance_after" IS 'balance of the account right after the entry';
\ No newline at end of file
@@ -0,0 +1,15 @@
+CREATE TABLE "statements" (
+  "id" bigserial PRIMARY KEY,
+  "account_id" bigint NOT NULL,
+  "period_start" timestamptz NOT NULL,
+  "period_end" timestamptz NOT NULL,
+  "opening_balance" bigint NOT NULL,
+  "closing_balance" bigint NOT NULL,
+  "csv" bytea NOT NULL,
+  "pdf" bytea NOT NULL,
+  "created_at" timestamptz NOT NULL DEFAULT (now())
+);
+CREATE UNIQUE INDEX ON "statements" ("account_id", "period_start");
+COMMENT ON COLUMN "statements"."period_end" IS 'exclusive, the start of the next period';
+ALTER TABLE "statements"
+ADD FOREIGN KEY ("account_id") REFERENCES "accounts" ("id");
\ No newline at end of file
@@ -0,0 +1,45 @@
+CREATE TABLE "import_jobs" (
+  "id" bigserial PRIMARY KEY,
+  "owner" varchar NOT NULL,
+  "format" varchar NOT NULL,
+  "status" varchar NOT NULL,
+  "line_count" bigint NOT NULL,
+  "invalid_count" bigint NOT NULL,
+  "created_at" timestamptz NOT NULL DEFAULT (now()),
+  "updated_at" timestamptz NOT NULL DEFAULT (now()),
+  CONSTRAINT "import_job_format_check" CHECK ("format" IN ('pain.001', 'csv')),
+  CONSTRAINT "import_job_status_check" CHECK (
+    "status" IN ('validated', 'rejected', 'executing', 'completed')
+  )
+);
+CREATE INDEX ON "import_jobs" ("owner");
+CREATE TABLE "import_job_lines" (
+  "id" bigserial PRIMARY KEY,
+  "job_id" bigint NOT NULL,
+  "line" bigint NOT NULL,
+  "from_account_id" bigint NOT NULL,
+  "to_account_id" bigint NOT NULL,
+  "amount" bigint NOT NULL,
+  "currency" varchar NOT NULL,
+  "reference" varchar NOT NULL,
+  "status" varchar NOT NULL,
+  "error" varchar NOT NULL DEFAULT '',
+  "transfer_id" bigint,
+  "updated_at" timestamptz NOT NULL DEFAULT (now()),
+  CONSTRAINT "import_job_line_status_check" CHECK (
+    "status" IN ('valid', 'invalid', 'succeeded', 'failed')
+  )
+);
+CREATE UNIQUE INDEX ON "import_job_lines" ("job_id", "line");
+COMMENT ON COLUMN "import_jobs"."invalid_count" IS 'lines that failed validation, a job with invalid lines is rejected';
+COMMENT ON COLUMN "import_job_lines"."line" IS 'position of the instruction in the file, from 1';
+COMMENT ON COLUMN "import_job_lines"."from_account_id" IS 'as given in the file, not a foreign key: an invalid line may name an unknown account';
+COMMENT ON COLUMN "import_job_lines"."reference" IS 'end to end reference of the instruction';
+COMMENT ON COLUMN "import_job_lines"."error" IS 'why the line is invalid or failed';
+COMMENT ON COLUMN "import_job_lines"."transfer_id" IS 'transfer made by the line';
+ALTER TABLE "import_jobs"
+ADD FOREIGN KEY ("owner") REFERENCES "users" ("username");
+ALTER TABLE "import_job_lines"
+ADD FOREIGN KEY ("job_id") REFERENCES "import_jobs" ("id");
+ALTER TABLE "import_job_lines"
+ADD FOREIGN KEY ("transfer_id") REFERENCES "transfers" ("id");
\ No newline at end of file
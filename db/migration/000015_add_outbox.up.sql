@@ -0,0 +1,15 @@
+CREATE TABLE "outbox" (
+  "id" bigserial PRIMARY KEY,
+  "event_type" varchar NOT NULL,
+  "schema_version" bigint NOT NULL,
+  "payload" jsonb NOT NULL,
+  "created_at" timestamptz NOT NULL DEFAULT (now()),
+  "published_at" timestamptz,
+  "attempts" bigint NOT NULL DEFAULT 0,
+  "last_error" varchar NOT NULL DEFAULT ''
+);
+CREATE INDEX ON "outbox" ("id")
+WHERE "published_at" IS NULL;
+COMMENT ON COLUMN "outbox"."schema_version" IS 'version of the JSON schema of the payload for its event type';
+COMMENT ON COLUMN "outbox"."published_at" IS 'null until the relay has published the event';
+COMMENT ON COLUMN "outbox"."attempts" IS 'failed attempts to publish the event';
\ No newline at end of file
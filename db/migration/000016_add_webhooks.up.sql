@@ -0,0 +1,52 @@
+CREATE TABLE "webhook_subscriptions" (
+  "id" bigserial PRIMARY KEY,
+  "owner" varchar NOT NULL,
+  "url" varchar NOT NULL,
+  "event_types" varchar [] NOT NULL,
+  "secret" varchar NOT NULL,
+  "active" boolean NOT NULL DEFAULT true,
+  "created_at" timestamptz NOT NULL DEFAULT (now())
+);
+CREATE INDEX ON "webhook_subscriptions" ("owner");
+CREATE TABLE "webhook_deliveries" (
+  "id" bigserial PRIMARY KEY,
+  "subscription_id" bigint NOT NULL,
+  "event_id" bigint NOT NULL,
+  "event_type" varchar NOT NULL,
+  "payload" jsonb NOT NULL,
+  "status" varchar NOT NULL DEFAULT 'pending',
+  "attempts" bigint NOT NULL DEFAULT 0,
+  "next_attempt_at" timestamptz NOT NULL DEFAULT (now()),
+  "last_status_code" bigint NOT NULL DEFAULT 0,
+  "last_error" varchar NOT NULL DEFAULT '',
+  "delivered_at" timestamptz,
+  "created_at" timestamptz NOT NULL DEFAULT (now()),
+  CONSTRAINT "webhook_delivery_status_check" CHECK ("status" IN ('pending', 'succeeded', 'dead'))
+);
+CREATE UNIQUE INDEX ON "webhook_deliveries" ("subscription_id", "event_id", "event_type");
+CREATE INDEX ON "webhook_deliveries" ("next_attempt_at")
+WHERE "status" = 'pending';
+CREATE TABLE "webhook_delivery_attempts" (
+  "id" bigserial PRIMARY KEY,
+  "delivery_id" bigint NOT NULL,
+  "status_code" bigint NOT NULL,
+  "error" varchar NOT NULL,
+  "duration_ms" bigint NOT NULL,
+  "created_at" timestamptz NOT NULL DEFAULT (now())
+);
+CREATE INDEX ON "webhook_delivery_attempts" ("delivery_id");
+COMMENT ON COLUMN "webhook_subscriptions"."secret" IS 'signs the deliveries with HMAC-SHA256';
+COMMENT ON COLUMN "webhook_subscriptions"."active" IS 'false once the owner deleted the subscription';
+COMMENT ON COLUMN "webhook_deliveries"."event_id" IS 'outbox event the delivery was made from';
+COMMENT ON COLUMN "webhook_deliveries"."payload" IS 'data of the customer event';
+COMMENT ON COLUMN "webhook_deliveries"."status" IS 'a delivery is dead once it failed too many times';
+COMMENT ON COLUMN "webhook_deliveries"."last_status_code" IS 'status code of the last response, 0 if there was none';
+COMMENT ON COLUMN "webhook_delivery_attempts"."status_code" IS '0 if the endpoint did not answer';
+ALTER TABLE "webhook_subscriptions"
+ADD FOREIGN KEY ("owner") REFERENCES "users" ("username");
+ALTER TABLE "webhook_deliveries"
+ADD FOREIGN KEY ("subscription_id") REFERENCES "webhook_subscriptions" ("id");
+ALTER TABLE "webhook_deliveries"
+ADD FOREIGN KEY ("event_id") REFERENCES "outbox" ("id");
+ALTER TABLE "webhook_delivery_attempts"
+ADD FOREIGN KEY ("delivery_id") REFERENCES "webhook_deliveries" ("id");
\ No newline at end of file
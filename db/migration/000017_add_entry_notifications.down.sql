@@ -0,0 +1,2 @@
+DROP TRIGGER IF EXISTS "entries_notify" ON "entries";
+DROP FUNCTION IF EXISTS "notify_entry"();
\ No newline at end of file
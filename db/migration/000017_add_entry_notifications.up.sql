@@ -0,0 +1,24 @@
+CREATE FUNCTION "notify_entry"() RETURNS trigger AS $$ BEGIN PERFORM pg_notify(
+    'account_entries',
+    json_build_object(
+      'id',
+      NEW."id",
+      'account_id',
+      NEW."account_id",
+      'amount',
+      NEW."amount",
+      'created_at',
+      NEW."created_at",
+      'transfer_id',
+      NEW."transfer_id",
+      'balance_after',
+      NEW."balance_after"
+    )::text
+  );
+RETURN NULL;
+END;
+$$ LANGUAGE plpgsql;
+CREATE TRIGGER "entries_notify"
+AFTER
+INSERT ON "entries" FOR EACH ROW EXECUTE FUNCTION "notify_entry"();
+COMMENT ON FUNCTION "notify_entry"() IS 'notifies the listeners of account_entries of each new entry, when its transaction commits';
\ No newline at end of file
@@ -0,0 +1,32 @@
+CREATE TABLE "audit_log" (
+  "id" bigserial PRIMARY KEY,
+  "actor" varchar NOT NULL,
+  "actor_role" varchar NOT NULL,
+  "action" varchar NOT NULL,
+  "target_type" varchar NOT NULL,
+  "target_id" varchar NOT NULL,
+  "request_id" varchar NOT NULL,
+  "client_ip" varchar NOT NULL,
+  "status" bigint NOT NULL,
+  "before" json,
+  "after" json,
+  "created_at" timestamptz NOT NULL,
+  "prev_hash" varchar NOT NULL,
+  "hash" varchar NOT NULL
+);
+CREATE INDEX ON "audit_log" ("actor");
+CREATE INDEX ON "audit_log" ("target_type", "target_id");
+CREATE INDEX ON "audit_log" ("created_at");
+COMMENT ON COLUMN "audit_log"."actor" IS 'username from the access token, empty for anonymous requests like sign ups';
+COMMENT ON COLUMN "audit_log"."action" IS 'method and route of the request, like POST /accounts/:id/close';
+COMMENT ON COLUMN "audit_log"."status" IS 'HTTP status of the response';
+COMMENT ON COLUMN "audit_log"."before" IS 'json, not jsonb, so the hashed text is kept as is';
+COMMENT ON COLUMN "audit_log"."prev_hash" IS 'hash of the previous entry, empty for the first one';
+COMMENT ON COLUMN "audit_log"."hash" IS 'hex SHA-256 of the entry and prev_hash';
+CREATE FUNCTION "reject_audit_log_change"() RETURNS trigger AS $$ BEGIN RAISE EXCEPTION 'audit_log is append-only';
+END;
+$$ LANGUAGE plpgsql;
+CREATE TRIGGER "audit_log_append_only" BEFORE
+UPDATE
+  OR DELETE ON "audit_log" FOR EACH ROW EXECUTE FUNCTION "reject_audit_log_change"();
+CREATE TRIGGER "audit_log_no_truncate" BEFORE TRUNCATE ON "audit_log" FOR EACH STATEMENT EXECUTE FUNCTION "reject_audit_log_change"();
\ No newline at end of file
@@ -0,0 +1,6 @@
+DROP TABLE IF EXISTS "manual_adjustments";
+DELETE FROM "system_accounts"
+WHERE purpose = 'suspense';
+DELETE FROM "accounts"
+WHERE owner = 'bank'
+  AND nickname = 'suspense';
\ No newline at end of file
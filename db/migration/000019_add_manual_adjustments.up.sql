@@ -0,0 +1,35 @@
+-- manual adjustments are booked against the suspense account of their currency,
+-- which holds them until they are reconciled
+WITH "created" AS (
+  INSERT INTO "accounts" (owner, balance, currency, type, nickname)
+  VALUES ('bank', 0, 'USD', 'internal', 'suspense'),
+    ('bank', 0, 'EUR', 'internal', 'suspense'),
+    ('bank', 0, 'CAD', 'internal', 'suspense')
+  RETURNING id,
+    currency
+)
+INSERT INTO "system_accounts" (purpose, currency, account_id)
+SELECT 'suspense',
+  currency,
+  id
+FROM "created";
+CREATE TABLE "manual_adjustments" (
+  "id" bigserial PRIMARY KEY,
+  "account_id" bigint NOT NULL,
+  "amount" bigint NOT NULL,
+  "reason" varchar NOT NULL,
+  "reference" varchar NOT NULL,
+  "created_by" varchar NOT NULL,
+  "transfer_id" bigint NOT NULL,
+  "created_at" timestamptz NOT NULL DEFAULT (now()),
+  CONSTRAINT "manual_adjustment_amount_check" CHECK ("amount" <> 0)
+);
+CREATE INDEX ON "manual_adjustments" ("account_id");
+COMMENT ON COLUMN "manual_adjustments"."amount" IS 'credited to the account if positive, debited if negative';
+COMMENT ON COLUMN "manual_adjustments"."reference" IS 'support ticket or case the adjustment is documented in';
+ALTER TABLE "manual_adjustments"
+ADD FOREIGN KEY ("account_id") REFERENCES "accounts" ("id");
+ALTER TABLE "manual_adjustments"
+ADD FOREIGN KEY ("created_by") REFERENCES "users" ("username");
+ALTER TABLE "manual_adjustments"
+ADD FOREIGN KEY ("transfer_id") REFERENCES "transfers" ("id");
\ No newline at end of file
@@ -0,0 +1,16 @@
+CREATE TABLE "payees" (
+  "id" bigserial PRIMARY KEY,
+  "owner" varchar NOT NULL,
+  "nickname" varchar NOT NULL,
+  "account_id" bigint NOT NULL,
+  "currency" varchar NOT NULL,
+  "created_at" timestamptz NOT NULL DEFAULT (now())
+);
+CREATE UNIQUE INDEX ON "payees" ("owner", "nickname");
+CREATE UNIQUE INDEX ON "payees" ("owner", "account_id");
+COMMENT ON COLUMN "payees"."account_id" IS 'account the payee receives money in';
+COMMENT ON COLUMN "payees"."created_at" IS 'start of the cooling-off period';
+ALTER TABLE "payees"
+ADD FOREIGN KEY ("owner") REFERENCES "users" ("username");
+ALTER TABLE "payees"
+ADD FOREIGN KEY ("account_id") REFERENCES "accounts" ("id");
\ No newline at end of file
@@ -0,0 +1,2 @@
+ALTER TABLE IF EXISTS "accounts" DROP COLUMN IF EXISTS "number";
+DROP FUNCTION IF EXISTS "generate_account_number"();
\ No newline at end of file
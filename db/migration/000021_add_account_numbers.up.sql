@@ -0,0 +1,29 @@
+-- 10 random digits and 2 check digits, ISO 7064 MOD 97-10 like the check digits of an IBAN:
+-- the whole number modulo 97 is 1
+CREATE FUNCTION "generate_account_number"() RETURNS varchar AS $$
+DECLARE "base" varchar;
+"candidate" varchar;
+BEGIN LOOP "base" := lpad(floor(random() * 10000000000)::bigint::text, 10, '0');
+"candidate" := "base" || lpad((98 - ("base"::numeric * 100) % 97)::text, 2, '0');
+EXIT
+WHEN NOT EXISTS (
+  SELECT 1
+  FROM "accounts"
+  WHERE "number" = "candidate"
+);
+END LOOP;
+RETURN "candidate";
+END;
+$$ LANGUAGE plpgsql;
+ALTER TABLE "accounts"
+ADD COLUMN "number" varchar;
+UPDATE "accounts"
+SET "number" = generate_account_number();
+ALTER TABLE "accounts"
+ALTER COLUMN "number"
+SET NOT NULL;
+ALTER TABLE "accounts"
+ALTER COLUMN "number"
+SET DEFAULT generate_account_number();
+CREATE UNIQUE INDEX ON "accounts" ("number");
+COMMENT ON COLUMN "accounts"."number" IS 'external account number given to customers, with check digits';
\ No newline at end of file
@@ -0,0 +1,34 @@
+CREATE TABLE "payment_requests" (
+  "id" bigserial PRIMARY KEY,
+  "requester" varchar NOT NULL,
+  "payer" varchar NOT NULL,
+  "to_account_id" bigint NOT NULL,
+  "amount" bigint NOT NULL,
+  "currency" varchar NOT NULL,
+  "message" varchar NOT NULL DEFAULT '',
+  "status" varchar NOT NULL DEFAULT 'pending',
+  "transfer_id" bigint,
+  "expires_at" timestamptz NOT NULL,
+  "created_at" timestamptz NOT NULL DEFAULT (now()),
+  "updated_at" timestamptz NOT NULL DEFAULT (now()),
+  CONSTRAINT "payment_request_amount_check" CHECK ("amount" > 0),
+  CONSTRAINT "payment_request_parties_check" CHECK ("requester" <> "payer"),
+  CONSTRAINT "payment_request_status_check" CHECK (
+    "status" IN ('pending', 'paid', 'declined', 'expired', 'cancelled')
+  )
+);
+CREATE INDEX ON "payment_requests" ("requester");
+CREATE INDEX ON "payment_requests" ("payer");
+CREATE INDEX ON "payment_requests" ("expires_at")
+WHERE "status" = 'pending';
+COMMENT ON COLUMN "payment_requests"."to_account_id" IS 'account of the requester credited when the request is paid';
+COMMENT ON COLUMN "payment_requests"."message" IS 'shown to the payer';
+COMMENT ON COLUMN "payment_requests"."transfer_id" IS 'transfer made by the payment';
+ALTER TABLE "payment_requests"
+ADD FOREIGN KEY ("requester") REFERENCES "users" ("username");
+ALTER TABLE "payment_requests"
+ADD FOREIGN KEY ("payer") REFERENCES "users" ("username");
+ALTER TABLE "payment_requests"
+ADD FOREIGN KEY ("to_account_id") REFERENCES "accounts" ("id");
+ALTER TABLE "payment_requests"
+ADD FOREIGN KEY ("transfer_id") REFERENCES "transfers" ("id");
\ No newline at end of file
@@ -0,0 +1,24 @@
+CREATE TABLE "account_members" (
+  "account_id" bigint NOT NULL,
+  "username" varchar NOT NULL,
+  "role" varchar NOT NULL,
+  "spend_limit" bigint,
+  "invited_by" varchar NOT NULL,
+  "accepted_at" timestamptz,
+  "created_at" timestamptz NOT NULL DEFAULT (now()),
+  PRIMARY KEY ("account_id", "username"),
+  CONSTRAINT "account_member_role_check" CHECK ("role" IN ('co-owner', 'viewer', 'spender')),
+  CONSTRAINT "account_member_spend_limit_check" CHECK (
+    ("role" = 'spender') = ("spend_limit" IS NOT NULL)
+  ),
+  CONSTRAINT "account_member_spend_limit_positive_check" CHECK ("spend_limit" > 0)
+);
+CREATE INDEX ON "account_members" ("username");
+COMMENT ON COLUMN "account_members"."spend_limit" IS 'largest amount a spender can send per transfer';
+COMMENT ON COLUMN "account_members"."accepted_at" IS 'null until the invited user accepts, an invitation gives no access';
+ALTER TABLE "account_members"
+ADD FOREIGN KEY ("account_id") REFERENCES "accounts" ("id");
+ALTER TABLE "account_members"
+ADD FOREIGN KEY ("username") REFERENCES "users" ("username");
+ALTER TABLE "account_members"
+ADD FOREIGN KEY ("invited_by") REFERENCES "users" ("username");
\ No newline at end of file
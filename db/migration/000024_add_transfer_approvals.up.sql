@@ -0,0 +1,60 @@
+CREATE TABLE "approval_policies" (
+  "account_id" bigint PRIMARY KEY,
+  "threshold" bigint NOT NULL,
+  "required_approvals" int NOT NULL,
+  "approvers" varchar [] NOT NULL,
+  "updated_by" varchar NOT NULL,
+  "updated_at" timestamptz NOT NULL DEFAULT (now()),
+  CONSTRAINT "approval_policy_threshold_check" CHECK ("threshold" > 0),
+  CONSTRAINT "approval_policy_required_approvals_check" CHECK (
+    "required_approvals" > 0
+    AND "required_approvals" <= cardinality("approvers")
+  )
+);
+CREATE TABLE "pending_transfers" (
+  "id" bigserial PRIMARY KEY,
+  "from_account_id" bigint NOT NULL,
+  "to_account_id" bigint NOT NULL,
+  "amount" bigint NOT NULL,
+  "requested_by" varchar NOT NULL,
+  "required_approvals" int NOT NULL,
+  "approvers" varchar [] NOT NULL,
+  "status" varchar NOT NULL DEFAULT 'pending',
+  "transfer_id" bigint,
+  "created_at" timestamptz NOT NULL DEFAULT (now()),
+  "updated_at" timestamptz NOT NULL DEFAULT (now()),
+  CONSTRAINT "pending_transfer_amount_check" CHECK ("amount" > 0),
+  CONSTRAINT "pending_transfer_status_check" CHECK (
+    "status" IN ('pending', 'executed', 'rejected')
+  )
+);
+CREATE INDEX ON "pending_transfers" ("from_account_id");
+CREATE TABLE "transfer_approvals" (
+  "pending_transfer_id" bigint NOT NULL,
+  "approver" varchar NOT NULL,
+  "decision" varchar NOT NULL,
+  "created_at" timestamptz NOT NULL DEFAULT (now()),
+  PRIMARY KEY ("pending_transfer_id", "approver"),
+  CONSTRAINT "transfer_approval_decision_check" CHECK ("decision" IN ('approved', 'rejected'))
+);
+COMMENT ON COLUMN "approval_policies"."threshold" IS 'transfers of at least this amount from the account need approvals';
+COMMENT ON COLUMN "approval_policies"."approvers" IS 'usernames of the members who can approve, never the requester of a transfer';
+COMMENT ON COLUMN "pending_transfers"."required_approvals" IS 'copied from the policy when the transfer was requested';
+COMMENT ON COLUMN "pending_transfers"."approvers" IS 'copied from the policy when the transfer was requested';
+COMMENT ON COLUMN "pending_transfers"."transfer_id" IS 'transfer made once the quorum was reached';
+ALTER TABLE "approval_policies"
+ADD FOREIGN KEY ("account_id") REFERENCES "accounts" ("id");
+ALTER TABLE "approval_policies"
+ADD FOREIGN KEY ("updated_by") REFERENCES "users" ("username");
+ALTER TABLE "pending_transfers"
+ADD FOREIGN KEY ("from_account_id") REFERENCES "accounts" ("id");
+ALTER TABLE "pending_transfers"
+ADD FOREIGN KEY ("to_account_id") REFERENCES "accounts" ("id");
+ALTER TABLE "pending_transfers"
+ADD FOREIGN KEY ("requested_by") REFERENCES "users" ("username");
+ALTER TABLE "pending_transfers"
+ADD FOREIGN KEY ("transfer_id") REFERENCES "transfers" ("id");
+ALTER TABLE "transfer_approvals"
+ADD FOREIGN KEY ("pending_transfer_id") REFERENCES "pending_transfers" ("id");
+ALTER TABLE "transfer_approvals"
+ADD FOREIGN KEY ("approver") REFERENCES "users" ("username");
\ No newline at end of file
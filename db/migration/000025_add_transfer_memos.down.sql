@@ -0,0 +1,6 @@
+ALTER TABLE IF EXISTS "pending_transfers" DROP COLUMN IF EXISTS "tags";
+ALTER TABLE IF EXISTS "pending_transfers" DROP COLUMN IF EXISTS "reference";
+ALTER TABLE IF EXISTS "pending_transfers" DROP COLUMN IF EXISTS "description";
+ALTER TABLE IF EXISTS "entries" DROP COLUMN IF EXISTS "tags";
+ALTER TABLE IF EXISTS "transfers" DROP COLUMN IF EXISTS "reference";
+ALTER TABLE IF EXISTS "transfers" DROP COLUMN IF EXISTS "description";
\ No newline at end of file
@@ -0,0 +1,25 @@
+ALTER TABLE "transfers"
+ADD COLUMN "description" varchar NOT NULL DEFAULT '';
+ALTER TABLE "transfers"
+ADD COLUMN "reference" varchar NOT NULL DEFAULT '';
+ALTER TABLE "transfers"
+ADD CONSTRAINT "transfer_description_length_check" CHECK (char_length("description") <= 140);
+ALTER TABLE "transfers"
+ADD CONSTRAINT "transfer_reference_length_check" CHECK (char_length("reference") <= 35);
+CREATE INDEX ON "transfers" ("reference")
+WHERE "reference" <> '';
+ALTER TABLE "entries"
+ADD COLUMN "tags" varchar [] NOT NULL DEFAULT '{}';
+ALTER TABLE "entries"
+ADD CONSTRAINT "entry_tags_count_check" CHECK (cardinality("tags") <= 10);
+CREATE INDEX ON "entries" USING GIN ("tags");
+ALTER TABLE "pending_transfers"
+ADD COLUMN "description" varchar NOT NULL DEFAULT '';
+ALTER TABLE "pending_transfers"
+ADD COLUMN "reference" varchar NOT NULL DEFAULT '';
+ALTER TABLE "pending_transfers"
+ADD COLUMN "tags" varchar [] NOT NULL DEFAULT '{}';
+COMMENT ON COLUMN "transfers"."description" IS 'free text from the sender, shown to both sides';
+COMMENT ON COLUMN "transfers"."reference" IS 'end to end reference from the sender, passed on unchanged to the recipient';
+COMMENT ON COLUMN "entries"."tags" IS 'labels of the account holders on their side of the transfer, never shown to the other side';
+COMMENT ON COLUMN "pending_transfers"."tags" IS 'put on the entry of the sender once the transfer is made';
\ No newline at end of file
@@ -0,0 +1,2 @@
+DROP TABLE IF EXISTS "policy_change_approvals";
+DROP TABLE IF EXISTS "policy_changes";
\ No newline at end of file
@@ -0,0 +1,48 @@
+CREATE TABLE "policy_changes" (
+  "id" bigserial PRIMARY KEY,
+  "account_id" bigint NOT NULL,
+  "new_threshold" bigint,
+  "new_required_approvals" int,
+  "new_approvers" varchar [],
+  "requested_by" varchar NOT NULL,
+  "required_approvals" int NOT NULL,
+  "approvers" varchar [] NOT NULL,
+  "status" varchar NOT NULL DEFAULT 'pending',
+  "created_at" timestamptz NOT NULL DEFAULT (now()),
+  "updated_at" timestamptz NOT NULL DEFAULT (now()),
+  CONSTRAINT "policy_change_new_policy_check" CHECK (
+    (
+      "new_threshold" IS NULL
+      AND "new_required_approvals" IS NULL
+      AND "new_approvers" IS NULL
+    )
+    OR (
+      "new_threshold" > 0
+      AND "new_required_approvals" > 0
+      AND "new_required_approvals" <= cardinality("new_approvers")
+    )
+  ),
+  CONSTRAINT "policy_change_status_check" CHECK (
+    "status" IN ('pending', 'executed', 'rejected')
+  )
+);
+CREATE INDEX ON "policy_changes" ("account_id");
+CREATE TABLE "policy_change_approvals" (
+  "policy_change_id" bigint NOT NULL,
+  "approver" varchar NOT NULL,
+  "decision" varchar NOT NULL,
+  "created_at" timestamptz NOT NULL DEFAULT (now()),
+  PRIMARY KEY ("policy_change_id", "approver"),
+  CONSTRAINT "policy_change_approval_decision_check" CHECK ("decision" IN ('approved', 'rejected'))
+);
+COMMENT ON COLUMN "policy_changes"."new_threshold" IS 'the new policy, NULL with the other new_ columns when the change removes the policy';
+COMMENT ON COLUMN "policy_changes"."required_approvals" IS 'copied from the policy when the change was requested';
+COMMENT ON COLUMN "policy_changes"."approvers" IS 'copied from the policy when the change was requested';
+ALTER TABLE "policy_changes"
+ADD FOREIGN KEY ("account_id") REFERENCES "accounts" ("id");
+ALTER TABLE "policy_changes"
+ADD FOREIGN KEY ("requested_by") REFERENCES "users" ("username");
+ALTER TABLE "policy_change_approvals"
+ADD FOREIGN KEY ("policy_change_id") REFERENCES "policy_changes" ("id");
+ALTER TABLE "policy_change_approvals"
+ADD FOREIGN KEY ("approver") REFERENCES "users" ("username");
\ No newline at end of file
@@ -0,0 +1,6 @@
+ALTER TABLE "transfers"
+ADD COLUMN "sent_by" varchar;
+ALTER TABLE "transfers"
+ADD FOREIGN KEY ("sent_by") REFERENCES "users" ("username");
+CREATE INDEX ON "transfers" ("from_account_id", "sent_by", "created_at");
+COMMENT ON COLUMN "transfers"."sent_by" IS 'user who sent the transfer, counted towards their spend limit on the from account; NULL for transfers made by the bank';
\ No newline at end of file
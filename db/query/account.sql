@@ -1,12 +1,17 @@
 -- name: CreateAccount :one
-INSERT INTO accounts (owner, balance, currency)
-VALUES ($1, $2, $3)
+INSERT INTO accounts (owner, balance, currency, type, nickname)
+VALUES ($1, $2, $3, $4, $5)
 RETURNING *;
 -- name: GetAccount :one
 SELECT *
 FROM accounts
 WHERE id = $1
 LIMIT 1;
+-- name: GetAccountByNumber :one
+SELECT *
+FROM accounts
+WHERE number = $1
+LIMIT 1;
 -- name: GetAccountForUpdate :one
 SELECT *
 FROM accounts
@@ -16,9 +21,25 @@ UPDATE;
 -- name: ListAccounts :many
 SELECT *
 FROM accounts
-WHERE owner = $1
+WHERE (
+    owner = sqlc.arg('username')
+    OR id IN (
+      SELECT account_id
+      FROM account_members
+      WHERE username = sqlc.arg('username')
+        AND accepted_at IS NOT NULL
+    )
+  ) -- accounts of the user, owned or shared with them
+  AND (
+    sqlc.narg('type')::varchar IS NULL
+    OR type = sqlc.narg('type')
+  ) -- optional filter by account type
+  AND (
+    sqlc.narg('currency')::varchar IS NULL
+    OR currency = sqlc.narg('currency')
+  ) -- optional filter by currency
 ORDER BY id
-LIMIT $2 OFFSET $3;
+LIMIT sqlc.arg('limit') OFFSET sqlc.arg('offset');
 -- name: UpdateAccount :one
 UPDATE accounts
 SET balance = $2
@@ -29,6 +50,16 @@ UPDATE accounts
 SET balance = balance + sqlc.arg(amount) -- "amount" is the generated parameter
 WHERE id = sqlc.arg(id) -- "id" is the generated parameter
 RETURNING *;
+-- name: AddAccountHeldAmount :one
+UPDATE accounts
+SET held_amount = held_amount + sqlc.arg(amount)
+WHERE id = sqlc.arg(id)
+RETURNING *;
 -- name: DeleteAccount :exec
 DELETE FROM accounts
-WHERE id = $1;
\ No newline at end of file
+WHERE id = $1;
+-- name: UpdateAccountStatus :one
+UPDATE accounts
+SET status = $2
+WHERE id = $1
+RETURNING *;
\ No newline at end of file
@@ -0,0 +1,24 @@
+-- name: SetApprovalPolicy :one
+INSERT INTO approval_policies (
+    account_id,
+    threshold,
+    required_approvals,
+    approvers,
+    updated_by
+  )
+VALUES ($1, $2, $3, $4, $5) ON CONFLICT (account_id) DO
+UPDATE
+SET threshold = EXCLUDED.threshold,
+  required_approvals = EXCLUDED.required_approvals,
+  approvers = EXCLUDED.approvers,
+  updated_by = EXCLUDED.updated_by,
+  updated_at = now()
+RETURNING *;
+-- name: GetApprovalPolicy :one
+SELECT *
+FROM approval_policies
+WHERE account_id = $1
+LIMIT 1;
+-- name: DeleteApprovalPolicy :exec
+DELETE FROM approval_policies
+WHERE account_id = $1;
\ No newline at end of file
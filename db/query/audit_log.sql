@@ -0,0 +1,74 @@
+-- name: LockAuditLog :exec
+SELECT pg_advisory_xact_lock(hashtext('audit_log'));
+-- name: GetLastAuditLogEntry :one
+SELECT *
+FROM audit_log
+ORDER BY id DESC
+LIMIT 1;
+-- name: CreateAuditLogEntry :one
+INSERT INTO audit_log (
+    actor,
+    actor_role,
+    action,
+    target_type,
+    target_id,
+    request_id,
+    client_ip,
+    status,
+    before,
+    after,
+    created_at,
+    prev_hash,
+    hash
+  )
+VALUES (
+    $1,
+    $2,
+    $3,
+    $4,
+    $5,
+    $6,
+    $7,
+    $8,
+    $9,
+    $10,
+    $11,
+    $12,
+    $13
+  )
+RETURNING *;
+-- name: ListAuditLog :many
+SELECT *
+FROM audit_log
+WHERE (
+    sqlc.narg('actor')::varchar IS NULL
+    OR actor = sqlc.narg('actor')
+  )
+  AND (
+    sqlc.narg('action')::varchar IS NULL
+    OR action = sqlc.narg('action')
+  )
+  AND (
+    sqlc.narg('target_type')::varchar IS NULL
+    OR target_type = sqlc.narg('target_type')
+  )
+  AND (
+    sqlc.narg('target_id')::varchar IS NULL
+    OR target_id = sqlc.narg('target_id')
+  )
+  AND (
+    sqlc.narg('since')::timestamptz IS NULL
+    OR created_at >= sqlc.narg('since')
+  )
+  AND (
+    sqlc.narg('until')::timestamptz IS NULL
+    OR created_at < sqlc.narg('until')
+  )
+ORDER BY id DESC
+LIMIT sqlc.arg('limit') OFFSET sqlc.arg('offset');
+-- name: ListAuditLogAfter :many
+SELECT *
+FROM audit_log
+WHERE id > sqlc.arg('after_id')
+ORDER BY id
+LIMIT sqlc.arg('limit');
\ No newline at end of file
@@ -1,6 +1,6 @@
 -- name: CreateEntry :one
-INSERT INTO entries (account_id, amount)
-VALUES ($1, $2)
+INSERT INTO entries (account_id, amount, transfer_id, balance_after)
+VALUES ($1, $2, $3, $4)
 RETURNING *;
 -- name: GetEntry :one
 SELECT *
@@ -12,4 +12,30 @@ SELECT *
 FROM entries
 WHERE account_id = $1
 ORDER BY id
-LIMIT $2 OFFSET $3;
\ No newline at end of file
+LIMIT $2 OFFSET $3;
+-- name: SetEntryTags :one
+UPDATE entries
+SET tags = $2
+WHERE id = $1
+RETURNING *;
+-- name: ListAccountEntries :many
+SELECT entries.*,
+  COALESCE(transfers.description, '')::varchar AS description,
+  COALESCE(transfers.reference, '')::varchar AS reference
+FROM entries
+  LEFT JOIN transfers ON transfers.id = entries.transfer_id
+WHERE entries.account_id = sqlc.arg('account_id')
+  AND (
+    sqlc.narg('tag')::varchar IS NULL
+    OR sqlc.narg('tag') = ANY(entries.tags)
+  ) -- optional filter by tag
+  AND (
+    sqlc.narg('reference')::varchar IS NULL
+    OR transfers.reference = sqlc.narg('reference')
+  ) -- optional filter by reference
+  AND (
+    sqlc.narg('search')::varchar IS NULL
+    OR strpos(lower(transfers.description), lower(sqlc.narg('search'))) > 0
+  ) -- optional search in the description, taken literally
+ORDER BY entries.id DESC
+LIMIT sqlc.arg('limit') OFFSET sqlc.arg('offset');
\ No newline at end of file
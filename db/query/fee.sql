@@ -0,0 +1,32 @@
+-- name: CreateFeeSchedule :one
+INSERT INTO fee_schedules (
+    currency,
+    account_type,
+    kind,
+    flat_fee,
+    rate_bps,
+    min_fee,
+    max_fee
+  )
+VALUES ($1, $2, $3, $4, $5, $6, $7)
+RETURNING *;
+-- name: GetFeeSchedule :one
+SELECT *
+FROM fee_schedules
+WHERE currency = $1
+  AND account_type = $2
+LIMIT 1;
+-- name: ListFeeSchedules :many
+SELECT *
+FROM fee_schedules
+ORDER BY id
+LIMIT $1 OFFSET $2;
+-- name: CreateFeeTier :one
+INSERT INTO fee_tiers (schedule_id, min_amount, flat_fee, rate_bps)
+VALUES ($1, $2, $3, $4)
+RETURNING *;
+-- name: ListFeeTiers :many
+SELECT *
+FROM fee_tiers
+WHERE schedule_id = $1
+ORDER BY min_amount;
\ No newline at end of file
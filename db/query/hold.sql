@@ -0,0 +1,43 @@
+-- name: CreateHold :one
+INSERT INTO holds (
+    account_id,
+    to_account_id,
+    amount,
+    created_by,
+    expires_at
+  )
+VALUES ($1, $2, $3, $4, $5)
+RETURNING *;
+-- name: GetHold :one
+SELECT *
+FROM holds
+WHERE id = $1
+LIMIT 1;
+-- name: GetHoldForUpdate :one
+SELECT *
+FROM holds
+WHERE id = $1
+LIMIT 1 FOR NO KEY
+UPDATE;
+-- name: ListHolds :many
+SELECT *
+FROM holds
+WHERE account_id = $1
+ORDER BY id
+LIMIT $2 OFFSET $3;
+-- name: ListExpiredHolds :many
+SELECT *
+FROM holds
+WHERE status = 'pending'
+  AND expires_at <= sqlc.arg('now')::timestamptz
+  AND id > sqlc.arg('after_id')
+ORDER BY id
+LIMIT sqlc.arg('limit');
+-- name: UpdateHold :one
+UPDATE holds
+SET status = $2,
+  captured_amount = $3,
+  transfer_id = $4,
+  updated_at = now()
+WHERE id = $1
+RETURNING *;
\ No newline at end of file
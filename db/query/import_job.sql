@@ -0,0 +1,75 @@
+-- name: CreateImportJob :one
+INSERT INTO import_jobs (
+    owner,
+    format,
+    status,
+    line_count,
+    invalid_count
+  )
+VALUES ($1, $2, $3, $4, $5)
+RETURNING *;
+-- name: GetImportJob :one
+SELECT *
+FROM import_jobs
+WHERE id = $1
+LIMIT 1;
+-- name: ListImportJobs :many
+SELECT *
+FROM import_jobs
+WHERE owner = $1
+ORDER BY id
+LIMIT $2 OFFSET $3;
+-- name: StartImportJob :one
+UPDATE import_jobs
+SET status = 'executing',
+  updated_at = now()
+WHERE id = $1
+  AND status IN ('validated', 'executing')
+RETURNING *;
+-- name: CompleteImportJob :one
+UPDATE import_jobs
+SET status = 'completed',
+  updated_at = now()
+WHERE id = $1
+  AND status = 'executing'
+RETURNING *;
+-- name: CreateImportJobLine :one
+INSERT INTO import_job_lines (
+    job_id,
+    line,
+    from_account_id,
+    to_account_id,
+    amount,
+    currency,
+    reference,
+    status,
+    error
+  )
+VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9)
+RETURNING *;
+-- name: GetImportJobLineForUpdate :one
+SELECT *
+FROM import_job_lines
+WHERE id = $1
+LIMIT 1 FOR NO KEY
+UPDATE;
+-- name: ListImportJobLines :many
+SELECT *
+FROM import_job_lines
+WHERE job_id = $1
+ORDER BY line;
+-- name: SucceedImportJobLine :one
+UPDATE import_job_lines
+SET status = 'succeeded',
+  transfer_id = $2,
+  updated_at = now()
+WHERE id = $1
+RETURNING *;
+-- name: FailImportJobLine :one
+UPDATE import_job_lines
+SET status = 'failed',
+  error = $2,
+  updated_at = now()
+WHERE id = $1
+  AND status = 'valid'
+RETURNING *;
\ No newline at end of file
@@ -0,0 +1,105 @@
+-- name: CreateInterestPlan :one
+INSERT INTO interest_plans (name, annual_rate_bps, day_count)
+VALUES ($1, $2, $3)
+RETURNING *;
+-- name: GetInterestPlan :one
+SELECT *
+FROM interest_plans
+WHERE id = $1
+LIMIT 1;
+-- name: ListInterestPlans :many
+SELECT *
+FROM interest_plans
+ORDER BY id
+LIMIT $1 OFFSET $2;
+-- name: SetAccountInterestPlan :one
+INSERT INTO account_interest_plans (account_id, plan_id)
+VALUES ($1, $2) ON CONFLICT (account_id) DO
+UPDATE
+SET plan_id = EXCLUDED.plan_id,
+  created_at = now()
+RETURNING *;
+-- name: ListInterestBearingAccounts :many
+SELECT accounts.id AS account_id,
+  accounts.currency,
+  account_interest_plans.created_at AS plan_attached_at,
+  interest_plans.annual_rate_bps,
+  interest_plans.day_count,
+  COALESCE(
+    (
+      SELECT MAX(interest_accruals.accrual_date)
+      FROM interest_accruals
+      WHERE interest_accruals.account_id = accounts.id
+    ),
+    (account_interest_plans.created_at AT TIME ZONE 'UTC')::date - 1
+  )::date AS last_accrual_date
+FROM account_interest_plans
+  JOIN accounts ON accounts.id = account_interest_plans.account_id
+  JOIN interest_plans ON interest_plans.id = account_interest_plans.plan_id
+WHERE accounts.status <> 'closed'
+  AND accounts.id > sqlc.arg(after_id)
+ORDER BY accounts.id
+LIMIT sqlc.arg('limit');
+-- name: GetAccountBalanceAt :one
+SELECT COALESCE(
+    (
+      SELECT entries.balance_after
+      FROM entries
+      WHERE entries.account_id = accounts.id
+        AND entries.created_at < sqlc.arg(at)
+      ORDER BY entries.id DESC
+      LIMIT 1
+    ), (
+      SELECT entries.balance_after - entries.amount
+      FROM entries
+      WHERE entries.account_id = accounts.id
+        AND entries.created_at >= sqlc.arg(at)
+      ORDER BY entries.id
+      LIMIT 1
+    ),
+    accounts.balance
+  )::bigint AS balance
+FROM accounts
+WHERE accounts.id = sqlc.arg(account_id)
+LIMIT 1;
+-- name: CreateInterestAccrual :exec
+INSERT INTO interest_accruals (
+    account_id,
+    accrual_date,
+    balance,
+    annual_rate_bps,
+    day_count,
+    amount_micros
+  )
+VALUES ($1, $2, $3, $4, $5, $6) ON CONFLICT (account_id, accrual_date) DO NOTHING;
+-- name: SumInterestAccruals :one
+SELECT COALESCE(SUM(amount_micros), 0)::bigint AS accrued_micros
+FROM interest_accruals
+WHERE account_id = sqlc.arg(account_id)
+  AND accrual_date >= sqlc.arg(period_start)
+  AND accrual_date < sqlc.arg(period_end);
+-- name: GetLastInterestPosting :one
+SELECT *
+FROM interest_postings
+WHERE account_id = $1
+  AND period_start < $2
+ORDER BY period_start DESC
+LIMIT 1;
+-- name: CreateInterestPosting :one
+INSERT INTO interest_postings (
+    account_id,
+    period_start,
+    period_end,
+    accrued_micros,
+    amount,
+    remainder_micros,
+    transfer_id
+  )
+VALUES ($1, $2, $3, $4, $5, $6, $7)
+RETURNING *;
+-- name: GetInterestPosting :one
+SELECT *
+FROM interest_postings
+WHERE account_id = $1
+  AND period_start = $2
+LIMIT 1;
\ No newline at end of file
@@ -0,0 +1,64 @@
+-- name: ListAccountBalanceMismatches :many
+SELECT accounts.id AS account_id,
+  accounts.currency,
+  accounts.balance,
+  COALESCE(sum(entries.amount), 0)::bigint AS entries_total,
+  count(*) OVER () AS mismatch_count
+FROM accounts
+  LEFT JOIN entries ON entries.account_id = accounts.id
+GROUP BY accounts.id
+HAVING accounts.balance <> COALESCE(sum(entries.amount), 0)
+ORDER BY accounts.id DESC
+LIMIT $1;
+-- name: ListUnbalancedTransfers :many
+SELECT transfers.id AS transfer_id,
+  transfers.from_account_id,
+  transfers.to_account_id,
+  transfers.amount,
+  transfers.fee,
+  count(entries.id) AS entry_count,
+  COALESCE(sum(entries.amount), 0)::bigint AS entries_total,
+  count(*) OVER () AS unbalanced_count
+FROM transfers
+  LEFT JOIN entries ON entries.transfer_id = transfers.id
+GROUP BY transfers.id
+HAVING count(entries.id) <> CASE
+    WHEN transfers.fee > 0 THEN 4
+    ELSE 2
+  END
+  OR COALESCE(sum(entries.amount), 0) <> 0
+  OR count(*) FILTER (
+    WHERE entries.account_id = transfers.from_account_id
+  ) <> CASE
+    WHEN transfers.fee > 0 THEN 2
+    ELSE 1
+  END
+  OR COALESCE(
+    sum(entries.amount) FILTER (
+      WHERE entries.account_id = transfers.from_account_id
+    ),
+    0
+  ) <> - (transfers.amount + transfers.fee)
+  OR count(*) FILTER (
+    WHERE entries.account_id = transfers.from_account_id
+      AND entries.amount = - transfers.amount
+  ) = 0
+  OR count(*) FILTER (
+    WHERE entries.account_id = transfers.to_account_id
+      AND entries.amount = transfers.amount
+  ) <> 1
+ORDER BY transfers.id DESC
+LIMIT $1;
+-- name: ListCurrencyTotals :many
+SELECT accounts.currency,
+  sum(accounts.balance)::bigint AS balance_total,
+  COALESCE(sum(account_entries.total), 0)::bigint AS entries_total
+FROM accounts
+  LEFT JOIN (
+    SELECT account_id,
+      sum(amount) AS total
+    FROM entries
+    GROUP BY account_id
+  ) AS account_entries ON account_entries.account_id = accounts.id
+GROUP BY accounts.currency
+ORDER BY accounts.currency;
\ No newline at end of file
@@ -0,0 +1,17 @@
+-- name: CreateManualAdjustment :one
+INSERT INTO manual_adjustments (
+    account_id,
+    amount,
+    reason,
+    reference,
+    created_by,
+    transfer_id
+  )
+VALUES ($1, $2, $3, $4, $5, $6)
+RETURNING *;
+-- name: ListManualAdjustments :many
+SELECT *
+FROM manual_adjustments
+WHERE account_id = $1
+ORDER BY id
+LIMIT $2 OFFSET $3;
\ No newline at end of file
@@ -0,0 +1,20 @@
+-- name: CreateOutboxEvent :one
+INSERT INTO outbox (event_type, schema_version, payload)
+VALUES ($1, $2, $3)
+RETURNING *;
+-- name: ListPendingOutboxEvents :many
+SELECT *
+FROM outbox
+WHERE published_at IS NULL
+  AND id > sqlc.arg('after_id')
+ORDER BY id
+LIMIT sqlc.arg('limit');
+-- name: MarkOutboxEventPublished :exec
+UPDATE outbox
+SET published_at = now()
+WHERE id = $1;
+-- name: RecordOutboxEventFailure :exec
+UPDATE outbox
+SET attempts = attempts + 1,
+  last_error = $2
+WHERE id = $1;
\ No newline at end of file
@@ -0,0 +1,62 @@
+-- name: CreatePayee :one
+INSERT INTO payees (owner, nickname, account_id, currency)
+VALUES ($1, $2, $3, $4)
+RETURNING *;
+-- name: GetPayee :one
+SELECT *
+FROM payees
+WHERE id = $1
+LIMIT 1;
+-- name: GetPayeeByAccount :one
+SELECT *
+FROM payees
+WHERE owner = $1
+  AND account_id = $2
+LIMIT 1;
+-- name: ListPayees :many
+SELECT *
+FROM payees
+WHERE owner = $1
+ORDER BY id
+LIMIT $2 OFFSET $3;
+-- name: UpdatePayee :one
+UPDATE payees
+SET nickname = $2
+WHERE id = $1
+RETURNING *;
+-- name: DeletePayee :exec
+DELETE FROM payees
+WHERE id = $1;
+-- name: SumPayeeTransfers :one
+WITH sender_accounts AS (
+  SELECT id
+  FROM accounts
+  WHERE owner = sqlc.arg(owner)
+  UNION
+  SELECT account_id
+  FROM account_members
+  WHERE username = sqlc.arg(owner)
+    AND accepted_at IS NOT NULL
+)
+SELECT (
+    COALESCE(
+      (
+        SELECT SUM(amount)
+        FROM transfers
+        WHERE to_account_id = sqlc.arg(to_account_id)
+          AND created_at >= sqlc.arg(since)
+          AND from_account_id IN (SELECT id FROM sender_accounts)
+      ),
+      0
+    ) + COALESCE(
+      (
+        SELECT SUM(amount)
+        FROM holds
+        WHERE to_account_id = sqlc.arg(to_account_id)
+          AND created_at >= sqlc.arg(since)
+          AND status = 'pending'
+          AND account_id IN (SELECT id FROM sender_accounts)
+      ),
+      0
+    )
+  )::bigint AS total;
\ No newline at end of file
@@ -0,0 +1,56 @@
+-- name: CreatePendingTransfer :one
+INSERT INTO pending_transfers (
+    from_account_id,
+    to_account_id,
+    amount,
+    requested_by,
+    required_approvals,
+    approvers,
+    description,
+    reference,
+    tags
+  )
+VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9)
+RETURNING *;
+-- name: GetPendingTransfer :one
+SELECT *
+FROM pending_transfers
+WHERE id = $1
+LIMIT 1;
+-- name: GetPendingTransferForUpdate :one
+SELECT *
+FROM pending_transfers
+WHERE id = $1
+LIMIT 1 FOR NO KEY
+UPDATE;
+-- name: ListPendingTransfers :many
+SELECT *
+FROM pending_transfers
+WHERE from_account_id = sqlc.arg('from_account_id')
+  AND (
+    sqlc.narg('status')::varchar IS NULL
+    OR status = sqlc.narg('status')
+  ) -- optional filter by status
+ORDER BY id DESC
+LIMIT sqlc.arg('limit') OFFSET sqlc.arg('offset');
+-- name: UpdatePendingTransfer :one
+UPDATE pending_transfers
+SET status = $2,
+  transfer_id = $3,
+  updated_at = now()
+WHERE id = $1
+RETURNING *;
+-- name: CreateTransferApproval :one
+INSERT INTO transfer_approvals (pending_transfer_id, approver, decision)
+VALUES ($1, $2, $3)
+RETURNING *;
+-- name: ListTransferApprovals :many
+SELECT *
+FROM transfer_approvals
+WHERE pending_transfer_id = $1
+ORDER BY created_at;
+-- name: CountTransferApprovals :one
+SELECT COUNT(*)
+FROM transfer_approvals
+WHERE pending_transfer_id = $1
+  AND decision = 'approved';
\ No newline at end of file
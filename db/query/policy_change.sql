@@ -0,0 +1,53 @@
+-- name: CreatePolicyChange :one
+INSERT INTO policy_changes (
+    account_id,
+    new_threshold,
+    new_required_approvals,
+    new_approvers,
+    requested_by,
+    required_approvals,
+    approvers
+  )
+VALUES ($1, $2, $3, $4, $5, $6, $7)
+RETURNING *;
+-- name: GetPolicyChange :one
+SELECT *
+FROM policy_changes
+WHERE id = $1
+LIMIT 1;
+-- name: GetPolicyChangeForUpdate :one
+SELECT *
+FROM policy_changes
+WHERE id = $1
+LIMIT 1 FOR NO KEY
+UPDATE;
+-- name: ListPolicyChanges :many
+SELECT *
+FROM policy_changes
+WHERE account_id = sqlc.arg('account_id')
+  AND (
+    sqlc.narg('status')::varchar IS NULL
+    OR status = sqlc.narg('status')
+  ) -- optional filter by status
+ORDER BY id DESC
+LIMIT sqlc.arg('limit') OFFSET sqlc.arg('offset');
+-- name: UpdatePolicyChangeStatus :one
+UPDATE policy_changes
+SET status = $2,
+  updated_at = now()
+WHERE id = $1
+RETURNING *;
+-- name: CreatePolicyChangeApproval :one
+INSERT INTO policy_change_approvals (policy_change_id, approver, decision)
+VALUES ($1, $2, $3)
+RETURNING *;
+-- name: ListPolicyChangeApprovals :many
+SELECT *
+FROM policy_change_approvals
+WHERE policy_change_id = $1
+ORDER BY created_at;
+-- name: CountPolicyChangeApprovals :one
+SELECT COUNT(*)
+FROM policy_change_approvals
+WHERE policy_change_id = $1
+  AND decision = 'approved';
\ No newline at end of file
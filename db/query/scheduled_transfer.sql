@@ -0,0 +1,69 @@
+-- name: CreateScheduledTransfer :one
+INSERT INTO scheduled_transfers (
+    owner,
+    from_account_id,
+    to_account_id,
+    amount,
+    currency,
+    schedule,
+    start_at,
+    next_run_at,
+    failure_policy,
+    max_retries
+  )
+VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10)
+RETURNING *;
+-- name: GetScheduledTransfer :one
+SELECT *
+FROM scheduled_transfers
+WHERE id = $1
+LIMIT 1;
+-- name: GetScheduledTransferForUpdate :one
+SELECT *
+FROM scheduled_transfers
+WHERE id = $1
+LIMIT 1 FOR NO KEY
+UPDATE;
+-- name: ListScheduledTransfers :many
+SELECT *
+FROM scheduled_transfers
+WHERE owner = $1
+ORDER BY id
+LIMIT $2 OFFSET $3;
+-- name: ListDueScheduledTransfers :many
+SELECT *
+FROM scheduled_transfers
+WHERE status = 'active'
+  AND next_run_at <= sqlc.arg('now')::timestamptz
+  AND id > sqlc.arg('after_id')
+ORDER BY id
+LIMIT sqlc.arg('limit');
+-- name: UpdateScheduledTransfer :one
+UPDATE scheduled_transfers
+SET amount = $2,
+  schedule = $3,
+  next_run_at = $4,
+  failure_policy = $5,
+  max_retries = $6,
+  retry_count = $7,
+  status = $8,
+  updated_at = now()
+WHERE id = $1
+RETURNING *;
+-- name: CreateScheduledTransferRun :one
+INSERT INTO scheduled_transfer_runs (
+    scheduled_transfer_id,
+    scheduled_for,
+    attempt,
+    outcome,
+    transfer_id,
+    error
+  )
+VALUES ($1, $2, $3, $4, $5, $6)
+RETURNING *;
+-- name: ListScheduledTransferRuns :many
+SELECT *
+FROM scheduled_transfer_runs
+WHERE scheduled_transfer_id = $1
+ORDER BY id
+LIMIT $2 OFFSET $3;
\ No newline at end of file
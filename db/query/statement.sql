@@ -0,0 +1,48 @@
+-- name: CreateStatement :one
+INSERT INTO statements (
+    account_id,
+    period_start,
+    period_end,
+    opening_balance,
+    closing_balance,
+    csv,
+    pdf
+  )
+VALUES ($1, $2, $3, $4, $5, $6, $7)
+RETURNING *;
+-- name: GetStatement :one
+SELECT *
+FROM statements
+WHERE account_id = $1
+  AND period_start = $2
+LIMIT 1;
+-- name: ListStatementEntries :many
+SELECT entries.id,
+  entries.amount,
+  entries.balance_after,
+  entries.created_at,
+  entries.transfer_id,
+  COALESCE(
+    entries.account_id NOT IN (transfers.from_account_id, transfers.to_account_id)
+    OR entries.id > (
+      SELECT min(payer_entries.id)
+      FROM entries AS payer_entries
+      WHERE payer_entries.transfer_id = entries.transfer_id
+        AND payer_entries.account_id = entries.account_id
+    ),
+    false
+  )::boolean AS fee,
+  counterparties.id AS counterparty_account_id,
+  counterparties.owner AS counterparty_owner,
+  transfers.description,
+  transfers.reference
+FROM entries
+  LEFT JOIN transfers ON transfers.id = entries.transfer_id
+  LEFT JOIN accounts AS counterparties ON counterparties.id = CASE
+      WHEN entries.account_id = transfers.from_account_id THEN transfers.to_account_id
+      ELSE transfers.from_account_id
+    END
+WHERE entries.account_id = sqlc.arg(account_id)
+  AND entries.created_at >= sqlc.arg(period_start)
+  AND entries.created_at < sqlc.arg(period_end)
+ORDER BY entries.id;
\ No newline at end of file
@@ -0,0 +1,11 @@
+-- name: CreateSystemAccount :one
+INSERT INTO system_accounts (purpose, currency, account_id)
+VALUES ($1, $2, $3)
+RETURNING *;
+-- name: GetSystemAccount :one
+SELECT accounts.*
+FROM accounts
+  JOIN system_accounts ON system_accounts.account_id = accounts.id
+WHERE system_accounts.purpose = $1
+  AND system_accounts.currency = $2
+LIMIT 1;
\ No newline at end of file
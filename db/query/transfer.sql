@@ -1,6 +1,14 @@
 -- name: CreateTransfer :one
-INSERT INTO transfers (from_account_id, to_account_id, amount)
-VALUES ($1, $2, $3)
+INSERT INTO transfers (
+    from_account_id,
+    to_account_id,
+    amount,
+    fee,
+    description,
+    reference,
+    sent_by
+  )
+VALUES ($1, $2, $3, $4, $5, $6, $7)
 RETURNING *;
 -- name: GetTransfer :one
 SELECT *
@@ -0,0 +1,105 @@
+-- name: GetDefaultTransferLimit :one
+SELECT *
+FROM transfer_limits
+WHERE username IS NULL
+  AND account_id IS NULL
+LIMIT 1;
+-- name: GetUserTransferLimit :one
+SELECT *
+FROM transfer_limits
+WHERE username = sqlc.arg('username')::varchar
+LIMIT 1;
+-- name: GetAccountTransferLimit :one
+SELECT *
+FROM transfer_limits
+WHERE account_id = sqlc.arg('account_id')::bigint
+LIMIT 1;
+-- name: UpdateDefaultTransferLimit :one
+UPDATE transfer_limits
+SET max_amount = $1,
+  daily_amount = $2,
+  monthly_amount = $3,
+  daily_count = $4,
+  updated_by = $5,
+  updated_at = now()
+WHERE username IS NULL
+  AND account_id IS NULL
+RETURNING *;
+-- name: SetUserTransferLimit :one
+INSERT INTO transfer_limits (
+    username,
+    max_amount,
+    daily_amount,
+    monthly_amount,
+    daily_count,
+    updated_by
+  )
+VALUES (
+    sqlc.arg('username')::varchar,
+    sqlc.arg('max_amount'),
+    sqlc.arg('daily_amount'),
+    sqlc.arg('monthly_amount'),
+    sqlc.arg('daily_count'),
+    sqlc.arg('updated_by')
+  ) ON CONFLICT (username)
+WHERE username IS NOT NULL DO
+UPDATE
+SET max_amount = EXCLUDED.max_amount,
+  daily_amount = EXCLUDED.daily_amount,
+  monthly_amount = EXCLUDED.monthly_amount,
+  daily_count = EXCLUDED.daily_count,
+  updated_by = EXCLUDED.updated_by,
+  updated_at = now()
+RETURNING *;
+-- name: SetAccountTransferLimit :one
+INSERT INTO transfer_limits (
+    account_id,
+    max_amount,
+    daily_amount,
+    monthly_amount,
+    daily_count,
+    updated_by
+  )
+VALUES (
+    sqlc.arg('account_id')::bigint,
+    sqlc.arg('max_amount'),
+    sqlc.arg('daily_amount'),
+    sqlc.arg('monthly_amount'),
+    sqlc.arg('daily_count'),
+    sqlc.arg('updated_by')
+  ) ON CONFLICT (account_id)
+WHERE account_id IS NOT NULL DO
+UPDATE
+SET max_amount = EXCLUDED.max_amount,
+  daily_amount = EXCLUDED.daily_amount,
+  monthly_amount = EXCLUDED.monthly_amount,
+  daily_count = EXCLUDED.daily_count,
+  updated_by = EXCLUDED.updated_by,
+  updated_at = now()
+RETURNING *;
+-- name: DeleteUserTransferLimit :exec
+DELETE FROM transfer_limits
+WHERE username = sqlc.arg('username')::varchar;
+-- name: DeleteAccountTransferLimit :exec
+DELETE FROM transfer_limits
+WHERE account_id = sqlc.arg('account_id')::bigint;
+-- name: GetOutgoingTransferTotalsByAccount :one
+SELECT COALESCE(SUM(amount), 0)::bigint AS total_amount,
+  COUNT(*) AS transfer_count
+FROM transfers
+WHERE from_account_id = sqlc.arg('account_id')
+  AND created_at >= sqlc.arg('since');
+-- name: GetOutgoingTransferTotalsByOwner :one
+SELECT COALESCE(SUM(transfers.amount), 0)::bigint AS total_amount,
+  COUNT(*) AS transfer_count
+FROM transfers
+  JOIN accounts ON accounts.id = transfers.from_account_id
+WHERE accounts.owner = sqlc.arg('owner')
+  AND transfers.created_at >= sqlc.arg('since');
+-- name: GetOutgoingTransferTotalsBySender :one
+SELECT COALESCE(SUM(amount), 0)::bigint AS total_amount,
+  COUNT(*) AS transfer_count
+FROM transfers
+WHERE from_account_id = sqlc.arg('account_id')
+  AND sent_by = sqlc.arg('sent_by')
+  AND created_at >= sqlc.arg('since');
\ No newline at end of file
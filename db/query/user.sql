@@ -6,4 +6,17 @@ RETURNING *;
 SELECT *
 FROM users
 WHERE username = $1
-LIMIT 1;
\ No newline at end of file
+LIMIT 1;
+-- name: GetUserForUpdate :one
+SELECT *
+FROM users
+WHERE username = $1
+LIMIT 1 FOR NO KEY
+UPDATE;
+-- name: SearchUsers :many
+SELECT *
+FROM users
+WHERE username ILIKE '%' || sqlc.arg('query')::varchar || '%'
+  OR email ILIKE '%' || sqlc.arg('query')::varchar || '%'
+ORDER BY username
+LIMIT sqlc.arg('limit') OFFSET sqlc.arg('offset');
\ No newline at end of file
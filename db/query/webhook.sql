@@ -0,0 +1,88 @@
+-- name: CreateWebhookSubscription :one
+INSERT INTO webhook_subscriptions (owner, url, event_types, secret)
+VALUES ($1, $2, $3, $4)
+RETURNING *;
+-- name: GetWebhookSubscription :one
+SELECT *
+FROM webhook_subscriptions
+WHERE id = $1
+LIMIT 1;
+-- name: ListWebhookSubscriptions :many
+SELECT *
+FROM webhook_subscriptions
+WHERE owner = $1
+ORDER BY id
+LIMIT $2 OFFSET $3;
+-- name: ListActiveWebhookSubscriptions :many
+SELECT *
+FROM webhook_subscriptions
+WHERE owner = $1
+  AND active
+ORDER BY id;
+-- name: DeactivateWebhookSubscription :one
+UPDATE webhook_subscriptions
+SET active = false
+WHERE id = $1
+  AND active
+RETURNING *;
+-- name: CreateWebhookDelivery :exec
+INSERT INTO webhook_deliveries (subscription_id, event_id, event_type, payload)
+VALUES ($1, $2, $3, $4) ON CONFLICT (subscription_id, event_id, event_type) DO NOTHING;
+-- name: GetWebhookDelivery :one
+SELECT *
+FROM webhook_deliveries
+WHERE id = $1
+LIMIT 1;
+-- name: ListWebhookDeliveries :many
+SELECT *
+FROM webhook_deliveries
+WHERE subscription_id = $1
+ORDER BY id
+LIMIT $2 OFFSET $3;
+-- name: ListDueWebhookDeliveries :many
+SELECT *
+FROM webhook_deliveries
+WHERE status = 'pending'
+  AND next_attempt_at <= sqlc.arg('now')
+  AND id > sqlc.arg('after_id')
+  AND subscription_id IN (
+    SELECT id
+    FROM webhook_subscriptions
+    WHERE active
+  )
+ORDER BY id
+LIMIT sqlc.arg('limit');
+-- name: ClaimWebhookDelivery :one
+UPDATE webhook_deliveries
+SET next_attempt_at = sqlc.arg('lease_until')
+WHERE id = sqlc.arg('id')
+  AND status = 'pending'
+  AND next_attempt_at <= sqlc.arg('now')
+RETURNING *;
+-- name: UpdateWebhookDelivery :one
+UPDATE webhook_deliveries
+SET status = $2,
+  attempts = $3,
+  next_attempt_at = $4,
+  last_status_code = $5,
+  last_error = $6,
+  delivered_at = $7
+WHERE id = $1
+RETURNING *;
+-- name: RetryWebhookDelivery :one
+UPDATE webhook_deliveries
+SET status = 'pending',
+  attempts = 0,
+  next_attempt_at = now()
+WHERE id = $1
+  AND status = 'dead'
+RETURNING *;
+-- name: CreateWebhookDeliveryAttempt :one
+INSERT INTO webhook_delivery_attempts (delivery_id, status_code, error, duration_ms)
+VALUES ($1, $2, $3, $4)
+RETURNING *;
+-- name: ListWebhookDeliveryAttempts :many
+SELECT *
+FROM webhook_delivery_attempts
+WHERE delivery_id = $1
+ORDER BY id;
\ No newline at end of file
@@ -0,0 +1,93 @@
+package db
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+// account roles, stored in account_members.role.
+// The owner of an account is accounts.owner, it is not a member of its own account
+const (
+	AccountOwner   = "owner"    // opened the account, the only one who can close it
+	AccountCoOwner = "co-owner" // same access as the owner, except closing the account
+	AccountSpender = "spender"  // views the account and sends money from it, up to spend_limit per day
+	AccountViewer  = "viewer"   // views the account only
+)
+
+// AccountRoleCanView reports whether a role can view an account, its balance and its statements.
+func AccountRoleCanView(role string) bool {
+	return role == AccountOwner || role == AccountCoOwner || role == AccountSpender || role == AccountViewer
+}
+
+// AccountRoleCanSpend reports whether a role can send money from an account.
+func AccountRoleCanSpend(role string) bool {
+	return role == AccountOwner || role == AccountCoOwner || role == AccountSpender
+}
+
+// AccountRoleCanManage reports whether a role can invite and remove the members of an account.
+func AccountRoleCanManage(role string) bool {
+	return role == AccountOwner || role == AccountCoOwner
+}
+
+// checkSender checks that a user may still send amount from an account: they must be its owner,
+// a co-owner, or a spender within their spend limit, as when the API makes a transfer.
+// ErrSenderNotAllowed is returned otherwise.
+func checkSender(ctx context.Context, q *Queries, username string, accountID int64, amount int64) error {
+	account, err := q.GetAccount(ctx, accountID)
+	if err != nil {
+		return err
+	}
+	return checkAccountSender(ctx, q, username, account, amount, time.Now())
+}
+
+// checkAccountSender is checkSender for an account already loaded. The spend limit of a member is a daily limit:
+// the transfers they sent from the account since the start of the day count towards it, including the earlier
+// transfers of the same database transaction, like the legs of a batch. The owner of the account is locked
+// first, as checkTransferLimits does, so concurrent transfers of a member cannot both fit under the limit.
+func checkAccountSender(ctx context.Context, q *Queries, username string, account Account, amount int64, now time.Time) error {
+	if account.Owner == username {
+		return nil
+	}
+
+	member, err := q.GetAccountMember(ctx, GetAccountMemberParams{
+		AccountID: account.ID,
+		Username:  username,
+	})
+	if errors.Is(err, ErrRecordNotFound) || (err == nil && !member.AcceptedAt.Valid) {
+		return fmt.Errorf("%s is not a member of account [%d]: %w", username, account.ID, ErrSenderNotAllowed)
+	}
+	if err != nil {
+		return err
+	}
+
+	if !AccountRoleCanSpend(member.Role) {
+		return fmt.Errorf("%s is a %s of account [%d]: %w", username, member.Role, account.ID, ErrSenderNotAllowed)
+	}
+	if !member.SpendLimit.Valid {
+		return nil
+	}
+	if amount > member.SpendLimit.Int64 {
+		return fmt.Errorf("amount %d is over the spend limit of %d on account [%d]: %w", amount, member.SpendLimit.Int64, account.ID, ErrSenderNotAllowed)
+	}
+
+	if _, err := q.GetUserForUpdate(ctx, account.Owner); err != nil {
+		return err
+	}
+	totals, err := q.GetOutgoingTransferTotalsBySender(ctx, GetOutgoingTransferTotalsBySenderParams{
+		AccountID: account.ID,
+		SentBy:    pgtype.Text{String: username, Valid: true},
+		Since:     startOfDay(now),
+	})
+	if err != nil {
+		return err
+	}
+	if totals.TotalAmount+amount > member.SpendLimit.Int64 {
+		return fmt.Errorf("%d already sent today from account [%d], the daily spend limit is %d: %w",
+			totals.TotalAmount, account.ID, member.SpendLimit.Int64, ErrSenderNotAllowed)
+	}
+	return nil
+}
@@ -0,0 +1,189 @@
+package db
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/XiaozhouCui/go-bank/db/util"
+	"github.com/jackc/pgx/v5/pgtype"
+	"github.com/stretchr/testify/require"
+)
+
+func createRandomAccountMember(t *testing.T, account Account, role string, spendLimit pgtype.Int8) AccountMember {
+	user := createRandomUser(t)
+
+	arg := CreateAccountMemberParams{
+		AccountID:  account.ID,
+		Username:   user.Username,
+		Role:       role,
+		SpendLimit: spendLimit,
+		InvitedBy:  account.Owner,
+	}
+	member, err := testQueries.CreateAccountMember(context.Background(), arg)
+	require.NoError(t, err)
+	require.Equal(t, arg.AccountID, member.AccountID)
+	require.Equal(t, arg.Username, member.Username)
+	require.Equal(t, arg.Role, member.Role)
+	require.Equal(t, arg.SpendLimit, member.SpendLimit)
+	require.Equal(t, arg.InvitedBy, member.InvitedBy)
+	require.False(t, member.AcceptedAt.Valid)
+	require.NotZero(t, member.CreatedAt)
+	return member
+}
+
+func TestAccountMembers(t *testing.T) {
+	account := createRandomAccount(t)
+	member1 := createRandomAccountMember(t, account, AccountViewer, pgtype.Int8{})
+	member2 := createRandomAccountMember(t, account, AccountSpender, pgtype.Int8{Int64: 100, Valid: true})
+
+	members, err := testQueries.ListAccountMembers(context.Background(), account.ID)
+	require.NoError(t, err)
+	require.Equal(t, []AccountMember{member1, member2}, members)
+
+	// a user is a member of an account once
+	_, err = testQueries.CreateAccountMember(context.Background(), CreateAccountMemberParams{
+		AccountID: account.ID,
+		Username:  member1.Username,
+		Role:      AccountCoOwner,
+		InvitedBy: account.Owner,
+	})
+	require.Equal(t, UniqueViolation, ErrorCode(err))
+
+	// only spenders have a spend limit, and they must have one
+	user := createRandomUser(t)
+	_, err = testQueries.CreateAccountMember(context.Background(), CreateAccountMemberParams{
+		AccountID: account.ID,
+		Username:  user.Username,
+		Role:      AccountSpender,
+		InvitedBy: account.Owner,
+	})
+	require.Error(t, err)
+	_, err = testQueries.CreateAccountMember(context.Background(), CreateAccountMemberParams{
+		AccountID:  account.ID,
+		Username:   user.Username,
+		Role:       AccountViewer,
+		SpendLimit: pgtype.Int8{Int64: 100, Valid: true},
+		InvitedBy:  account.Owner,
+	})
+	require.Error(t, err)
+
+	err = testQueries.DeleteAccountMember(context.Background(), DeleteAccountMemberParams{
+		AccountID: account.ID,
+		Username:  member1.Username,
+	})
+	require.NoError(t, err)
+
+	_, err = testQueries.GetAccountMember(context.Background(), GetAccountMemberParams{
+		AccountID: account.ID,
+		Username:  member1.Username,
+	})
+	require.ErrorIs(t, err, ErrRecordNotFound)
+}
+
+func TestListSharedAccounts(t *testing.T) {
+	account := createRandomAccount(t)
+	member := createRandomAccountMember(t, account, AccountCoOwner, pgtype.Int8{})
+
+	arg := ListAccountsParams{
+		Username: member.Username,
+		Limit:    5,
+	}
+
+	// an invitation gives no access
+	accounts, err := testQueries.ListAccounts(context.Background(), arg)
+	require.NoError(t, err)
+	require.Empty(t, accounts)
+
+	member, err = testQueries.AcceptAccountMember(context.Background(), AcceptAccountMemberParams{
+		AccountID: account.ID,
+		Username:  member.Username,
+	})
+	require.NoError(t, err)
+	require.True(t, member.AcceptedAt.Valid)
+
+	accounts, err = testQueries.ListAccounts(context.Background(), arg)
+	require.NoError(t, err)
+	require.Len(t, accounts, 1)
+	require.Equal(t, account.ID, accounts[0].ID)
+	require.Equal(t, account.Owner, accounts[0].Owner)
+}
+
+func TestSpendLimitDaily(t *testing.T) {
+	store := NewStore(testDB)
+
+	currency := strings.ToUpper(util.RandomString(6))
+	account1 := createAccountInCurrency(t, currency, util.Checking)
+	account2 := createAccountInCurrency(t, currency, util.Checking)
+	account1, err := testQueries.AddAccountBalance(context.Background(), AddAccountBalanceParams{
+		ID:     account1.ID,
+		Amount: 100,
+	})
+	require.NoError(t, err)
+
+	spender := createRandomAccountMember(t, account1, AccountSpender, pgtype.Int8{Int64: 50, Valid: true})
+	_, err = testQueries.AcceptAccountMember(context.Background(), AcceptAccountMemberParams{
+		AccountID: account1.ID,
+		Username:  spender.Username,
+	})
+	require.NoError(t, err)
+
+	arg := TransferTxParams{
+		FromAccountID: account1.ID,
+		ToAccountID:   account2.ID,
+		Amount:        30,
+		SentBy:        spender.Username,
+	}
+	result, err := store.TransferTx(context.Background(), arg)
+	require.NoError(t, err)
+	require.Equal(t, spender.Username, result.Transfer.SentBy.String)
+
+	// the first transfer of the day counts towards the limit of the second one
+	_, err = store.TransferTx(context.Background(), arg)
+	require.ErrorIs(t, err, ErrSenderNotAllowed)
+
+	// the owner has no spend limit
+	arg.SentBy = account1.Owner
+	_, err = store.TransferTx(context.Background(), arg)
+	require.NoError(t, err)
+}
+
+func TestSpendLimitDailyBatch(t *testing.T) {
+	store := NewStore(testDB)
+
+	currency := strings.ToUpper(util.RandomString(6))
+	account1 := createAccountInCurrency(t, currency, util.Checking)
+	account2 := createAccountInCurrency(t, currency, util.Checking)
+	account1, err := testQueries.AddAccountBalance(context.Background(), AddAccountBalanceParams{
+		ID:     account1.ID,
+		Amount: 100,
+	})
+	require.NoError(t, err)
+
+	spender := createRandomAccountMember(t, account1, AccountSpender, pgtype.Int8{Int64: 50, Valid: true})
+	_, err = testQueries.AcceptAccountMember(context.Background(), AcceptAccountMemberParams{
+		AccountID: account1.ID,
+		Username:  spender.Username,
+	})
+	require.NoError(t, err)
+
+	// each leg fits under the limit alone, the second one counts the first
+	leg := TransferTxParams{
+		FromAccountID: account1.ID,
+		ToAccountID:   account2.ID,
+		Amount:        30,
+		SentBy:        spender.Username,
+	}
+	result, err := store.BatchTransferTx(context.Background(), BatchTransferTxParams{
+		Transfers:  []TransferTxParams{leg, leg},
+		BestEffort: true,
+	})
+	require.NoError(t, err)
+	require.Len(t, result.Legs, 2)
+	require.NoError(t, result.Legs[0].Err)
+	require.ErrorIs(t, result.Legs[1].Err, ErrSenderNotAllowed)
+
+	account, err := testQueries.GetAccount(context.Background(), account1.ID)
+	require.NoError(t, err)
+	require.Equal(t, account1.Balance-30, account.Balance)
+}
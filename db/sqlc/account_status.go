@@ -0,0 +1,25 @@
+package db
+
+// account statuses, stored in accounts.status
+const (
+	AccountStatusActive = "active" // can send and receive money
+	AccountStatusFrozen = "frozen" // blocked by bank staff, no money can move in or out
+	AccountStatusClosed = "closed" // closed by the owner, final
+)
+
+// accountStatusTransitions lists the statuses each status is allowed to move to.
+// A closed account cannot be reopened.
+var accountStatusTransitions = map[string][]string{
+	AccountStatusActive: {AccountStatusFrozen, AccountStatusClosed},
+	AccountStatusFrozen: {AccountStatusActive},
+}
+
+// CanTransitionAccountStatus reports whether an account can move from one status to another.
+func CanTransitionAccountStatus(from, to string) bool {
+	for _, status := range accountStatusTransitions[from] {
+		if status == to {
+			return true
+		}
+	}
+	return false
+}
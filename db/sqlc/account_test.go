@@ -2,11 +2,11 @@ package db
 
 import (
 	"context"
-	"database/sql"
 	"testing"
 	"time"
 
 	"github.com/XiaozhouCui/go-bank/db/util"
+	"github.com/jackc/pgx/v5/pgtype"
 	"github.com/stretchr/testify/require"
 )
 
@@ -14,8 +14,10 @@ func createRandomAccount(t *testing.T) Account {
 	user := createRandomUser(t)
 	arg := CreateAccountParams{
 		Owner:    user.Username,
-		Balance:  util.RandomMoney(),
+		Balance:  util.RandomInt(1000, 2000), // enough for the transfers of the tests, which cannot overdraw the account
 		Currency: util.RandomCurrency(),
+		Type:     util.RandomAccountType(),
+		Nickname: util.RandomString(8),
 	}
 
 	account, err := testQueries.CreateAccount(context.Background(), arg)
@@ -25,6 +27,11 @@ func createRandomAccount(t *testing.T) Account {
 	require.Equal(t, arg.Owner, account.Owner)
 	require.Equal(t, arg.Balance, account.Balance)
 	require.Equal(t, arg.Currency, account.Currency)
+	require.Equal(t, AccountStatusActive, account.Status)
+	require.Equal(t, arg.Type, account.Type)
+	require.Equal(t, arg.Nickname, account.Nickname)
+	require.Equal(t, arg.Balance, account.AvailableBalance)
+	require.True(t, util.IsValidAccountNumber(account.Number))
 
 	require.NotZero(t, account.ID)
 	require.NotZero(t, account.CreatedAt)
@@ -51,6 +58,16 @@ func TestGetAccount(t *testing.T) {
 	require.WithinDuration(t, account1.CreatedAt, account2.CreatedAt, time.Second)
 }
 
+func TestGetAccountByNumber(t *testing.T) {
+	account1 := createRandomAccount(t)
+	account2, err := testQueries.GetAccountByNumber(context.Background(), account1.Number)
+	require.NoError(t, err)
+	require.Equal(t, account1.ID, account2.ID)
+
+	_, err = testQueries.GetAccountByNumber(context.Background(), util.RandomAccountNumber())
+	require.ErrorIs(t, err, ErrRecordNotFound)
+}
+
 func TestUpdateAccount(t *testing.T) {
 	account1 := createRandomAccount(t)
 
@@ -78,7 +95,7 @@ func TestDeleteAccount(t *testing.T) {
 
 	account2, err := testQueries.GetAccount(context.Background(), account1.ID)
 	require.Error(t, err)
-	require.EqualError(t, err, sql.ErrNoRows.Error())
+	require.EqualError(t, err, ErrRecordNotFound.Error())
 	require.Empty(t, account2)
 }
 
@@ -90,9 +107,9 @@ func TestListAccounts(t *testing.T) {
 
 	// skip the first 0 accounts and return the next 5 accounts
 	arg := ListAccountsParams{
-		Owner:  lastAccount.Owner,
-		Limit:  5,
-		Offset: 0,
+		Username: lastAccount.Owner,
+		Limit:    5,
+		Offset:   0,
 	}
 
 	accounts, err := testQueries.ListAccounts(context.Background(), arg)
@@ -101,6 +118,56 @@ func TestListAccounts(t *testing.T) {
 
 	for _, account := range accounts {
 		require.NotEmpty(t, account)
-		require.Equal(t, arg.Owner, account.Owner)
+		require.Equal(t, arg.Username, account.Owner)
+	}
+}
+
+func TestListAccountsByTypeAndCurrency(t *testing.T) {
+	user := createRandomUser(t)
+
+	// the same user can hold one account of each type in the same currency
+	for _, accountType := range []string{util.Checking, util.Savings, util.Escrow} {
+		_, err := testQueries.CreateAccount(context.Background(), CreateAccountParams{
+			Owner:    user.Username,
+			Currency: util.USD,
+			Type:     accountType,
+		})
+		require.NoError(t, err)
 	}
+
+	// but not two accounts of the same type
+	_, err := testQueries.CreateAccount(context.Background(), CreateAccountParams{
+		Owner:    user.Username,
+		Currency: util.USD,
+		Type:     util.Savings,
+	})
+	require.Equal(t, UniqueViolation, ErrorCode(err))
+
+	accounts, err := testQueries.ListAccounts(context.Background(), ListAccountsParams{
+		Username: user.Username,
+		Limit:    5,
+		Offset:   0,
+	})
+	require.NoError(t, err)
+	require.Len(t, accounts, 3)
+
+	accounts, err = testQueries.ListAccounts(context.Background(), ListAccountsParams{
+		Username: user.Username,
+		Type:     pgtype.Text{String: util.Savings, Valid: true},
+		Currency: pgtype.Text{String: util.USD, Valid: true},
+		Limit:    5,
+		Offset:   0,
+	})
+	require.NoError(t, err)
+	require.Len(t, accounts, 1)
+	require.Equal(t, util.Savings, accounts[0].Type)
+
+	accounts, err = testQueries.ListAccounts(context.Background(), ListAccountsParams{
+		Username: user.Username,
+		Currency: pgtype.Text{String: util.EUR, Valid: true},
+		Limit:    5,
+		Offset:   0,
+	})
+	require.NoError(t, err)
+	require.Empty(t, accounts)
 }
@@ -0,0 +1,24 @@
+package db
+
+import (
+	"context"
+	"errors"
+	"fmt"
+)
+
+// checkNoApproval checks that a transfer of amount from an account does not need the approvals
+// of its policy, ErrApprovalRequired is returned if it does. It runs when the money moves,
+// so a transfer set up before the policy, like a scheduled transfer or a hold, is caught too.
+func checkNoApproval(ctx context.Context, q *Queries, accountID int64, amount int64) error {
+	policy, err := q.GetApprovalPolicy(ctx, accountID)
+	if errors.Is(err, ErrRecordNotFound) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	if amount >= policy.Threshold {
+		return fmt.Errorf("transfers of %d or more from account [%d]: %w", policy.Threshold, accountID, ErrApprovalRequired)
+	}
+	return nil
+}
@@ -0,0 +1,63 @@
+package db
+
+import (
+	"context"
+	"testing"
+
+	"github.com/XiaozhouCui/go-bank/db/util"
+	"github.com/stretchr/testify/require"
+)
+
+func addRandomAuditLogEntry(t *testing.T, store Store) AuditLog {
+	arg := AddAuditLogTxParams{
+		Actor:      util.RandomOwner(),
+		ActorRole:  util.DepositorRole,
+		Action:     "POST /accounts/:id/close",
+		TargetType: "accounts",
+		TargetID:   "1",
+		RequestID:  util.RandomString(12),
+		ClientIp:   "127.0.0.1",
+		Status:     200,
+		Before:     []byte(`{"id": 1, "status": "active"}`),
+		After:      []byte(`{"id": 1, "status": "closed"}`),
+	}
+	entry, err := store.AddAuditLogTx(context.Background(), arg)
+	require.NoError(t, err)
+	require.Equal(t, arg.Actor, entry.Actor)
+	require.Equal(t, arg.Action, entry.Action)
+	require.Equal(t, arg.RequestID, entry.RequestID)
+	// snapshots are kept as they were written, the hash covers their exact text
+	require.Equal(t, string(arg.Before), string(entry.Before))
+	require.Equal(t, string(arg.After), string(entry.After))
+	return entry
+}
+
+func TestAddAuditLogTx(t *testing.T) {
+	store := NewStore(testDB)
+
+	first := addRandomAuditLogEntry(t, store)
+	second := addRandomAuditLogEntry(t, store)
+	require.Equal(t, first.Hash, second.PrevHash)
+
+	stored, err := testQueries.GetLastAuditLogEntry(context.Background())
+	require.NoError(t, err)
+	require.Equal(t, second.ID, stored.ID)
+
+	// the hash is recomputed from the stored entry
+	hash, err := AuditLogHash(stored)
+	require.NoError(t, err)
+	require.Equal(t, stored.Hash, hash)
+}
+
+func TestAuditLogAppendOnly(t *testing.T) {
+	entry := addRandomAuditLogEntry(t, NewStore(testDB))
+
+	_, err := testDB.Exec(context.Background(), "UPDATE audit_log SET actor = $1 WHERE id = $2", util.RandomOwner(), entry.ID)
+	require.ErrorContains(t, err, "append-only")
+
+	_, err = testDB.Exec(context.Background(), "DELETE FROM audit_log WHERE id = $1", entry.ID)
+	require.ErrorContains(t, err, "append-only")
+
+	_, err = testDB.Exec(context.Background(), "TRUNCATE audit_log")
+	require.ErrorContains(t, err, "append-only")
+}
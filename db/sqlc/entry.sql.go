@@ -7,52 +7,155 @@ package db
 
 import (
 	"context"
+	"time"
+
+	"github.com/jackc/pgx/v5/pgtype"
 )
 
 const createEntry = `-- name: CreateEntry :one
-INSERT INTO entries (account_id, amount)
-VALUES ($1, $2)
-RETURNING id, account_id, amount, created_at
+INSERT INTO entries (account_id, amount, transfer_id, balance_after)
+VALUES ($1, $2, $3, $4)
+RETURNING id, account_id, amount, created_at, transfer_id, balance_after, tags
 `
 
 type CreateEntryParams struct {
-	AccountID int64 `json:"account_id"`
-	Amount    int64 `json:"amount"`
+	AccountID    int64       `json:"account_id"`
+	Amount       int64       `json:"amount"`
+	TransferID   pgtype.Int8 `json:"transfer_id"`
+	BalanceAfter int64       `json:"balance_after"`
 }
 
 func (q *Queries) CreateEntry(ctx context.Context, arg CreateEntryParams) (Entry, error) {
-	row := q.db.QueryRowContext(ctx, createEntry, arg.AccountID, arg.Amount)
+	row := q.db.QueryRow(ctx, createEntry,
+		arg.AccountID,
+		arg.Amount,
+		arg.TransferID,
+		arg.BalanceAfter,
+	)
 	var i Entry
 	err := row.Scan(
 		&i.ID,
 		&i.AccountID,
 		&i.Amount,
 		&i.CreatedAt,
+		&i.TransferID,
+		&i.BalanceAfter,
+		&i.Tags,
 	)
 	return i, err
 }
 
 const getEntry = `-- name: GetEntry :one
-SELECT id, account_id, amount, created_at
+SELECT id, account_id, amount, created_at, transfer_id, balance_after, tags
 FROM entries
 WHERE id = $1
 LIMIT 1
 `
 
 func (q *Queries) GetEntry(ctx context.Context, id int64) (Entry, error) {
-	row := q.db.QueryRowContext(ctx, getEntry, id)
+	row := q.db.QueryRow(ctx, getEntry, id)
 	var i Entry
 	err := row.Scan(
 		&i.ID,
 		&i.AccountID,
 		&i.Amount,
 		&i.CreatedAt,
+		&i.TransferID,
+		&i.BalanceAfter,
+		&i.Tags,
 	)
 	return i, err
 }
 
+const listAccountEntries = `-- name: ListAccountEntries :many
+SELECT entries.id,
+  entries.account_id,
+  entries.amount,
+  entries.created_at,
+  entries.transfer_id,
+  entries.balance_after,
+  entries.tags,
+  COALESCE(transfers.description, '')::varchar AS description,
+  COALESCE(transfers.reference, '')::varchar AS reference
+FROM entries
+  LEFT JOIN transfers ON transfers.id = entries.transfer_id
+WHERE entries.account_id = $1
+  AND (
+    $2::varchar IS NULL
+    OR $2 = ANY(entries.tags)
+  ) -- optional filter by tag
+  AND (
+    $3::varchar IS NULL
+    OR transfers.reference = $3
+  ) -- optional filter by reference
+  AND (
+    $4::varchar IS NULL
+    OR strpos(lower(transfers.description), lower($4)) > 0
+  ) -- optional search in the description, taken literally
+ORDER BY entries.id DESC
+LIMIT $5 OFFSET $6
+`
+
+type ListAccountEntriesParams struct {
+	AccountID int64       `json:"account_id"`
+	Tag       pgtype.Text `json:"tag"`
+	Reference pgtype.Text `json:"reference"`
+	Search    pgtype.Text `json:"search"`
+	Limit     int32       `json:"limit"`
+	Offset    int32       `json:"offset"`
+}
+
+type ListAccountEntriesRow struct {
+	ID           int64       `json:"id"`
+	AccountID    int64       `json:"account_id"`
+	Amount       int64       `json:"amount"`
+	CreatedAt    time.Time   `json:"created_at"`
+	TransferID   pgtype.Int8 `json:"transfer_id"`
+	BalanceAfter int64       `json:"balance_after"`
+	Tags         []string    `json:"tags"`
+	Description  string      `json:"description"`
+	Reference    string      `json:"reference"`
+}
+
+func (q *Queries) ListAccountEntries(ctx context.Context, arg ListAccountEntriesParams) ([]ListAccountEntriesRow, error) {
+	rows, err := q.db.Query(ctx, listAccountEntries,
+		arg.AccountID,
+		arg.Tag,
+		arg.Reference,
+		arg.Search,
+		arg.Limit,
+		arg.Offset,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []ListAccountEntriesRow{}
+	for rows.Next() {
+		var i ListAccountEntriesRow
+		if err := rows.Scan(
+			&i.ID,
+			&i.AccountID,
+			&i.Amount,
+			&i.CreatedAt,
+			&i.TransferID,
+			&i.BalanceAfter,
+			&i.Tags,
+			&i.Description,
+			&i.Reference,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
 const listEntries = `-- name: ListEntries :many
-SELECT id, account_id, amount, created_at
+SELECT id, account_id, amount, created_at, transfer_id, balance_after, tags
 FROM entries
 WHERE account_id = $1
 ORDER BY id
@@ -66,7 +169,7 @@ type ListEntriesParams struct {
 }
 
 func (q *Queries) ListEntries(ctx context.Context, arg ListEntriesParams) ([]Entry, error) {
-	rows, err := q.db.QueryContext(ctx, listEntries, arg.AccountID, arg.Limit, arg.Offset)
+	rows, err := q.db.Query(ctx, listEntries, arg.AccountID, arg.Limit, arg.Offset)
 	if err != nil {
 		return nil, err
 	}
@@ -79,16 +182,43 @@ func (q *Queries) ListEntries(ctx context.Context, arg ListEntriesParams) ([]Ent
 			&i.AccountID,
 			&i.Amount,
 			&i.CreatedAt,
+			&i.TransferID,
+			&i.BalanceAfter,
+			&i.Tags,
 		); err != nil {
 			return nil, err
 		}
 		items = append(items, i)
 	}
-	if err := rows.Close(); err != nil {
-		return nil, err
-	}
 	if err := rows.Err(); err != nil {
 		return nil, err
 	}
 	return items, nil
 }
+
+const setEntryTags = `-- name: SetEntryTags :one
+UPDATE entries
+SET tags = $2
+WHERE id = $1
+RETURNING id, account_id, amount, created_at, transfer_id, balance_after, tags
+`
+
+type SetEntryTagsParams struct {
+	ID   int64    `json:"id"`
+	Tags []string `json:"tags"`
+}
+
+func (q *Queries) SetEntryTags(ctx context.Context, arg SetEntryTagsParams) (Entry, error) {
+	row := q.db.QueryRow(ctx, setEntryTags, arg.ID, arg.Tags)
+	var i Entry
+	err := row.Scan(
+		&i.ID,
+		&i.AccountID,
+		&i.Amount,
+		&i.CreatedAt,
+		&i.TransferID,
+		&i.BalanceAfter,
+		&i.Tags,
+	)
+	return i, err
+}
@@ -2,18 +2,23 @@ package db
 
 import (
 	"context"
+	"encoding/json"
+	"strings"
 	"testing"
 	"time"
 
 	"github.com/XiaozhouCui/go-bank/db/util"
+	"github.com/jackc/pgx/v5/pgtype"
 	"github.com/stretchr/testify/require"
 )
 
 // createRandomEntry creates a random entry
 func createRandomEntry(t *testing.T, account Account) Entry {
+	amount := util.RandomMoney()
 	arg := CreateEntryParams{
-		AccountID: account.ID,
-		Amount:    util.RandomMoney(),
+		AccountID:    account.ID,
+		Amount:       amount,
+		BalanceAfter: account.Balance + amount,
 	}
 
 	entry, err := testQueries.CreateEntry(context.Background(), arg)
@@ -22,6 +27,7 @@ func createRandomEntry(t *testing.T, account Account) Entry {
 
 	require.Equal(t, arg.AccountID, entry.AccountID)
 	require.Equal(t, arg.Amount, entry.Amount)
+	require.Equal(t, arg.BalanceAfter, entry.BalanceAfter)
 
 	require.NotZero(t, entry.ID)
 	require.NotZero(t, entry.CreatedAt)
@@ -80,3 +86,162 @@ func TestListEntries(t *testing.T) {
 		require.Equal(t, arg.AccountID, entry.AccountID)
 	}
 }
+
+// TestGetAccountBalanceAt reads balances at points in time from the running balance on entries
+func TestGetAccountBalanceAt(t *testing.T) {
+	store := NewStore(testDB)
+
+	account1 := createRandomAccount(t)
+	account2 := createRandomAccount(t)
+	amount := int64(10)
+
+	// no entries yet, the balance at any time is the current balance
+	balance, err := testQueries.GetAccountBalanceAt(context.Background(), GetAccountBalanceAtParams{
+		At:        time.Now(),
+		AccountID: account1.ID,
+	})
+	require.NoError(t, err)
+	require.Equal(t, account1.Balance, balance)
+
+	results := make([]TransferTxResult, 2)
+	for i := range results {
+		results[i], err = store.TransferTx(context.Background(), TransferTxParams{
+			FromAccountID: account1.ID,
+			ToAccountID:   account2.ID,
+			Amount:        amount,
+		})
+		require.NoError(t, err)
+		require.Equal(t, results[i].FromAccount.Balance, results[i].FromEntry.BalanceAfter)
+		require.Equal(t, results[i].ToAccount.Balance, results[i].ToEntry.BalanceAfter)
+	}
+
+	first := results[0].FromEntry.CreatedAt
+	second := results[1].FromEntry.CreatedAt
+
+	testCases := []struct {
+		at      time.Time
+		balance int64
+	}{
+		{at: first, balance: account1.Balance},                                   // before the first transfer
+		{at: first.Add(time.Microsecond), balance: account1.Balance - amount},    // after the first transfer
+		{at: second.Add(time.Microsecond), balance: account1.Balance - 2*amount}, // after the second transfer
+		{at: time.Now().Add(time.Hour), balance: results[1].FromAccount.Balance}, // in the future
+	}
+
+	for _, tc := range testCases {
+		balance, err := testQueries.GetAccountBalanceAt(context.Background(), GetAccountBalanceAtParams{
+			At:        tc.at,
+			AccountID: account1.ID,
+		})
+		require.NoError(t, err)
+		require.Equal(t, tc.balance, balance)
+	}
+}
+
+func TestEntryNotification(t *testing.T) {
+	conn, err := testDB.Acquire(context.Background())
+	require.NoError(t, err)
+	defer conn.Release()
+	_, err = conn.Exec(context.Background(), "LISTEN account_entries")
+	require.NoError(t, err)
+	defer conn.Exec(context.Background(), "UNLISTEN account_entries")
+
+	entry := createRandomEntry(t, createRandomAccount(t))
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	for {
+		notification, err := conn.Conn().WaitForNotification(ctx)
+		require.NoError(t, err)
+
+		var notified Entry
+		require.NoError(t, json.Unmarshal([]byte(notification.Payload), &notified))
+		// entries made by other tests are notified too
+		if notified.ID != entry.ID {
+			continue
+		}
+		require.Equal(t, entry.AccountID, notified.AccountID)
+		require.Equal(t, entry.Amount, notified.Amount)
+		require.Equal(t, entry.BalanceAfter, notified.BalanceAfter)
+		require.WithinDuration(t, entry.CreatedAt, notified.CreatedAt, time.Microsecond)
+		return
+	}
+}
+
+// TestListAccountEntries filters the entries of an account by tag, reference and description of their transfers
+func TestListAccountEntries(t *testing.T) {
+	store := NewStore(testDB)
+
+	// use a currency of its own, so no fee entries are listed
+	currency := strings.ToUpper(util.RandomString(6))
+	account1 := createAccountInCurrency(t, currency, util.Checking)
+	account2 := createAccountInCurrency(t, currency, util.Checking)
+
+	tagged, err := store.TransferTx(context.Background(), TransferTxParams{
+		FromAccountID: account1.ID,
+		ToAccountID:   account2.ID,
+		Amount:        10,
+		Description:   "March rent",
+		Reference:     "INV-42",
+		Tags:          []string{"rent", "home"},
+	})
+	require.NoError(t, err)
+	require.Equal(t, "March rent", tagged.Transfer.Description)
+	require.Equal(t, "INV-42", tagged.Transfer.Reference)
+	require.Equal(t, []string{"rent", "home"}, tagged.FromEntry.Tags)
+	// the tags are the sender's, the recipient's entry has none
+	require.Empty(t, tagged.ToEntry.Tags)
+
+	_, err = store.TransferTx(context.Background(), TransferTxParams{
+		FromAccountID: account1.ID,
+		ToAccountID:   account2.ID,
+		Amount:        10,
+	})
+	require.NoError(t, err)
+
+	testCases := []struct {
+		name    string
+		arg     ListAccountEntriesParams
+		entries int
+	}{
+		{name: "All", arg: ListAccountEntriesParams{AccountID: account1.ID, Limit: 5}, entries: 2},
+		{name: "Tag", arg: ListAccountEntriesParams{AccountID: account1.ID, Tag: pgtype.Text{String: "home", Valid: true}, Limit: 5}, entries: 1},
+		{name: "Reference", arg: ListAccountEntriesParams{AccountID: account1.ID, Reference: pgtype.Text{String: "INV-42", Valid: true}, Limit: 5}, entries: 1},
+		{name: "Search", arg: ListAccountEntriesParams{AccountID: account1.ID, Search: pgtype.Text{String: "RENT", Valid: true}, Limit: 5}, entries: 1},
+		{name: "RecipientTag", arg: ListAccountEntriesParams{AccountID: account2.ID, Tag: pgtype.Text{String: "rent", Valid: true}, Limit: 5}, entries: 0},
+		{name: "RecipientReference", arg: ListAccountEntriesParams{AccountID: account2.ID, Reference: pgtype.Text{String: "INV-42", Valid: true}, Limit: 5}, entries: 1},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			entries, err := testQueries.ListAccountEntries(context.Background(), tc.arg)
+			require.NoError(t, err)
+			require.Len(t, entries, tc.entries)
+		})
+	}
+
+	entries, err := testQueries.ListAccountEntries(context.Background(), ListAccountEntriesParams{
+		AccountID: account1.ID,
+		Tag:       pgtype.Text{String: "rent", Valid: true},
+		Limit:     5,
+	})
+	require.NoError(t, err)
+	require.Equal(t, tagged.FromEntry.ID, entries[0].ID)
+	require.Equal(t, "March rent", entries[0].Description)
+	require.Equal(t, "INV-42", entries[0].Reference)
+
+	// tags are replaced as a whole
+	entry, err := testQueries.SetEntryTags(context.Background(), SetEntryTagsParams{
+		ID:   tagged.FromEntry.ID,
+		Tags: []string{},
+	})
+	require.NoError(t, err)
+	require.Empty(t, entry.Tags)
+
+	// the check constraint rejects more than MaxTags tags
+	_, err = testQueries.SetEntryTags(context.Background(), SetEntryTagsParams{
+		ID:   tagged.FromEntry.ID,
+		Tags: []string{"a", "b", "c", "d", "e", "f", "g", "h", "i", "j", "k"},
+	})
+	require.Error(t, err)
+}
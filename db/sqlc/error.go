@@ -0,0 +1,54 @@
+package db
+
+import (
+	"errors"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+)
+
+// postgres error codes the API layer cares about
+const (
+	ForeignKeyViolation = "23503"
+	UniqueViolation     = "23505"
+)
+
+// ErrRecordNotFound is returned by ":one" queries when no row matches.
+var ErrRecordNotFound = pgx.ErrNoRows
+
+// errors returned by the store when a business rule is broken
+var (
+	ErrAccountNotActive          = errors.New("account is not active")
+	ErrInvalidStatusTransition   = errors.New("invalid account status transition")
+	ErrAccountBalanceNotZero     = errors.New("account balance must be zero")
+	ErrInterestAlreadyPosted     = errors.New("interest already posted for this period")
+	ErrTransferLimitExceeded     = errors.New("transfer limit exceeded")
+	ErrInsufficientFunds         = errors.New("insufficient funds")
+	ErrScheduledTransferNotDue   = errors.New("scheduled transfer is not due")
+	ErrScheduledTransferEnded    = errors.New("scheduled transfer has ended")
+	ErrHoldNotPending            = errors.New("hold is not pending")
+	ErrCaptureExceedsHold        = errors.New("capture amount exceeds the hold")
+	ErrImportJobNotExecutable    = errors.New("import job cannot be executed")
+	ErrImportLineNotPending      = errors.New("import line is not pending")
+	ErrInternalAccount           = errors.New("account is internal")
+	ErrPaymentRequestNotPending  = errors.New("payment request is not pending")
+	ErrPendingTransferNotPending = errors.New("pending transfer is not pending")
+	ErrNotApprover               = errors.New("user is not an approver")
+	ErrSenderNotAllowed          = errors.New("user is not allowed to send money from the account")
+	ErrPolicyChangeNotPending    = errors.New("policy change is not pending")
+	ErrApprovalRequired          = errors.New("transfer needs approvals")
+)
+
+// ErrUniqueViolation is a sample unique violation error, handy for stubbing the store in tests.
+var ErrUniqueViolation = &pgconn.PgError{
+	Code: UniqueViolation,
+}
+
+// ErrorCode returns the postgres error code of err, or an empty string if err is not a postgres error.
+func ErrorCode(err error) string {
+	var pgErr *pgconn.PgError
+	if errors.As(err, &pgErr) {
+		return pgErr.Code
+	}
+	return ""
+}
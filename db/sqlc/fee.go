@@ -0,0 +1,89 @@
+package db
+
+import (
+	"context"
+	"errors"
+)
+
+// kinds of fee schedules, stored in fee_schedules.kind
+const (
+	FeeKindFlat       = "flat"       // the same fee for any amount
+	FeeKindPercentage = "percentage" // a flat fee plus a percentage of the amount
+	FeeKindTiered     = "tiered"     // the flat fee and percentage of the tier the amount falls in
+)
+
+// IsSupportedFeeKind returns true if the fee kind is supported.
+func IsSupportedFeeKind(kind string) bool {
+	switch kind {
+	case FeeKindFlat, FeeKindPercentage, FeeKindTiered:
+		return true
+	}
+	return false
+}
+
+// CalculateFee returns the fee charged by a schedule for a transfer amount.
+// Tiers must be sorted by MinAmount, they are only used by tiered schedules.
+// The result is clamped to the MinFee and MaxFee of the schedule, a MaxFee of 0 means no cap.
+func CalculateFee(schedule FeeSchedule, tiers []FeeTier, amount int64) int64 {
+	var fee int64
+
+	switch schedule.Kind {
+	case FeeKindFlat:
+		fee = schedule.FlatFee
+	case FeeKindPercentage:
+		fee = schedule.FlatFee + percentOf(amount, schedule.RateBps)
+	case FeeKindTiered:
+		// use the last tier starting at or below the amount
+		for _, tier := range tiers {
+			if tier.MinAmount > amount {
+				break
+			}
+			fee = tier.FlatFee + percentOf(amount, tier.RateBps)
+		}
+	}
+
+	if fee < schedule.MinFee {
+		fee = schedule.MinFee
+	}
+	if schedule.MaxFee > 0 && fee > schedule.MaxFee {
+		fee = schedule.MaxFee
+	}
+	return fee
+}
+
+// percentOf returns rateBps basis points of amount, rounded half up to the minor unit.
+// The amount is split so that the multiplication cannot overflow for rates up to 100%.
+func percentOf(amount, rateBps int64) int64 {
+	return amount/10000*rateBps + (amount%10000*rateBps+5000)/10000
+}
+
+// transferFee returns the fee charged for sending an amount from an account.
+// The fee schedule is chosen by the currency and type of the account, without a schedule transfers are free.
+func transferFee(ctx context.Context, q *Queries, account Account, amount int64) (int64, error) {
+	schedule, err := q.GetFeeSchedule(ctx, GetFeeScheduleParams{
+		Currency:    account.Currency,
+		AccountType: account.Type,
+	})
+	if err != nil {
+		if errors.Is(err, ErrRecordNotFound) {
+			return 0, nil
+		}
+		return 0, err
+	}
+
+	var tiers []FeeTier
+	if schedule.Kind == FeeKindTiered {
+		tiers, err = q.ListFeeTiers(ctx, schedule.ID)
+		if err != nil {
+			return 0, err
+		}
+	}
+
+	return CalculateFee(schedule, tiers, amount), nil
+}
+
+// QuoteTransferFee returns the fee TransferTx would charge for sending an amount from an account,
+// without moving any money.
+func (store *SQLStore) QuoteTransferFee(ctx context.Context, account Account, amount int64) (int64, error) {
+	return transferFee(ctx, store.Queries, account, amount)
+}
@@ -0,0 +1,114 @@
+package db
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/XiaozhouCui/go-bank/db/util"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCalculateFee(t *testing.T) {
+	tiers := []FeeTier{
+		{MinAmount: 0, FlatFee: 10},
+		{MinAmount: 1000, FlatFee: 0, RateBps: 100},
+		{MinAmount: 100000, FlatFee: 500},
+	}
+
+	testCases := []struct {
+		name     string
+		schedule FeeSchedule
+		amount   int64
+		fee      int64
+	}{
+		{"Flat", FeeSchedule{Kind: FeeKindFlat, FlatFee: 25}, 5000, 25},
+		{"Percentage", FeeSchedule{Kind: FeeKindPercentage, RateBps: 50}, 5000, 25},
+		{"PercentageRoundsHalfUp", FeeSchedule{Kind: FeeKindPercentage, RateBps: 50}, 100, 1},
+		{"PercentagePlusFlat", FeeSchedule{Kind: FeeKindPercentage, FlatFee: 30, RateBps: 290}, 10000, 320},
+		{"MinFee", FeeSchedule{Kind: FeeKindPercentage, RateBps: 10, MinFee: 5}, 100, 5},
+		{"MaxFee", FeeSchedule{Kind: FeeKindPercentage, RateBps: 100, MaxFee: 200}, 1000000, 200},
+		{"FirstTier", FeeSchedule{Kind: FeeKindTiered}, 999, 10},
+		{"MiddleTier", FeeSchedule{Kind: FeeKindTiered}, 1000, 10},
+		{"LastTier", FeeSchedule{Kind: FeeKindTiered}, 250000, 500},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			require.Equal(t, tc.fee, CalculateFee(tc.schedule, tiers, tc.amount))
+		})
+	}
+}
+
+func TestTransferTxWithFee(t *testing.T) {
+	store := NewStore(testDB)
+
+	// use a currency of its own, so the fee schedule does not apply to other tests
+	currency := strings.ToUpper(util.RandomString(6))
+	account1 := createAccountInCurrency(t, currency, util.Checking)
+	account2 := createAccountInCurrency(t, currency, util.Checking)
+
+	revenueAccount := createAccountInCurrency(t, currency, util.Internal)
+	_, err := testQueries.CreateSystemAccount(context.Background(), CreateSystemAccountParams{
+		Purpose:   SystemAccountFeeRevenue,
+		Currency:  currency,
+		AccountID: revenueAccount.ID,
+	})
+	require.NoError(t, err)
+
+	result, err := store.CreateFeeScheduleTx(context.Background(), CreateFeeScheduleTxParams{
+		CreateFeeScheduleParams: CreateFeeScheduleParams{
+			Currency:    currency,
+			AccountType: util.Checking,
+			Kind:        FeeKindTiered,
+			MinFee:      1,
+		},
+		Tiers: []CreateFeeTierParams{
+			{MinAmount: 0, FlatFee: 2},
+			{MinAmount: 50, RateBps: 1000},
+		},
+	})
+	require.NoError(t, err)
+	require.NotZero(t, result.Schedule.ID)
+	require.Len(t, result.Tiers, 2)
+
+	amount := int64(60)
+	fee, err := store.QuoteTransferFee(context.Background(), account1, amount)
+	require.NoError(t, err)
+	require.Equal(t, int64(6), fee)
+
+	transferResult, err := store.TransferTx(context.Background(), TransferTxParams{
+		FromAccountID: account1.ID,
+		ToAccountID:   account2.ID,
+		Amount:        amount,
+	})
+	require.NoError(t, err)
+	require.Equal(t, fee, transferResult.Transfer.Fee)
+	require.Equal(t, account1.ID, transferResult.FeeEntry.AccountID)
+	require.Equal(t, -fee, transferResult.FeeEntry.Amount)
+	require.Equal(t, account1.Balance-amount-fee, transferResult.FromAccount.Balance)
+	require.Equal(t, account2.Balance+amount, transferResult.ToAccount.Balance)
+
+	updatedRevenueAccount, err := testQueries.GetAccount(context.Background(), revenueAccount.ID)
+	require.NoError(t, err)
+	require.Equal(t, revenueAccount.Balance+fee, updatedRevenueAccount.Balance)
+
+	// no fee schedule for savings accounts in this currency
+	account3 := createAccountInCurrency(t, currency, util.Savings)
+	fee, err = store.QuoteTransferFee(context.Background(), account3, amount)
+	require.NoError(t, err)
+	require.Zero(t, fee)
+}
+
+func createAccountInCurrency(t *testing.T, currency, accountType string) Account {
+	user := createRandomUser(t)
+	account, err := testQueries.CreateAccount(context.Background(), CreateAccountParams{
+		Owner:    user.Username,
+		Balance:  util.RandomInt(1000, 2000),
+		Currency: currency,
+		Type:     accountType,
+		Nickname: util.RandomString(8),
+	})
+	require.NoError(t, err)
+	return account
+}
@@ -0,0 +1,127 @@
+package db
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/XiaozhouCui/go-bank/db/util"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCreateImportJobTx(t *testing.T) {
+	store := NewStore(testDB)
+	account1 := createRandomAccount(t)
+	account2 := createRandomAccount(t)
+
+	result, err := store.CreateImportJobTx(context.Background(), CreateImportJobTxParams{
+		Owner:  account1.Owner,
+		Format: ImportFormatCSV,
+		Lines: []CreateImportJobLineParams{
+			{
+				Line:          1,
+				FromAccountID: account1.ID,
+				ToAccountID:   account2.ID,
+				Amount:        10,
+				Currency:      account1.Currency,
+				Status:        ImportLineValid,
+			},
+			{
+				Line:          2,
+				FromAccountID: account1.ID,
+				ToAccountID:   0,
+				Amount:        10,
+				Currency:      account1.Currency,
+				Status:        ImportLineInvalid,
+				Error:         "to account [0] not found",
+			},
+		},
+	})
+	require.NoError(t, err)
+
+	// a single invalid line rejects the job
+	require.Equal(t, ImportJobRejected, result.Job.Status)
+	require.Equal(t, int64(2), result.Job.LineCount)
+	require.Equal(t, int64(1), result.Job.InvalidCount)
+	require.Len(t, result.Lines, 2)
+	for _, line := range result.Lines {
+		require.Equal(t, result.Job.ID, line.JobID)
+	}
+
+	// a rejected job cannot be started
+	_, err = store.StartImportJob(context.Background(), result.Job.ID)
+	require.ErrorIs(t, err, ErrRecordNotFound)
+}
+
+func TestExecuteImportLineTx(t *testing.T) {
+	store := NewStore(testDB)
+
+	// use a currency of its own, so no fee schedule applies
+	currency := strings.ToUpper(util.RandomString(6))
+	account1 := createAccountInCurrency(t, currency, util.Checking)
+	account2 := createAccountInCurrency(t, currency, util.Checking)
+	account1, err := testQueries.AddAccountBalance(context.Background(), AddAccountBalanceParams{
+		ID:     account1.ID,
+		Amount: 100,
+	})
+	require.NoError(t, err)
+
+	line := func(number, amount int64) CreateImportJobLineParams {
+		return CreateImportJobLineParams{
+			Line:          number,
+			FromAccountID: account1.ID,
+			ToAccountID:   account2.ID,
+			Amount:        amount,
+			Currency:      currency,
+			Status:        ImportLineValid,
+		}
+	}
+	created, err := store.CreateImportJobTx(context.Background(), CreateImportJobTxParams{
+		Owner:  account1.Owner,
+		Format: ImportFormatCSV,
+		Lines:  []CreateImportJobLineParams{line(1, 60), line(2, 60)},
+	})
+	require.NoError(t, err)
+	require.Equal(t, ImportJobValidated, created.Job.Status)
+
+	job, err := store.StartImportJob(context.Background(), created.Job.ID)
+	require.NoError(t, err)
+	require.Equal(t, ImportJobExecuting, job.Status)
+
+	result, err := store.ExecuteImportLineTx(context.Background(), created.Lines[0].ID)
+	require.NoError(t, err)
+	require.Equal(t, ImportLineSucceeded, result.Line.Status)
+	require.Equal(t, result.Transfer.Transfer.ID, result.Line.TransferID.Int64)
+	require.Equal(t, account1.Balance-60, result.Transfer.FromAccount.Balance)
+
+	// a line is paid once
+	_, err = store.ExecuteImportLineTx(context.Background(), created.Lines[0].ID)
+	require.ErrorIs(t, err, ErrImportLineNotPending)
+
+	// the second line would overdraw the sender, nothing is written
+	_, err = store.ExecuteImportLineTx(context.Background(), created.Lines[1].ID)
+	require.ErrorIs(t, err, ErrInsufficientFunds)
+
+	failed, err := store.FailImportJobLine(context.Background(), FailImportJobLineParams{
+		ID:    created.Lines[1].ID,
+		Error: err.Error(),
+	})
+	require.NoError(t, err)
+	require.Equal(t, ImportLineFailed, failed.Status)
+	require.False(t, failed.TransferID.Valid)
+
+	// a line that is not valid anymore keeps its outcome
+	_, err = store.FailImportJobLine(context.Background(), FailImportJobLineParams{
+		ID:    created.Lines[0].ID,
+		Error: "too late",
+	})
+	require.ErrorIs(t, err, ErrRecordNotFound)
+
+	sender, err := store.GetAccount(context.Background(), account1.ID)
+	require.NoError(t, err)
+	require.Equal(t, account1.Balance-60, sender.Balance)
+
+	job, err = store.CompleteImportJob(context.Background(), job.ID)
+	require.NoError(t, err)
+	require.Equal(t, ImportJobCompleted, job.Status)
+}
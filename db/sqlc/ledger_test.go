@@ -0,0 +1,112 @@
+package db
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/XiaozhouCui/go-bank/db/util"
+	"github.com/stretchr/testify/require"
+)
+
+func TestListAccountBalanceMismatches(t *testing.T) {
+	// an account created with a balance but no entries does not add up
+	account := createRandomAccount(t)
+	_, err := testQueries.AddAccountBalance(context.Background(), AddAccountBalanceParams{
+		ID:     account.ID,
+		Amount: 1,
+	})
+	require.NoError(t, err)
+
+	mismatches, err := testQueries.ListAccountBalanceMismatches(context.Background(), 1)
+	require.NoError(t, err)
+	require.Len(t, mismatches, 1)
+	require.Equal(t, account.ID, mismatches[0].AccountID)
+	require.Equal(t, account.Balance+1, mismatches[0].Balance)
+	require.Zero(t, mismatches[0].EntriesTotal)
+	require.GreaterOrEqual(t, mismatches[0].MismatchCount, int64(1))
+}
+
+func TestListUnbalancedTransfers(t *testing.T) {
+	store := NewStore(testDB)
+
+	currency := strings.ToUpper(util.RandomString(6))
+	account1 := createAccountInCurrency(t, currency, util.Checking)
+	account2 := createAccountInCurrency(t, currency, util.Checking)
+
+	result, err := store.TransferTx(context.Background(), TransferTxParams{
+		FromAccountID: account1.ID,
+		ToAccountID:   account2.ID,
+		Amount:        10,
+	})
+	require.NoError(t, err)
+	require.Equal(t, result.Transfer.ID, result.FromEntry.TransferID.Int64)
+	require.Equal(t, result.Transfer.ID, result.ToEntry.TransferID.Int64)
+
+	// a transfer recorded without its entries is unbalanced
+	transfer, err := testQueries.CreateTransfer(context.Background(), CreateTransferParams{
+		FromAccountID: account1.ID,
+		ToAccountID:   account2.ID,
+		Amount:        10,
+	})
+	require.NoError(t, err)
+
+	unbalanced, err := testQueries.ListUnbalancedTransfers(context.Background(), 2)
+	require.NoError(t, err)
+	require.NotEmpty(t, unbalanced)
+	require.Equal(t, transfer.ID, unbalanced[0].TransferID)
+	require.Zero(t, unbalanced[0].EntryCount)
+	for _, row := range unbalanced {
+		require.NotEqual(t, result.Transfer.ID, row.TransferID)
+	}
+
+	// both accounts add up to their entries, and the entries of the currency to zero
+	totals, err := testQueries.ListCurrencyTotals(context.Background())
+	require.NoError(t, err)
+	for _, total := range totals {
+		if total.Currency == currency {
+			require.Zero(t, total.EntriesTotal)
+			require.Equal(t, account1.Balance+account2.Balance, total.BalanceTotal)
+		}
+	}
+}
+
+func TestListUnbalancedTransfersFeeEqualsAmount(t *testing.T) {
+	store := NewStore(testDB)
+
+	currency := strings.ToUpper(util.RandomString(6))
+	account1 := createAccountInCurrency(t, currency, util.Checking)
+	account2 := createAccountInCurrency(t, currency, util.Checking)
+	revenueAccount := createAccountInCurrency(t, currency, util.Internal)
+	_, err := testQueries.CreateSystemAccount(context.Background(), CreateSystemAccountParams{
+		Purpose:   SystemAccountFeeRevenue,
+		Currency:  currency,
+		AccountID: revenueAccount.ID,
+	})
+	require.NoError(t, err)
+
+	_, err = store.CreateFeeScheduleTx(context.Background(), CreateFeeScheduleTxParams{
+		CreateFeeScheduleParams: CreateFeeScheduleParams{
+			Currency:    currency,
+			AccountType: util.Checking,
+			Kind:        FeeKindTiered,
+		},
+		Tiers: []CreateFeeTierParams{{MinAmount: 0, FlatFee: 10}},
+	})
+	require.NoError(t, err)
+
+	// the sender has two entries of -10: the amount and the fee
+	result, err := store.TransferTx(context.Background(), TransferTxParams{
+		FromAccountID: account1.ID,
+		ToAccountID:   account2.ID,
+		Amount:        10,
+	})
+	require.NoError(t, err)
+	require.Equal(t, result.FromEntry.Amount, result.FeeEntry.Amount)
+
+	unbalanced, err := testQueries.ListUnbalancedTransfers(context.Background(), 10)
+	require.NoError(t, err)
+	for _, row := range unbalanced {
+		require.NotEqual(t, result.Transfer.ID, row.TransferID)
+	}
+}
@@ -1,18 +1,18 @@
 package db
 
 import (
-	"database/sql"
+	"context"
 	"log"
 	"os"
 	"testing"
 
 	"github.com/XiaozhouCui/go-bank/db/util"
-	_ "github.com/lib/pq"
+	"github.com/jackc/pgx/v5/pgxpool"
 )
 
 var testQueries *Queries
 
-var testDB *sql.DB
+var testDB *pgxpool.Pool
 
 // TestMain is the entry point for all tests.
 func TestMain(m *testing.M) {
@@ -20,7 +20,7 @@ func TestMain(m *testing.M) {
 	if err != nil {
 		log.Fatal("cannot load fonfig:", err)
 	}
-	testDB, err = sql.Open(config.DBDriver, config.DBSource)
+	testDB, err = NewConnPool(context.Background(), config)
 	if err != nil {
 		log.Fatal("cannot connect to db:", err)
 	}
@@ -6,6 +6,8 @@ package db
 
 import (
 	"time"
+
+	"github.com/jackc/pgx/v5/pgtype"
 )
 
 type Account struct {
@@ -14,6 +16,77 @@ type Account struct {
 	Balance   int64     `json:"balance"`
 	Currency  string    `json:"currency"`
 	CreatedAt time.Time `json:"created_at"`
+	Status    string    `json:"status"`
+	Type      string    `json:"type"`
+	Nickname  string    `json:"nickname"`
+	// funds reserved by pending holds, they still count in the balance but cannot be spent
+	HeldAmount int64 `json:"held_amount"`
+	// balance minus held_amount
+	AvailableBalance int64 `json:"available_balance"`
+	// external account number given to customers, with check digits
+	Number string `json:"number"`
+}
+
+type AccountInterestPlan struct {
+	AccountID int64     `json:"account_id"`
+	PlanID    int64     `json:"plan_id"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+type AccountMember struct {
+	AccountID int64  `json:"account_id"`
+	Username  string `json:"username"`
+	Role      string `json:"role"`
+	// largest amount a spender can send per transfer
+	SpendLimit pgtype.Int8 `json:"spend_limit"`
+	InvitedBy  string      `json:"invited_by"`
+	// null until the invited user accepts, an invitation gives no access
+	AcceptedAt pgtype.Timestamptz `json:"accepted_at"`
+	CreatedAt  time.Time          `json:"created_at"`
+}
+
+type AccountStatusChange struct {
+	ID         int64     `json:"id"`
+	AccountID  int64     `json:"account_id"`
+	FromStatus string    `json:"from_status"`
+	ToStatus   string    `json:"to_status"`
+	Reason     string    `json:"reason"`
+	ChangedBy  string    `json:"changed_by"`
+	CreatedAt  time.Time `json:"created_at"`
+}
+
+type ApprovalPolicy struct {
+	AccountID int64 `json:"account_id"`
+	// transfers of at least this amount from the account need approvals
+	Threshold         int64 `json:"threshold"`
+	RequiredApprovals int32 `json:"required_approvals"`
+	// usernames of the members who can approve, never the requester of a transfer
+	Approvers []string  `json:"approvers"`
+	UpdatedBy string    `json:"updated_by"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+type AuditLog struct {
+	ID int64 `json:"id"`
+	// username from the access token, empty for anonymous requests like sign ups
+	Actor     string `json:"actor"`
+	ActorRole string `json:"actor_role"`
+	// method and route of the request, like POST /accounts/:id/close
+	Action     string `json:"action"`
+	TargetType string `json:"target_type"`
+	TargetID   string `json:"target_id"`
+	RequestID  string `json:"request_id"`
+	ClientIp   string `json:"client_ip"`
+	// HTTP status of the response
+	Status int64 `json:"status"`
+	// json, not jsonb, so the hashed text is kept as is
+	Before    []byte    `json:"before"`
+	After     []byte    `json:"after"`
+	CreatedAt time.Time `json:"created_at"`
+	// hash of the previous entry, empty for the first one
+	PrevHash string `json:"prev_hash"`
+	// hex SHA-256 of the entry and prev_hash
+	Hash string `json:"hash"`
 }
 
 type Entry struct {
@@ -22,6 +95,276 @@ type Entry struct {
 	// can be negative or positive
 	Amount    int64     `json:"amount"`
 	CreatedAt time.Time `json:"created_at"`
+	// transfer that made the entry, null for entries made before it was recorded
+	TransferID pgtype.Int8 `json:"transfer_id"`
+	// balance of the account right after the entry
+	BalanceAfter int64 `json:"balance_after"`
+	// labels of the account holders on their side of the transfer, never shown to the other side
+	Tags []string `json:"tags"`
+}
+
+type FeeSchedule struct {
+	ID       int64  `json:"id"`
+	Currency string `json:"currency"`
+	// type of the sending account
+	AccountType string `json:"account_type"`
+	Kind        string `json:"kind"`
+	FlatFee     int64  `json:"flat_fee"`
+	// percentage of the amount in basis points, 50 is 0.50%
+	RateBps int64 `json:"rate_bps"`
+	MinFee  int64 `json:"min_fee"`
+	// 0 means no cap
+	MaxFee    int64     `json:"max_fee"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+type FeeTier struct {
+	ID         int64 `json:"id"`
+	ScheduleID int64 `json:"schedule_id"`
+	// the tier applies from this amount up to the next tier
+	MinAmount int64 `json:"min_amount"`
+	FlatFee   int64 `json:"flat_fee"`
+	RateBps   int64 `json:"rate_bps"`
+}
+
+type Hold struct {
+	ID        int64 `json:"id"`
+	AccountID int64 `json:"account_id"`
+	// account credited when the hold is captured
+	ToAccountID int64 `json:"to_account_id"`
+	Amount      int64 `json:"amount"`
+	// can be less than amount, the rest is released
+	CapturedAmount int64  `json:"captured_amount"`
+	Status         string `json:"status"`
+	// transfer made by the capture
+	TransferID pgtype.Int8 `json:"transfer_id"`
+	CreatedBy  string      `json:"created_by"`
+	ExpiresAt  time.Time   `json:"expires_at"`
+	CreatedAt  time.Time   `json:"created_at"`
+	UpdatedAt  time.Time   `json:"updated_at"`
+}
+
+type ImportJob struct {
+	ID        int64  `json:"id"`
+	Owner     string `json:"owner"`
+	Format    string `json:"format"`
+	Status    string `json:"status"`
+	LineCount int64  `json:"line_count"`
+	// lines that failed validation, a job with invalid lines is rejected
+	InvalidCount int64     `json:"invalid_count"`
+	CreatedAt    time.Time `json:"created_at"`
+	UpdatedAt    time.Time `json:"updated_at"`
+}
+
+type ImportJobLine struct {
+	ID    int64 `json:"id"`
+	JobID int64 `json:"job_id"`
+	// position of the instruction in the file, from 1
+	Line int64 `json:"line"`
+	// as given in the file, not a foreign key: an invalid line may name an unknown account
+	FromAccountID int64  `json:"from_account_id"`
+	ToAccountID   int64  `json:"to_account_id"`
+	Amount        int64  `json:"amount"`
+	Currency      string `json:"currency"`
+	// end to end reference of the instruction
+	Reference string `json:"reference"`
+	Status    string `json:"status"`
+	// why the line is invalid or failed
+	Error string `json:"error"`
+	// transfer made by the line
+	TransferID pgtype.Int8 `json:"transfer_id"`
+	UpdatedAt  time.Time   `json:"updated_at"`
+}
+
+type InterestAccrual struct {
+	ID          int64     `json:"id"`
+	AccountID   int64     `json:"account_id"`
+	AccrualDate time.Time `json:"accrual_date"`
+	// end-of-day balance
+	Balance       int64  `json:"balance"`
+	AnnualRateBps int64  `json:"annual_rate_bps"`
+	DayCount      string `json:"day_count"`
+	// interest in millionths of the minor currency unit
+	AmountMicros int64     `json:"amount_micros"`
+	CreatedAt    time.Time `json:"created_at"`
+}
+
+type InterestPlan struct {
+	ID   int64  `json:"id"`
+	Name string `json:"name"`
+	// annual rate in basis points, 250 is 2.50%
+	AnnualRateBps int64 `json:"annual_rate_bps"`
+	// ACT/365, ACT/360, ACT/ACT or 30/360
+	DayCount  string    `json:"day_count"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+type InterestPosting struct {
+	ID            int64     `json:"id"`
+	AccountID     int64     `json:"account_id"`
+	PeriodStart   time.Time `json:"period_start"`
+	PeriodEnd     time.Time `json:"period_end"`
+	AccruedMicros int64     `json:"accrued_micros"`
+	// interest credited to the account, in the minor currency unit
+	Amount int64 `json:"amount"`
+	// fraction too small to credit, carried to the next period
+	RemainderMicros int64       `json:"remainder_micros"`
+	TransferID      pgtype.Int8 `json:"transfer_id"`
+	CreatedAt       time.Time   `json:"created_at"`
+}
+
+type ManualAdjustment struct {
+	ID        int64 `json:"id"`
+	AccountID int64 `json:"account_id"`
+	// credited to the account if positive, debited if negative
+	Amount int64  `json:"amount"`
+	Reason string `json:"reason"`
+	// support ticket or case the adjustment is documented in
+	Reference  string    `json:"reference"`
+	CreatedBy  string    `json:"created_by"`
+	TransferID int64     `json:"transfer_id"`
+	CreatedAt  time.Time `json:"created_at"`
+}
+
+type Outbox struct {
+	ID        int64  `json:"id"`
+	EventType string `json:"event_type"`
+	// version of the JSON schema of the payload for its event type
+	SchemaVersion int64     `json:"schema_version"`
+	Payload       []byte    `json:"payload"`
+	CreatedAt     time.Time `json:"created_at"`
+	// null until the relay has published the event
+	PublishedAt pgtype.Timestamptz `json:"published_at"`
+	// failed attempts to publish the event
+	Attempts  int64  `json:"attempts"`
+	LastError string `json:"last_error"`
+}
+
+type Payee struct {
+	ID       int64  `json:"id"`
+	Owner    string `json:"owner"`
+	Nickname string `json:"nickname"`
+	// account the payee receives money in
+	AccountID int64  `json:"account_id"`
+	Currency  string `json:"currency"`
+	// start of the cooling-off period
+	CreatedAt time.Time `json:"created_at"`
+}
+
+type PaymentRequest struct {
+	ID        int64  `json:"id"`
+	Requester string `json:"requester"`
+	Payer     string `json:"payer"`
+	// account of the requester credited when the request is paid
+	ToAccountID int64  `json:"to_account_id"`
+	Amount      int64  `json:"amount"`
+	Currency    string `json:"currency"`
+	// shown to the payer
+	Message string `json:"message"`
+	Status  string `json:"status"`
+	// transfer made by the payment
+	TransferID pgtype.Int8 `json:"transfer_id"`
+	ExpiresAt  time.Time   `json:"expires_at"`
+	CreatedAt  time.Time   `json:"created_at"`
+	UpdatedAt  time.Time   `json:"updated_at"`
+}
+
+type PendingTransfer struct {
+	ID            int64  `json:"id"`
+	FromAccountID int64  `json:"from_account_id"`
+	ToAccountID   int64  `json:"to_account_id"`
+	Amount        int64  `json:"amount"`
+	RequestedBy   string `json:"requested_by"`
+	// copied from the policy when the transfer was requested
+	RequiredApprovals int32 `json:"required_approvals"`
+	// copied from the policy when the transfer was requested
+	Approvers []string `json:"approvers"`
+	Status    string   `json:"status"`
+	// transfer made once the quorum was reached
+	TransferID  pgtype.Int8 `json:"transfer_id"`
+	CreatedAt   time.Time   `json:"created_at"`
+	UpdatedAt   time.Time   `json:"updated_at"`
+	Description string      `json:"description"`
+	Reference   string      `json:"reference"`
+	// put on the entry of the sender once the transfer is made
+	Tags []string `json:"tags"`
+}
+
+type PolicyChange struct {
+	ID        int64 `json:"id"`
+	AccountID int64 `json:"account_id"`
+	// the new policy, NULL with the other new_ columns when the change removes the policy
+	NewThreshold         pgtype.Int8 `json:"new_threshold"`
+	NewRequiredApprovals pgtype.Int4 `json:"new_required_approvals"`
+	NewApprovers         []string    `json:"new_approvers"`
+	RequestedBy          string      `json:"requested_by"`
+	// copied from the policy when the change was requested
+	RequiredApprovals int32 `json:"required_approvals"`
+	// copied from the policy when the change was requested
+	Approvers []string  `json:"approvers"`
+	Status    string    `json:"status"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+type PolicyChangeApproval struct {
+	PolicyChangeID int64     `json:"policy_change_id"`
+	Approver       string    `json:"approver"`
+	Decision       string    `json:"decision"`
+	CreatedAt      time.Time `json:"created_at"`
+}
+
+type ScheduledTransfer struct {
+	ID            int64  `json:"id"`
+	Owner         string `json:"owner"`
+	FromAccountID int64  `json:"from_account_id"`
+	ToAccountID   int64  `json:"to_account_id"`
+	Amount        int64  `json:"amount"`
+	Currency      string `json:"currency"`
+	// recurrence rule like FREQ=MONTHLY;BYMONTHDAY=1, empty for a one-off transfer
+	Schedule string `json:"schedule"`
+	// first occurrence, later occurrences follow the schedule from it
+	StartAt time.Time `json:"start_at"`
+	// null once there is nothing left to run
+	NextRunAt pgtype.Timestamptz `json:"next_run_at"`
+	// retry: try again up to max_retries times, skip: wait for the next occurrence
+	FailurePolicy string    `json:"failure_policy"`
+	MaxRetries    int64     `json:"max_retries"`
+	RetryCount    int64     `json:"retry_count"`
+	Status        string    `json:"status"`
+	CreatedAt     time.Time `json:"created_at"`
+	UpdatedAt     time.Time `json:"updated_at"`
+}
+
+type ScheduledTransferRun struct {
+	ID                  int64     `json:"id"`
+	ScheduledTransferID int64     `json:"scheduled_transfer_id"`
+	ScheduledFor        time.Time `json:"scheduled_for"`
+	// starts at 1, increases with each retry of the same occurrence
+	Attempt    int64       `json:"attempt"`
+	Outcome    string      `json:"outcome"`
+	TransferID pgtype.Int8 `json:"transfer_id"`
+	Error      string      `json:"error"`
+	CreatedAt  time.Time   `json:"created_at"`
+}
+
+type Statement struct {
+	ID          int64     `json:"id"`
+	AccountID   int64     `json:"account_id"`
+	PeriodStart time.Time `json:"period_start"`
+	// exclusive, the start of the next period
+	PeriodEnd      time.Time `json:"period_end"`
+	OpeningBalance int64     `json:"opening_balance"`
+	ClosingBalance int64     `json:"closing_balance"`
+	Csv            []byte    `json:"csv"`
+	Pdf            []byte    `json:"pdf"`
+	CreatedAt      time.Time `json:"created_at"`
+}
+
+type SystemAccount struct {
+	Purpose   string `json:"purpose"`
+	Currency  string `json:"currency"`
+	AccountID int64  `json:"account_id"`
 }
 
 type Transfer struct {
@@ -31,6 +374,37 @@ type Transfer struct {
 	// must be positive
 	Amount    int64     `json:"amount"`
 	CreatedAt time.Time `json:"created_at"`
+	// paid by the sender on top of the amount
+	Fee int64 `json:"fee"`
+	// free text from the sender, shown to both sides
+	Description string `json:"description"`
+	// end to end reference from the sender, passed on unchanged to the recipient
+	Reference string `json:"reference"`
+	// user who sent the transfer, counted towards their spend limit on the from account; NULL for transfers made by the bank
+	SentBy pgtype.Text `json:"sent_by"`
+}
+
+type TransferApproval struct {
+	PendingTransferID int64     `json:"pending_transfer_id"`
+	Approver          string    `json:"approver"`
+	Decision          string    `json:"decision"`
+	CreatedAt         time.Time `json:"created_at"`
+}
+
+type TransferLimit struct {
+	ID        int64       `json:"id"`
+	Username  pgtype.Text `json:"username"`
+	AccountID pgtype.Int8 `json:"account_id"`
+	// largest single transfer
+	MaxAmount int64 `json:"max_amount"`
+	// total sent per calendar day (UTC)
+	DailyAmount int64 `json:"daily_amount"`
+	// total sent per calendar month (UTC)
+	MonthlyAmount int64 `json:"monthly_amount"`
+	// number of transfers per calendar day (UTC)
+	DailyCount int64     `json:"daily_count"`
+	UpdatedBy  string    `json:"updated_by"`
+	UpdatedAt  time.Time `json:"updated_at"`
 }
 
 type User struct {
@@ -40,4 +414,46 @@ type User struct {
 	Email             string    `json:"email"`
 	PasswordChangedAt time.Time `json:"password_changed_at"`
 	CreatedAt         time.Time `json:"created_at"`
+	Role              string    `json:"role"`
+}
+
+type WebhookDelivery struct {
+	ID             int64 `json:"id"`
+	SubscriptionID int64 `json:"subscription_id"`
+	// outbox event the delivery was made from
+	EventID   int64  `json:"event_id"`
+	EventType string `json:"event_type"`
+	// data of the customer event
+	Payload []byte `json:"payload"`
+	// a delivery is dead once it failed too many times
+	Status        string    `json:"status"`
+	Attempts      int64     `json:"attempts"`
+	NextAttemptAt time.Time `json:"next_attempt_at"`
+	// status code of the last response, 0 if there was none
+	LastStatusCode int64              `json:"last_status_code"`
+	LastError      string             `json:"last_error"`
+	DeliveredAt    pgtype.Timestamptz `json:"delivered_at"`
+	CreatedAt      time.Time          `json:"created_at"`
+}
+
+type WebhookDeliveryAttempt struct {
+	ID         int64 `json:"id"`
+	DeliveryID int64 `json:"delivery_id"`
+	// 0 if the endpoint did not answer
+	StatusCode int64     `json:"status_code"`
+	Error      string    `json:"error"`
+	DurationMs int64     `json:"duration_ms"`
+	CreatedAt  time.Time `json:"created_at"`
+}
+
+type WebhookSubscription struct {
+	ID         int64    `json:"id"`
+	Owner      string   `json:"owner"`
+	Url        string   `json:"url"`
+	EventTypes []string `json:"event_types"`
+	// signs the deliveries with HMAC-SHA256
+	Secret string `json:"secret"`
+	// false once the owner deleted the subscription
+	Active    bool      `json:"active"`
+	CreatedAt time.Time `json:"created_at"`
 }
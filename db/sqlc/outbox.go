@@ -0,0 +1,24 @@
+package db
+
+import (
+	"context"
+	"encoding/json"
+
+	"github.com/XiaozhouCui/go-bank/events"
+)
+
+// addEvent writes an event to the outbox using the queries of an open transaction,
+// so the event is published if and only if the transaction commits.
+func addEvent(ctx context.Context, q *Queries, payload events.Payload) error {
+	data, err := json.Marshal(payload)
+	if err != nil {
+		return err
+	}
+
+	_, err = q.CreateOutboxEvent(ctx, CreateOutboxEventParams{
+		EventType:     payload.EventType(),
+		SchemaVersion: payload.SchemaVersion(),
+		Payload:       data,
+	})
+	return err
+}
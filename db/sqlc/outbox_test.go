@@ -0,0 +1,124 @@
+package db
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+
+	"github.com/XiaozhouCui/go-bank/db/util"
+	"github.com/XiaozhouCui/go-bank/events"
+	"github.com/stretchr/testify/require"
+)
+
+// outboxMarker writes an event to the outbox, the events written after it are listed by eventsAfter
+func outboxMarker(t *testing.T) Outbox {
+	marker, err := testQueries.CreateOutboxEvent(context.Background(), CreateOutboxEventParams{
+		EventType:     "Marker",
+		SchemaVersion: 1,
+		Payload:       []byte(`{}`),
+	})
+	require.NoError(t, err)
+	return marker
+}
+
+// eventsAfter returns the pending events of a type written after the marker
+func eventsAfter(t *testing.T, marker Outbox, eventType string) []Outbox {
+	pending, err := testQueries.ListPendingOutboxEvents(context.Background(), ListPendingOutboxEventsParams{
+		AfterID: marker.ID,
+		Limit:   100,
+	})
+	require.NoError(t, err)
+
+	var result []Outbox
+	for _, event := range pending {
+		if event.EventType == eventType {
+			result = append(result, event)
+		}
+	}
+	return result
+}
+
+func TestCreateUserTx(t *testing.T) {
+	store := NewStore(testDB)
+	marker := outboxMarker(t)
+
+	user, err := store.CreateUserTx(context.Background(), CreateUserParams{
+		Username:       util.RandomOwner(),
+		HashedPassword: "secret",
+		FullName:       util.RandomOwner(),
+		Email:          util.RandomEmail(),
+	})
+	require.NoError(t, err)
+
+	written := eventsAfter(t, marker, events.UserRegistered)
+	require.Len(t, written, 1)
+	require.Equal(t, int64(1), written[0].SchemaVersion)
+	require.False(t, written[0].PublishedAt.Valid)
+
+	var payload events.UserRegisteredV1
+	require.NoError(t, json.Unmarshal(written[0].Payload, &payload))
+	require.Equal(t, user.Username, payload.Username)
+	require.Equal(t, user.Email, payload.Email)
+	require.NotContains(t, string(written[0].Payload), "secret")
+}
+
+func TestCreateAccountTx(t *testing.T) {
+	store := NewStore(testDB)
+	user := createRandomUser(t)
+	marker := outboxMarker(t)
+
+	account, err := store.CreateAccountTx(context.Background(), CreateAccountParams{
+		Owner:    user.Username,
+		Currency: util.RandomCurrency(),
+		Type:     util.Checking,
+	})
+	require.NoError(t, err)
+
+	written := eventsAfter(t, marker, events.AccountCreated)
+	require.Len(t, written, 1)
+
+	var payload events.AccountCreatedV1
+	require.NoError(t, json.Unmarshal(written[0].Payload, &payload))
+	require.Equal(t, account.ID, payload.AccountID)
+	require.Equal(t, account.Currency, payload.Currency)
+
+	// nothing is written if the account cannot be created
+	marker = outboxMarker(t)
+	_, err = store.CreateAccountTx(context.Background(), CreateAccountParams{
+		Owner:    user.Username,
+		Currency: account.Currency,
+		Type:     util.Checking,
+	})
+	require.Equal(t, UniqueViolation, ErrorCode(err))
+	require.Empty(t, eventsAfter(t, marker, events.AccountCreated))
+}
+
+func TestTransferTxEvent(t *testing.T) {
+	store := NewStore(testDB)
+	account1 := createRandomAccount(t)
+	account2 := createRandomAccount(t)
+	marker := outboxMarker(t)
+
+	result, err := store.TransferTx(context.Background(), TransferTxParams{
+		FromAccountID: account1.ID,
+		ToAccountID:   account2.ID,
+		Amount:        10,
+	})
+	require.NoError(t, err)
+
+	written := eventsAfter(t, marker, events.TransferCompleted)
+	require.Len(t, written, 1)
+
+	var payload events.TransferCompletedV1
+	require.NoError(t, json.Unmarshal(written[0].Payload, &payload))
+	require.Equal(t, result.Transfer.ID, payload.TransferID)
+	require.Equal(t, account1.ID, payload.FromAccountID)
+	require.Equal(t, account2.ID, payload.ToAccountID)
+	require.Equal(t, int64(10), payload.Amount)
+	require.Equal(t, result.Transfer.Fee, payload.Fee)
+	require.Equal(t, account1.Currency, payload.Currency)
+
+	// a published event is not pending anymore
+	require.NoError(t, testQueries.MarkOutboxEventPublished(context.Background(), written[0].ID))
+	require.Empty(t, eventsAfter(t, marker, events.TransferCompleted))
+}
@@ -0,0 +1,109 @@
+package db
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/XiaozhouCui/go-bank/db/util"
+	"github.com/stretchr/testify/require"
+)
+
+func createRandomPayee(t *testing.T, owner User) Payee {
+	account := createRandomAccount(t)
+
+	arg := CreatePayeeParams{
+		Owner:     owner.Username,
+		Nickname:  util.RandomOwner(),
+		AccountID: account.ID,
+		Currency:  account.Currency,
+	}
+	payee, err := testQueries.CreatePayee(context.Background(), arg)
+	require.NoError(t, err)
+	require.Equal(t, arg.Owner, payee.Owner)
+	require.Equal(t, arg.Nickname, payee.Nickname)
+	require.Equal(t, arg.AccountID, payee.AccountID)
+	require.Equal(t, arg.Currency, payee.Currency)
+	require.NotZero(t, payee.CreatedAt)
+	return payee
+}
+
+func TestPayees(t *testing.T) {
+	owner := createRandomUser(t)
+	payee1 := createRandomPayee(t, owner)
+	payee2 := createRandomPayee(t, owner)
+
+	payees, err := testQueries.ListPayees(context.Background(), ListPayeesParams{
+		Owner: owner.Username,
+		Limit: 5,
+	})
+	require.NoError(t, err)
+	require.Equal(t, []Payee{payee1, payee2}, payees)
+
+	// an account is only once in an address book
+	_, err = testQueries.CreatePayee(context.Background(), CreatePayeeParams{
+		Owner:     owner.Username,
+		Nickname:  util.RandomOwner(),
+		AccountID: payee1.AccountID,
+		Currency:  payee1.Currency,
+	})
+	require.Equal(t, UniqueViolation, ErrorCode(err))
+
+	// and so is a nickname
+	_, err = testQueries.UpdatePayee(context.Background(), UpdatePayeeParams{
+		ID:       payee2.ID,
+		Nickname: payee1.Nickname,
+	})
+	require.Equal(t, UniqueViolation, ErrorCode(err))
+
+	renamed, err := testQueries.UpdatePayee(context.Background(), UpdatePayeeParams{
+		ID:       payee2.ID,
+		Nickname: util.RandomOwner(),
+	})
+	require.NoError(t, err)
+	require.Equal(t, payee2.AccountID, renamed.AccountID)
+	require.Equal(t, payee2.CreatedAt, renamed.CreatedAt)
+
+	require.NoError(t, testQueries.DeletePayee(context.Background(), payee1.ID))
+	_, err = testQueries.GetPayee(context.Background(), payee1.ID)
+	require.ErrorIs(t, err, ErrRecordNotFound)
+}
+
+func TestSumPayeeTransfers(t *testing.T) {
+	sender := createRandomAccount(t)
+	owner, err := testQueries.GetUser(context.Background(), sender.Owner)
+	require.NoError(t, err)
+
+	payee := createRandomPayee(t, owner)
+	got, err := testQueries.GetPayeeByAccount(context.Background(), GetPayeeByAccountParams{
+		Owner:     owner.Username,
+		AccountID: payee.AccountID,
+	})
+	require.NoError(t, err)
+	require.Equal(t, payee, got)
+
+	_, err = testQueries.CreateTransfer(context.Background(), CreateTransferParams{
+		FromAccountID: sender.ID,
+		ToAccountID:   payee.AccountID,
+		Amount:        100,
+	})
+	require.NoError(t, err)
+	_, err = testQueries.CreateHold(context.Background(), CreateHoldParams{
+		AccountID:   sender.ID,
+		ToAccountID: payee.AccountID,
+		Amount:      50,
+		CreatedBy:   owner.Username,
+		ExpiresAt:   time.Now().Add(time.Hour),
+	})
+	require.NoError(t, err)
+	// sent by someone else, it does not count
+	createRandomTransfer(t, createRandomAccount(t), Account{ID: payee.AccountID})
+
+	total, err := testQueries.SumPayeeTransfers(context.Background(), SumPayeeTransfersParams{
+		Owner:       owner.Username,
+		ToAccountID: payee.AccountID,
+		Since:       payee.CreatedAt,
+	})
+	require.NoError(t, err)
+	require.Equal(t, int64(150), total)
+}
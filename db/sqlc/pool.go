@@ -0,0 +1,32 @@
+package db
+
+import (
+	"context"
+
+	"github.com/XiaozhouCui/go-bank/db/util"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// NewConnPool creates a pgx connection pool sized according to the config.
+// Pool settings left at zero fall back to the pgxpool defaults.
+func NewConnPool(ctx context.Context, config util.Config) (*pgxpool.Pool, error) {
+	poolConfig, err := pgxpool.ParseConfig(config.DBSource)
+	if err != nil {
+		return nil, err
+	}
+
+	if config.DBMaxConns > 0 {
+		poolConfig.MaxConns = config.DBMaxConns
+	}
+	if config.DBMinConns > 0 {
+		poolConfig.MinConns = config.DBMinConns
+	}
+	if config.DBMaxConnLifetime > 0 {
+		poolConfig.MaxConnLifetime = config.DBMaxConnLifetime
+	}
+	if config.DBMaxConnIdleTime > 0 {
+		poolConfig.MaxConnIdleTime = config.DBMaxConnIdleTime
+	}
+
+	return pgxpool.NewWithConfig(ctx, poolConfig)
+}
@@ -6,24 +6,156 @@ package db
 
 import (
 	"context"
+	"time"
 )
 
 type Querier interface {
+	AcceptAccountMember(ctx context.Context, arg AcceptAccountMemberParams) (AccountMember, error)
 	AddAccountBalance(ctx context.Context, arg AddAccountBalanceParams) (Account, error)
+	AddAccountHeldAmount(ctx context.Context, arg AddAccountHeldAmountParams) (Account, error)
+	ClaimWebhookDelivery(ctx context.Context, arg ClaimWebhookDeliveryParams) (WebhookDelivery, error)
+	CompleteImportJob(ctx context.Context, id int64) (ImportJob, error)
+	CountPolicyChangeApprovals(ctx context.Context, policyChangeID int64) (int64, error)
+	CountTransferApprovals(ctx context.Context, pendingTransferID int64) (int64, error)
 	CreateAccount(ctx context.Context, arg CreateAccountParams) (Account, error)
+	CreateAccountMember(ctx context.Context, arg CreateAccountMemberParams) (AccountMember, error)
+	CreateAccountStatusChange(ctx context.Context, arg CreateAccountStatusChangeParams) (AccountStatusChange, error)
+	CreateAuditLogEntry(ctx context.Context, arg CreateAuditLogEntryParams) (AuditLog, error)
 	CreateEntry(ctx context.Context, arg CreateEntryParams) (Entry, error)
+	CreateFeeSchedule(ctx context.Context, arg CreateFeeScheduleParams) (FeeSchedule, error)
+	CreateFeeTier(ctx context.Context, arg CreateFeeTierParams) (FeeTier, error)
+	CreateHold(ctx context.Context, arg CreateHoldParams) (Hold, error)
+	CreateImportJob(ctx context.Context, arg CreateImportJobParams) (ImportJob, error)
+	CreateImportJobLine(ctx context.Context, arg CreateImportJobLineParams) (ImportJobLine, error)
+	CreateInterestAccrual(ctx context.Context, arg CreateInterestAccrualParams) error
+	CreateInterestPlan(ctx context.Context, arg CreateInterestPlanParams) (InterestPlan, error)
+	CreateInterestPosting(ctx context.Context, arg CreateInterestPostingParams) (InterestPosting, error)
+	CreateManualAdjustment(ctx context.Context, arg CreateManualAdjustmentParams) (ManualAdjustment, error)
+	CreateOutboxEvent(ctx context.Context, arg CreateOutboxEventParams) (Outbox, error)
+	CreatePayee(ctx context.Context, arg CreatePayeeParams) (Payee, error)
+	CreatePaymentRequest(ctx context.Context, arg CreatePaymentRequestParams) (PaymentRequest, error)
+	CreatePendingTransfer(ctx context.Context, arg CreatePendingTransferParams) (PendingTransfer, error)
+	CreatePolicyChange(ctx context.Context, arg CreatePolicyChangeParams) (PolicyChange, error)
+	CreatePolicyChangeApproval(ctx context.Context, arg CreatePolicyChangeApprovalParams) (PolicyChangeApproval, error)
+	CreateScheduledTransfer(ctx context.Context, arg CreateScheduledTransferParams) (ScheduledTransfer, error)
+	CreateScheduledTransferRun(ctx context.Context, arg CreateScheduledTransferRunParams) (ScheduledTransferRun, error)
+	CreateStatement(ctx context.Context, arg CreateStatementParams) (Statement, error)
+	CreateSystemAccount(ctx context.Context, arg CreateSystemAccountParams) (SystemAccount, error)
 	CreateTransfer(ctx context.Context, arg CreateTransferParams) (Transfer, error)
+	CreateTransferApproval(ctx context.Context, arg CreateTransferApprovalParams) (TransferApproval, error)
 	CreateUser(ctx context.Context, arg CreateUserParams) (User, error)
+	CreateWebhookDelivery(ctx context.Context, arg CreateWebhookDeliveryParams) error
+	CreateWebhookDeliveryAttempt(ctx context.Context, arg CreateWebhookDeliveryAttemptParams) (WebhookDeliveryAttempt, error)
+	CreateWebhookSubscription(ctx context.Context, arg CreateWebhookSubscriptionParams) (WebhookSubscription, error)
+	DeactivateWebhookSubscription(ctx context.Context, id int64) (WebhookSubscription, error)
 	DeleteAccount(ctx context.Context, id int64) error
+	DeleteAccountMember(ctx context.Context, arg DeleteAccountMemberParams) error
+	DeleteAccountTransferLimit(ctx context.Context, accountID int64) error
+	DeleteApprovalPolicy(ctx context.Context, accountID int64) error
+	DeletePayee(ctx context.Context, id int64) error
+	DeleteUserTransferLimit(ctx context.Context, username string) error
+	ExpirePaymentRequests(ctx context.Context, now time.Time) ([]PaymentRequest, error)
+	FailImportJobLine(ctx context.Context, arg FailImportJobLineParams) (ImportJobLine, error)
 	GetAccount(ctx context.Context, id int64) (Account, error)
+	GetAccountBalanceAt(ctx context.Context, arg GetAccountBalanceAtParams) (int64, error)
+	GetAccountByNumber(ctx context.Context, number string) (Account, error)
 	GetAccountForUpdate(ctx context.Context, id int64) (Account, error)
+	GetAccountMember(ctx context.Context, arg GetAccountMemberParams) (AccountMember, error)
+	GetAccountTransferLimit(ctx context.Context, accountID int64) (TransferLimit, error)
+	GetApprovalPolicy(ctx context.Context, accountID int64) (ApprovalPolicy, error)
+	GetDefaultTransferLimit(ctx context.Context) (TransferLimit, error)
 	GetEntry(ctx context.Context, id int64) (Entry, error)
+	GetFeeSchedule(ctx context.Context, arg GetFeeScheduleParams) (FeeSchedule, error)
+	GetHold(ctx context.Context, id int64) (Hold, error)
+	GetHoldForUpdate(ctx context.Context, id int64) (Hold, error)
+	GetImportJob(ctx context.Context, id int64) (ImportJob, error)
+	GetImportJobLineForUpdate(ctx context.Context, id int64) (ImportJobLine, error)
+	GetInterestPlan(ctx context.Context, id int64) (InterestPlan, error)
+	GetInterestPosting(ctx context.Context, arg GetInterestPostingParams) (InterestPosting, error)
+	GetLastAuditLogEntry(ctx context.Context) (AuditLog, error)
+	GetLastInterestPosting(ctx context.Context, arg GetLastInterestPostingParams) (InterestPosting, error)
+	GetOutgoingTransferTotalsByAccount(ctx context.Context, arg GetOutgoingTransferTotalsByAccountParams) (GetOutgoingTransferTotalsByAccountRow, error)
+	GetOutgoingTransferTotalsByOwner(ctx context.Context, arg GetOutgoingTransferTotalsByOwnerParams) (GetOutgoingTransferTotalsByOwnerRow, error)
+	GetOutgoingTransferTotalsBySender(ctx context.Context, arg GetOutgoingTransferTotalsBySenderParams) (GetOutgoingTransferTotalsBySenderRow, error)
+	GetPayee(ctx context.Context, id int64) (Payee, error)
+	GetPayeeByAccount(ctx context.Context, arg GetPayeeByAccountParams) (Payee, error)
+	GetPaymentRequest(ctx context.Context, id int64) (PaymentRequest, error)
+	GetPaymentRequestForUpdate(ctx context.Context, id int64) (PaymentRequest, error)
+	GetPendingTransfer(ctx context.Context, id int64) (PendingTransfer, error)
+	GetPendingTransferForUpdate(ctx context.Context, id int64) (PendingTransfer, error)
+	GetPolicyChange(ctx context.Context, id int64) (PolicyChange, error)
+	GetPolicyChangeForUpdate(ctx context.Context, id int64) (PolicyChange, error)
+	GetScheduledTransfer(ctx context.Context, id int64) (ScheduledTransfer, error)
+	GetScheduledTransferForUpdate(ctx context.Context, id int64) (ScheduledTransfer, error)
+	GetStatement(ctx context.Context, arg GetStatementParams) (Statement, error)
+	GetSystemAccount(ctx context.Context, arg GetSystemAccountParams) (Account, error)
 	GetTransfer(ctx context.Context, id int64) (Transfer, error)
 	GetUser(ctx context.Context, username string) (User, error)
+	GetUserForUpdate(ctx context.Context, username string) (User, error)
+	GetUserTransferLimit(ctx context.Context, username string) (TransferLimit, error)
+	GetWebhookDelivery(ctx context.Context, id int64) (WebhookDelivery, error)
+	GetWebhookSubscription(ctx context.Context, id int64) (WebhookSubscription, error)
+	ListAccountBalanceMismatches(ctx context.Context, limit int32) ([]ListAccountBalanceMismatchesRow, error)
+	ListAccountEntries(ctx context.Context, arg ListAccountEntriesParams) ([]ListAccountEntriesRow, error)
+	ListAccountMembers(ctx context.Context, accountID int64) ([]AccountMember, error)
+	ListAccountStatusChanges(ctx context.Context, arg ListAccountStatusChangesParams) ([]AccountStatusChange, error)
 	ListAccounts(ctx context.Context, arg ListAccountsParams) ([]Account, error)
+	ListActiveWebhookSubscriptions(ctx context.Context, owner string) ([]WebhookSubscription, error)
+	ListAuditLog(ctx context.Context, arg ListAuditLogParams) ([]AuditLog, error)
+	ListAuditLogAfter(ctx context.Context, arg ListAuditLogAfterParams) ([]AuditLog, error)
+	ListCurrencyTotals(ctx context.Context) ([]ListCurrencyTotalsRow, error)
+	ListDueScheduledTransfers(ctx context.Context, arg ListDueScheduledTransfersParams) ([]ScheduledTransfer, error)
+	ListDueWebhookDeliveries(ctx context.Context, arg ListDueWebhookDeliveriesParams) ([]WebhookDelivery, error)
 	ListEntries(ctx context.Context, arg ListEntriesParams) ([]Entry, error)
+	ListExpiredHolds(ctx context.Context, arg ListExpiredHoldsParams) ([]Hold, error)
+	ListFeeSchedules(ctx context.Context, arg ListFeeSchedulesParams) ([]FeeSchedule, error)
+	ListFeeTiers(ctx context.Context, scheduleID int64) ([]FeeTier, error)
+	ListHolds(ctx context.Context, arg ListHoldsParams) ([]Hold, error)
+	ListImportJobLines(ctx context.Context, jobID int64) ([]ImportJobLine, error)
+	ListImportJobs(ctx context.Context, arg ListImportJobsParams) ([]ImportJob, error)
+	ListIncomingPaymentRequests(ctx context.Context, arg ListIncomingPaymentRequestsParams) ([]PaymentRequest, error)
+	ListInterestBearingAccounts(ctx context.Context, arg ListInterestBearingAccountsParams) ([]ListInterestBearingAccountsRow, error)
+	ListInterestPlans(ctx context.Context, arg ListInterestPlansParams) ([]InterestPlan, error)
+	ListManualAdjustments(ctx context.Context, arg ListManualAdjustmentsParams) ([]ManualAdjustment, error)
+	ListOutgoingPaymentRequests(ctx context.Context, arg ListOutgoingPaymentRequestsParams) ([]PaymentRequest, error)
+	ListPayees(ctx context.Context, arg ListPayeesParams) ([]Payee, error)
+	ListPendingOutboxEvents(ctx context.Context, arg ListPendingOutboxEventsParams) ([]Outbox, error)
+	ListPendingTransfers(ctx context.Context, arg ListPendingTransfersParams) ([]PendingTransfer, error)
+	ListPolicyChangeApprovals(ctx context.Context, policyChangeID int64) ([]PolicyChangeApproval, error)
+	ListPolicyChanges(ctx context.Context, arg ListPolicyChangesParams) ([]PolicyChange, error)
+	ListScheduledTransferRuns(ctx context.Context, arg ListScheduledTransferRunsParams) ([]ScheduledTransferRun, error)
+	ListScheduledTransfers(ctx context.Context, arg ListScheduledTransfersParams) ([]ScheduledTransfer, error)
+	ListStatementEntries(ctx context.Context, arg ListStatementEntriesParams) ([]ListStatementEntriesRow, error)
+	ListTransferApprovals(ctx context.Context, pendingTransferID int64) ([]TransferApproval, error)
 	ListTransfers(ctx context.Context, arg ListTransfersParams) ([]Transfer, error)
+	ListUnbalancedTransfers(ctx context.Context, limit int32) ([]ListUnbalancedTransfersRow, error)
+	ListWebhookDeliveries(ctx context.Context, arg ListWebhookDeliveriesParams) ([]WebhookDelivery, error)
+	ListWebhookDeliveryAttempts(ctx context.Context, deliveryID int64) ([]WebhookDeliveryAttempt, error)
+	ListWebhookSubscriptions(ctx context.Context, arg ListWebhookSubscriptionsParams) ([]WebhookSubscription, error)
+	LockAuditLog(ctx context.Context) error
+	MarkOutboxEventPublished(ctx context.Context, id int64) error
+	RecordOutboxEventFailure(ctx context.Context, arg RecordOutboxEventFailureParams) error
+	RetryWebhookDelivery(ctx context.Context, id int64) (WebhookDelivery, error)
+	SearchUsers(ctx context.Context, arg SearchUsersParams) ([]User, error)
+	SetAccountInterestPlan(ctx context.Context, arg SetAccountInterestPlanParams) (AccountInterestPlan, error)
+	SetAccountTransferLimit(ctx context.Context, arg SetAccountTransferLimitParams) (TransferLimit, error)
+	SetApprovalPolicy(ctx context.Context, arg SetApprovalPolicyParams) (ApprovalPolicy, error)
+	SetEntryTags(ctx context.Context, arg SetEntryTagsParams) (Entry, error)
+	SetUserTransferLimit(ctx context.Context, arg SetUserTransferLimitParams) (TransferLimit, error)
+	StartImportJob(ctx context.Context, id int64) (ImportJob, error)
+	SucceedImportJobLine(ctx context.Context, arg SucceedImportJobLineParams) (ImportJobLine, error)
+	SumInterestAccruals(ctx context.Context, arg SumInterestAccrualsParams) (int64, error)
+	SumPayeeTransfers(ctx context.Context, arg SumPayeeTransfersParams) (int64, error)
 	UpdateAccount(ctx context.Context, arg UpdateAccountParams) (Account, error)
+	UpdateAccountStatus(ctx context.Context, arg UpdateAccountStatusParams) (Account, error)
+	UpdateDefaultTransferLimit(ctx context.Context, arg UpdateDefaultTransferLimitParams) (TransferLimit, error)
+	UpdateHold(ctx context.Context, arg UpdateHoldParams) (Hold, error)
+	UpdatePayee(ctx context.Context, arg UpdatePayeeParams) (Payee, error)
+	UpdatePaymentRequest(ctx context.Context, arg UpdatePaymentRequestParams) (PaymentRequest, error)
+	UpdatePendingTransfer(ctx context.Context, arg UpdatePendingTransferParams) (PendingTransfer, error)
+	UpdatePolicyChangeStatus(ctx context.Context, arg UpdatePolicyChangeStatusParams) (PolicyChange, error)
+	UpdateScheduledTransfer(ctx context.Context, arg UpdateScheduledTransferParams) (ScheduledTransfer, error)
+	UpdateWebhookDelivery(ctx context.Context, arg UpdateWebhookDeliveryParams) (WebhookDelivery, error)
 }
 
 var _ Querier = (*Queries)(nil)
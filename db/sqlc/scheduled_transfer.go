@@ -0,0 +1,157 @@
+package db
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+// scheduled transfer statuses, stored in scheduled_transfers.status
+const (
+	ScheduledTransferActive    = "active"    // runs when due
+	ScheduledTransferPaused    = "paused"    // paused by the owner, can be resumed
+	ScheduledTransferCompleted = "completed" // a one-off transfer that has run, final
+	ScheduledTransferFailed    = "failed"    // a one-off transfer that could not run, final
+	ScheduledTransferCancelled = "cancelled" // cancelled by the owner, final
+)
+
+// what to do when a scheduled transfer cannot run, stored in scheduled_transfers.failure_policy
+const (
+	FailurePolicyRetry = "retry" // try again later, up to max_retries times, then skip
+	FailurePolicySkip  = "skip"  // give up on this occurrence and wait for the next one
+)
+
+// outcomes of a scheduled transfer run, stored in scheduled_transfer_runs.outcome
+const (
+	RunOutcomeSucceeded = "succeeded"
+	RunOutcomeFailed    = "failed"
+)
+
+// recurrence frequencies of a schedule
+const (
+	FrequencyDaily   = "DAILY"
+	FrequencyWeekly  = "WEEKLY"
+	FrequencyMonthly = "MONTHLY"
+)
+
+// Recurrence is a parsed schedule, a small subset of the iCalendar RRULE,
+// e.g. "FREQ=MONTHLY;BYMONTHDAY=1" or "FREQ=WEEKLY;INTERVAL=2".
+type Recurrence struct {
+	Frequency string // DAILY, WEEKLY or MONTHLY
+	Interval  int    // every n days, weeks or months, 1 by default
+	MonthDay  int    // day of the month of a MONTHLY schedule, the day of the start by default
+}
+
+// ParseRecurrence parses a schedule. The parts are separated by ";" and can come in any order.
+func ParseRecurrence(spec string) (Recurrence, error) {
+	rule := Recurrence{Interval: 1}
+
+	for _, part := range strings.Split(spec, ";") {
+		key, value, found := strings.Cut(strings.TrimSpace(part), "=")
+		if !found {
+			return rule, fmt.Errorf("invalid schedule part %q", part)
+		}
+
+		switch strings.ToUpper(key) {
+		case "FREQ":
+			rule.Frequency = strings.ToUpper(value)
+		case "INTERVAL":
+			n, err := strconv.Atoi(value)
+			if err != nil || n < 1 || n > 366 {
+				return rule, fmt.Errorf("invalid schedule interval %q", value)
+			}
+			rule.Interval = n
+		case "BYMONTHDAY":
+			n, err := strconv.Atoi(value)
+			if err != nil || n < 1 || n > 31 {
+				return rule, fmt.Errorf("invalid schedule month day %q", value)
+			}
+			rule.MonthDay = n
+		default:
+			return rule, fmt.Errorf("unsupported schedule part %q", key)
+		}
+	}
+
+	switch rule.Frequency {
+	case FrequencyDaily, FrequencyWeekly:
+		if rule.MonthDay != 0 {
+			return rule, errors.New("BYMONTHDAY needs a MONTHLY schedule")
+		}
+	case FrequencyMonthly:
+	default:
+		return rule, fmt.Errorf("unsupported schedule frequency %q", rule.Frequency)
+	}
+	return rule, nil
+}
+
+// Next returns the first occurrence strictly after a point in time.
+// Occurrences start at start and keep its time of day (UTC); a month day past
+// the end of a short month falls on its last day, e.g. the 31st runs on April 30th.
+func (rule Recurrence) Next(start, after time.Time) time.Time {
+	start = start.UTC()
+	if after.Before(start) {
+		return start
+	}
+
+	if rule.Frequency == FrequencyMonthly {
+		months := (after.Year()-start.Year())*12 + int(after.Month()-start.Month())
+		k := months/rule.Interval - 1
+		if k < 0 {
+			k = 0
+		}
+		for {
+			t := rule.monthlyOccurrence(start, k)
+			if !t.Before(start) && t.After(after) {
+				return t
+			}
+			k++
+		}
+	}
+
+	step := rule.Interval
+	if rule.Frequency == FrequencyWeekly {
+		step *= 7
+	}
+	k := int(after.Sub(start).Hours()/24) / step
+	t := start.AddDate(0, 0, k*step)
+	for !t.After(after) {
+		t = t.AddDate(0, 0, step)
+	}
+	return t
+}
+
+// monthlyOccurrence returns the occurrence k intervals after the month of start.
+func (rule Recurrence) monthlyOccurrence(start time.Time, k int) time.Time {
+	day := rule.MonthDay
+	if day == 0 {
+		day = start.Day()
+	}
+
+	month := time.Date(start.Year(), start.Month()+time.Month(k*rule.Interval), 1, 0, 0, 0, 0, time.UTC)
+	if last := month.AddDate(0, 1, -1).Day(); day > last {
+		day = last
+	}
+	return time.Date(month.Year(), month.Month(), day,
+		start.Hour(), start.Minute(), start.Second(), start.Nanosecond(), time.UTC)
+}
+
+// NextScheduledRun returns when a scheduled transfer runs next after a point in time.
+// A one-off transfer (empty spec) only runs at start, the result is null once start has passed.
+func NextScheduledRun(spec string, start, after time.Time) (pgtype.Timestamptz, error) {
+	if spec == "" {
+		if start.After(after) {
+			return pgtype.Timestamptz{Time: start, Valid: true}, nil
+		}
+		return pgtype.Timestamptz{}, nil
+	}
+
+	rule, err := ParseRecurrence(spec)
+	if err != nil {
+		return pgtype.Timestamptz{}, err
+	}
+	return pgtype.Timestamptz{Time: rule.Next(start, after), Valid: true}, nil
+}
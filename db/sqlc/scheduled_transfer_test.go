@@ -0,0 +1,259 @@
+package db
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/XiaozhouCui/go-bank/db/util"
+	"github.com/jackc/pgx/v5/pgtype"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseRecurrence(t *testing.T) {
+	testCases := []struct {
+		spec  string
+		rule  Recurrence
+		valid bool
+	}{
+		{"FREQ=DAILY", Recurrence{Frequency: FrequencyDaily, Interval: 1}, true},
+		{"freq=weekly;interval=2", Recurrence{Frequency: FrequencyWeekly, Interval: 2}, true},
+		{"BYMONTHDAY=31;FREQ=MONTHLY", Recurrence{Frequency: FrequencyMonthly, Interval: 1, MonthDay: 31}, true},
+		{"", Recurrence{}, false},
+		{"FREQ=YEARLY", Recurrence{}, false},
+		{"FREQ=DAILY;INTERVAL=0", Recurrence{}, false},
+		{"FREQ=WEEKLY;BYMONTHDAY=1", Recurrence{}, false},
+		{"FREQ=DAILY;COUNT=3", Recurrence{}, false},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.spec, func(t *testing.T) {
+			rule, err := ParseRecurrence(tc.spec)
+			if !tc.valid {
+				require.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			require.Equal(t, tc.rule, rule)
+		})
+	}
+}
+
+func TestRecurrenceNext(t *testing.T) {
+	start := time.Date(2023, time.January, 31, 9, 0, 0, 0, time.UTC)
+
+	testCases := []struct {
+		name  string
+		spec  string
+		after time.Time
+		next  time.Time
+	}{
+		{"BeforeStart", "FREQ=DAILY", start.Add(-time.Hour), start},
+		{"AtStart", "FREQ=DAILY", start, start.AddDate(0, 0, 1)},
+		{"Daily", "FREQ=DAILY", start.Add(30 * time.Hour), start.AddDate(0, 0, 2)},
+		{"EveryTwoWeeks", "FREQ=WEEKLY;INTERVAL=2", start.AddDate(0, 0, 14), start.AddDate(0, 0, 28)},
+		{"MonthEnd", "FREQ=MONTHLY", start, time.Date(2023, time.February, 28, 9, 0, 0, 0, time.UTC)},
+		{"MonthEndAfterShortMonth", "FREQ=MONTHLY", time.Date(2023, time.March, 1, 0, 0, 0, 0, time.UTC),
+			time.Date(2023, time.March, 31, 9, 0, 0, 0, time.UTC)},
+		{"MonthDay", "FREQ=MONTHLY;BYMONTHDAY=15", start, time.Date(2023, time.February, 15, 9, 0, 0, 0, time.UTC)},
+		{"Quarterly", "FREQ=MONTHLY;INTERVAL=3;BYMONTHDAY=1", start, time.Date(2023, time.April, 1, 9, 0, 0, 0, time.UTC)},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			rule, err := ParseRecurrence(tc.spec)
+			require.NoError(t, err)
+			require.Equal(t, tc.next, rule.Next(start, tc.after))
+		})
+	}
+}
+
+func scheduleTransfer(t *testing.T, from, to Account, amount int64, schedule string, startAt time.Time) ScheduledTransfer {
+	scheduled, err := testQueries.CreateScheduledTransfer(context.Background(), CreateScheduledTransferParams{
+		Owner:         from.Owner,
+		FromAccountID: from.ID,
+		ToAccountID:   to.ID,
+		Amount:        amount,
+		Currency:      from.Currency,
+		Schedule:      schedule,
+		StartAt:       startAt,
+		NextRunAt:     pgtype.Timestamptz{Time: startAt, Valid: true},
+		FailurePolicy: FailurePolicyRetry,
+		MaxRetries:    1,
+	})
+	require.NoError(t, err)
+	require.Equal(t, ScheduledTransferActive, scheduled.Status)
+	return scheduled
+}
+
+func TestRunScheduledTransferTx(t *testing.T) {
+	store := NewStore(testDB)
+
+	// use a currency of its own, so no fee schedule applies
+	currency := strings.ToUpper(util.RandomString(6))
+	account1 := createAccountInCurrency(t, currency, util.Checking)
+	account2 := createAccountInCurrency(t, currency, util.Checking)
+
+	startAt := time.Now().UTC().Truncate(time.Second).Add(-time.Minute)
+	scheduled := scheduleTransfer(t, account1, account2, account1.Balance+1, "FREQ=DAILY", startAt)
+
+	// the sender cannot be overdrawn by a scheduled transfer
+	_, err := store.RunScheduledTransferTx(context.Background(), RunScheduledTransferTxParams{
+		ID:    scheduled.ID,
+		DueAt: startAt,
+		Now:   startAt,
+	})
+	require.ErrorIs(t, err, ErrInsufficientFunds)
+
+	// retried after the delay
+	failed, err := store.FailScheduledTransferTx(context.Background(), FailScheduledTransferTxParams{
+		ID:         scheduled.ID,
+		DueAt:      startAt,
+		Now:        startAt,
+		Error:      err.Error(),
+		RetryDelay: time.Second,
+	})
+	require.NoError(t, err)
+	require.Equal(t, RunOutcomeFailed, failed.Run.Outcome)
+	require.Equal(t, int64(1), failed.ScheduledTransfer.RetryCount)
+	retryAt := startAt.Add(time.Second)
+	require.WithinDuration(t, retryAt, failed.ScheduledTransfer.NextRunAt.Time, time.Millisecond)
+
+	_, err = testQueries.AddAccountBalance(context.Background(), AddAccountBalanceParams{
+		ID:     account1.ID,
+		Amount: 1,
+	})
+	require.NoError(t, err)
+
+	result, err := store.RunScheduledTransferTx(context.Background(), RunScheduledTransferTxParams{
+		ID:    scheduled.ID,
+		DueAt: retryAt,
+		Now:   retryAt,
+	})
+	require.NoError(t, err)
+	require.Equal(t, RunOutcomeSucceeded, result.Run.Outcome)
+	require.Equal(t, int64(2), result.Run.Attempt)
+	require.Equal(t, result.Transfer.Transfer.ID, result.Run.TransferID.Int64)
+	require.Zero(t, result.Transfer.FromAccount.Balance)
+	require.Zero(t, result.ScheduledTransfer.RetryCount)
+	require.WithinDuration(t, startAt.AddDate(0, 0, 1), result.ScheduledTransfer.NextRunAt.Time, time.Millisecond)
+
+	// the same occurrence is never paid twice
+	_, err = store.RunScheduledTransferTx(context.Background(), RunScheduledTransferTxParams{
+		ID:    scheduled.ID,
+		DueAt: retryAt,
+		Now:   retryAt,
+	})
+	require.ErrorIs(t, err, ErrScheduledTransferNotDue)
+}
+
+func TestRunOneOffScheduledTransferTx(t *testing.T) {
+	store := NewStore(testDB)
+
+	currency := strings.ToUpper(util.RandomString(6))
+	account1 := createAccountInCurrency(t, currency, util.Checking)
+	account2 := createAccountInCurrency(t, currency, util.Checking)
+	_, err := testQueries.AddAccountBalance(context.Background(), AddAccountBalanceParams{
+		ID:     account1.ID,
+		Amount: 10,
+	})
+	require.NoError(t, err)
+
+	startAt := time.Now().UTC().Truncate(time.Second)
+	scheduled := scheduleTransfer(t, account1, account2, 10, "", startAt)
+
+	result, err := store.RunScheduledTransferTx(context.Background(), RunScheduledTransferTxParams{
+		ID:    scheduled.ID,
+		DueAt: startAt,
+		Now:   startAt,
+	})
+	require.NoError(t, err)
+	require.Equal(t, ScheduledTransferCompleted, result.ScheduledTransfer.Status)
+	require.False(t, result.ScheduledTransfer.NextRunAt.Valid)
+	require.Equal(t, account2.Balance+10, result.Transfer.ToAccount.Balance)
+}
+
+func TestRunScheduledTransferTxRemovedSpender(t *testing.T) {
+	store := NewStore(testDB)
+
+	currency := strings.ToUpper(util.RandomString(6))
+	account1 := createAccountInCurrency(t, currency, util.Checking)
+	account2 := createAccountInCurrency(t, currency, util.Checking)
+
+	spender := createRandomAccountMember(t, account1, AccountSpender, pgtype.Int8{Int64: 10, Valid: true})
+	_, err := testQueries.AcceptAccountMember(context.Background(), AcceptAccountMemberParams{
+		AccountID: account1.ID,
+		Username:  spender.Username,
+	})
+	require.NoError(t, err)
+
+	startAt := time.Now().UTC().Truncate(time.Second)
+	sender := account1
+	sender.Owner = spender.Username
+	scheduled := scheduleTransfer(t, sender, account2, 10, "FREQ=DAILY", startAt)
+
+	err = testQueries.DeleteAccountMember(context.Background(), DeleteAccountMemberParams{
+		AccountID: account1.ID,
+		Username:  spender.Username,
+	})
+	require.NoError(t, err)
+
+	// a removed member cannot keep paying from the account
+	_, err = store.RunScheduledTransferTx(context.Background(), RunScheduledTransferTxParams{
+		ID:    scheduled.ID,
+		DueAt: startAt,
+		Now:   startAt,
+	})
+	require.ErrorIs(t, err, ErrSenderNotAllowed)
+
+	failed, err := store.FailScheduledTransferTx(context.Background(), FailScheduledTransferTxParams{
+		ID:         scheduled.ID,
+		DueAt:      startAt,
+		Now:        startAt,
+		Error:      err.Error(),
+		RetryDelay: time.Second,
+		Cancel:     true,
+	})
+	require.NoError(t, err)
+	require.Equal(t, RunOutcomeFailed, failed.Run.Outcome)
+	require.Equal(t, ScheduledTransferCancelled, failed.ScheduledTransfer.Status)
+	require.False(t, failed.ScheduledTransfer.NextRunAt.Valid)
+
+	account, err := testQueries.GetAccount(context.Background(), account1.ID)
+	require.NoError(t, err)
+	require.Equal(t, account1.Balance, account.Balance)
+}
+
+func TestRunScheduledTransferTxApprovalRequired(t *testing.T) {
+	store := NewStore(testDB)
+
+	currency := strings.ToUpper(util.RandomString(6))
+	account1 := createAccountInCurrency(t, currency, util.Checking)
+	account2 := createAccountInCurrency(t, currency, util.Checking)
+
+	startAt := time.Now().UTC().Truncate(time.Second)
+	scheduled := scheduleTransfer(t, account1, account2, 10, "FREQ=DAILY", startAt)
+
+	// a policy set after the transfer was scheduled covers its amount
+	approver := createRandomUser(t)
+	_, err := testQueries.SetApprovalPolicy(context.Background(), SetApprovalPolicyParams{
+		AccountID:         account1.ID,
+		Threshold:         10,
+		RequiredApprovals: 1,
+		Approvers:         []string{approver.Username},
+		UpdatedBy:         account1.Owner,
+	})
+	require.NoError(t, err)
+
+	_, err = store.RunScheduledTransferTx(context.Background(), RunScheduledTransferTxParams{
+		ID:    scheduled.ID,
+		DueAt: startAt,
+		Now:   startAt,
+	})
+	require.ErrorIs(t, err, ErrApprovalRequired)
+
+	account, err := testQueries.GetAccount(context.Background(), account1.ID)
+	require.NoError(t, err)
+	require.Equal(t, account1.Balance, account.Balance)
+}
@@ -0,0 +1,75 @@
+package db
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/XiaozhouCui/go-bank/db/util"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCreateStatement(t *testing.T) {
+	account := createRandomAccount(t)
+	start := time.Date(2023, time.January, 1, 0, 0, 0, 0, time.UTC)
+
+	arg := CreateStatementParams{
+		AccountID:      account.ID,
+		PeriodStart:    start,
+		PeriodEnd:      start.AddDate(0, 1, 0),
+		OpeningBalance: account.Balance,
+		ClosingBalance: account.Balance,
+		Csv:            []byte("date,entry_id,description,amount,balance\n"),
+		Pdf:            []byte("%PDF-1.4\n"),
+	}
+	statement, err := testQueries.CreateStatement(context.Background(), arg)
+	require.NoError(t, err)
+	require.Equal(t, arg.Csv, statement.Csv)
+	require.Equal(t, arg.Pdf, statement.Pdf)
+
+	got, err := testQueries.GetStatement(context.Background(), GetStatementParams{
+		AccountID:   account.ID,
+		PeriodStart: start,
+	})
+	require.NoError(t, err)
+	require.Equal(t, statement.ID, got.ID)
+	require.WithinDuration(t, arg.PeriodEnd, got.PeriodEnd, time.Second)
+
+	// one statement per account and month
+	_, err = testQueries.CreateStatement(context.Background(), arg)
+	require.Equal(t, UniqueViolation, ErrorCode(err))
+}
+
+func TestListStatementEntries(t *testing.T) {
+	store := NewStore(testDB)
+
+	// a currency without fee schedules
+	currency := strings.ToUpper(util.RandomString(6))
+	account1 := createAccountInCurrency(t, currency, util.Checking)
+	account2 := createAccountInCurrency(t, currency, util.Checking)
+
+	result, err := store.TransferTx(context.Background(), TransferTxParams{
+		FromAccountID: account1.ID,
+		ToAccountID:   account2.ID,
+		Amount:        10,
+	})
+	require.NoError(t, err)
+
+	entries, err := testQueries.ListStatementEntries(context.Background(), ListStatementEntriesParams{
+		AccountID:   account1.ID,
+		PeriodStart: time.Now().Add(-time.Hour),
+		PeriodEnd:   time.Now().Add(time.Hour),
+	})
+	require.NoError(t, err)
+	require.Len(t, entries, 1)
+
+	entry := entries[0]
+	require.Equal(t, result.FromEntry.ID, entry.ID)
+	require.Equal(t, int64(-10), entry.Amount)
+	require.Equal(t, result.FromAccount.Balance, entry.BalanceAfter)
+	require.Equal(t, result.Transfer.ID, entry.TransferID.Int64)
+	require.False(t, entry.Fee)
+	require.Equal(t, account2.ID, entry.CounterpartyAccountID.Int64)
+	require.Equal(t, account2.Owner, entry.CounterpartyOwner.String)
+}
@@ -2,37 +2,63 @@ package db
 
 import (
 	"context"
-	"database/sql"
 	"fmt"
+	"time"
+
+	"github.com/XiaozhouCui/go-bank/events"
+	"github.com/jackc/pgx/v5/pgtype"
+	"github.com/jackc/pgx/v5/pgxpool"
 )
 
 // Store interface provides all function signatures to execute db queries and transactions.
 type Store interface {
 	Querier
+	CreateUserTx(ctx context.Context, arg CreateUserParams) (User, error)
+	CreateAccountTx(ctx context.Context, arg CreateAccountParams) (Account, error)
 	TransferTx(ctx context.Context, arg TransferTxParams) (TransferTxResult, error)
+	BatchTransferTx(ctx context.Context, arg BatchTransferTxParams) (BatchTransferTxResult, error)
+	UpdateAccountStatusTx(ctx context.Context, arg UpdateAccountStatusTxParams) (UpdateAccountStatusTxResult, error)
+	PostInterestTx(ctx context.Context, arg PostInterestTxParams) (PostInterestTxResult, error)
+	CreateFeeScheduleTx(ctx context.Context, arg CreateFeeScheduleTxParams) (CreateFeeScheduleTxResult, error)
+	QuoteTransferFee(ctx context.Context, account Account, amount int64) (int64, error)
+	RunScheduledTransferTx(ctx context.Context, arg RunScheduledTransferTxParams) (RunScheduledTransferTxResult, error)
+	FailScheduledTransferTx(ctx context.Context, arg FailScheduledTransferTxParams) (FailScheduledTransferTxResult, error)
+	UpdateScheduledTransferTx(ctx context.Context, arg UpdateScheduledTransferTxParams) (ScheduledTransfer, error)
+	PlaceHoldTx(ctx context.Context, arg PlaceHoldTxParams) (HoldTxResult, error)
+	CaptureHoldTx(ctx context.Context, arg CaptureHoldTxParams) (HoldTxResult, error)
+	ReleaseHoldTx(ctx context.Context, arg ReleaseHoldTxParams) (HoldTxResult, error)
+	CreateImportJobTx(ctx context.Context, arg CreateImportJobTxParams) (CreateImportJobTxResult, error)
+	ExecuteImportLineTx(ctx context.Context, id int64) (ExecuteImportLineTxResult, error)
+	RecordWebhookAttemptTx(ctx context.Context, arg RecordWebhookAttemptTxParams) (WebhookDelivery, error)
+	AddAuditLogTx(ctx context.Context, arg AddAuditLogTxParams) (AuditLog, error)
+	ManualAdjustmentTx(ctx context.Context, arg ManualAdjustmentTxParams) (ManualAdjustmentTxResult, error)
+	PayPaymentRequestTx(ctx context.Context, arg PayPaymentRequestTxParams) (PayPaymentRequestTxResult, error)
+	ClosePaymentRequestTx(ctx context.Context, arg ClosePaymentRequestTxParams) (PaymentRequest, error)
+	DecidePendingTransferTx(ctx context.Context, arg DecidePendingTransferTxParams) (DecidePendingTransferTxResult, error)
+	DecidePolicyChangeTx(ctx context.Context, arg DecidePolicyChangeTxParams) (DecidePolicyChangeTxResult, error)
 }
 
 // SQLStore struct provides all functions to execute SQL queries and transactions.
 type SQLStore struct {
 	// composit: embed Queries struct to extend Store with all query methods.
 	*Queries
-	db *sql.DB
+	connPool *pgxpool.Pool
 }
 
 // NewStore creates a new store.
-func NewStore(db *sql.DB) Store {
+func NewStore(connPool *pgxpool.Pool) Store {
 	// Store interface has actual implementation of SQLStore connecting to real db
 	return &SQLStore{
-		db:      db,
-		Queries: New(db), // New() is generated by sqlc
+		connPool: connPool,
+		Queries:  New(connPool), // New() is generated by sqlc
 	}
 }
 
 // execTx executes a function within a database transaction.
 func (store *SQLStore) execTx(ctx context.Context, fn func(*Queries) error) error {
-	// BeginTx starts a transaction, and returns a transaction object
+	// Begin acquires a connection from the pool and starts a transaction on it.
 	// The provided context is used until the transaction is committed or rolled back.
-	tx, err := store.db.BeginTx(ctx, nil) // nil means use default tx options.
+	tx, err := store.connPool.Begin(ctx)
 	if err != nil {
 		return err
 	}
@@ -43,7 +69,7 @@ func (store *SQLStore) execTx(ctx context.Context, fn func(*Queries) error) erro
 	// Check if there is a transaction error.
 	if err != nil {
 		// Rollback returns an error if the transaction has already been committed or rolled back.
-		if rbErr := tx.Rollback(); rbErr != nil {
+		if rbErr := tx.Rollback(ctx); rbErr != nil {
 			// If there is an error rolling back, return both errors.
 			return fmt.Errorf("tx error: %v, rb error: %v", err, rbErr)
 		}
@@ -51,14 +77,20 @@ func (store *SQLStore) execTx(ctx context.Context, fn func(*Queries) error) erro
 		return err
 	}
 	// If there is no error, commit the transaction.
-	return tx.Commit()
+	return tx.Commit(ctx)
 }
 
 // TransferTxParams contains the input parameters of the transfer transaction.
 type TransferTxParams struct {
-	FromAccountID int64 `json:"from_account_id"`
-	ToAccountID   int64 `json:"to_account_id"`
-	Amount        int64 `json:"amount"`
+	FromAccountID int64  `json:"from_account_id"`
+	ToAccountID   int64  `json:"to_account_id"`
+	Amount        int64  `json:"amount"`
+	Description   string `json:"description"`
+	Reference     string `json:"reference"` // end to end reference from the sender
+	// tags of the sender, put on the entry for the from account only
+	Tags []string `json:"tags"`
+	// user who sends the transfer, it counts towards their spend limit if they are a member of the from account
+	SentBy string `json:"sent_by"`
 }
 
 // TransferTxResult contains the result of the transfer transaction.
@@ -68,60 +100,220 @@ type TransferTxResult struct {
 	ToAccount   Account  `json:"to_account"`   // amount after transfer
 	FromEntry   Entry    `json:"from_entry"`   // new entry for from account, records the money is moving out
 	ToEntry     Entry    `json:"to_entry"`     // new entry for to account, records the money is moving in
+	FeeEntry    Entry    `json:"fee_entry"`    // new entry for from account, records the fee paid; empty if the transfer is free
 }
 
 // TransferTx performs a transfer between two accounts within a database transaction.
 // It creates a transfer record, add account entries, and update account balances within a single transaction.
+// Like every transfer, it writes a TransferCompleted event to the outbox.
+// The sender also pays the fee of its fee schedule, which is credited to the fee revenue account.
+// It returns ErrTransferLimitExceeded if the transfer goes over the limits of the sender,
+// ErrSenderNotAllowed if SentBy cannot send it from the account, see checkSender,
+// ErrInsufficientFunds if it goes over its available balance, and ErrApprovalRequired
+// if the approval policy of the sender covers it: see DecidePendingTransferTx.
 func (store *SQLStore) TransferTx(ctx context.Context, arg TransferTxParams) (TransferTxResult, error) {
 	// create an empty result
 	var result TransferTxResult
 
 	// create and run new database transaction
 	err := store.execTx(ctx, func(q *Queries) error {
-		// start the callback function "fn"
 		var err error
-		// create transfer record, using the generated query method "CreateTransfer" from sqlc
-		result.Transfer, err = q.CreateTransfer(ctx, CreateTransferParams{
-			FromAccountID: arg.FromAccountID,
-			ToAccountID:   arg.ToAccountID,
-			Amount:        arg.Amount,
-		})
-		if err != nil {
-			return err
-		}
+		result, err = customerTransfer(ctx, q, arg)
+		return err
+	})
 
-		// add account entry for the FromAccount
-		result.FromEntry, err = q.CreateEntry(ctx, CreateEntryParams{
-			AccountID: arg.FromAccountID,
-			Amount:    -arg.Amount, // negative value: money is moving out
-		})
-		if err != nil {
-			return err
+	return result, err
+}
+
+// customerTransfer runs a transfer sent by a customer using the queries of an open transaction.
+// It checks the transfer limits of the sender and charges its fee on top of the transfer.
+// It returns ErrInsufficientFunds if the transfer and its fee spend more than the available balance,
+// so funds reserved by holds cannot be spent, and ErrApprovalRequired if the transfer needs approvals.
+func customerTransfer(ctx context.Context, q *Queries, arg TransferTxParams) (TransferTxResult, error) {
+	return heldTransfer(ctx, q, arg, 0)
+}
+
+// heldTransfer runs a customer transfer that may also spend up to held of the funds reserved by holds,
+// for a hold captured by the transfer
+func heldTransfer(ctx context.Context, q *Queries, arg TransferTxParams, held int64) (TransferTxResult, error) {
+	if err := checkNoApproval(ctx, q, arg.FromAccountID, arg.Amount); err != nil {
+		return TransferTxResult{}, err
+	}
+	return limitedTransfer(ctx, q, arg, held)
+}
+
+// approvedTransfer runs a customer transfer approved by the quorum of the approval policy of the sender
+func approvedTransfer(ctx context.Context, q *Queries, arg TransferTxParams) (TransferTxResult, error) {
+	return limitedTransfer(ctx, q, arg, 0)
+}
+
+// limitedTransfer runs a customer transfer within the limits of the sender, with its fee
+func limitedTransfer(ctx context.Context, q *Queries, arg TransferTxParams, held int64) (TransferTxResult, error) {
+	fromAccount, err := q.GetAccount(ctx, arg.FromAccountID)
+	if err != nil {
+		return TransferTxResult{}, err
+	}
+
+	now := time.Now()
+	if err = checkTransferLimits(ctx, q, fromAccount, arg.Amount, now); err != nil {
+		return TransferTxResult{}, err
+	}
+	// the owner is locked by the limits, so the spend of a member today is summed once
+	if arg.SentBy != "" {
+		if err = checkAccountSender(ctx, q, arg.SentBy, fromAccount, arg.Amount, now); err != nil {
+			return TransferTxResult{}, err
 		}
+	}
+
+	fee, err := transferFee(ctx, q, fromAccount, arg.Amount)
+	if err != nil {
+		return TransferTxResult{}, err
+	}
+
+	result, err := transfer(ctx, q, arg, fee)
+	if err != nil {
+		return result, err
+	}
+	// the sender is locked by the transfer, so its balance is checked once it is moved
+	if result.FromAccount.AvailableBalance+held < 0 {
+		return result, fmt.Errorf("account [%d]: %w", arg.FromAccountID, ErrInsufficientFunds)
+	}
+	return result, nil
+}
+
+// transfer moves money between two accounts using the queries of an open transaction.
+// It is shared by all transactions that move money, so they record transfers and entries the same way.
+// A non-zero fee is taken from the sender and credited to the fee revenue account of its currency.
+func transfer(ctx context.Context, q *Queries, arg TransferTxParams, fee int64) (TransferTxResult, error) {
+	var result TransferTxResult
+	var err error
+
+	// create transfer record, using the generated query method "CreateTransfer" from sqlc
+	result.Transfer, err = q.CreateTransfer(ctx, CreateTransferParams{
+		FromAccountID: arg.FromAccountID,
+		ToAccountID:   arg.ToAccountID,
+		Amount:        arg.Amount,
+		Fee:           fee,
+		Description:   arg.Description,
+		Reference:     arg.Reference,
+		SentBy:        pgtype.Text{String: arg.SentBy, Valid: arg.SentBy != ""},
+	})
+	if err != nil {
+		return result, err
+	}
+
+	// update account balances first: the entries record the balances after the transfer,
+	// and are created in the order the account rows are locked
+	if arg.FromAccountID < arg.ToAccountID {
+		// to avoid deadlock, always update the smaller account ID first
+		result.FromAccount, result.ToAccount, err = addMoney(ctx, q, arg.FromAccountID, -arg.Amount, arg.ToAccountID, arg.Amount)
+	} else {
+		result.ToAccount, result.FromAccount, err = addMoney(ctx, q, arg.ToAccountID, arg.Amount, arg.FromAccountID, -arg.Amount)
+	}
+	if err != nil {
+		return result, err
+	}
+
+	// money can only move between active accounts.
+	// the balance updates above hold both row locks, so the statuses cannot change until commit
+	if err = requireActiveAccount(result.FromAccount); err != nil {
+		return result, err
+	}
+	if err = requireActiveAccount(result.ToAccount); err != nil {
+		return result, err
+	}
+
+	// every entry points to the transfer that made it, so the ledger can be verified
+	transferID := pgtype.Int8{Int64: result.Transfer.ID, Valid: true}
 
-		// add account entry for the ToAccount
-		result.ToEntry, err = q.CreateEntry(ctx, CreateEntryParams{
-			AccountID: arg.ToAccountID,
-			Amount:    arg.Amount, // positive value: money is moving in
+	// add account entry for the FromAccount
+	result.FromEntry, err = q.CreateEntry(ctx, CreateEntryParams{
+		AccountID:    arg.FromAccountID,
+		Amount:       -arg.Amount, // negative value: money is moving out
+		TransferID:   transferID,
+		BalanceAfter: result.FromAccount.Balance,
+	})
+	if err != nil {
+		return result, err
+	}
+	if len(arg.Tags) > 0 {
+		result.FromEntry, err = q.SetEntryTags(ctx, SetEntryTagsParams{
+			ID:   result.FromEntry.ID,
+			Tags: arg.Tags,
 		})
 		if err != nil {
-			return err
+			return result, err
 		}
+	}
+
+	// add account entry for the ToAccount
+	result.ToEntry, err = q.CreateEntry(ctx, CreateEntryParams{
+		AccountID:    arg.ToAccountID,
+		Amount:       arg.Amount, // positive value: money is moving in
+		TransferID:   transferID,
+		BalanceAfter: result.ToAccount.Balance,
+	})
+	if err != nil {
+		return result, err
+	}
 
-		// update account balances
-		if arg.FromAccountID < arg.ToAccountID {
-			// to avoid deadlock, always update the smaller account ID first
-			result.FromAccount, result.ToAccount, err = addMoney(ctx, q, arg.FromAccountID, -arg.Amount, arg.ToAccountID, arg.Amount)
-		} else {
-			result.ToAccount, result.FromAccount, err = addMoney(ctx, q, arg.ToAccountID, arg.Amount, arg.FromAccountID, -arg.Amount)
+	if fee > 0 {
+		if err = chargeFee(ctx, q, &result, fee); err != nil {
+			return result, err
 		}
+	}
 
-		return nil
+	// downstream systems learn about the transfer from the outbox, written in the same transaction
+	err = addEvent(ctx, q, events.TransferCompletedV1{
+		TransferID:    result.Transfer.ID,
+		FromAccountID: arg.FromAccountID,
+		ToAccountID:   arg.ToAccountID,
+		Amount:        arg.Amount,
+		Fee:           fee,
+		Currency:      result.FromAccount.Currency,
+		CompletedAt:   result.Transfer.CreatedAt,
 	})
-
 	return result, err
 }
 
+// chargeFee moves the fee of a transfer from the sender to the fee revenue account.
+// The sender is already locked by the transfer, the revenue account is always locked last to avoid deadlocks.
+func chargeFee(ctx context.Context, q *Queries, result *TransferTxResult, fee int64) error {
+	revenueAccount, err := q.GetSystemAccount(ctx, GetSystemAccountParams{
+		Purpose:  SystemAccountFeeRevenue,
+		Currency: result.FromAccount.Currency,
+	})
+	if err != nil {
+		return err
+	}
+
+	var revenue Account
+	result.FromAccount, revenue, err = addMoney(ctx, q, result.FromAccount.ID, -fee, revenueAccount.ID, fee)
+	if err != nil {
+		return err
+	}
+
+	transferID := pgtype.Int8{Int64: result.Transfer.ID, Valid: true}
+
+	result.FeeEntry, err = q.CreateEntry(ctx, CreateEntryParams{
+		AccountID:    result.FromAccount.ID,
+		Amount:       -fee,
+		TransferID:   transferID,
+		BalanceAfter: result.FromAccount.Balance,
+	})
+	if err != nil {
+		return err
+	}
+
+	_, err = q.CreateEntry(ctx, CreateEntryParams{
+		AccountID:    revenueAccount.ID,
+		Amount:       fee,
+		TransferID:   transferID,
+		BalanceAfter: revenue.Balance,
+	})
+	return err
+}
+
 func addMoney(
 	ctx context.Context,
 	q *Queries,
@@ -143,3 +335,11 @@ func addMoney(
 	})
 	return
 }
+
+// requireActiveAccount returns ErrAccountNotActive if the account is frozen or closed.
+func requireActiveAccount(account Account) error {
+	if account.Status != AccountStatusActive {
+		return fmt.Errorf("account [%d] is %s: %w", account.ID, account.Status, ErrAccountNotActive)
+	}
+	return nil
+}
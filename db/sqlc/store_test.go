@@ -5,6 +5,7 @@ import (
 	"fmt"
 	"testing"
 
+	"github.com/XiaozhouCui/go-bank/db/util"
 	"github.com/stretchr/testify/require"
 )
 
@@ -69,6 +70,7 @@ func TestTransferTx(t *testing.T) {
 		require.Equal(t, -amount, fromEntry.Amount) // money is going out
 		require.NotZero(t, fromEntry.ID)            // auto increment field
 		require.NotZero(t, fromEntry.CreatedAt)     // timestamp field
+		require.Equal(t, result.FromAccount.Balance, fromEntry.BalanceAfter)
 		_, err = store.GetEntry(context.Background(), fromEntry.ID)
 		require.NoError(t, err)
 
@@ -79,6 +81,7 @@ func TestTransferTx(t *testing.T) {
 		require.Equal(t, amount, toEntry.Amount) // money is going in
 		require.NotZero(t, toEntry.ID)           // auto increment field
 		require.NotZero(t, toEntry.CreatedAt)    // timestamp field
+		require.Equal(t, result.ToAccount.Balance, toEntry.BalanceAfter)
 		_, err = store.GetEntry(context.Background(), toEntry.ID)
 		require.NoError(t, err)
 
@@ -167,3 +170,43 @@ func TestTransferTxDeadlock(t *testing.T) {
 	require.Equal(t, account1.Balance, updatedAccount1.Balance)
 	require.Equal(t, account2.Balance, updatedAccount2.Balance)
 }
+
+func TestTransferTxAccountNotActive(t *testing.T) {
+	store := NewStore(testDB)
+
+	account1 := createRandomAccount(t)
+	account2 := createRandomAccount(t)
+
+	_, err := store.UpdateAccountStatusTx(context.Background(), UpdateAccountStatusTxParams{
+		AccountID: account2.ID,
+		Status:    AccountStatusFrozen,
+		Reason:    util.RandomString(10),
+		ChangedBy: account2.Owner,
+	})
+	require.NoError(t, err)
+
+	// money cannot move into a frozen account
+	_, err = store.TransferTx(context.Background(), TransferTxParams{
+		FromAccountID: account1.ID,
+		ToAccountID:   account2.ID,
+		Amount:        10,
+	})
+	require.ErrorIs(t, err, ErrAccountNotActive)
+
+	// nor out of it
+	_, err = store.TransferTx(context.Background(), TransferTxParams{
+		FromAccountID: account2.ID,
+		ToAccountID:   account1.ID,
+		Amount:        10,
+	})
+	require.ErrorIs(t, err, ErrAccountNotActive)
+
+	// the whole transaction is rolled back, balances are unchanged
+	updatedAccount1, err := testQueries.GetAccount(context.Background(), account1.ID)
+	require.NoError(t, err)
+	require.Equal(t, account1.Balance, updatedAccount1.Balance)
+
+	updatedAccount2, err := testQueries.GetAccount(context.Background(), account2.ID)
+	require.NoError(t, err)
+	require.Equal(t, account2.Balance, updatedAccount2.Balance)
+}
@@ -0,0 +1,8 @@
+package db
+
+// purposes of the internal accounts owned by the bank, one account per purpose and currency
+const (
+	SystemAccountInterestExpense = "interest_expense" // pays interest to customer accounts
+	SystemAccountFeeRevenue      = "fee_revenue"      // receives transfer fees
+	SystemAccountSuspense        = "suspense"         // other side of manual adjustments until they are reconciled
+)
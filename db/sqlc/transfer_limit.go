@@ -0,0 +1,105 @@
+package db
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+)
+
+// transferTotalsFunc returns the total amount and number of transfers sent since a point in time.
+type transferTotalsFunc func(since time.Time) (amount int64, count int64, err error)
+
+// checkTransferLimits returns ErrTransferLimitExceeded if sending amount from the account would go over
+// the limits of its owner, or the limits of the account itself.
+// The owner is locked first, so the transfers of a user are counted one after the other, never concurrently.
+func checkTransferLimits(ctx context.Context, q *Queries, account Account, amount int64, now time.Time) error {
+	if _, err := q.GetUserForUpdate(ctx, account.Owner); err != nil {
+		return err
+	}
+
+	// a user without limits of their own gets the default limits
+	userLimit, err := q.GetUserTransferLimit(ctx, account.Owner)
+	if errors.Is(err, ErrRecordNotFound) {
+		userLimit, err = q.GetDefaultTransferLimit(ctx)
+	}
+	if err != nil && !errors.Is(err, ErrRecordNotFound) {
+		return err
+	}
+	if err == nil {
+		scope := fmt.Sprintf("user %s", account.Owner)
+		err = checkTransferLimit(userLimit, scope, amount, now, func(since time.Time) (int64, int64, error) {
+			totals, err := q.GetOutgoingTransferTotalsByOwner(ctx, GetOutgoingTransferTotalsByOwnerParams{
+				Owner: account.Owner,
+				Since: since,
+			})
+			return totals.TotalAmount, totals.TransferCount, err
+		})
+		if err != nil {
+			return err
+		}
+	}
+
+	accountLimit, err := q.GetAccountTransferLimit(ctx, account.ID)
+	if errors.Is(err, ErrRecordNotFound) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	scope := fmt.Sprintf("account [%d]", account.ID)
+	return checkTransferLimit(accountLimit, scope, amount, now, func(since time.Time) (int64, int64, error) {
+		totals, err := q.GetOutgoingTransferTotalsByAccount(ctx, GetOutgoingTransferTotalsByAccountParams{
+			AccountID: account.ID,
+			Since:     since,
+		})
+		return totals.TotalAmount, totals.TransferCount, err
+	})
+}
+
+// checkTransferLimit checks one set of limits, a limit of 0 means no limit.
+// Days and months are calendar days and months in UTC.
+func checkTransferLimit(limit TransferLimit, scope string, amount int64, now time.Time, totals transferTotalsFunc) error {
+	if limit.MaxAmount > 0 && amount > limit.MaxAmount {
+		return fmt.Errorf("%s: amount %d is over the single transfer limit of %d: %w",
+			scope, amount, limit.MaxAmount, ErrTransferLimitExceeded)
+	}
+
+	if limit.DailyAmount > 0 || limit.DailyCount > 0 {
+		sent, count, err := totals(startOfDay(now))
+		if err != nil {
+			return err
+		}
+		if limit.DailyAmount > 0 && sent+amount > limit.DailyAmount {
+			return fmt.Errorf("%s: %d already sent today, the daily limit is %d: %w",
+				scope, sent, limit.DailyAmount, ErrTransferLimitExceeded)
+		}
+		if limit.DailyCount > 0 && count >= limit.DailyCount {
+			return fmt.Errorf("%s: %d transfers already sent today, the daily limit is %d: %w",
+				scope, count, limit.DailyCount, ErrTransferLimitExceeded)
+		}
+	}
+
+	if limit.MonthlyAmount > 0 {
+		sent, _, err := totals(startOfMonth(now))
+		if err != nil {
+			return err
+		}
+		if sent+amount > limit.MonthlyAmount {
+			return fmt.Errorf("%s: %d already sent this month, the monthly limit is %d: %w",
+				scope, sent, limit.MonthlyAmount, ErrTransferLimitExceeded)
+		}
+	}
+
+	return nil
+}
+
+func startOfDay(t time.Time) time.Time {
+	t = t.UTC()
+	return time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, time.UTC)
+}
+
+func startOfMonth(t time.Time) time.Time {
+	t = t.UTC()
+	return time.Date(t.Year(), t.Month(), 1, 0, 0, 0, 0, time.UTC)
+}
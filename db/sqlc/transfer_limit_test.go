@@ -0,0 +1,97 @@
+package db
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestCheckTransferLimit(t *testing.T) {
+	now := time.Date(2023, time.March, 15, 12, 0, 0, 0, time.UTC)
+
+	// 300 sent in 2 transfers today, 900 this month
+	totals := func(since time.Time) (int64, int64, error) {
+		if since.Equal(startOfDay(now)) {
+			return 300, 2, nil
+		}
+		require.Equal(t, startOfMonth(now), since)
+		return 900, 6, nil
+	}
+
+	testCases := []struct {
+		name     string
+		limit    TransferLimit
+		amount   int64
+		exceeded bool
+	}{
+		{"NoLimits", TransferLimit{}, 1000000, false},
+		{"MaxAmount", TransferLimit{MaxAmount: 100}, 101, true},
+		{"UnderMaxAmount", TransferLimit{MaxAmount: 100}, 100, false},
+		{"DailyAmount", TransferLimit{DailyAmount: 400}, 101, true},
+		{"UnderDailyAmount", TransferLimit{DailyAmount: 400}, 100, false},
+		{"DailyCount", TransferLimit{DailyCount: 2}, 1, true},
+		{"UnderDailyCount", TransferLimit{DailyCount: 3}, 1, false},
+		{"MonthlyAmount", TransferLimit{MonthlyAmount: 1000}, 101, true},
+		{"UnderMonthlyAmount", TransferLimit{MonthlyAmount: 1000}, 100, false},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			err := checkTransferLimit(tc.limit, "user test", tc.amount, now, totals)
+			if tc.exceeded {
+				require.ErrorIs(t, err, ErrTransferLimitExceeded)
+			} else {
+				require.NoError(t, err)
+			}
+		})
+	}
+}
+
+func TestTransferTxLimitExceeded(t *testing.T) {
+	store := NewStore(testDB)
+	account1 := createRandomAccount(t)
+	account2 := createRandomAccount(t)
+
+	// the owner of account1 can only send one transfer a day
+	_, err := testQueries.SetUserTransferLimit(context.Background(), SetUserTransferLimitParams{
+		Username:   account1.Owner,
+		DailyCount: 1,
+		UpdatedBy:  "banker",
+	})
+	require.NoError(t, err)
+
+	arg := TransferTxParams{
+		FromAccountID: account1.ID,
+		ToAccountID:   account2.ID,
+		Amount:        1,
+	}
+	_, err = store.TransferTx(context.Background(), arg)
+	require.NoError(t, err)
+
+	_, err = store.TransferTx(context.Background(), arg)
+	require.ErrorIs(t, err, ErrTransferLimitExceeded)
+
+	// removing the override restores the default limits
+	err = testQueries.DeleteUserTransferLimit(context.Background(), account1.Owner)
+	require.NoError(t, err)
+	_, err = store.TransferTx(context.Background(), arg)
+	require.NoError(t, err)
+
+	// an account limit applies on top of the user limits
+	_, err = testQueries.SetAccountTransferLimit(context.Background(), SetAccountTransferLimitParams{
+		AccountID: account1.ID,
+		MaxAmount: 5,
+		UpdatedBy: "banker",
+	})
+	require.NoError(t, err)
+
+	arg.Amount = 6
+	_, err = store.TransferTx(context.Background(), arg)
+	require.ErrorIs(t, err, ErrTransferLimitExceeded)
+
+	account, err := testQueries.GetAccount(context.Background(), account1.ID)
+	require.NoError(t, err)
+	require.Equal(t, account1.Balance-2, account.Balance)
+}
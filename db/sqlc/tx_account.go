@@ -0,0 +1,30 @@
+package db
+
+import (
+	"context"
+
+	"github.com/XiaozhouCui/go-bank/events"
+)
+
+// CreateAccountTx opens an account within a database transaction, and writes its AccountCreated event to the outbox.
+func (store *SQLStore) CreateAccountTx(ctx context.Context, arg CreateAccountParams) (Account, error) {
+	var result Account
+
+	err := store.execTx(ctx, func(q *Queries) error {
+		var err error
+		result, err = q.CreateAccount(ctx, arg)
+		if err != nil {
+			return err
+		}
+
+		return addEvent(ctx, q, events.AccountCreatedV1{
+			AccountID: result.ID,
+			Owner:     result.Owner,
+			Currency:  result.Currency,
+			Type:      result.Type,
+			CreatedAt: result.CreatedAt,
+		})
+	})
+
+	return result, err
+}
@@ -0,0 +1,65 @@
+package db
+
+import (
+	"context"
+	"fmt"
+)
+
+// UpdateAccountStatusTxParams contains the input parameters of the account status transaction.
+type UpdateAccountStatusTxParams struct {
+	AccountID int64  `json:"account_id"`
+	Status    string `json:"status"`     // the new status
+	Reason    string `json:"reason"`     // why the status is changed, kept in account_status_changes
+	ChangedBy string `json:"changed_by"` // username of the owner or staff member making the change
+}
+
+// UpdateAccountStatusTxResult contains the result of the account status transaction.
+type UpdateAccountStatusTxResult struct {
+	Account      Account             `json:"account"`       // account with the new status
+	StatusChange AccountStatusChange `json:"status_change"` // new record of the status change
+}
+
+// UpdateAccountStatusTx moves an account to a new status within a database transaction.
+// It enforces the allowed status transitions, only closes accounts with a zero balance,
+// and records the change together with its reason.
+func (store *SQLStore) UpdateAccountStatusTx(ctx context.Context, arg UpdateAccountStatusTxParams) (UpdateAccountStatusTxResult, error) {
+	var result UpdateAccountStatusTxResult
+
+	err := store.execTx(ctx, func(q *Queries) error {
+		// lock the account row, so no transfer can change its balance before the new status is committed
+		account, err := q.GetAccountForUpdate(ctx, arg.AccountID)
+		if err != nil {
+			return err
+		}
+
+		if !CanTransitionAccountStatus(account.Status, arg.Status) {
+			return fmt.Errorf("account [%d] cannot move from %s to %s: %w", account.ID, account.Status, arg.Status, ErrInvalidStatusTransition)
+		}
+
+		if arg.Status == AccountStatusClosed && account.Balance != 0 {
+			return fmt.Errorf("account [%d] has balance %d: %w", account.ID, account.Balance, ErrAccountBalanceNotZero)
+		}
+		if arg.Status == AccountStatusClosed && account.HeldAmount != 0 {
+			return fmt.Errorf("account [%d] has %d on hold: %w", account.ID, account.HeldAmount, ErrAccountBalanceNotZero)
+		}
+
+		result.Account, err = q.UpdateAccountStatus(ctx, UpdateAccountStatusParams{
+			ID:     arg.AccountID,
+			Status: arg.Status,
+		})
+		if err != nil {
+			return err
+		}
+
+		result.StatusChange, err = q.CreateAccountStatusChange(ctx, CreateAccountStatusChangeParams{
+			AccountID:  arg.AccountID,
+			FromStatus: account.Status,
+			ToStatus:   arg.Status,
+			Reason:     arg.Reason,
+			ChangedBy:  arg.ChangedBy,
+		})
+		return err
+	})
+
+	return result, err
+}
@@ -0,0 +1,94 @@
+package db
+
+import (
+	"context"
+	"testing"
+
+	"github.com/XiaozhouCui/go-bank/db/util"
+	"github.com/stretchr/testify/require"
+)
+
+func TestUpdateAccountStatusTx(t *testing.T) {
+	store := NewStore(testDB)
+	account := createRandomAccount(t)
+
+	// freeze then unfreeze the account, each change is recorded with its reason
+	for _, status := range []string{AccountStatusFrozen, AccountStatusActive} {
+		arg := UpdateAccountStatusTxParams{
+			AccountID: account.ID,
+			Status:    status,
+			Reason:    util.RandomString(10),
+			ChangedBy: account.Owner,
+		}
+
+		result, err := store.UpdateAccountStatusTx(context.Background(), arg)
+		require.NoError(t, err)
+		require.Equal(t, account.ID, result.Account.ID)
+		require.Equal(t, status, result.Account.Status)
+
+		change := result.StatusChange
+		require.NotZero(t, change.ID)
+		require.Equal(t, account.ID, change.AccountID)
+		require.Equal(t, status, change.ToStatus)
+		require.Equal(t, arg.Reason, change.Reason)
+		require.Equal(t, arg.ChangedBy, change.ChangedBy)
+		require.NotZero(t, change.CreatedAt)
+	}
+
+	changes, err := testQueries.ListAccountStatusChanges(context.Background(), ListAccountStatusChangesParams{
+		AccountID: account.ID,
+		Limit:     5,
+		Offset:    0,
+	})
+	require.NoError(t, err)
+	require.Len(t, changes, 2)
+	require.Equal(t, AccountStatusActive, changes[0].FromStatus)
+	require.Equal(t, AccountStatusFrozen, changes[1].FromStatus)
+}
+
+func TestCloseAccountTx(t *testing.T) {
+	store := NewStore(testDB)
+	account := createRandomAccount(t)
+
+	arg := UpdateAccountStatusTxParams{
+		AccountID: account.ID,
+		Status:    AccountStatusClosed,
+		Reason:    util.RandomString(10),
+		ChangedBy: account.Owner,
+	}
+
+	// an account with money left cannot be closed
+	_, err := testQueries.UpdateAccount(context.Background(), UpdateAccountParams{
+		ID:      account.ID,
+		Balance: 10,
+	})
+	require.NoError(t, err)
+
+	_, err = store.UpdateAccountStatusTx(context.Background(), arg)
+	require.ErrorIs(t, err, ErrAccountBalanceNotZero)
+
+	// once empty it can
+	_, err = testQueries.UpdateAccount(context.Background(), UpdateAccountParams{
+		ID:      account.ID,
+		Balance: 0,
+	})
+	require.NoError(t, err)
+
+	result, err := store.UpdateAccountStatusTx(context.Background(), arg)
+	require.NoError(t, err)
+	require.Equal(t, AccountStatusClosed, result.Account.Status)
+
+	// a closed account cannot be reopened
+	arg.Status = AccountStatusActive
+	_, err = store.UpdateAccountStatusTx(context.Background(), arg)
+	require.ErrorIs(t, err, ErrInvalidStatusTransition)
+}
+
+func TestCanTransitionAccountStatus(t *testing.T) {
+	require.True(t, CanTransitionAccountStatus(AccountStatusActive, AccountStatusFrozen))
+	require.True(t, CanTransitionAccountStatus(AccountStatusActive, AccountStatusClosed))
+	require.True(t, CanTransitionAccountStatus(AccountStatusFrozen, AccountStatusActive))
+	require.False(t, CanTransitionAccountStatus(AccountStatusFrozen, AccountStatusClosed))
+	require.False(t, CanTransitionAccountStatus(AccountStatusClosed, AccountStatusActive))
+	require.False(t, CanTransitionAccountStatus(AccountStatusActive, AccountStatusActive))
+}
@@ -0,0 +1,121 @@
+package db
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"time"
+)
+
+// AddAuditLogTxParams contains the input parameters of the audit log transaction.
+type AddAuditLogTxParams struct {
+	Actor      string `json:"actor"`
+	ActorRole  string `json:"actor_role"`
+	Action     string `json:"action"`
+	TargetType string `json:"target_type"`
+	TargetID   string `json:"target_id"`
+	RequestID  string `json:"request_id"`
+	ClientIp   string `json:"client_ip"`
+	Status     int64  `json:"status"`
+	Before     []byte `json:"before"` // JSON snapshot of the target, nil if there is none
+	After      []byte `json:"after"`
+}
+
+// AddAuditLogTx appends an entry to the audit log, chained to the last one by its hash, within a database transaction.
+// Entries are appended one at a time, so the chain follows the order of their IDs.
+func (store *SQLStore) AddAuditLogTx(ctx context.Context, arg AddAuditLogTxParams) (AuditLog, error) {
+	var result AuditLog
+
+	err := store.execTx(ctx, func(q *Queries) error {
+		// held until the end of the transaction
+		if err := q.LockAuditLog(ctx); err != nil {
+			return err
+		}
+
+		var prevHash string
+		last, err := q.GetLastAuditLogEntry(ctx)
+		if err == nil {
+			prevHash = last.Hash
+		} else if !errors.Is(err, ErrRecordNotFound) {
+			return err
+		}
+
+		entry := CreateAuditLogEntryParams{
+			Actor:      arg.Actor,
+			ActorRole:  arg.ActorRole,
+			Action:     arg.Action,
+			TargetType: arg.TargetType,
+			TargetID:   arg.TargetID,
+			RequestID:  arg.RequestID,
+			ClientIp:   arg.ClientIp,
+			Status:     arg.Status,
+			Before:     arg.Before,
+			After:      arg.After,
+			// postgres keeps microseconds, the hash must cover the stored time
+			CreatedAt: time.Now().Truncate(time.Microsecond),
+			PrevHash:  prevHash,
+		}
+		entry.Hash, err = AuditLogHash(AuditLog{
+			Actor:      entry.Actor,
+			ActorRole:  entry.ActorRole,
+			Action:     entry.Action,
+			TargetType: entry.TargetType,
+			TargetID:   entry.TargetID,
+			RequestID:  entry.RequestID,
+			ClientIp:   entry.ClientIp,
+			Status:     entry.Status,
+			Before:     entry.Before,
+			After:      entry.After,
+			CreatedAt:  entry.CreatedAt,
+			PrevHash:   entry.PrevHash,
+		})
+		if err != nil {
+			return err
+		}
+
+		result, err = q.CreateAuditLogEntry(ctx, entry)
+		return err
+	})
+
+	return result, err
+}
+
+// AuditLogHash returns the hash of an audit log entry: the hex SHA-256 of its fields, its ID and hash aside,
+// as a JSON object. The snapshots are compacted, and the time is in UTC with nanoseconds.
+func AuditLogHash(entry AuditLog) (string, error) {
+	content, err := json.Marshal(struct {
+		PrevHash   string          `json:"prev_hash"`
+		Actor      string          `json:"actor"`
+		ActorRole  string          `json:"actor_role"`
+		Action     string          `json:"action"`
+		TargetType string          `json:"target_type"`
+		TargetID   string          `json:"target_id"`
+		RequestID  string          `json:"request_id"`
+		ClientIp   string          `json:"client_ip"`
+		Status     int64           `json:"status"`
+		Before     json.RawMessage `json:"before"`
+		After      json.RawMessage `json:"after"`
+		CreatedAt  string          `json:"created_at"`
+	}{
+		PrevHash:   entry.PrevHash,
+		Actor:      entry.Actor,
+		ActorRole:  entry.ActorRole,
+		Action:     entry.Action,
+		TargetType: entry.TargetType,
+		TargetID:   entry.TargetID,
+		RequestID:  entry.RequestID,
+		ClientIp:   entry.ClientIp,
+		Status:     entry.Status,
+		Before:     entry.Before,
+		After:      entry.After,
+		CreatedAt:  entry.CreatedAt.UTC().Format(time.RFC3339Nano),
+	})
+	if err != nil {
+		return "", err
+	}
+
+	sum := sha256.Sum256(content)
+	return hex.EncodeToString(sum[:]), nil
+}
@@ -0,0 +1,171 @@
+package db
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+
+	"github.com/jackc/pgx/v5"
+)
+
+// BatchTransferTxParams contains the input parameters of the batch transfer transaction.
+type BatchTransferTxParams struct {
+	Transfers []TransferTxParams `json:"transfers"`
+	// BestEffort commits the transfers that succeed and reports the ones that fail,
+	// otherwise a single failing transfer rolls back the whole batch
+	BestEffort bool `json:"best_effort"`
+}
+
+// BatchTransferLeg is the outcome of one transfer of a batch.
+type BatchTransferLeg struct {
+	Result TransferTxResult `json:"result"` // empty if the transfer failed or was rolled back
+	Err    error            `json:"-"`      // why the transfer failed, nil if it succeeded or was rolled back
+}
+
+// BatchTransferTxResult contains the result of the batch transfer transaction, one leg per transfer in the same order.
+type BatchTransferTxResult struct {
+	Legs []BatchTransferLeg `json:"legs"`
+}
+
+// BatchTransferTx performs many transfers within a single database transaction.
+// Each transfer is made like TransferTx, with the limits and fee of its sender.
+// All accounts of the batch are locked up front in a consistent order: the owners of the senders
+// by username, then the accounts by ID, so concurrent batches and transfers cannot deadlock.
+// Without BestEffort, the first failing transfer is returned as an error wrapping its cause and
+// nothing is written. With BestEffort, each transfer runs in a savepoint, and a failing one is
+// rolled back on its own and reported in its leg.
+func (store *SQLStore) BatchTransferTx(ctx context.Context, arg BatchTransferTxParams) (BatchTransferTxResult, error) {
+	result := BatchTransferTxResult{
+		Legs: make([]BatchTransferLeg, len(arg.Transfers)),
+	}
+	failed := -1
+
+	err := store.execTx(ctx, func(q *Queries) error {
+		missing, err := lockBatchAccounts(ctx, q, arg.Transfers)
+		if err != nil {
+			return err
+		}
+
+		for i, leg := range arg.Transfers {
+			var legErr error
+			switch {
+			case missing[leg.FromAccountID]:
+				legErr = fmt.Errorf("account [%d]: %w", leg.FromAccountID, ErrRecordNotFound)
+			case missing[leg.ToAccountID]:
+				legErr = fmt.Errorf("account [%d]: %w", leg.ToAccountID, ErrRecordNotFound)
+			case arg.BestEffort:
+				legErr = savepoint(ctx, q, func(q *Queries) error {
+					var err error
+					result.Legs[i].Result, err = customerTransfer(ctx, q, leg)
+					return err
+				})
+			default:
+				result.Legs[i].Result, legErr = customerTransfer(ctx, q, leg)
+			}
+
+			if legErr != nil {
+				result.Legs[i] = BatchTransferLeg{Err: legErr}
+				if !arg.BestEffort {
+					failed = i
+					return fmt.Errorf("transfer %d of the batch: %w", i, legErr)
+				}
+			}
+		}
+		return nil
+	})
+
+	if err != nil {
+		// nothing was written, so no leg has a result
+		legs := make([]BatchTransferLeg, len(arg.Transfers))
+		if failed >= 0 {
+			legs[failed] = result.Legs[failed]
+		}
+		result.Legs = legs
+	}
+	return result, err
+}
+
+// lockBatchAccounts locks the owners of the senders and all accounts of a batch, and returns the accounts that do not exist.
+// The owners are locked first, as in checkTransferLimits, then the accounts from the smallest ID to the largest,
+// generalising the order of the balance updates of a single transfer.
+func lockBatchAccounts(ctx context.Context, q *Queries, transfers []TransferTxParams) (map[int64]bool, error) {
+	missing := make(map[int64]bool)
+	owners := make(map[string]bool)
+	accountIDs := make(map[int64]bool)
+
+	for _, leg := range transfers {
+		accountIDs[leg.FromAccountID] = true
+		accountIDs[leg.ToAccountID] = true
+
+		if missing[leg.FromAccountID] {
+			continue
+		}
+		// the owner of an account never changes, so it can be read before the account is locked
+		account, err := q.GetAccount(ctx, leg.FromAccountID)
+		if errors.Is(err, ErrRecordNotFound) {
+			missing[leg.FromAccountID] = true
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+		owners[account.Owner] = true
+	}
+
+	usernames := make([]string, 0, len(owners))
+	for username := range owners {
+		usernames = append(usernames, username)
+	}
+	sort.Strings(usernames)
+	for _, username := range usernames {
+		if _, err := q.GetUserForUpdate(ctx, username); err != nil {
+			return nil, err
+		}
+	}
+
+	ids := make([]int64, 0, len(accountIDs))
+	for id := range accountIDs {
+		ids = append(ids, id)
+	}
+	sort.Slice(ids, func(i, j int) bool { return ids[i] < ids[j] })
+	for _, id := range ids {
+		if missing[id] {
+			continue
+		}
+		_, err := q.GetAccountForUpdate(ctx, id)
+		if errors.Is(err, ErrRecordNotFound) {
+			missing[id] = true
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return missing, nil
+}
+
+// savepoint runs fn within a savepoint of the open transaction of q.
+// If fn fails, only its own writes are rolled back and the transaction can go on.
+func savepoint(ctx context.Context, q *Queries, fn func(*Queries) error) error {
+	tx, ok := q.db.(pgx.Tx)
+	if !ok {
+		return errors.New("savepoint needs an open transaction")
+	}
+
+	// Begin on a transaction creates a savepoint, Commit releases it
+	sp, err := tx.Begin(ctx)
+	if err != nil {
+		return err
+	}
+
+	err = fn(q.WithTx(sp))
+	if err != nil {
+		if rbErr := sp.Rollback(ctx); rbErr != nil {
+			return fmt.Errorf("tx error: %v, rb error: %v", err, rbErr)
+		}
+		return err
+	}
+	return sp.Commit(ctx)
+}
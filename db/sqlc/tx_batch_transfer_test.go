@@ -0,0 +1,133 @@
+package db
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/XiaozhouCui/go-bank/db/util"
+	"github.com/stretchr/testify/require"
+)
+
+func TestBatchTransferTx(t *testing.T) {
+	store := NewStore(testDB)
+
+	// use a currency of its own, so no fee schedule applies
+	currency := strings.ToUpper(util.RandomString(6))
+	payer := createAccountInCurrency(t, currency, util.Checking)
+	payee1 := createAccountInCurrency(t, currency, util.Checking)
+	payee2 := createAccountInCurrency(t, currency, util.Checking)
+
+	result, err := store.BatchTransferTx(context.Background(), BatchTransferTxParams{
+		Transfers: []TransferTxParams{
+			{FromAccountID: payer.ID, ToAccountID: payee1.ID, Amount: 10},
+			{FromAccountID: payer.ID, ToAccountID: payee2.ID, Amount: 20},
+		},
+	})
+	require.NoError(t, err)
+	require.Len(t, result.Legs, 2)
+	for _, leg := range result.Legs {
+		require.NoError(t, leg.Err)
+		require.NotZero(t, leg.Result.Transfer.ID)
+	}
+	require.Equal(t, payer.Balance-30, result.Legs[1].Result.FromAccount.Balance)
+	require.Equal(t, payee1.Balance+10, result.Legs[0].Result.ToAccount.Balance)
+	require.Equal(t, payee2.Balance+20, result.Legs[1].Result.ToAccount.Balance)
+}
+
+func TestBatchTransferTxAllOrNothing(t *testing.T) {
+	store := NewStore(testDB)
+
+	currency := strings.ToUpper(util.RandomString(6))
+	payer := createAccountInCurrency(t, currency, util.Checking)
+	payee := createAccountInCurrency(t, currency, util.Checking)
+
+	result, err := store.BatchTransferTx(context.Background(), BatchTransferTxParams{
+		Transfers: []TransferTxParams{
+			{FromAccountID: payer.ID, ToAccountID: payee.ID, Amount: 10},
+			{FromAccountID: payer.ID, ToAccountID: -1, Amount: 10},
+		},
+	})
+	require.ErrorIs(t, err, ErrRecordNotFound)
+	require.Empty(t, result.Legs[0].Result.Transfer)
+	require.ErrorIs(t, result.Legs[1].Err, ErrRecordNotFound)
+
+	// the first transfer is rolled back with the second one
+	updatedPayee, err := testQueries.GetAccount(context.Background(), payee.ID)
+	require.NoError(t, err)
+	require.Equal(t, payee.Balance, updatedPayee.Balance)
+}
+
+func TestBatchTransferTxBestEffort(t *testing.T) {
+	store := NewStore(testDB)
+
+	currency := strings.ToUpper(util.RandomString(6))
+	payer := createAccountInCurrency(t, currency, util.Checking)
+	payee1 := createAccountInCurrency(t, currency, util.Checking)
+	payee2 := createAccountInCurrency(t, currency, util.Checking)
+
+	_, err := store.UpdateAccountStatusTx(context.Background(), UpdateAccountStatusTxParams{
+		AccountID: payee1.ID,
+		Status:    AccountStatusFrozen,
+		Reason:    util.RandomString(10),
+		ChangedBy: payee1.Owner,
+	})
+	require.NoError(t, err)
+
+	result, err := store.BatchTransferTx(context.Background(), BatchTransferTxParams{
+		Transfers: []TransferTxParams{
+			{FromAccountID: payer.ID, ToAccountID: payee1.ID, Amount: 10},
+			{FromAccountID: payer.ID, ToAccountID: payee2.ID, Amount: 20},
+		},
+		BestEffort: true,
+	})
+	require.NoError(t, err)
+	require.ErrorIs(t, result.Legs[0].Err, ErrAccountNotActive)
+	require.NoError(t, result.Legs[1].Err)
+
+	// only the valid transfer is committed
+	updatedPayer, err := testQueries.GetAccount(context.Background(), payer.ID)
+	require.NoError(t, err)
+	require.Equal(t, payer.Balance-20, updatedPayer.Balance)
+}
+
+func TestBatchTransferTxDeadlock(t *testing.T) {
+	store := NewStore(testDB)
+
+	currency := strings.ToUpper(util.RandomString(6))
+	accounts := make([]Account, 3)
+	for i := range accounts {
+		accounts[i] = createAccountInCurrency(t, currency, util.Checking)
+	}
+
+	// run concurrent batches that send money around the accounts in opposite directions
+	n := 10
+	errs := make(chan error)
+	for i := 0; i < n; i++ {
+		transfers := []TransferTxParams{
+			{FromAccountID: accounts[0].ID, ToAccountID: accounts[1].ID, Amount: 10},
+			{FromAccountID: accounts[1].ID, ToAccountID: accounts[2].ID, Amount: 10},
+			{FromAccountID: accounts[2].ID, ToAccountID: accounts[0].ID, Amount: 10},
+		}
+		if i%2 == 1 {
+			for j := range transfers {
+				transfers[j].FromAccountID, transfers[j].ToAccountID = transfers[j].ToAccountID, transfers[j].FromAccountID
+			}
+		}
+		go func() {
+			_, err := store.BatchTransferTx(context.Background(), BatchTransferTxParams{Transfers: transfers})
+			errs <- err
+		}()
+	}
+
+	for i := 0; i < n; i++ {
+		require.NoError(t, <-errs)
+	}
+
+	// every account sends and receives the same amount
+	for _, account := range accounts {
+		updatedAccount, err := testQueries.GetAccount(context.Background(), account.ID)
+		require.NoError(t, err)
+		require.Equal(t, account.Balance, updatedAccount.Balance)
+	}
+}
@@ -0,0 +1,42 @@
+package db
+
+import "context"
+
+// CreateFeeScheduleTxParams contains the input parameters of the fee schedule creation transaction.
+type CreateFeeScheduleTxParams struct {
+	CreateFeeScheduleParams
+	Tiers []CreateFeeTierParams `json:"tiers"` // ScheduleID is set by the transaction
+}
+
+// CreateFeeScheduleTxResult contains the result of the fee schedule creation transaction.
+type CreateFeeScheduleTxResult struct {
+	Schedule FeeSchedule `json:"schedule"`
+	Tiers    []FeeTier   `json:"tiers"`
+}
+
+// CreateFeeScheduleTx creates a fee schedule together with its tiers within a database transaction.
+func (store *SQLStore) CreateFeeScheduleTx(ctx context.Context, arg CreateFeeScheduleTxParams) (CreateFeeScheduleTxResult, error) {
+	var result CreateFeeScheduleTxResult
+
+	err := store.execTx(ctx, func(q *Queries) error {
+		var err error
+
+		result.Schedule, err = q.CreateFeeSchedule(ctx, arg.CreateFeeScheduleParams)
+		if err != nil {
+			return err
+		}
+
+		result.Tiers = make([]FeeTier, 0, len(arg.Tiers))
+		for _, tierArg := range arg.Tiers {
+			tierArg.ScheduleID = result.Schedule.ID
+			tier, err := q.CreateFeeTier(ctx, tierArg)
+			if err != nil {
+				return err
+			}
+			result.Tiers = append(result.Tiers, tier)
+		}
+		return nil
+	})
+
+	return result, err
+}
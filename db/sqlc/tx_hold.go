@@ -0,0 +1,182 @@
+package db
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+// hold statuses, stored in holds.status
+const (
+	HoldPending  = "pending"  // funds are reserved
+	HoldCaptured = "captured" // settled by a transfer, final
+	HoldReleased = "released" // released before it expired, final
+	HoldExpired  = "expired"  // released when it expired, final
+)
+
+// PlaceHoldTxParams contains the input parameters of the hold transaction.
+type PlaceHoldTxParams struct {
+	AccountID   int64     `json:"account_id"`
+	ToAccountID int64     `json:"to_account_id"`
+	Amount      int64     `json:"amount"`
+	CreatedBy   string    `json:"created_by"`
+	ExpiresAt   time.Time `json:"expires_at"`
+}
+
+// HoldTxResult contains the result of the hold transactions.
+type HoldTxResult struct {
+	Hold     Hold             `json:"hold"`
+	Account  Account          `json:"account"`  // held account with its new available balance
+	Transfer TransferTxResult `json:"transfer"` // made by a capture, empty otherwise
+}
+
+// PlaceHoldTx reserves funds of an account within a database transaction.
+// The funds stay in the balance but leave the available balance until the hold is captured, released or expires.
+// It returns ErrInsufficientFunds if the available balance is too low.
+func (store *SQLStore) PlaceHoldTx(ctx context.Context, arg PlaceHoldTxParams) (HoldTxResult, error) {
+	var result HoldTxResult
+
+	err := store.execTx(ctx, func(q *Queries) error {
+		account, err := q.GetAccountForUpdate(ctx, arg.AccountID)
+		if err != nil {
+			return err
+		}
+		if err = requireActiveAccount(account); err != nil {
+			return err
+		}
+		if account.AvailableBalance < arg.Amount {
+			return fmt.Errorf("account [%d] has %d available: %w", account.ID, account.AvailableBalance, ErrInsufficientFunds)
+		}
+
+		result.Account, err = q.AddAccountHeldAmount(ctx, AddAccountHeldAmountParams{
+			ID:     arg.AccountID,
+			Amount: arg.Amount,
+		})
+		if err != nil {
+			return err
+		}
+
+		result.Hold, err = q.CreateHold(ctx, CreateHoldParams(arg))
+		return err
+	})
+
+	return result, err
+}
+
+// CaptureHoldTxParams contains the input parameters of the hold capture transaction.
+type CaptureHoldTxParams struct {
+	ID     int64     `json:"id"`
+	Amount int64     `json:"amount"` // up to the amount of the hold, the rest is released
+	Now    time.Time `json:"now"`
+}
+
+// CaptureHoldTx settles a pending hold within a database transaction.
+// The captured amount moves like TransferTx, with the limits and fee of the sender,
+// and the whole hold leaves the held amount of the account.
+// It returns ErrHoldNotPending if the hold has been captured, released or has expired,
+// and ErrApprovalRequired if an approval policy set since the hold was placed covers the captured amount.
+func (store *SQLStore) CaptureHoldTx(ctx context.Context, arg CaptureHoldTxParams) (HoldTxResult, error) {
+	var result HoldTxResult
+
+	err := store.execTx(ctx, func(q *Queries) error {
+		hold, err := getPendingHold(ctx, q, arg.ID, arg.Now)
+		if err != nil {
+			return err
+		}
+		if arg.Amount > hold.Amount {
+			return fmt.Errorf("hold [%d] is for %d: %w", hold.ID, hold.Amount, ErrCaptureExceedsHold)
+		}
+
+		// the transfer locks the owner then the accounts, as any other transfer does,
+		// so the held amount is only changed once the account is locked.
+		// it spends the funds reserved by the hold, which are released below
+		result.Transfer, err = heldTransfer(ctx, q, TransferTxParams{
+			FromAccountID: hold.AccountID,
+			ToAccountID:   hold.ToAccountID,
+			Amount:        arg.Amount,
+			SentBy:        hold.CreatedBy,
+		}, hold.Amount)
+		if err != nil {
+			return err
+		}
+
+		result.Account, err = q.AddAccountHeldAmount(ctx, AddAccountHeldAmountParams{
+			ID:     hold.AccountID,
+			Amount: -hold.Amount,
+		})
+		if err != nil {
+			return err
+		}
+		result.Transfer.FromAccount = result.Account
+
+		result.Hold, err = q.UpdateHold(ctx, UpdateHoldParams{
+			ID:             hold.ID,
+			Status:         HoldCaptured,
+			CapturedAmount: arg.Amount,
+			TransferID:     pgtype.Int8{Int64: result.Transfer.Transfer.ID, Valid: true},
+		})
+		return err
+	})
+
+	return result, err
+}
+
+// ReleaseHoldTxParams contains the input parameters of the hold release transaction.
+type ReleaseHoldTxParams struct {
+	ID  int64     `json:"id"`
+	Now time.Time `json:"now"`
+}
+
+// ReleaseHoldTx gives the funds of a pending hold back to the available balance within a database transaction.
+// A hold released after its expiry is marked expired, so the expiry job and a user releasing it cannot both succeed.
+func (store *SQLStore) ReleaseHoldTx(ctx context.Context, arg ReleaseHoldTxParams) (HoldTxResult, error) {
+	var result HoldTxResult
+
+	err := store.execTx(ctx, func(q *Queries) error {
+		hold, err := q.GetHoldForUpdate(ctx, arg.ID)
+		if err != nil {
+			return err
+		}
+		if hold.Status != HoldPending {
+			return fmt.Errorf("hold [%d] is %s: %w", hold.ID, hold.Status, ErrHoldNotPending)
+		}
+
+		status := HoldReleased
+		if !hold.ExpiresAt.After(arg.Now) {
+			status = HoldExpired
+		}
+
+		result.Account, err = q.AddAccountHeldAmount(ctx, AddAccountHeldAmountParams{
+			ID:     hold.AccountID,
+			Amount: -hold.Amount,
+		})
+		if err != nil {
+			return err
+		}
+
+		result.Hold, err = q.UpdateHold(ctx, UpdateHoldParams{
+			ID:     hold.ID,
+			Status: status,
+		})
+		return err
+	})
+
+	return result, err
+}
+
+// getPendingHold locks a hold, and returns ErrHoldNotPending unless it is pending and has not expired.
+func getPendingHold(ctx context.Context, q *Queries, id int64, now time.Time) (Hold, error) {
+	hold, err := q.GetHoldForUpdate(ctx, id)
+	if err != nil {
+		return hold, err
+	}
+	if hold.Status != HoldPending {
+		return hold, fmt.Errorf("hold [%d] is %s: %w", hold.ID, hold.Status, ErrHoldNotPending)
+	}
+	if !hold.ExpiresAt.After(now) {
+		return hold, fmt.Errorf("hold [%d] expired at %s: %w", hold.ID, hold.ExpiresAt.Format(time.RFC3339), ErrHoldNotPending)
+	}
+	return hold, nil
+}
@@ -0,0 +1,206 @@
+package db
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/XiaozhouCui/go-bank/db/util"
+	"github.com/stretchr/testify/require"
+)
+
+func placeHold(t *testing.T, store Store, from, to Account, amount int64, expiresAt time.Time) Hold {
+	result, err := store.PlaceHoldTx(context.Background(), PlaceHoldTxParams{
+		AccountID:   from.ID,
+		ToAccountID: to.ID,
+		Amount:      amount,
+		CreatedBy:   from.Owner,
+		ExpiresAt:   expiresAt,
+	})
+	require.NoError(t, err)
+	require.Equal(t, HoldPending, result.Hold.Status)
+	require.Equal(t, amount, result.Hold.Amount)
+	require.Equal(t, from.Balance, result.Account.Balance)
+	require.Equal(t, from.AvailableBalance-amount, result.Account.AvailableBalance)
+	return result.Hold
+}
+
+func TestPlaceHoldTx(t *testing.T) {
+	store := NewStore(testDB)
+
+	account1 := createRandomAccount(t)
+	account2 := createRandomAccount(t)
+	require.Equal(t, account1.Balance, account1.AvailableBalance)
+
+	// funds on hold cannot be held twice
+	_, err := store.PlaceHoldTx(context.Background(), PlaceHoldTxParams{
+		AccountID:   account1.ID,
+		ToAccountID: account2.ID,
+		Amount:      account1.AvailableBalance + 1,
+		CreatedBy:   account1.Owner,
+		ExpiresAt:   time.Now().Add(time.Hour),
+	})
+	require.ErrorIs(t, err, ErrInsufficientFunds)
+}
+
+func TestCaptureHoldTx(t *testing.T) {
+	store := NewStore(testDB)
+
+	// use a currency of its own, so no fee schedule applies
+	currency := strings.ToUpper(util.RandomString(6))
+	account1 := createAccountInCurrency(t, currency, util.Checking)
+	account2 := createAccountInCurrency(t, currency, util.Checking)
+	account1, err := testQueries.AddAccountBalance(context.Background(), AddAccountBalanceParams{
+		ID:     account1.ID,
+		Amount: 100,
+	})
+	require.NoError(t, err)
+
+	hold := placeHold(t, store, account1, account2, 100, time.Now().Add(time.Hour))
+
+	// partial capture, the rest of the hold is released
+	result, err := store.CaptureHoldTx(context.Background(), CaptureHoldTxParams{
+		ID:     hold.ID,
+		Amount: 60,
+		Now:    time.Now(),
+	})
+	require.NoError(t, err)
+	require.Equal(t, HoldCaptured, result.Hold.Status)
+	require.Equal(t, int64(60), result.Hold.CapturedAmount)
+	require.Equal(t, result.Transfer.Transfer.ID, result.Hold.TransferID.Int64)
+	require.Equal(t, account1.Balance-60, result.Account.Balance)
+	require.Equal(t, account1.Balance-60, result.Account.AvailableBalance)
+	require.Zero(t, result.Account.HeldAmount)
+	require.Equal(t, account2.Balance+60, result.Transfer.ToAccount.Balance)
+
+	// a hold is captured once
+	_, err = store.CaptureHoldTx(context.Background(), CaptureHoldTxParams{
+		ID:     hold.ID,
+		Amount: 40,
+		Now:    time.Now(),
+	})
+	require.ErrorIs(t, err, ErrHoldNotPending)
+}
+
+func TestCaptureHoldTxApprovalRequired(t *testing.T) {
+	store := NewStore(testDB)
+
+	currency := strings.ToUpper(util.RandomString(6))
+	account1 := createAccountInCurrency(t, currency, util.Checking)
+	account2 := createAccountInCurrency(t, currency, util.Checking)
+	account1, err := testQueries.AddAccountBalance(context.Background(), AddAccountBalanceParams{
+		ID:     account1.ID,
+		Amount: 100,
+	})
+	require.NoError(t, err)
+
+	hold := placeHold(t, store, account1, account2, 100, time.Now().Add(time.Hour))
+
+	// a policy set after the hold was placed covers its capture
+	approver := createRandomUser(t)
+	_, err = testQueries.SetApprovalPolicy(context.Background(), SetApprovalPolicyParams{
+		AccountID:         account1.ID,
+		Threshold:         50,
+		RequiredApprovals: 1,
+		Approvers:         []string{approver.Username},
+		UpdatedBy:         account1.Owner,
+	})
+	require.NoError(t, err)
+
+	_, err = store.CaptureHoldTx(context.Background(), CaptureHoldTxParams{
+		ID:     hold.ID,
+		Amount: 60,
+		Now:    time.Now(),
+	})
+	require.ErrorIs(t, err, ErrApprovalRequired)
+
+	// a capture under the threshold goes through
+	result, err := store.CaptureHoldTx(context.Background(), CaptureHoldTxParams{
+		ID:     hold.ID,
+		Amount: 40,
+		Now:    time.Now(),
+	})
+	require.NoError(t, err)
+	require.Equal(t, account1.Balance-40, result.Account.Balance)
+}
+
+func TestReleaseHoldTx(t *testing.T) {
+	store := NewStore(testDB)
+
+	account1 := createRandomAccount(t)
+	account2 := createRandomAccount(t)
+	account1, err := testQueries.AddAccountBalance(context.Background(), AddAccountBalanceParams{
+		ID:     account1.ID,
+		Amount: 20,
+	})
+	require.NoError(t, err)
+
+	expiresAt := time.Now().Add(time.Hour)
+	released := placeHold(t, store, account1, account2, 10, expiresAt)
+	expired := placeHold(t, store, account1, account2, 10, expiresAt)
+
+	result, err := store.ReleaseHoldTx(context.Background(), ReleaseHoldTxParams{
+		ID:  released.ID,
+		Now: time.Now(),
+	})
+	require.NoError(t, err)
+	require.Equal(t, HoldReleased, result.Hold.Status)
+	require.Equal(t, int64(10), result.Account.HeldAmount)
+
+	// an expired hold cannot be captured, and is marked expired when released
+	_, err = store.CaptureHoldTx(context.Background(), CaptureHoldTxParams{
+		ID:     expired.ID,
+		Amount: 10,
+		Now:    expiresAt,
+	})
+	require.ErrorIs(t, err, ErrHoldNotPending)
+
+	result, err = store.ReleaseHoldTx(context.Background(), ReleaseHoldTxParams{
+		ID:  expired.ID,
+		Now: expiresAt,
+	})
+	require.NoError(t, err)
+	require.Equal(t, HoldExpired, result.Hold.Status)
+	require.Zero(t, result.Account.HeldAmount)
+	require.Equal(t, account1.Balance, result.Account.AvailableBalance)
+}
+
+func TestTransferTxHeldFunds(t *testing.T) {
+	store := NewStore(testDB)
+
+	// use a currency of its own, so no fee schedule applies
+	currency := strings.ToUpper(util.RandomString(6))
+	account1 := createAccountInCurrency(t, currency, util.Checking)
+	account2 := createAccountInCurrency(t, currency, util.Checking)
+
+	hold := placeHold(t, store, account1, account2, 100, time.Now().Add(time.Hour))
+
+	// the reserved funds cannot be spent by a transfer
+	_, err := store.TransferTx(context.Background(), TransferTxParams{
+		FromAccountID: account1.ID,
+		ToAccountID:   account2.ID,
+		Amount:        account1.Balance,
+	})
+	require.ErrorIs(t, err, ErrInsufficientFunds)
+
+	// the rest of the balance can
+	result, err := store.TransferTx(context.Background(), TransferTxParams{
+		FromAccountID: account1.ID,
+		ToAccountID:   account2.ID,
+		Amount:        account1.Balance - 100,
+	})
+	require.NoError(t, err)
+	require.Equal(t, int64(100), result.FromAccount.Balance)
+	require.Zero(t, result.FromAccount.AvailableBalance)
+
+	// and the hold is still covered when it is captured
+	captured, err := store.CaptureHoldTx(context.Background(), CaptureHoldTxParams{
+		ID:     hold.ID,
+		Amount: 100,
+		Now:    time.Now(),
+	})
+	require.NoError(t, err)
+	require.Zero(t, captured.Account.Balance)
+	require.Zero(t, captured.Account.HeldAmount)
+}
@@ -0,0 +1,135 @@
+package db
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+// payment file formats, stored in import_jobs.format
+const (
+	ImportFormatPain001 = "pain.001" // ISO 20022 customer credit transfer initiation
+	ImportFormatCSV     = "csv"
+)
+
+// import job statuses, stored in import_jobs.status
+const (
+	ImportJobValidated = "validated" // every line is valid, nothing has been paid yet
+	ImportJobRejected  = "rejected"  // some lines are invalid, the job cannot be executed, final
+	ImportJobExecuting = "executing" // its lines are being paid
+	ImportJobCompleted = "completed" // every valid line has succeeded or failed, final
+)
+
+// import job line statuses, stored in import_job_lines.status
+const (
+	ImportLineValid     = "valid"     // waiting to be paid
+	ImportLineInvalid   = "invalid"   // failed validation, never paid
+	ImportLineSucceeded = "succeeded" // paid by its transfer
+	ImportLineFailed    = "failed"    // the transfer could not be made
+)
+
+// CreateImportJobTxParams contains the input parameters of the import job creation transaction.
+type CreateImportJobTxParams struct {
+	Owner  string                      `json:"owner"`
+	Format string                      `json:"format"`
+	Lines  []CreateImportJobLineParams `json:"lines"` // JobID is set by the transaction
+}
+
+// CreateImportJobTxResult contains the result of the import job creation transaction.
+type CreateImportJobTxResult struct {
+	Job   ImportJob       `json:"job"`
+	Lines []ImportJobLine `json:"lines"`
+}
+
+// CreateImportJobTx stores a validated payment file as an import job with one line per instruction,
+// within a database transaction. The job is rejected if any line is invalid.
+func (store *SQLStore) CreateImportJobTx(ctx context.Context, arg CreateImportJobTxParams) (CreateImportJobTxResult, error) {
+	var result CreateImportJobTxResult
+
+	var invalid int64
+	for _, line := range arg.Lines {
+		if line.Status == ImportLineInvalid {
+			invalid++
+		}
+	}
+	status := ImportJobValidated
+	if invalid > 0 {
+		status = ImportJobRejected
+	}
+
+	err := store.execTx(ctx, func(q *Queries) error {
+		var err error
+		result.Job, err = q.CreateImportJob(ctx, CreateImportJobParams{
+			Owner:        arg.Owner,
+			Format:       arg.Format,
+			Status:       status,
+			LineCount:    int64(len(arg.Lines)),
+			InvalidCount: invalid,
+		})
+		if err != nil {
+			return err
+		}
+
+		result.Lines = make([]ImportJobLine, len(arg.Lines))
+		for i, line := range arg.Lines {
+			line.JobID = result.Job.ID
+			result.Lines[i], err = q.CreateImportJobLine(ctx, line)
+			if err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+
+	return result, err
+}
+
+// ExecuteImportLineTxResult contains the result of the import line transaction.
+type ExecuteImportLineTxResult struct {
+	Line     ImportJobLine    `json:"line"`
+	Transfer TransferTxResult `json:"transfer"`
+}
+
+// ExecuteImportLineTx pays a valid line of an import job within a database transaction.
+// The money moves the same way as TransferTx, with the same limits and fee, but the sender cannot
+// go below its available balance. The line is marked as succeeded in the same transaction, so it is never
+// paid twice: ErrImportLineNotPending is returned if it is not valid anymore.
+// On any other error nothing is written, and the failure is recorded with FailImportJobLine.
+func (store *SQLStore) ExecuteImportLineTx(ctx context.Context, id int64) (ExecuteImportLineTxResult, error) {
+	var result ExecuteImportLineTxResult
+
+	err := store.execTx(ctx, func(q *Queries) error {
+		line, err := q.GetImportJobLineForUpdate(ctx, id)
+		if err != nil {
+			return err
+		}
+		if line.Status != ImportLineValid {
+			return fmt.Errorf("import line [%d] is %s: %w", id, line.Status, ErrImportLineNotPending)
+		}
+		// the user who imported the file sends its lines
+		job, err := q.GetImportJob(ctx, line.JobID)
+		if err != nil {
+			return err
+		}
+
+		result.Transfer, err = customerTransfer(ctx, q, TransferTxParams{
+			FromAccountID: line.FromAccountID,
+			ToAccountID:   line.ToAccountID,
+			Amount:        line.Amount,
+			Reference:     line.Reference,
+			SentBy:        job.Owner,
+		})
+		if err != nil {
+			return err
+		}
+
+		result.Line, err = q.SucceedImportJobLine(ctx, SucceedImportJobLineParams{
+			ID:         line.ID,
+			TransferID: pgtype.Int8{Int64: result.Transfer.Transfer.ID, Valid: true},
+		})
+		return err
+	})
+
+	return result, err
+}
@@ -0,0 +1,111 @@
+package db
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+// MicrosPerMinorUnit is the precision of accrued interest: 1 cent is 1,000,000 micros.
+const MicrosPerMinorUnit = 1_000_000
+
+// PostInterestTxParams contains the input parameters of the interest posting transaction.
+type PostInterestTxParams struct {
+	AccountID   int64     `json:"account_id"`
+	PeriodStart time.Time `json:"period_start"` // first day of the period
+	PeriodEnd   time.Time `json:"period_end"`   // first day after the period
+}
+
+// PostInterestTxResult contains the result of the interest posting transaction.
+type PostInterestTxResult struct {
+	Posting  InterestPosting  `json:"posting"`  // new posting record of the period
+	Transfer TransferTxResult `json:"transfer"` // empty if the interest is less than one minor unit
+}
+
+// PostInterestTx credits the interest accrued by an account over a period within a database transaction.
+// The interest is paid from the interest expense account of the same currency, through the same transfer
+// and entries as TransferTx but without a fee. Fractions of a minor unit are carried to the next period.
+// Each period is posted at most once per account, otherwise ErrInterestAlreadyPosted is returned.
+func (store *SQLStore) PostInterestTx(ctx context.Context, arg PostInterestTxParams) (PostInterestTxResult, error) {
+	var result PostInterestTxResult
+
+	err := store.execTx(ctx, func(q *Queries) error {
+		_, err := q.GetInterestPosting(ctx, GetInterestPostingParams{
+			AccountID:   arg.AccountID,
+			PeriodStart: arg.PeriodStart,
+		})
+		if err == nil {
+			return ErrInterestAlreadyPosted
+		}
+		if !errors.Is(err, ErrRecordNotFound) {
+			return err
+		}
+
+		account, err := q.GetAccount(ctx, arg.AccountID)
+		if err != nil {
+			return err
+		}
+
+		accrued, err := q.SumInterestAccruals(ctx, SumInterestAccrualsParams{
+			AccountID:   arg.AccountID,
+			PeriodStart: arg.PeriodStart,
+			PeriodEnd:   arg.PeriodEnd,
+		})
+		if err != nil {
+			return err
+		}
+
+		// add the fraction left over by the previous posting
+		var carried int64
+		last, err := q.GetLastInterestPosting(ctx, GetLastInterestPostingParams{
+			AccountID:   arg.AccountID,
+			PeriodStart: arg.PeriodStart,
+		})
+		if err == nil {
+			carried = last.RemainderMicros
+		} else if !errors.Is(err, ErrRecordNotFound) {
+			return err
+		}
+
+		total := accrued + carried
+		posting := CreateInterestPostingParams{
+			AccountID:       arg.AccountID,
+			PeriodStart:     arg.PeriodStart,
+			PeriodEnd:       arg.PeriodEnd,
+			AccruedMicros:   accrued,
+			Amount:          total / MicrosPerMinorUnit,
+			RemainderMicros: total % MicrosPerMinorUnit,
+		}
+
+		if posting.Amount > 0 {
+			expenseAccount, err := q.GetSystemAccount(ctx, GetSystemAccountParams{
+				Purpose:  SystemAccountInterestExpense,
+				Currency: account.Currency,
+			})
+			if err != nil {
+				return err
+			}
+
+			result.Transfer, err = transfer(ctx, q, TransferTxParams{
+				FromAccountID: expenseAccount.ID,
+				ToAccountID:   account.ID,
+				Amount:        posting.Amount,
+			}, 0)
+			if err != nil {
+				return err
+			}
+			posting.TransferID = pgtype.Int8{Int64: result.Transfer.Transfer.ID, Valid: true}
+		}
+
+		result.Posting, err = q.CreateInterestPosting(ctx, posting)
+		if ErrorCode(err) == UniqueViolation {
+			// posted by a concurrent transaction in the meantime
+			return ErrInterestAlreadyPosted
+		}
+		return err
+	})
+
+	return result, err
+}
@@ -0,0 +1,173 @@
+package db
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/XiaozhouCui/go-bank/db/util"
+	"github.com/stretchr/testify/require"
+)
+
+func createRandomInterestPlan(t *testing.T) InterestPlan {
+	arg := CreateInterestPlanParams{
+		Name:          util.RandomString(12),
+		AnnualRateBps: util.RandomInt(1, 500),
+		DayCount:      "ACT/365",
+	}
+
+	plan, err := testQueries.CreateInterestPlan(context.Background(), arg)
+	require.NoError(t, err)
+	require.NotZero(t, plan.ID)
+	require.Equal(t, arg.Name, plan.Name)
+	require.Equal(t, arg.AnnualRateBps, plan.AnnualRateBps)
+	require.Equal(t, arg.DayCount, plan.DayCount)
+	require.NotZero(t, plan.CreatedAt)
+
+	return plan
+}
+
+func addInterestAccrual(t *testing.T, accountID int64, date time.Time, amountMicros int64) {
+	err := testQueries.CreateInterestAccrual(context.Background(), CreateInterestAccrualParams{
+		AccountID:     accountID,
+		AccrualDate:   date,
+		Balance:       1000,
+		AnnualRateBps: 250,
+		DayCount:      "ACT/365",
+		AmountMicros:  amountMicros,
+	})
+	require.NoError(t, err)
+}
+
+func TestPostInterestTx(t *testing.T) {
+	store := NewStore(testDB)
+	account := createRandomAccount(t)
+	plan := createRandomInterestPlan(t)
+
+	_, err := testQueries.SetAccountInterestPlan(context.Background(), SetAccountInterestPlanParams{
+		AccountID: account.ID,
+		PlanID:    plan.ID,
+	})
+	require.NoError(t, err)
+
+	expense, err := testQueries.GetSystemAccount(context.Background(), GetSystemAccountParams{
+		Purpose:  SystemAccountInterestExpense,
+		Currency: account.Currency,
+	})
+	require.NoError(t, err)
+
+	january := time.Date(2023, time.January, 1, 0, 0, 0, 0, time.UTC)
+	february := january.AddDate(0, 1, 0)
+	march := february.AddDate(0, 1, 0)
+
+	// 1.5 cents accrued in January: 1 cent is credited, half a cent is carried
+	addInterestAccrual(t, account.ID, january, 700_000)
+	addInterestAccrual(t, account.ID, january.AddDate(0, 0, 1), 800_000)
+	// accruals are recorded once a day
+	addInterestAccrual(t, account.ID, january.AddDate(0, 0, 1), 800_000)
+
+	result, err := store.PostInterestTx(context.Background(), PostInterestTxParams{
+		AccountID:   account.ID,
+		PeriodStart: january,
+		PeriodEnd:   february,
+	})
+	require.NoError(t, err)
+
+	posting := result.Posting
+	require.NotZero(t, posting.ID)
+	require.Equal(t, account.ID, posting.AccountID)
+	require.Equal(t, int64(1_500_000), posting.AccruedMicros)
+	require.Equal(t, int64(1), posting.Amount)
+	require.Equal(t, int64(500_000), posting.RemainderMicros)
+	require.True(t, posting.TransferID.Valid)
+
+	transfer := result.Transfer
+	require.Equal(t, posting.TransferID.Int64, transfer.Transfer.ID)
+	require.Equal(t, expense.ID, transfer.FromAccount.ID)
+	require.Equal(t, account.ID, transfer.ToAccount.ID)
+	require.Equal(t, account.Balance+1, transfer.ToAccount.Balance)
+
+	// the same period cannot be posted twice
+	_, err = store.PostInterestTx(context.Background(), PostInterestTxParams{
+		AccountID:   account.ID,
+		PeriodStart: january,
+		PeriodEnd:   february,
+	})
+	require.ErrorIs(t, err, ErrInterestAlreadyPosted)
+
+	// the carried half cent is added to February
+	addInterestAccrual(t, account.ID, february, 600_000)
+
+	result, err = store.PostInterestTx(context.Background(), PostInterestTxParams{
+		AccountID:   account.ID,
+		PeriodStart: february,
+		PeriodEnd:   march,
+	})
+	require.NoError(t, err)
+	require.Equal(t, int64(600_000), result.Posting.AccruedMicros)
+	require.Equal(t, int64(1), result.Posting.Amount)
+	require.Equal(t, int64(100_000), result.Posting.RemainderMicros)
+
+	updatedAccount, err := testQueries.GetAccount(context.Background(), account.ID)
+	require.NoError(t, err)
+	require.Equal(t, account.Balance+2, updatedAccount.Balance)
+}
+
+func TestPostInterestTxBelowMinorUnit(t *testing.T) {
+	store := NewStore(testDB)
+	account := createRandomAccount(t)
+
+	period := time.Date(2023, time.April, 1, 0, 0, 0, 0, time.UTC)
+	addInterestAccrual(t, account.ID, period, 300_000)
+
+	// nothing to credit yet, the posting only records the fraction
+	result, err := store.PostInterestTx(context.Background(), PostInterestTxParams{
+		AccountID:   account.ID,
+		PeriodStart: period,
+		PeriodEnd:   period.AddDate(0, 1, 0),
+	})
+	require.NoError(t, err)
+	require.Zero(t, result.Posting.Amount)
+	require.Equal(t, int64(300_000), result.Posting.RemainderMicros)
+	require.False(t, result.Posting.TransferID.Valid)
+	require.Empty(t, result.Transfer)
+
+	updatedAccount, err := testQueries.GetAccount(context.Background(), account.ID)
+	require.NoError(t, err)
+	require.Equal(t, account.Balance, updatedAccount.Balance)
+}
+
+func TestListInterestBearingAccounts(t *testing.T) {
+	account := createRandomAccount(t)
+	plan := createRandomInterestPlan(t)
+
+	attached, err := testQueries.SetAccountInterestPlan(context.Background(), SetAccountInterestPlanParams{
+		AccountID: account.ID,
+		PlanID:    plan.ID,
+	})
+	require.NoError(t, err)
+
+	arg := ListInterestBearingAccountsParams{
+		AfterID: account.ID - 1,
+		Limit:   1,
+	}
+	accounts, err := testQueries.ListInterestBearingAccounts(context.Background(), arg)
+	require.NoError(t, err)
+	require.Len(t, accounts, 1)
+	require.Equal(t, account.ID, accounts[0].AccountID)
+	require.Equal(t, plan.AnnualRateBps, accounts[0].AnnualRateBps)
+
+	// nothing accrued yet, the first day to accrue is the day the plan was attached
+	year, month, day := attached.CreatedAt.UTC().Date()
+	attachedOn := time.Date(year, month, day, 0, 0, 0, 0, time.UTC)
+	require.True(t, accounts[0].LastAccrualDate.Equal(attachedOn.AddDate(0, 0, -1)))
+
+	// then the last day accrued for the account
+	addInterestAccrual(t, account.ID, attachedOn, 1000)
+	addInterestAccrual(t, account.ID, attachedOn.AddDate(0, 0, 1), 1000)
+
+	accounts, err = testQueries.ListInterestBearingAccounts(context.Background(), arg)
+	require.NoError(t, err)
+	require.Len(t, accounts, 1)
+	require.True(t, accounts[0].LastAccrualDate.Equal(attachedOn.AddDate(0, 0, 1)))
+}
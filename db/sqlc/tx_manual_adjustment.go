@@ -0,0 +1,81 @@
+package db
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/XiaozhouCui/go-bank/db/util"
+)
+
+// ManualAdjustmentTxParams contains the input parameters of the manual adjustment transaction.
+type ManualAdjustmentTxParams struct {
+	AccountID int64  `json:"account_id"`
+	Amount    int64  `json:"amount"` // credited if positive, debited if negative
+	Reason    string `json:"reason"`
+	Reference string `json:"reference"`
+	CreatedBy string `json:"created_by"`
+}
+
+// ManualAdjustmentTxResult contains the result of the manual adjustment transaction.
+type ManualAdjustmentTxResult struct {
+	Adjustment ManualAdjustment `json:"adjustment"`
+	Transfer   TransferTxResult `json:"transfer"`
+}
+
+// ManualAdjustmentTx corrects the balance of a customer account within a database transaction.
+// The balance never changes directly: a credit is a transfer from the suspense account of the account currency,
+// a debit a transfer to it, so the ledger stays balanced. A debit returns ErrInsufficientFunds
+// if the available balance is too low, and internal accounts return ErrInternalAccount.
+func (store *SQLStore) ManualAdjustmentTx(ctx context.Context, arg ManualAdjustmentTxParams) (ManualAdjustmentTxResult, error) {
+	var result ManualAdjustmentTxResult
+
+	err := store.execTx(ctx, func(q *Queries) error {
+		account, err := q.GetAccountForUpdate(ctx, arg.AccountID)
+		if err != nil {
+			return err
+		}
+		if account.Type == util.Internal {
+			return fmt.Errorf("account [%d]: %w", account.ID, ErrInternalAccount)
+		}
+		if account.AvailableBalance < -arg.Amount {
+			return fmt.Errorf("account [%d] has %d available: %w", account.ID, account.AvailableBalance, ErrInsufficientFunds)
+		}
+
+		suspenseAccount, err := q.GetSystemAccount(ctx, GetSystemAccountParams{
+			Purpose:  SystemAccountSuspense,
+			Currency: account.Currency,
+		})
+		if err != nil {
+			return err
+		}
+
+		transferArg := TransferTxParams{
+			FromAccountID: suspenseAccount.ID,
+			ToAccountID:   account.ID,
+			Amount:        arg.Amount,
+		}
+		if arg.Amount < 0 {
+			transferArg = TransferTxParams{
+				FromAccountID: account.ID,
+				ToAccountID:   suspenseAccount.ID,
+				Amount:        -arg.Amount,
+			}
+		}
+		result.Transfer, err = transfer(ctx, q, transferArg, 0)
+		if err != nil {
+			return err
+		}
+
+		result.Adjustment, err = q.CreateManualAdjustment(ctx, CreateManualAdjustmentParams{
+			AccountID:  account.ID,
+			Amount:     arg.Amount,
+			Reason:     arg.Reason,
+			Reference:  arg.Reference,
+			CreatedBy:  arg.CreatedBy,
+			TransferID: result.Transfer.Transfer.ID,
+		})
+		return err
+	})
+
+	return result, err
+}
@@ -0,0 +1,106 @@
+package db
+
+import (
+	"context"
+	"testing"
+
+	"github.com/XiaozhouCui/go-bank/db/util"
+	"github.com/stretchr/testify/require"
+)
+
+func TestManualAdjustmentTx(t *testing.T) {
+	store := NewStore(testDB)
+	account := createRandomAccount(t)
+	staff := createRandomUser(t)
+
+	suspense, err := store.GetSystemAccount(context.Background(), GetSystemAccountParams{
+		Purpose:  SystemAccountSuspense,
+		Currency: account.Currency,
+	})
+	require.NoError(t, err)
+
+	// a credit comes from the suspense account
+	credit, err := store.ManualAdjustmentTx(context.Background(), ManualAdjustmentTxParams{
+		AccountID: account.ID,
+		Amount:    10,
+		Reason:    "refund of a duplicate fee",
+		Reference: "CASE-" + util.RandomString(6),
+		CreatedBy: staff.Username,
+	})
+	require.NoError(t, err)
+	require.Equal(t, int64(10), credit.Adjustment.Amount)
+	require.Equal(t, credit.Transfer.Transfer.ID, credit.Adjustment.TransferID)
+	require.Equal(t, suspense.ID, credit.Transfer.Transfer.FromAccountID)
+	require.Equal(t, account.Balance+10, credit.Transfer.ToAccount.Balance)
+
+	// a debit goes to it
+	debit, err := store.ManualAdjustmentTx(context.Background(), ManualAdjustmentTxParams{
+		AccountID: account.ID,
+		Amount:    -4,
+		Reason:    "reversal of a manual credit",
+		Reference: credit.Adjustment.Reference,
+		CreatedBy: staff.Username,
+	})
+	require.NoError(t, err)
+	require.Equal(t, suspense.ID, debit.Transfer.Transfer.ToAccountID)
+	require.Equal(t, int64(4), debit.Transfer.Transfer.Amount)
+	require.Equal(t, account.Balance+6, debit.Transfer.FromAccount.Balance)
+
+	adjustments, err := store.ListManualAdjustments(context.Background(), ListManualAdjustmentsParams{
+		AccountID: account.ID,
+		Limit:     5,
+	})
+	require.NoError(t, err)
+	require.Len(t, adjustments, 2)
+	require.Equal(t, credit.Adjustment, adjustments[0])
+	require.Equal(t, debit.Adjustment, adjustments[1])
+}
+
+func TestManualAdjustmentTxRejected(t *testing.T) {
+	store := NewStore(testDB)
+	account := createRandomAccount(t)
+	staff := createRandomUser(t)
+
+	_, err := store.ManualAdjustmentTx(context.Background(), ManualAdjustmentTxParams{
+		AccountID: account.ID,
+		Amount:    -(account.AvailableBalance + 1),
+		Reason:    "overdraft",
+		Reference: "CASE-" + util.RandomString(6),
+		CreatedBy: staff.Username,
+	})
+	require.ErrorIs(t, err, ErrInsufficientFunds)
+
+	suspense, err := store.GetSystemAccount(context.Background(), GetSystemAccountParams{
+		Purpose:  SystemAccountSuspense,
+		Currency: account.Currency,
+	})
+	require.NoError(t, err)
+
+	_, err = store.ManualAdjustmentTx(context.Background(), ManualAdjustmentTxParams{
+		AccountID: suspense.ID,
+		Amount:    1,
+		Reason:    "internal",
+		Reference: "CASE-" + util.RandomString(6),
+		CreatedBy: staff.Username,
+	})
+	require.ErrorIs(t, err, ErrInternalAccount)
+}
+
+func TestSearchUsers(t *testing.T) {
+	user := createRandomUser(t)
+
+	users, err := testQueries.SearchUsers(context.Background(), SearchUsersParams{
+		Query: user.Username[1:4],
+		Limit: 10,
+	})
+	require.NoError(t, err)
+	require.NotEmpty(t, users)
+
+	users, err = testQueries.SearchUsers(context.Background(), SearchUsersParams{
+		Query: user.Email,
+		Limit: 10,
+	})
+	require.NoError(t, err)
+	require.Len(t, users, 1)
+	require.Equal(t, user.Username, users[0].Username)
+}
@@ -0,0 +1,110 @@
+package db
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+// payment request statuses, stored in payment_requests.status
+const (
+	PaymentRequestPending   = "pending"   // waiting for the payer
+	PaymentRequestPaid      = "paid"      // paid by a transfer, final
+	PaymentRequestDeclined  = "declined"  // declined by the payer, final
+	PaymentRequestExpired   = "expired"   // not paid before it expired, final
+	PaymentRequestCancelled = "cancelled" // cancelled by the requester, final
+)
+
+// PayPaymentRequestTxParams contains the input parameters of the payment request payment transaction.
+type PayPaymentRequestTxParams struct {
+	ID            int64     `json:"id"`
+	FromAccountID int64     `json:"from_account_id"` // account of the payer
+	Payer         string    `json:"payer"`           // user who pays, the owner or a member of the account
+	Now           time.Time `json:"now"`
+}
+
+// PayPaymentRequestTxResult contains the result of the payment request payment transaction.
+type PayPaymentRequestTxResult struct {
+	PaymentRequest PaymentRequest   `json:"payment_request"`
+	Transfer       TransferTxResult `json:"transfer"`
+}
+
+// PayPaymentRequestTx pays a pending payment request within a database transaction.
+// The money moves like TransferTx, with the limits and fee of the payer, and the request links to the transfer.
+// It returns ErrPaymentRequestNotPending if the request has been paid, declined, cancelled or has expired,
+// so a request is never paid twice.
+func (store *SQLStore) PayPaymentRequestTx(ctx context.Context, arg PayPaymentRequestTxParams) (PayPaymentRequestTxResult, error) {
+	var result PayPaymentRequestTxResult
+
+	err := store.execTx(ctx, func(q *Queries) error {
+		request, err := getPendingPaymentRequest(ctx, q, arg.ID, arg.Now)
+		if err != nil {
+			return err
+		}
+
+		result.Transfer, err = customerTransfer(ctx, q, TransferTxParams{
+			FromAccountID: arg.FromAccountID,
+			ToAccountID:   request.ToAccountID,
+			Amount:        request.Amount,
+			SentBy:        arg.Payer,
+		})
+		if err != nil {
+			return err
+		}
+
+		result.PaymentRequest, err = q.UpdatePaymentRequest(ctx, UpdatePaymentRequestParams{
+			ID:         request.ID,
+			Status:     PaymentRequestPaid,
+			TransferID: pgtype.Int8{Int64: result.Transfer.Transfer.ID, Valid: true},
+		})
+		return err
+	})
+
+	return result, err
+}
+
+// ClosePaymentRequestTxParams contains the input parameters of the payment request closing transaction.
+type ClosePaymentRequestTxParams struct {
+	ID     int64     `json:"id"`
+	Status string    `json:"status"` // PaymentRequestDeclined or PaymentRequestCancelled
+	Now    time.Time `json:"now"`
+}
+
+// ClosePaymentRequestTx declines or cancels a pending payment request within a database transaction.
+// It returns ErrPaymentRequestNotPending if the request has been paid, declined, cancelled or has expired.
+func (store *SQLStore) ClosePaymentRequestTx(ctx context.Context, arg ClosePaymentRequestTxParams) (PaymentRequest, error) {
+	var result PaymentRequest
+
+	err := store.execTx(ctx, func(q *Queries) error {
+		request, err := getPendingPaymentRequest(ctx, q, arg.ID, arg.Now)
+		if err != nil {
+			return err
+		}
+
+		result, err = q.UpdatePaymentRequest(ctx, UpdatePaymentRequestParams{
+			ID:     request.ID,
+			Status: arg.Status,
+		})
+		return err
+	})
+
+	return result, err
+}
+
+// getPendingPaymentRequest locks a payment request, and returns ErrPaymentRequestNotPending
+// unless it is pending and has not expired. The expiry sweeper may not have marked it expired yet.
+func getPendingPaymentRequest(ctx context.Context, q *Queries, id int64, now time.Time) (PaymentRequest, error) {
+	request, err := q.GetPaymentRequestForUpdate(ctx, id)
+	if err != nil {
+		return request, err
+	}
+	if request.Status != PaymentRequestPending {
+		return request, fmt.Errorf("payment request [%d] is %s: %w", request.ID, request.Status, ErrPaymentRequestNotPending)
+	}
+	if !request.ExpiresAt.After(now) {
+		return request, fmt.Errorf("payment request [%d] expired at %s: %w", request.ID, request.ExpiresAt.Format(time.RFC3339), ErrPaymentRequestNotPending)
+	}
+	return request, nil
+}
@@ -0,0 +1,163 @@
+package db
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/XiaozhouCui/go-bank/db/util"
+	"github.com/jackc/pgx/v5/pgtype"
+	"github.com/stretchr/testify/require"
+)
+
+func createRandomPaymentRequest(t *testing.T, payer string, to Account, amount int64, expiresAt time.Time) PaymentRequest {
+	arg := CreatePaymentRequestParams{
+		Requester:   to.Owner,
+		Payer:       payer,
+		ToAccountID: to.ID,
+		Amount:      amount,
+		Currency:    to.Currency,
+		Message:     util.RandomString(12),
+		ExpiresAt:   expiresAt,
+	}
+	request, err := testQueries.CreatePaymentRequest(context.Background(), arg)
+	require.NoError(t, err)
+	require.Equal(t, arg.Requester, request.Requester)
+	require.Equal(t, arg.Payer, request.Payer)
+	require.Equal(t, arg.ToAccountID, request.ToAccountID)
+	require.Equal(t, arg.Amount, request.Amount)
+	require.Equal(t, arg.Message, request.Message)
+	require.Equal(t, PaymentRequestPending, request.Status)
+	require.False(t, request.TransferID.Valid)
+	return request
+}
+
+func TestListPaymentRequests(t *testing.T) {
+	payer := createRandomUser(t)
+	account := createRandomAccount(t)
+
+	request1 := createRandomPaymentRequest(t, payer.Username, account, 10, time.Now().Add(time.Hour))
+	request2 := createRandomPaymentRequest(t, payer.Username, account, 20, time.Now().Add(time.Hour))
+
+	// newest first
+	incoming, err := testQueries.ListIncomingPaymentRequests(context.Background(), ListIncomingPaymentRequestsParams{
+		Payer:  payer.Username,
+		Status: pgtype.Text{String: PaymentRequestPending, Valid: true},
+		Limit:  5,
+	})
+	require.NoError(t, err)
+	require.Equal(t, []PaymentRequest{request2, request1}, incoming)
+
+	outgoing, err := testQueries.ListOutgoingPaymentRequests(context.Background(), ListOutgoingPaymentRequestsParams{
+		Requester: account.Owner,
+		Limit:     5,
+	})
+	require.NoError(t, err)
+	require.Equal(t, []PaymentRequest{request2, request1}, outgoing)
+
+	paid, err := testQueries.ListIncomingPaymentRequests(context.Background(), ListIncomingPaymentRequestsParams{
+		Payer:  payer.Username,
+		Status: pgtype.Text{String: PaymentRequestPaid, Valid: true},
+		Limit:  5,
+	})
+	require.NoError(t, err)
+	require.Empty(t, paid)
+}
+
+func TestPayPaymentRequestTx(t *testing.T) {
+	store := NewStore(testDB)
+
+	// use a currency of its own, so no fee schedule applies
+	currency := strings.ToUpper(util.RandomString(6))
+	from := createAccountInCurrency(t, currency, util.Checking)
+	to := createAccountInCurrency(t, currency, util.Checking)
+
+	request := createRandomPaymentRequest(t, from.Owner, to, 30, time.Now().Add(time.Hour))
+
+	result, err := store.PayPaymentRequestTx(context.Background(), PayPaymentRequestTxParams{
+		ID:            request.ID,
+		FromAccountID: from.ID,
+		Now:           time.Now(),
+	})
+	require.NoError(t, err)
+	require.Equal(t, PaymentRequestPaid, result.PaymentRequest.Status)
+	require.Equal(t, result.Transfer.Transfer.ID, result.PaymentRequest.TransferID.Int64)
+	require.Equal(t, int64(30), result.Transfer.Transfer.Amount)
+	require.Equal(t, from.Balance-30, result.Transfer.FromAccount.Balance)
+	require.Equal(t, to.Balance+30, result.Transfer.ToAccount.Balance)
+
+	// a request is paid once
+	_, err = store.PayPaymentRequestTx(context.Background(), PayPaymentRequestTxParams{
+		ID:            request.ID,
+		FromAccountID: from.ID,
+		Now:           time.Now(),
+	})
+	require.ErrorIs(t, err, ErrPaymentRequestNotPending)
+
+	// nor declined after it is paid
+	_, err = store.ClosePaymentRequestTx(context.Background(), ClosePaymentRequestTxParams{
+		ID:     request.ID,
+		Status: PaymentRequestDeclined,
+		Now:    time.Now(),
+	})
+	require.ErrorIs(t, err, ErrPaymentRequestNotPending)
+}
+
+func TestClosePaymentRequestTx(t *testing.T) {
+	store := NewStore(testDB)
+
+	payer := createRandomAccount(t)
+	account := createRandomAccount(t)
+	request := createRandomPaymentRequest(t, payer.Owner, account, 10, time.Now().Add(time.Hour))
+
+	declined, err := store.ClosePaymentRequestTx(context.Background(), ClosePaymentRequestTxParams{
+		ID:     request.ID,
+		Status: PaymentRequestDeclined,
+		Now:    time.Now(),
+	})
+	require.NoError(t, err)
+	require.Equal(t, PaymentRequestDeclined, declined.Status)
+
+	// a declined request cannot be paid
+	_, err = store.PayPaymentRequestTx(context.Background(), PayPaymentRequestTxParams{
+		ID:            request.ID,
+		FromAccountID: payer.ID,
+		Now:           time.Now(),
+	})
+	require.ErrorIs(t, err, ErrPaymentRequestNotPending)
+}
+
+func TestExpirePaymentRequests(t *testing.T) {
+	store := NewStore(testDB)
+
+	payer := createRandomAccount(t)
+	account := createRandomAccount(t)
+	now := time.Now()
+
+	expired := createRandomPaymentRequest(t, payer.Owner, account, 10, now.Add(-time.Minute))
+	pending := createRandomPaymentRequest(t, payer.Owner, account, 10, now.Add(time.Hour))
+
+	// an expired request cannot be paid before it is swept
+	_, err := store.PayPaymentRequestTx(context.Background(), PayPaymentRequestTxParams{
+		ID:            expired.ID,
+		FromAccountID: payer.ID,
+		Now:           now,
+	})
+	require.ErrorIs(t, err, ErrPaymentRequestNotPending)
+
+	requests, err := testQueries.ExpirePaymentRequests(context.Background(), now)
+	require.NoError(t, err)
+
+	var ids []int64
+	for _, request := range requests {
+		require.Equal(t, PaymentRequestExpired, request.Status)
+		ids = append(ids, request.ID)
+	}
+	require.Contains(t, ids, expired.ID)
+	require.NotContains(t, ids, pending.ID)
+
+	pending, err = testQueries.GetPaymentRequest(context.Background(), pending.ID)
+	require.NoError(t, err)
+	require.Equal(t, PaymentRequestPending, pending.Status)
+}
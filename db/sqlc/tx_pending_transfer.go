@@ -0,0 +1,146 @@
+package db
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+// pending transfer statuses, stored in pending_transfers.status
+const (
+	PendingTransferPending  = "pending"  // waiting for approvals
+	PendingTransferExecuted = "executed" // approved by the quorum and made, final
+	PendingTransferRejected = "rejected" // rejected by an approver, final
+)
+
+// approval decisions, stored in transfer_approvals.decision
+const (
+	ApprovalApproved = "approved"
+	ApprovalRejected = "rejected"
+)
+
+// DecidePendingTransferTxParams contains the input parameters of the pending transfer decision transaction.
+type DecidePendingTransferTxParams struct {
+	ID       int64  `json:"id"`
+	Approver string `json:"approver"`
+	Decision string `json:"decision"` // ApprovalApproved or ApprovalRejected
+}
+
+// DecidePendingTransferTxResult contains the result of the pending transfer decision transaction.
+type DecidePendingTransferTxResult struct {
+	PendingTransfer PendingTransfer  `json:"pending_transfer"`
+	Approval        TransferApproval `json:"approval"`
+	Transfer        TransferTxResult `json:"transfer"` // made by the approval that reached the quorum, empty otherwise
+}
+
+// DecidePendingTransferTx records the decision of an approver on a pending transfer within a database transaction.
+// A rejection rejects the transfer. The approval that reaches the quorum makes the transfer like TransferTx,
+// with the limits and fee of the sender, as long as the available balance still covers it. If the transfer fails,
+// the approval is not recorded either and the transfer stays pending, so it can be approved again later.
+// The user who requested the transfer must still be allowed to send it: if not, the transfer is rejected
+// and ErrSenderNotAllowed is returned with the result.
+// It returns ErrPendingTransferNotPending if the transfer has been executed or rejected,
+// and ErrNotApprover if the user is not one of its approvers or requested it.
+func (store *SQLStore) DecidePendingTransferTx(ctx context.Context, arg DecidePendingTransferTxParams) (DecidePendingTransferTxResult, error) {
+	var result DecidePendingTransferTxResult
+	var senderErr error
+
+	err := store.execTx(ctx, func(q *Queries) error {
+		pending, err := q.GetPendingTransferForUpdate(ctx, arg.ID)
+		if err != nil {
+			return err
+		}
+		if pending.Status != PendingTransferPending {
+			return fmt.Errorf("pending transfer [%d] is %s: %w", pending.ID, pending.Status, ErrPendingTransferNotPending)
+		}
+		if !CanApproveTransfer(pending, arg.Approver) {
+			return fmt.Errorf("pending transfer [%d], %s: %w", pending.ID, arg.Approver, ErrNotApprover)
+		}
+
+		// an approver decides once, the primary key rejects a second decision
+		result.Approval, err = q.CreateTransferApproval(ctx, CreateTransferApprovalParams{
+			PendingTransferID: pending.ID,
+			Approver:          arg.Approver,
+			Decision:          arg.Decision,
+		})
+		if err != nil {
+			return err
+		}
+
+		status := PendingTransferPending
+		var transferID pgtype.Int8
+
+		if arg.Decision == ApprovalRejected {
+			status = PendingTransferRejected
+		} else {
+			approvals, err := q.CountTransferApprovals(ctx, pending.ID)
+			if err != nil {
+				return err
+			}
+			if approvals < int64(pending.RequiredApprovals) {
+				result.PendingTransfer = pending
+				return nil
+			}
+
+			// a member removed from the account, or whose role or spend limit changed,
+			// cannot have the transfer they requested made
+			senderErr = checkSender(ctx, q, pending.RequestedBy, pending.FromAccountID, pending.Amount)
+			if senderErr != nil && !errors.Is(senderErr, ErrSenderNotAllowed) {
+				return senderErr
+			}
+
+			if senderErr != nil {
+				status = PendingTransferRejected
+			} else {
+				result.Transfer, err = approvedTransfer(ctx, q, TransferTxParams{
+					FromAccountID: pending.FromAccountID,
+					ToAccountID:   pending.ToAccountID,
+					Amount:        pending.Amount,
+					Description:   pending.Description,
+					Reference:     pending.Reference,
+					Tags:          pending.Tags,
+					SentBy:        pending.RequestedBy,
+				})
+				if err != nil {
+					return err
+				}
+
+				status = PendingTransferExecuted
+				transferID = pgtype.Int8{Int64: result.Transfer.Transfer.ID, Valid: true}
+			}
+		}
+
+		result.PendingTransfer, err = q.UpdatePendingTransfer(ctx, UpdatePendingTransferParams{
+			ID:         pending.ID,
+			Status:     status,
+			TransferID: transferID,
+		})
+		return err
+	})
+	if err == nil {
+		err = senderErr
+	}
+
+	return result, err
+}
+
+// CanApproveTransfer reports whether a user is one of the approvers of a pending transfer.
+// The user who requested a transfer never approves it, even if they are an approver of the account.
+func CanApproveTransfer(pending PendingTransfer, username string) bool {
+	return canApprove(pending.RequestedBy, pending.Approvers, username)
+}
+
+// canApprove reports whether a user is one of the approvers of a request, and did not make it.
+func canApprove(requestedBy string, approvers []string, username string) bool {
+	if username == requestedBy {
+		return false
+	}
+	for _, approver := range approvers {
+		if approver == username {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,253 @@
+package db
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/XiaozhouCui/go-bank/db/util"
+	"github.com/jackc/pgx/v5/pgtype"
+	"github.com/stretchr/testify/require"
+)
+
+func createRandomPendingTransfer(t *testing.T, from, to Account, amount int64, approvers []string) PendingTransfer {
+	arg := CreatePendingTransferParams{
+		FromAccountID:     from.ID,
+		ToAccountID:       to.ID,
+		Amount:            amount,
+		RequestedBy:       from.Owner,
+		RequiredApprovals: 2,
+		Approvers:         approvers,
+	}
+	pending, err := testQueries.CreatePendingTransfer(context.Background(), arg)
+	require.NoError(t, err)
+	require.Equal(t, arg.FromAccountID, pending.FromAccountID)
+	require.Equal(t, arg.Amount, pending.Amount)
+	require.Equal(t, arg.Approvers, pending.Approvers)
+	require.Equal(t, PendingTransferPending, pending.Status)
+	require.False(t, pending.TransferID.Valid)
+	return pending
+}
+
+func TestSetApprovalPolicy(t *testing.T) {
+	account := createRandomAccount(t)
+	approver := createRandomUser(t)
+
+	policy, err := testQueries.SetApprovalPolicy(context.Background(), SetApprovalPolicyParams{
+		AccountID:         account.ID,
+		Threshold:         1000,
+		RequiredApprovals: 1,
+		Approvers:         []string{approver.Username},
+		UpdatedBy:         account.Owner,
+	})
+	require.NoError(t, err)
+	require.Equal(t, int64(1000), policy.Threshold)
+
+	// a policy replaces the previous one
+	policy, err = testQueries.SetApprovalPolicy(context.Background(), SetApprovalPolicyParams{
+		AccountID:         account.ID,
+		Threshold:         500,
+		RequiredApprovals: 2,
+		Approvers:         []string{account.Owner, approver.Username},
+		UpdatedBy:         account.Owner,
+	})
+	require.NoError(t, err)
+
+	got, err := testQueries.GetApprovalPolicy(context.Background(), account.ID)
+	require.NoError(t, err)
+	require.Equal(t, policy, got)
+	require.Equal(t, int32(2), got.RequiredApprovals)
+
+	// the check constraint rejects more required approvals than approvers
+	_, err = testQueries.SetApprovalPolicy(context.Background(), SetApprovalPolicyParams{
+		AccountID:         account.ID,
+		Threshold:         500,
+		RequiredApprovals: 2,
+		Approvers:         []string{approver.Username},
+		UpdatedBy:         account.Owner,
+	})
+	require.Error(t, err)
+
+	require.NoError(t, testQueries.DeleteApprovalPolicy(context.Background(), account.ID))
+	_, err = testQueries.GetApprovalPolicy(context.Background(), account.ID)
+	require.ErrorIs(t, err, ErrRecordNotFound)
+}
+
+func TestDecidePendingTransferTx(t *testing.T) {
+	store := NewStore(testDB)
+
+	// use a currency of its own, so no fee schedule applies
+	currency := strings.ToUpper(util.RandomString(6))
+	from := createAccountInCurrency(t, currency, util.Checking)
+	to := createAccountInCurrency(t, currency, util.Checking)
+	approver1 := createRandomUser(t)
+	approver2 := createRandomUser(t)
+
+	pending := createRandomPendingTransfer(t, from, to, 30, []string{from.Owner, approver1.Username, approver2.Username})
+
+	// the requester does not approve their own transfer
+	_, err := store.DecidePendingTransferTx(context.Background(), DecidePendingTransferTxParams{
+		ID:       pending.ID,
+		Approver: from.Owner,
+		Decision: ApprovalApproved,
+	})
+	require.ErrorIs(t, err, ErrNotApprover)
+
+	// the first approval is under the quorum
+	result, err := store.DecidePendingTransferTx(context.Background(), DecidePendingTransferTxParams{
+		ID:       pending.ID,
+		Approver: approver1.Username,
+		Decision: ApprovalApproved,
+	})
+	require.NoError(t, err)
+	require.Equal(t, PendingTransferPending, result.PendingTransfer.Status)
+	require.Equal(t, ApprovalApproved, result.Approval.Decision)
+	require.Zero(t, result.Transfer.Transfer.ID)
+
+	// an approver decides once
+	_, err = store.DecidePendingTransferTx(context.Background(), DecidePendingTransferTxParams{
+		ID:       pending.ID,
+		Approver: approver1.Username,
+		Decision: ApprovalApproved,
+	})
+	require.Equal(t, UniqueViolation, ErrorCode(err))
+
+	// the second approval makes the transfer
+	result, err = store.DecidePendingTransferTx(context.Background(), DecidePendingTransferTxParams{
+		ID:       pending.ID,
+		Approver: approver2.Username,
+		Decision: ApprovalApproved,
+	})
+	require.NoError(t, err)
+	require.Equal(t, PendingTransferExecuted, result.PendingTransfer.Status)
+	require.Equal(t, result.Transfer.Transfer.ID, result.PendingTransfer.TransferID.Int64)
+	require.Equal(t, from.Balance-30, result.Transfer.FromAccount.Balance)
+	require.Equal(t, to.Balance+30, result.Transfer.ToAccount.Balance)
+
+	approvals, err := testQueries.ListTransferApprovals(context.Background(), pending.ID)
+	require.NoError(t, err)
+	require.Len(t, approvals, 2)
+
+	// an executed transfer is final
+	_, err = store.DecidePendingTransferTx(context.Background(), DecidePendingTransferTxParams{
+		ID:       pending.ID,
+		Approver: approver1.Username,
+		Decision: ApprovalRejected,
+	})
+	require.ErrorIs(t, err, ErrPendingTransferNotPending)
+}
+
+func TestRejectPendingTransferTx(t *testing.T) {
+	store := NewStore(testDB)
+
+	from := createRandomAccount(t)
+	to := createRandomAccount(t)
+	approver1 := createRandomUser(t)
+	approver2 := createRandomUser(t)
+
+	pending := createRandomPendingTransfer(t, from, to, 10, []string{approver1.Username, approver2.Username})
+
+	result, err := store.DecidePendingTransferTx(context.Background(), DecidePendingTransferTxParams{
+		ID:       pending.ID,
+		Approver: approver1.Username,
+		Decision: ApprovalRejected,
+	})
+	require.NoError(t, err)
+	require.Equal(t, PendingTransferRejected, result.PendingTransfer.Status)
+	require.False(t, result.PendingTransfer.TransferID.Valid)
+
+	_, err = store.DecidePendingTransferTx(context.Background(), DecidePendingTransferTxParams{
+		ID:       pending.ID,
+		Approver: approver2.Username,
+		Decision: ApprovalApproved,
+	})
+	require.ErrorIs(t, err, ErrPendingTransferNotPending)
+}
+
+func TestDecidePendingTransferTxInsufficientFunds(t *testing.T) {
+	store := NewStore(testDB)
+
+	currency := strings.ToUpper(util.RandomString(6))
+	from := createAccountInCurrency(t, currency, util.Checking)
+	to := createAccountInCurrency(t, currency, util.Checking)
+	approver1 := createRandomUser(t)
+	approver2 := createRandomUser(t)
+
+	// the funds are spent while the transfer waits for its approvals
+	pending := createRandomPendingTransfer(t, from, to, from.Balance+1, []string{approver1.Username, approver2.Username})
+
+	_, err := store.DecidePendingTransferTx(context.Background(), DecidePendingTransferTxParams{
+		ID:       pending.ID,
+		Approver: approver1.Username,
+		Decision: ApprovalApproved,
+	})
+	require.NoError(t, err)
+
+	_, err = store.DecidePendingTransferTx(context.Background(), DecidePendingTransferTxParams{
+		ID:       pending.ID,
+		Approver: approver2.Username,
+		Decision: ApprovalApproved,
+	})
+	require.ErrorIs(t, err, ErrInsufficientFunds)
+
+	// the failed approval is not recorded, the transfer can still be approved once funded
+	pending, err = testQueries.GetPendingTransfer(context.Background(), pending.ID)
+	require.NoError(t, err)
+	require.Equal(t, PendingTransferPending, pending.Status)
+
+	approvals, err := testQueries.ListTransferApprovals(context.Background(), pending.ID)
+	require.NoError(t, err)
+	require.Len(t, approvals, 1)
+}
+
+func TestDecidePendingTransferTxRemovedRequester(t *testing.T) {
+	store := NewStore(testDB)
+
+	from := createRandomAccount(t)
+	to := createRandomAccount(t)
+	approver1 := createRandomUser(t)
+	approver2 := createRandomUser(t)
+
+	spender := createRandomAccountMember(t, from, AccountSpender, pgtype.Int8{})
+	_, err := testQueries.AcceptAccountMember(context.Background(), AcceptAccountMemberParams{
+		AccountID: from.ID,
+		Username:  spender.Username,
+	})
+	require.NoError(t, err)
+
+	sender := from
+	sender.Owner = spender.Username
+	pending := createRandomPendingTransfer(t, sender, to, 10, []string{approver1.Username, approver2.Username})
+
+	_, err = store.DecidePendingTransferTx(context.Background(), DecidePendingTransferTxParams{
+		ID:       pending.ID,
+		Approver: approver1.Username,
+		Decision: ApprovalApproved,
+	})
+	require.NoError(t, err)
+
+	// the requester is removed from the account while the transfer waits for its approvals
+	err = testQueries.DeleteAccountMember(context.Background(), DeleteAccountMemberParams{
+		AccountID: from.ID,
+		Username:  spender.Username,
+	})
+	require.NoError(t, err)
+
+	result, err := store.DecidePendingTransferTx(context.Background(), DecidePendingTransferTxParams{
+		ID:       pending.ID,
+		Approver: approver2.Username,
+		Decision: ApprovalApproved,
+	})
+	require.ErrorIs(t, err, ErrSenderNotAllowed)
+	require.Equal(t, PendingTransferRejected, result.PendingTransfer.Status)
+	require.False(t, result.PendingTransfer.TransferID.Valid)
+
+	// the rejection is kept, and no money moved
+	pending, err = testQueries.GetPendingTransfer(context.Background(), pending.ID)
+	require.NoError(t, err)
+	require.Equal(t, PendingTransferRejected, pending.Status)
+
+	account, err := testQueries.GetAccount(context.Background(), from.ID)
+	require.NoError(t, err)
+	require.Equal(t, from.Balance, account.Balance)
+}
@@ -0,0 +1,103 @@
+package db
+
+import (
+	"context"
+	"fmt"
+)
+
+// policy change statuses, stored in policy_changes.status, the same as the ones of pending transfers
+const (
+	PolicyChangePending  = PendingTransferPending  // waiting for approvals
+	PolicyChangeExecuted = PendingTransferExecuted // approved by the quorum and applied, final
+	PolicyChangeRejected = PendingTransferRejected // rejected by an approver, final
+)
+
+// DecidePolicyChangeTxParams contains the input parameters of the policy change decision transaction.
+type DecidePolicyChangeTxParams struct {
+	ID       int64  `json:"id"`
+	Approver string `json:"approver"`
+	Decision string `json:"decision"` // ApprovalApproved or ApprovalRejected
+}
+
+// DecidePolicyChangeTxResult contains the result of the policy change decision transaction.
+type DecidePolicyChangeTxResult struct {
+	PolicyChange PolicyChange         `json:"policy_change"`
+	Approval     PolicyChangeApproval `json:"approval"`
+	// the policy of the account once the change is applied, empty until then or if the change removes it
+	Policy ApprovalPolicy `json:"policy"`
+}
+
+// DecidePolicyChangeTx records the decision of an approver on a change of the approval policy of an account
+// within a database transaction. A change loosening or removing a policy needs the approvals of its current quorum,
+// like a transfer above its threshold. A rejection rejects the change, the approval that reaches the quorum
+// applies it, on behalf of the member who requested it.
+// It returns ErrPolicyChangeNotPending if the change has been applied or rejected,
+// and ErrNotApprover if the user is not one of its approvers or requested it.
+func (store *SQLStore) DecidePolicyChangeTx(ctx context.Context, arg DecidePolicyChangeTxParams) (DecidePolicyChangeTxResult, error) {
+	var result DecidePolicyChangeTxResult
+
+	err := store.execTx(ctx, func(q *Queries) error {
+		change, err := q.GetPolicyChangeForUpdate(ctx, arg.ID)
+		if err != nil {
+			return err
+		}
+		if change.Status != PolicyChangePending {
+			return fmt.Errorf("policy change [%d] is %s: %w", change.ID, change.Status, ErrPolicyChangeNotPending)
+		}
+		if !CanApprovePolicyChange(change, arg.Approver) {
+			return fmt.Errorf("policy change [%d], %s: %w", change.ID, arg.Approver, ErrNotApprover)
+		}
+
+		// an approver decides once, the primary key rejects a second decision
+		result.Approval, err = q.CreatePolicyChangeApproval(ctx, CreatePolicyChangeApprovalParams{
+			PolicyChangeID: change.ID,
+			Approver:       arg.Approver,
+			Decision:       arg.Decision,
+		})
+		if err != nil {
+			return err
+		}
+
+		status := PolicyChangeRejected
+		if arg.Decision == ApprovalApproved {
+			approvals, err := q.CountPolicyChangeApprovals(ctx, change.ID)
+			if err != nil {
+				return err
+			}
+			if approvals < int64(change.RequiredApprovals) {
+				result.PolicyChange = change
+				return nil
+			}
+
+			if change.NewThreshold.Valid {
+				result.Policy, err = q.SetApprovalPolicy(ctx, SetApprovalPolicyParams{
+					AccountID:         change.AccountID,
+					Threshold:         change.NewThreshold.Int64,
+					RequiredApprovals: change.NewRequiredApprovals.Int32,
+					Approvers:         change.NewApprovers,
+					UpdatedBy:         change.RequestedBy,
+				})
+			} else {
+				err = q.DeleteApprovalPolicy(ctx, change.AccountID)
+			}
+			if err != nil {
+				return err
+			}
+			status = PolicyChangeExecuted
+		}
+
+		result.PolicyChange, err = q.UpdatePolicyChangeStatus(ctx, UpdatePolicyChangeStatusParams{
+			ID:     change.ID,
+			Status: status,
+		})
+		return err
+	})
+
+	return result, err
+}
+
+// CanApprovePolicyChange reports whether a user is one of the approvers of a policy change.
+// The user who requested a change never approves it, even if they are an approver of the account.
+func CanApprovePolicyChange(change PolicyChange, username string) bool {
+	return canApprove(change.RequestedBy, change.Approvers, username)
+}
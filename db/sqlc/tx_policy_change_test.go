@@ -0,0 +1,123 @@
+package db
+
+import (
+	"context"
+	"testing"
+
+	"github.com/jackc/pgx/v5/pgtype"
+	"github.com/stretchr/testify/require"
+)
+
+// createRandomPolicyChange sets a policy on the account approved by its owner and approver,
+// and asks to replace it with newPolicy, or to remove it if newPolicy is nil
+func createRandomPolicyChange(t *testing.T, account Account, approver User, newPolicy *SetApprovalPolicyParams) PolicyChange {
+	policy, err := testQueries.SetApprovalPolicy(context.Background(), SetApprovalPolicyParams{
+		AccountID:         account.ID,
+		Threshold:         1000,
+		RequiredApprovals: 1,
+		Approvers:         []string{account.Owner, approver.Username},
+		UpdatedBy:         account.Owner,
+	})
+	require.NoError(t, err)
+
+	arg := CreatePolicyChangeParams{
+		AccountID:         account.ID,
+		RequestedBy:       account.Owner,
+		RequiredApprovals: policy.RequiredApprovals,
+		Approvers:         policy.Approvers,
+	}
+	if newPolicy != nil {
+		arg.NewThreshold = pgtype.Int8{Int64: newPolicy.Threshold, Valid: true}
+		arg.NewRequiredApprovals = pgtype.Int4{Int32: newPolicy.RequiredApprovals, Valid: true}
+		arg.NewApprovers = newPolicy.Approvers
+	}
+	change, err := testQueries.CreatePolicyChange(context.Background(), arg)
+	require.NoError(t, err)
+	require.Equal(t, PolicyChangePending, change.Status)
+	require.Equal(t, arg.NewThreshold, change.NewThreshold)
+	return change
+}
+
+func TestDecidePolicyChangeTx(t *testing.T) {
+	store := NewStore(testDB)
+
+	account := createRandomAccount(t)
+	approver := createRandomUser(t)
+	change := createRandomPolicyChange(t, account, approver, &SetApprovalPolicyParams{
+		Threshold:         5000,
+		RequiredApprovals: 1,
+		Approvers:         []string{account.Owner, approver.Username},
+	})
+
+	// the requester does not approve their own change
+	_, err := store.DecidePolicyChangeTx(context.Background(), DecidePolicyChangeTxParams{
+		ID:       change.ID,
+		Approver: account.Owner,
+		Decision: ApprovalApproved,
+	})
+	require.ErrorIs(t, err, ErrNotApprover)
+
+	policy, err := testQueries.GetApprovalPolicy(context.Background(), account.ID)
+	require.NoError(t, err)
+	require.Equal(t, int64(1000), policy.Threshold)
+
+	// the approval that reaches the quorum applies the change
+	result, err := store.DecidePolicyChangeTx(context.Background(), DecidePolicyChangeTxParams{
+		ID:       change.ID,
+		Approver: approver.Username,
+		Decision: ApprovalApproved,
+	})
+	require.NoError(t, err)
+	require.Equal(t, PolicyChangeExecuted, result.PolicyChange.Status)
+	require.Equal(t, int64(5000), result.Policy.Threshold)
+	require.Equal(t, account.Owner, result.Policy.UpdatedBy)
+
+	policy, err = testQueries.GetApprovalPolicy(context.Background(), account.ID)
+	require.NoError(t, err)
+	require.Equal(t, result.Policy, policy)
+
+	// an applied change is final
+	_, err = store.DecidePolicyChangeTx(context.Background(), DecidePolicyChangeTxParams{
+		ID:       change.ID,
+		Approver: approver.Username,
+		Decision: ApprovalRejected,
+	})
+	require.ErrorIs(t, err, ErrPolicyChangeNotPending)
+}
+
+func TestDecidePolicyChangeTxRemove(t *testing.T) {
+	store := NewStore(testDB)
+
+	account := createRandomAccount(t)
+	approver := createRandomUser(t)
+
+	// a rejected removal leaves the policy as it is
+	change := createRandomPolicyChange(t, account, approver, nil)
+	result, err := store.DecidePolicyChangeTx(context.Background(), DecidePolicyChangeTxParams{
+		ID:       change.ID,
+		Approver: approver.Username,
+		Decision: ApprovalRejected,
+	})
+	require.NoError(t, err)
+	require.Equal(t, PolicyChangeRejected, result.PolicyChange.Status)
+
+	_, err = testQueries.GetApprovalPolicy(context.Background(), account.ID)
+	require.NoError(t, err)
+
+	// an approved one removes it
+	change = createRandomPolicyChange(t, account, approver, nil)
+	result, err = store.DecidePolicyChangeTx(context.Background(), DecidePolicyChangeTxParams{
+		ID:       change.ID,
+		Approver: approver.Username,
+		Decision: ApprovalApproved,
+	})
+	require.NoError(t, err)
+	require.Equal(t, PolicyChangeExecuted, result.PolicyChange.Status)
+
+	_, err = testQueries.GetApprovalPolicy(context.Background(), account.ID)
+	require.ErrorIs(t, err, ErrRecordNotFound)
+
+	approvals, err := testQueries.ListPolicyChangeApprovals(context.Background(), change.ID)
+	require.NoError(t, err)
+	require.Len(t, approvals, 1)
+}
@@ -0,0 +1,226 @@
+package db
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+// RunScheduledTransferTxParams contains the input parameters of the scheduled transfer run transaction.
+type RunScheduledTransferTxParams struct {
+	ID    int64     `json:"id"`
+	DueAt time.Time `json:"due_at"` // next_run_at the run was picked up for
+	Now   time.Time `json:"now"`
+}
+
+// RunScheduledTransferTxResult contains the result of the scheduled transfer run transaction.
+type RunScheduledTransferTxResult struct {
+	ScheduledTransfer ScheduledTransfer    `json:"scheduled_transfer"` // with its next run
+	Run               ScheduledTransferRun `json:"run"`
+	Transfer          TransferTxResult     `json:"transfer"`
+}
+
+// RunScheduledTransferTx runs a due scheduled transfer within a database transaction.
+// The money moves the same way as TransferTx, with the same limits and fee, but the sender cannot
+// go below its available balance. The run is recorded and the next run is set in the same transaction, so an
+// occurrence is never paid twice: ErrScheduledTransferNotDue is returned if it has already run.
+// Its owner must still be allowed to send the amount from the account, or ErrSenderNotAllowed is returned:
+// a member removed from the account, or whose role or spend limit changed, cannot keep paying from it,
+// and the runs count towards the daily spend limit of a member like their other transfers.
+// ErrApprovalRequired is returned if an approval policy set since covers the amount, a scheduled transfer
+// never waits for approvals.
+// On any other error nothing is written, see FailScheduledTransferTx.
+func (store *SQLStore) RunScheduledTransferTx(ctx context.Context, arg RunScheduledTransferTxParams) (RunScheduledTransferTxResult, error) {
+	var result RunScheduledTransferTxResult
+
+	err := store.execTx(ctx, func(q *Queries) error {
+		scheduled, err := getDueScheduledTransfer(ctx, q, arg.ID, arg.DueAt)
+		if err != nil {
+			return err
+		}
+		result.Transfer, err = customerTransfer(ctx, q, TransferTxParams{
+			FromAccountID: scheduled.FromAccountID,
+			ToAccountID:   scheduled.ToAccountID,
+			Amount:        scheduled.Amount,
+			SentBy:        scheduled.Owner,
+		})
+		if err != nil {
+			return err
+		}
+
+		result.Run, err = q.CreateScheduledTransferRun(ctx, CreateScheduledTransferRunParams{
+			ScheduledTransferID: scheduled.ID,
+			ScheduledFor:        arg.DueAt,
+			Attempt:             scheduled.RetryCount + 1,
+			Outcome:             RunOutcomeSucceeded,
+			TransferID:          pgtype.Int8{Int64: result.Transfer.Transfer.ID, Valid: true},
+		})
+		if err != nil {
+			return err
+		}
+
+		nextRunAt, err := NextScheduledRun(scheduled.Schedule, scheduled.StartAt, arg.Now)
+		if err != nil {
+			return err
+		}
+		status := ScheduledTransferActive
+		if !nextRunAt.Valid {
+			status = ScheduledTransferCompleted
+		}
+
+		result.ScheduledTransfer, err = q.UpdateScheduledTransfer(ctx, UpdateScheduledTransferParams{
+			ID:            scheduled.ID,
+			Amount:        scheduled.Amount,
+			Schedule:      scheduled.Schedule,
+			NextRunAt:     nextRunAt,
+			FailurePolicy: scheduled.FailurePolicy,
+			MaxRetries:    scheduled.MaxRetries,
+			RetryCount:    0,
+			Status:        status,
+		})
+		return err
+	})
+
+	return result, err
+}
+
+// FailScheduledTransferTxParams contains the input parameters of the scheduled transfer failure transaction.
+type FailScheduledTransferTxParams struct {
+	ID         int64         `json:"id"`
+	DueAt      time.Time     `json:"due_at"` // next_run_at the failed run was picked up for
+	Now        time.Time     `json:"now"`
+	Error      string        `json:"error"`       // why the run failed
+	RetryDelay time.Duration `json:"retry_delay"` // wait before a retry
+	Cancel     bool          `json:"cancel"`      // the transfer can never run again, whatever its policy
+}
+
+// FailScheduledTransferTxResult contains the result of the scheduled transfer failure transaction.
+type FailScheduledTransferTxResult struct {
+	ScheduledTransfer ScheduledTransfer    `json:"scheduled_transfer"` // with its next run
+	Run               ScheduledTransferRun `json:"run"`
+}
+
+// FailScheduledTransferTx records a failed run of a scheduled transfer within a database transaction,
+// and applies its failure policy: it is either retried after RetryDelay, or the occurrence is skipped.
+// A one-off transfer that is skipped has failed for good. A cancelled transfer is not run again.
+func (store *SQLStore) FailScheduledTransferTx(ctx context.Context, arg FailScheduledTransferTxParams) (FailScheduledTransferTxResult, error) {
+	var result FailScheduledTransferTxResult
+
+	err := store.execTx(ctx, func(q *Queries) error {
+		scheduled, err := getDueScheduledTransfer(ctx, q, arg.ID, arg.DueAt)
+		if err != nil {
+			return err
+		}
+
+		result.Run, err = q.CreateScheduledTransferRun(ctx, CreateScheduledTransferRunParams{
+			ScheduledTransferID: scheduled.ID,
+			ScheduledFor:        arg.DueAt,
+			Attempt:             scheduled.RetryCount + 1,
+			Outcome:             RunOutcomeFailed,
+			Error:               arg.Error,
+		})
+		if err != nil {
+			return err
+		}
+
+		update := UpdateScheduledTransferParams{
+			ID:            scheduled.ID,
+			Amount:        scheduled.Amount,
+			Schedule:      scheduled.Schedule,
+			FailurePolicy: scheduled.FailurePolicy,
+			MaxRetries:    scheduled.MaxRetries,
+			Status:        ScheduledTransferActive,
+		}
+		switch {
+		case arg.Cancel:
+			update.Status = ScheduledTransferCancelled
+		case scheduled.FailurePolicy == FailurePolicyRetry && scheduled.RetryCount < scheduled.MaxRetries:
+			update.NextRunAt = pgtype.Timestamptz{Time: arg.Now.Add(arg.RetryDelay), Valid: true}
+			update.RetryCount = scheduled.RetryCount + 1
+		default:
+			update.NextRunAt, err = NextScheduledRun(scheduled.Schedule, scheduled.StartAt, arg.Now)
+			if err != nil {
+				return err
+			}
+			if !update.NextRunAt.Valid {
+				update.Status = ScheduledTransferFailed
+			}
+		}
+
+		result.ScheduledTransfer, err = q.UpdateScheduledTransfer(ctx, update)
+		return err
+	})
+
+	return result, err
+}
+
+// UpdateScheduledTransferTxParams contains the input parameters of the scheduled transfer update transaction.
+type UpdateScheduledTransferTxParams struct {
+	ID            int64     `json:"id"`
+	Amount        int64     `json:"amount"`
+	FailurePolicy string    `json:"failure_policy"`
+	MaxRetries    int64     `json:"max_retries"`
+	Status        string    `json:"status"` // active, paused or cancelled
+	Now           time.Time `json:"now"`
+}
+
+// UpdateScheduledTransferTx changes a scheduled transfer within a database transaction.
+// The row is locked, so the change cannot interleave with a run. The schedule itself cannot change.
+// A recurring transfer resumed after some of its occurrences went by skips them.
+// ErrScheduledTransferEnded is returned if the transfer is completed, failed or cancelled.
+func (store *SQLStore) UpdateScheduledTransferTx(ctx context.Context, arg UpdateScheduledTransferTxParams) (ScheduledTransfer, error) {
+	var result ScheduledTransfer
+
+	err := store.execTx(ctx, func(q *Queries) error {
+		scheduled, err := q.GetScheduledTransferForUpdate(ctx, arg.ID)
+		if err != nil {
+			return err
+		}
+		if scheduled.Status != ScheduledTransferActive && scheduled.Status != ScheduledTransferPaused {
+			return fmt.Errorf("scheduled transfer [%d] is %s: %w", scheduled.ID, scheduled.Status, ErrScheduledTransferEnded)
+		}
+
+		nextRunAt := scheduled.NextRunAt
+		retryCount := scheduled.RetryCount
+		switch {
+		case arg.Status == ScheduledTransferCancelled:
+			nextRunAt = pgtype.Timestamptz{}
+		case scheduled.Status == ScheduledTransferPaused && arg.Status == ScheduledTransferActive &&
+			scheduled.Schedule != "" && nextRunAt.Time.Before(arg.Now):
+			// skip the occurrences missed while paused
+			nextRunAt, err = NextScheduledRun(scheduled.Schedule, scheduled.StartAt, arg.Now)
+			if err != nil {
+				return err
+			}
+			retryCount = 0
+		}
+
+		result, err = q.UpdateScheduledTransfer(ctx, UpdateScheduledTransferParams{
+			ID:            scheduled.ID,
+			Amount:        arg.Amount,
+			Schedule:      scheduled.Schedule,
+			NextRunAt:     nextRunAt,
+			FailurePolicy: arg.FailurePolicy,
+			MaxRetries:    arg.MaxRetries,
+			RetryCount:    retryCount,
+			Status:        arg.Status,
+		})
+		return err
+	})
+
+	return result, err
+}
+
+// getDueScheduledTransfer locks a scheduled transfer and checks that it is still due at dueAt.
+func getDueScheduledTransfer(ctx context.Context, q *Queries, id int64, dueAt time.Time) (ScheduledTransfer, error) {
+	scheduled, err := q.GetScheduledTransferForUpdate(ctx, id)
+	if err != nil {
+		return scheduled, err
+	}
+	if scheduled.Status != ScheduledTransferActive || !scheduled.NextRunAt.Valid || !scheduled.NextRunAt.Time.Equal(dueAt) {
+		return scheduled, fmt.Errorf("scheduled transfer [%d]: %w", id, ErrScheduledTransferNotDue)
+	}
+	return scheduled, nil
+}
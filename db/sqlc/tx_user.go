@@ -0,0 +1,30 @@
+package db
+
+import (
+	"context"
+
+	"github.com/XiaozhouCui/go-bank/events"
+)
+
+// CreateUserTx registers a user within a database transaction, and writes its UserRegistered event to the outbox.
+func (store *SQLStore) CreateUserTx(ctx context.Context, arg CreateUserParams) (User, error) {
+	var result User
+
+	err := store.execTx(ctx, func(q *Queries) error {
+		var err error
+		result, err = q.CreateUser(ctx, arg)
+		if err != nil {
+			return err
+		}
+
+		return addEvent(ctx, q, events.UserRegisteredV1{
+			Username:     result.Username,
+			FullName:     result.FullName,
+			Email:        result.Email,
+			Role:         result.Role,
+			RegisteredAt: result.CreatedAt,
+		})
+	})
+
+	return result, err
+}
@@ -0,0 +1,62 @@
+package db
+
+import (
+	"context"
+	"time"
+
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+// webhook delivery statuses, stored in webhook_deliveries.status
+const (
+	WebhookDeliveryPending   = "pending"   // waiting for its next attempt
+	WebhookDeliverySucceeded = "succeeded" // the endpoint accepted it, final
+	WebhookDeliveryDead      = "dead"      // failed too many times, only retried on request
+)
+
+// RecordWebhookAttemptTxParams contains the input parameters of the webhook attempt transaction.
+type RecordWebhookAttemptTxParams struct {
+	Delivery   WebhookDelivery `json:"delivery"`
+	StatusCode int64           `json:"status_code"` // 0 if the endpoint did not answer
+	Error      string          `json:"error"`       // empty if the endpoint accepted the delivery
+	Duration   time.Duration   `json:"duration"`
+	// status of the delivery after the attempt, and when to try again if it is still pending
+	Status        string    `json:"status"`
+	NextAttemptAt time.Time `json:"next_attempt_at"`
+}
+
+// RecordWebhookAttemptTx logs an attempt to deliver a webhook and updates the delivery with its outcome,
+// within a database transaction.
+func (store *SQLStore) RecordWebhookAttemptTx(ctx context.Context, arg RecordWebhookAttemptTxParams) (WebhookDelivery, error) {
+	var result WebhookDelivery
+
+	err := store.execTx(ctx, func(q *Queries) error {
+		attempt, err := q.CreateWebhookDeliveryAttempt(ctx, CreateWebhookDeliveryAttemptParams{
+			DeliveryID: arg.Delivery.ID,
+			StatusCode: arg.StatusCode,
+			Error:      arg.Error,
+			DurationMs: arg.Duration.Milliseconds(),
+		})
+		if err != nil {
+			return err
+		}
+
+		var deliveredAt pgtype.Timestamptz
+		if arg.Status == WebhookDeliverySucceeded {
+			deliveredAt = pgtype.Timestamptz{Time: attempt.CreatedAt, Valid: true}
+		}
+
+		result, err = q.UpdateWebhookDelivery(ctx, UpdateWebhookDeliveryParams{
+			ID:             arg.Delivery.ID,
+			Status:         arg.Status,
+			Attempts:       arg.Delivery.Attempts + 1,
+			NextAttemptAt:  arg.NextAttemptAt,
+			LastStatusCode: arg.StatusCode,
+			LastError:      arg.Error,
+			DeliveredAt:    deliveredAt,
+		})
+		return err
+	})
+
+	return result, err
+}
@@ -0,0 +1,175 @@
+package db
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/XiaozhouCui/go-bank/db/util"
+	"github.com/stretchr/testify/require"
+)
+
+func createRandomWebhookSubscription(t *testing.T) WebhookSubscription {
+	user := createRandomUser(t)
+
+	arg := CreateWebhookSubscriptionParams{
+		Owner:      user.Username,
+		Url:        "https://example.com/webhooks",
+		EventTypes: []string{"TransferReceived", "TransferSent"},
+		Secret:     util.RandomString(32),
+	}
+	subscription, err := testQueries.CreateWebhookSubscription(context.Background(), arg)
+	require.NoError(t, err)
+	require.Equal(t, arg.Owner, subscription.Owner)
+	require.Equal(t, arg.EventTypes, subscription.EventTypes)
+	require.Equal(t, arg.Secret, subscription.Secret)
+	require.True(t, subscription.Active)
+	return subscription
+}
+
+func createRandomWebhookDelivery(t *testing.T, subscription WebhookSubscription) WebhookDelivery {
+	event := outboxMarker(t)
+
+	arg := CreateWebhookDeliveryParams{
+		SubscriptionID: subscription.ID,
+		EventID:        event.ID,
+		EventType:      "TransferReceived",
+		Payload:        []byte(`{"transfer_id":1}`),
+	}
+	require.NoError(t, testQueries.CreateWebhookDelivery(context.Background(), arg))
+	// the same event is only delivered once per subscription and type
+	require.NoError(t, testQueries.CreateWebhookDelivery(context.Background(), arg))
+
+	deliveries, err := testQueries.ListWebhookDeliveries(context.Background(), ListWebhookDeliveriesParams{
+		SubscriptionID: subscription.ID,
+		Limit:          100,
+	})
+	require.NoError(t, err)
+
+	var created []WebhookDelivery
+	for _, delivery := range deliveries {
+		if delivery.EventID == event.ID {
+			created = append(created, delivery)
+		}
+	}
+	require.Len(t, created, 1)
+	require.Equal(t, WebhookDeliveryPending, created[0].Status)
+	require.JSONEq(t, `{"transfer_id":1}`, string(created[0].Payload))
+	return created[0]
+}
+
+// isDue reports whether the delivery is listed as due at now
+func isDue(t *testing.T, delivery WebhookDelivery, now time.Time) bool {
+	due, err := testQueries.ListDueWebhookDeliveries(context.Background(), ListDueWebhookDeliveriesParams{
+		Now:     now,
+		AfterID: delivery.ID - 1,
+		Limit:   1,
+	})
+	require.NoError(t, err)
+	return len(due) == 1 && due[0].ID == delivery.ID
+}
+
+func TestClaimWebhookDelivery(t *testing.T) {
+	delivery := createRandomWebhookDelivery(t, createRandomWebhookSubscription(t))
+	now := time.Now()
+	require.True(t, isDue(t, delivery, now))
+
+	arg := ClaimWebhookDeliveryParams{
+		LeaseUntil: now.Add(time.Minute),
+		ID:         delivery.ID,
+		Now:        now,
+	}
+	claimed, err := testQueries.ClaimWebhookDelivery(context.Background(), arg)
+	require.NoError(t, err)
+	require.WithinDuration(t, arg.LeaseUntil, claimed.NextAttemptAt, time.Millisecond)
+
+	// another deliverer cannot claim it until the lease is over
+	_, err = testQueries.ClaimWebhookDelivery(context.Background(), arg)
+	require.ErrorIs(t, err, ErrRecordNotFound)
+	require.False(t, isDue(t, delivery, now))
+	require.True(t, isDue(t, delivery, arg.LeaseUntil))
+}
+
+func TestRecordWebhookAttemptTx(t *testing.T) {
+	store := NewStore(testDB)
+	delivery := createRandomWebhookDelivery(t, createRandomWebhookSubscription(t))
+	retryAt := time.Now().Add(time.Hour)
+
+	failed, err := store.RecordWebhookAttemptTx(context.Background(), RecordWebhookAttemptTxParams{
+		Delivery:      delivery,
+		StatusCode:    503,
+		Error:         "endpoint answered 503 Service Unavailable",
+		Duration:      20 * time.Millisecond,
+		Status:        WebhookDeliveryPending,
+		NextAttemptAt: retryAt,
+	})
+	require.NoError(t, err)
+	require.Equal(t, WebhookDeliveryPending, failed.Status)
+	require.Equal(t, int64(1), failed.Attempts)
+	require.Equal(t, int64(503), failed.LastStatusCode)
+	require.WithinDuration(t, retryAt, failed.NextAttemptAt, time.Millisecond)
+	require.False(t, failed.DeliveredAt.Valid)
+
+	succeeded, err := store.RecordWebhookAttemptTx(context.Background(), RecordWebhookAttemptTxParams{
+		Delivery:      failed,
+		StatusCode:    200,
+		Status:        WebhookDeliverySucceeded,
+		NextAttemptAt: failed.NextAttemptAt,
+	})
+	require.NoError(t, err)
+	require.Equal(t, WebhookDeliverySucceeded, succeeded.Status)
+	require.Equal(t, int64(2), succeeded.Attempts)
+	require.Empty(t, succeeded.LastError)
+	require.True(t, succeeded.DeliveredAt.Valid)
+	require.False(t, isDue(t, succeeded, retryAt))
+
+	attempts, err := testQueries.ListWebhookDeliveryAttempts(context.Background(), delivery.ID)
+	require.NoError(t, err)
+	require.Len(t, attempts, 2)
+	require.Equal(t, int64(503), attempts[0].StatusCode)
+	require.Equal(t, int64(20), attempts[0].DurationMs)
+	require.Equal(t, int64(200), attempts[1].StatusCode)
+}
+
+func TestRetryWebhookDelivery(t *testing.T) {
+	store := NewStore(testDB)
+	delivery := createRandomWebhookDelivery(t, createRandomWebhookSubscription(t))
+
+	// only a dead delivery can be retried
+	_, err := testQueries.RetryWebhookDelivery(context.Background(), delivery.ID)
+	require.ErrorIs(t, err, ErrRecordNotFound)
+
+	dead, err := store.RecordWebhookAttemptTx(context.Background(), RecordWebhookAttemptTxParams{
+		Delivery:      delivery,
+		Error:         "connection refused",
+		Status:        WebhookDeliveryDead,
+		NextAttemptAt: delivery.NextAttemptAt,
+	})
+	require.NoError(t, err)
+	require.False(t, isDue(t, dead, time.Now().Add(time.Hour)))
+
+	retried, err := testQueries.RetryWebhookDelivery(context.Background(), delivery.ID)
+	require.NoError(t, err)
+	require.Equal(t, WebhookDeliveryPending, retried.Status)
+	require.Zero(t, retried.Attempts)
+	require.True(t, isDue(t, retried, time.Now().Add(time.Second)))
+}
+
+func TestDeactivateWebhookSubscription(t *testing.T) {
+	subscription := createRandomWebhookSubscription(t)
+	delivery := createRandomWebhookDelivery(t, subscription)
+
+	deactivated, err := testQueries.DeactivateWebhookSubscription(context.Background(), subscription.ID)
+	require.NoError(t, err)
+	require.False(t, deactivated.Active)
+
+	// nothing is delivered to an inactive subscription
+	require.False(t, isDue(t, delivery, time.Now().Add(time.Hour)))
+
+	active, err := testQueries.ListActiveWebhookSubscriptions(context.Background(), subscription.Owner)
+	require.NoError(t, err)
+	require.Empty(t, active)
+
+	_, err = testQueries.DeactivateWebhookSubscription(context.Background(), subscription.ID)
+	require.ErrorIs(t, err, ErrRecordNotFound)
+}
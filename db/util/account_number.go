@@ -0,0 +1,43 @@
+package util
+
+import (
+	"fmt"
+	"strings"
+)
+
+// AccountNumberLength is the number of digits of an account number, the last 2 are check digits
+const AccountNumberLength = 12
+
+// AccountNumberCheckDigits returns the ISO 7064 MOD 97-10 check digits of the base of an account number,
+// the same as the check digits of an IBAN. The db generates the account numbers the same way.
+func AccountNumberCheckDigits(base string) string {
+	return fmt.Sprintf("%02d", 98-mod97(base+"00"))
+}
+
+// IsValidAccountNumber returns true if the number has the length and the check digits of an account number.
+// It catches any single mistyped digit, and most swapped digits.
+func IsValidAccountNumber(number string) bool {
+	if len(number) != AccountNumberLength {
+		return false
+	}
+	for _, c := range number {
+		if c < '0' || c > '9' {
+			return false
+		}
+	}
+	return mod97(number) == 1
+}
+
+// NormalizeAccountNumber removes the spaces and dashes people type in account numbers
+func NormalizeAccountNumber(number string) string {
+	return strings.NewReplacer(" ", "", "-", "").Replace(number)
+}
+
+// mod97 computes a decimal string modulo 97 digit by digit, it may be too long for an int64
+func mod97(digits string) int {
+	remainder := 0
+	for _, c := range digits {
+		remainder = (remainder*10 + int(c-'0')) % 97
+	}
+	return remainder
+}
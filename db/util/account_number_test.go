@@ -0,0 +1,42 @@
+package util
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestAccountNumberCheckDigits(t *testing.T) {
+	// the check digits of an IBAN are computed the same way, over the digits of the bank code
+	// and account number followed by the country code: GB82 WEST 1234 5698 7654 32
+	require.Equal(t, "82", AccountNumberCheckDigits("32142829123456987654321611"))
+
+	number := RandomAccountNumber()
+	require.Len(t, number, AccountNumberLength)
+	require.True(t, IsValidAccountNumber(number))
+}
+
+func TestIsValidAccountNumber(t *testing.T) {
+	base := "1234567890"
+	number := base + AccountNumberCheckDigits(base)
+
+	testCases := []struct {
+		name   string
+		number string
+		valid  bool
+	}{
+		{name: "OK", number: number, valid: true},
+		{name: "MistypedDigit", number: "1234567891" + number[10:], valid: false},
+		{name: "SwappedDigits", number: "2134567890" + number[10:], valid: false},
+		{name: "TooShort", number: number[1:], valid: false},
+		{name: "TooLong", number: number + "0", valid: false},
+		{name: "NotDigits", number: "12345678x0" + number[10:], valid: false},
+		{name: "Formatted", number: NormalizeAccountNumber(number[:4] + " " + number[4:8] + "-" + number[8:]), valid: true},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			require.Equal(t, tc.valid, IsValidAccountNumber(tc.number))
+		})
+	}
+}
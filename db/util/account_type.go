@@ -0,0 +1,19 @@
+package util
+
+// constants for all supported account types
+const (
+	Checking = "checking"
+	Savings  = "savings"
+	Escrow   = "escrow"
+	// Internal accounts are owned by the bank, customers cannot open them
+	Internal = "internal"
+)
+
+// IsSupportedAccountType returns true if the account type is supported for customer accounts
+func IsSupportedAccountType(accountType string) bool {
+	switch accountType {
+	case Checking, Savings, Escrow:
+		return true
+	}
+	return false
+}
@@ -9,11 +9,30 @@ import (
 // Config stores all configuration of the application.
 // The values are read by viper from a config file or environment variables
 type Config struct {
-	DBDriver            string        `mapstructure:"DB_DRIVER"`
 	DBSource            string        `mapstructure:"DB_SOURCE"`
+	DBMaxConns          int32         `mapstructure:"DB_MAX_CONNS"`          // 0 keeps the pgxpool default
+	DBMinConns          int32         `mapstructure:"DB_MIN_CONNS"`          // 0 keeps the pgxpool default
+	DBMaxConnLifetime   time.Duration `mapstructure:"DB_MAX_CONN_LIFETIME"`  // 0 keeps the pgxpool default
+	DBMaxConnIdleTime   time.Duration `mapstructure:"DB_MAX_CONN_IDLE_TIME"` // 0 keeps the pgxpool default
 	ServerAddress       string        `mapstructure:"SERVER_ADDRESS"`
 	TokenSymmetricKey   string        `mapstructure:"TOKEN_SYMMETRIC_KEY"`
 	AccessTokenDuration time.Duration `mapstructure:"ACCESS_TOKEN_DURATION"`
+	InterestJobInterval time.Duration `mapstructure:"INTEREST_JOB_INTERVAL"` // 0 disables the interest job
+	SchedulerInterval   time.Duration `mapstructure:"SCHEDULER_INTERVAL"`    // 0 disables the scheduled transfers
+	SchedulerRetryDelay time.Duration `mapstructure:"SCHEDULER_RETRY_DELAY"` // wait before retrying a failed scheduled transfer
+	HoldExpiryInterval  time.Duration `mapstructure:"HOLD_EXPIRY_INTERVAL"`  // 0 disables the expiry of stale holds
+	LedgerCheckInterval time.Duration `mapstructure:"LEDGER_CHECK_INTERVAL"` // 0 disables the periodic ledger check
+	OutboxRelayInterval time.Duration `mapstructure:"OUTBOX_RELAY_INTERVAL"` // 0 disables the publication of events
+	OutboxSink          string        `mapstructure:"OUTBOX_SINK"`           // file:<path> or a webhook URL
+	// 0 disables the customer webhooks, which need the outbox relay
+	WebhookDeliveryInterval time.Duration `mapstructure:"WEBHOOK_DELIVERY_INTERVAL"`
+	WebhookMaxAttempts      int64         `mapstructure:"WEBHOOK_MAX_ATTEMPTS"`  // a delivery is dead after this many failures
+	WebhookRetryBackoff     time.Duration `mapstructure:"WEBHOOK_RETRY_BACKOFF"` // wait after the first failure, doubled after each next one
+	// a new payee cannot receive more than PayeeCoolingOffAmount per transfer during this period, 0 disables it
+	PayeeCoolingOffPeriod time.Duration `mapstructure:"PAYEE_COOLING_OFF_PERIOD"`
+	PayeeCoolingOffAmount int64         `mapstructure:"PAYEE_COOLING_OFF_AMOUNT"`
+	// 0 disables the expiry of stale payment requests, they still cannot be paid once expired
+	PaymentRequestExpiryInterval time.Duration `mapstructure:"PAYMENT_REQUEST_EXPIRY_INTERVAL"`
 }
 
 // LoadConfig reads configuration from file or environment vairables.
@@ -0,0 +1,81 @@
+package util
+
+import (
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+const (
+	MaxDescriptionLength = 140 // characters, like the unstructured remittance information of ISO 20022
+	MaxReferenceLength   = 35  // the longest end to end reference of ISO 20022
+	MaxTagLength         = 32  // characters
+	MaxTags              = 10  // per entry
+)
+
+// SanitizeDescription cleans up the free text description of a transfer, so it prints on a single line
+// in statements and exports: invalid UTF-8 and invisible formatting characters, such as the ones reversing
+// the direction of the text, are removed, and the line breaks, tabs and runs of spaces become a single space.
+func SanitizeDescription(description string) string {
+	description = strings.Map(func(r rune) rune {
+		if unicode.Is(unicode.Cf, r) {
+			return -1
+		}
+		return r
+	}, strings.ToValidUTF8(description, ""))
+
+	return strings.Join(strings.FieldsFunc(description, func(r rune) bool {
+		return unicode.IsSpace(r) || unicode.IsControl(r)
+	}), " ")
+}
+
+// IsValidReference returns true if the reference is not longer than MaxReferenceLength
+// and only uses the characters ISO 20022 allows in references: latin letters, digits, spaces and / - ? : ( ) . , ' +
+func IsValidReference(reference string) bool {
+	if len(reference) > MaxReferenceLength {
+		return false
+	}
+	for _, c := range reference {
+		switch {
+		case c >= 'a' && c <= 'z', c >= 'A' && c <= 'Z', c >= '0' && c <= '9':
+		case strings.ContainsRune(" /-?:().,'+", c):
+		default:
+			return false
+		}
+	}
+	return true
+}
+
+// NormalizeTag trims and lowercases a tag, so "Rent " and "rent" are the same tag
+func NormalizeTag(tag string) string {
+	return strings.ToLower(strings.TrimSpace(tag))
+}
+
+// IsValidTag returns true if a normalized tag is made of letters, digits, dashes and underscores,
+// and is not longer than MaxTagLength
+func IsValidTag(tag string) bool {
+	if tag == "" || utf8.RuneCountInString(tag) > MaxTagLength {
+		return false
+	}
+	for _, c := range tag {
+		if !unicode.IsLetter(c) && !unicode.IsDigit(c) && c != '-' && c != '_' {
+			return false
+		}
+	}
+	return true
+}
+
+// NormalizeTags normalizes each tag and removes the duplicates, keeping the tags in the order they were given.
+// It never returns nil, the db stores no tags as an empty array.
+func NormalizeTags(tags []string) []string {
+	normalized := make([]string, 0, len(tags))
+	seen := make(map[string]bool, len(tags))
+	for _, tag := range tags {
+		tag = NormalizeTag(tag)
+		if !seen[tag] {
+			seen[tag] = true
+			normalized = append(normalized, tag)
+		}
+	}
+	return normalized
+}
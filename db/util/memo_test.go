@@ -0,0 +1,81 @@
+package util
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestSanitizeDescription(t *testing.T) {
+	testCases := []struct {
+		name        string
+		description string
+		sanitized   string
+	}{
+		{name: "OK", description: "Rent for March", sanitized: "Rent for March"},
+		{name: "Spaces", description: "  Rent \t for\n\nMarch  ", sanitized: "Rent for March"},
+		{name: "ControlCharacters", description: "Rent\x00for\x1bMarch", sanitized: "Rent for March"},
+		{name: "BidiOverride", description: "invoice \u202egpj.exe", sanitized: "invoice gpj.exe"},
+		{name: "InvalidUTF8", description: "caf\xe9 bill", sanitized: "caf bill"},
+		{name: "Unicode", description: "Café für Zoë 🎉", sanitized: "Café für Zoë 🎉"},
+		{name: "Empty", description: " \n ", sanitized: ""},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			require.Equal(t, tc.sanitized, SanitizeDescription(tc.description))
+		})
+	}
+}
+
+func TestIsValidReference(t *testing.T) {
+	testCases := []struct {
+		name      string
+		reference string
+		valid     bool
+	}{
+		{name: "OK", reference: "INV-2023/0042", valid: true},
+		{name: "Punctuation", reference: "Order (12), ref: A.B'C+D?", valid: true},
+		{name: "Empty", reference: "", valid: true},
+		{name: "Longest", reference: strings.Repeat("A", MaxReferenceLength), valid: true},
+		{name: "TooLong", reference: strings.Repeat("A", MaxReferenceLength+1), valid: false},
+		{name: "NotLatin", reference: "Café", valid: false},
+		{name: "Markup", reference: "<script>", valid: false},
+		{name: "LineBreak", reference: "INV\n42", valid: false},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			require.Equal(t, tc.valid, IsValidReference(tc.reference))
+		})
+	}
+}
+
+func TestIsValidTag(t *testing.T) {
+	testCases := []struct {
+		name  string
+		tag   string
+		valid bool
+	}{
+		{name: "OK", tag: "groceries", valid: true},
+		{name: "DashAndUnderscore", tag: "tax-2023_q1", valid: true},
+		{name: "Unicode", tag: "café", valid: true},
+		{name: "Longest", tag: strings.Repeat("é", MaxTagLength), valid: true},
+		{name: "TooLong", tag: strings.Repeat("a", MaxTagLength+1), valid: false},
+		{name: "Empty", tag: "", valid: false},
+		{name: "Space", tag: "eating out", valid: false},
+		{name: "Punctuation", tag: "#food", valid: false},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			require.Equal(t, tc.valid, IsValidTag(tc.tag))
+		})
+	}
+}
+
+func TestNormalizeTags(t *testing.T) {
+	require.Equal(t, []string{"rent", "home"}, NormalizeTags([]string{" Rent", "home", "RENT "}))
+	require.Equal(t, []string{}, NormalizeTags(nil))
+}
@@ -48,7 +48,20 @@ func RandomCurrency() string {
 	return currencies[rand.Intn(n)]
 }
 
+// RandomAccountType generates a random account type.
+func RandomAccountType() string {
+	accountTypes := []string{Checking, Savings, Escrow}
+	n := len(accountTypes)
+	return accountTypes[rand.Intn(n)]
+}
+
 // RandomEmail generates a random email.
 func RandomEmail() string {
 	return fmt.Sprintf("%s@email.com", RandomString(6))
 }
+
+// RandomAccountNumber generates a random account number with valid check digits.
+func RandomAccountNumber() string {
+	base := fmt.Sprintf("%010d", RandomInt(0, 9_999_999_999))
+	return base + AccountNumberCheckDigits(base)
+}
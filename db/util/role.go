@@ -0,0 +1,8 @@
+package util
+
+// constants for all user roles
+const (
+	DepositorRole = "depositor" // customers, can only manage their own accounts
+	BankerRole    = "banker"    // bank staff, can freeze and unfreeze any account
+	SupportRole   = "support"   // customer support, can look up any customer and adjust balances
+)
@@ -0,0 +1,81 @@
+// Package events defines the domain events the bank publishes to downstream systems, such as
+// notifications and analytics. Events are written to the outbox in the same database transaction
+// as the change they describe, and relayed from there with at-least-once delivery.
+//
+// The payload of each event type has a versioned JSON schema in schemas/, named <type>.v<version>.json.
+// A version only gets new optional fields: a breaking change is a new version, with a new payload type.
+package events
+
+import (
+	"embed"
+	"encoding/json"
+	"time"
+)
+
+// event types
+const (
+	TransferCompleted = "TransferCompleted"
+	AccountCreated    = "AccountCreated"
+	UserRegistered    = "UserRegistered"
+)
+
+// Schemas holds the JSON schemas of the payloads.
+//
+//go:embed schemas/*.json
+var Schemas embed.FS
+
+// Payload is the data of an event, for a version of the schema of its type.
+type Payload interface {
+	EventType() string
+	SchemaVersion() int64
+}
+
+// Event is the envelope an event is published in.
+type Event struct {
+	// the ID of the event in the outbox, increasing in the order the events were written.
+	// an event can be delivered more than once, consumers use the ID to ignore duplicates
+	ID            int64           `json:"id"`
+	Type          string          `json:"type"`
+	SchemaVersion int64           `json:"schema_version"`
+	OccurredAt    time.Time       `json:"occurred_at"`
+	Data          json.RawMessage `json:"data"`
+}
+
+// TransferCompletedV1 is published when money has moved between two accounts, whatever made the transfer:
+// a customer, a scheduled transfer, a hold capture, an import or an interest posting.
+type TransferCompletedV1 struct {
+	TransferID    int64     `json:"transfer_id"`
+	FromAccountID int64     `json:"from_account_id"`
+	ToAccountID   int64     `json:"to_account_id"`
+	Amount        int64     `json:"amount"`
+	Fee           int64     `json:"fee"` // paid by the sender on top of the amount
+	Currency      string    `json:"currency"`
+	CompletedAt   time.Time `json:"completed_at"`
+}
+
+func (TransferCompletedV1) EventType() string    { return TransferCompleted }
+func (TransferCompletedV1) SchemaVersion() int64 { return 1 }
+
+// AccountCreatedV1 is published when a customer opens an account.
+type AccountCreatedV1 struct {
+	AccountID int64     `json:"account_id"`
+	Owner     string    `json:"owner"`
+	Currency  string    `json:"currency"`
+	Type      string    `json:"type"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+func (AccountCreatedV1) EventType() string    { return AccountCreated }
+func (AccountCreatedV1) SchemaVersion() int64 { return 1 }
+
+// UserRegisteredV1 is published when a user signs up. It never carries the password hash.
+type UserRegisteredV1 struct {
+	Username     string    `json:"username"`
+	FullName     string    `json:"full_name"`
+	Email        string    `json:"email"`
+	Role         string    `json:"role"`
+	RegisteredAt time.Time `json:"registered_at"`
+}
+
+func (UserRegisteredV1) EventType() string    { return UserRegistered }
+func (UserRegisteredV1) SchemaVersion() int64 { return 1 }
@@ -0,0 +1,57 @@
+package events
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+// every payload type, a new version must be added here
+var payloads = []Payload{
+	TransferCompletedV1{},
+	AccountCreatedV1{},
+	UserRegisteredV1{},
+}
+
+// TestSchemas checks that the JSON schema of each payload describes exactly the fields it is marshalled with.
+func TestSchemas(t *testing.T) {
+	for _, payload := range payloads {
+		name := fmt.Sprintf("%s.v%d.json", payload.EventType(), payload.SchemaVersion())
+
+		t.Run(name, func(t *testing.T) {
+			data, err := Schemas.ReadFile("schemas/" + name)
+			require.NoError(t, err)
+
+			var schema struct {
+				Title      string                     `json:"title"`
+				Properties map[string]json.RawMessage `json:"properties"`
+				Required   []string                   `json:"required"`
+			}
+			require.NoError(t, json.Unmarshal(data, &schema))
+			require.Equal(t, payload.EventType(), schema.Title)
+
+			var fields []string
+			payloadType := reflect.TypeOf(payload)
+			for i := 0; i < payloadType.NumField(); i++ {
+				tag, _, _ := strings.Cut(payloadType.Field(i).Tag.Get("json"), ",")
+				fields = append(fields, tag)
+			}
+			sort.Strings(fields)
+
+			properties := make([]string, 0, len(schema.Properties))
+			for property := range schema.Properties {
+				properties = append(properties, property)
+			}
+			sort.Strings(properties)
+			sort.Strings(schema.Required)
+
+			require.Equal(t, fields, properties)
+			require.Equal(t, fields, schema.Required)
+		})
+	}
+}
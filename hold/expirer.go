@@ -0,0 +1,83 @@
+package hold
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"time"
+
+	db "github.com/XiaozhouCui/go-bank/db/sqlc"
+)
+
+// number of holds loaded from the db at a time
+const batchSize = 100
+
+// Expirer releases the pending holds that have expired, so their funds are available again.
+type Expirer struct {
+	store db.Store
+}
+
+// NewExpirer creates a new hold expirer.
+func NewExpirer(store db.Store) *Expirer {
+	return &Expirer{
+		store: store,
+	}
+}
+
+// Run expires the stale holds right away, then again at every interval, until the context is done.
+func (expirer *Expirer) Run(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		if err := expirer.ExpireDue(ctx, time.Now()); err != nil {
+			log.Println("cannot expire holds:", err)
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// ExpireDue releases every pending hold that expired at or before now.
+// A hold captured or released in the meantime is skipped.
+func (expirer *Expirer) ExpireDue(ctx context.Context, now time.Time) error {
+	failed := 0
+
+	var afterID int64
+	for {
+		holds, err := expirer.store.ListExpiredHolds(ctx, db.ListExpiredHoldsParams{
+			Now:     now,
+			AfterID: afterID,
+			Limit:   batchSize,
+		})
+		if err != nil {
+			return err
+		}
+
+		for _, hold := range holds {
+			_, err := expirer.store.ReleaseHoldTx(ctx, db.ReleaseHoldTxParams{
+				ID:  hold.ID,
+				Now: now,
+			})
+			if err != nil && !errors.Is(err, db.ErrHoldNotPending) {
+				log.Printf("cannot expire hold [%d]: %v", hold.ID, err)
+				failed++
+			}
+			afterID = hold.ID
+		}
+
+		if len(holds) < batchSize {
+			break
+		}
+	}
+
+	if failed > 0 {
+		return fmt.Errorf("expiry failed for %d holds", failed)
+	}
+	return nil
+}
@@ -0,0 +1,60 @@
+package hold
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	mockdb "github.com/XiaozhouCui/go-bank/db/mock"
+	db "github.com/XiaozhouCui/go-bank/db/sqlc"
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/require"
+)
+
+func TestExpireDue(t *testing.T) {
+	now := time.Now()
+
+	expired := []db.Hold{{ID: 1}, {ID: 2}}
+
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	store := mockdb.NewMockStore(ctrl)
+	store.EXPECT().
+		ListExpiredHolds(gomock.Any(), gomock.Eq(db.ListExpiredHoldsParams{Now: now, Limit: batchSize})).
+		Times(1).
+		Return(expired, nil)
+
+	// 1 expires, 2 has been captured in the meantime
+	store.EXPECT().
+		ReleaseHoldTx(gomock.Any(), gomock.Eq(db.ReleaseHoldTxParams{ID: 1, Now: now})).
+		Times(1).
+		Return(db.HoldTxResult{}, nil)
+	store.EXPECT().
+		ReleaseHoldTx(gomock.Any(), gomock.Eq(db.ReleaseHoldTxParams{ID: 2, Now: now})).
+		Times(1).
+		Return(db.HoldTxResult{}, db.ErrHoldNotPending)
+
+	err := NewExpirer(store).ExpireDue(context.Background(), now)
+	require.NoError(t, err)
+}
+
+func TestExpireDueError(t *testing.T) {
+	now := time.Now()
+
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	store := mockdb.NewMockStore(ctrl)
+	store.EXPECT().
+		ListExpiredHolds(gomock.Any(), gomock.Any()).
+		Times(1).
+		Return([]db.Hold{{ID: 1}}, nil)
+	store.EXPECT().
+		ReleaseHoldTx(gomock.Any(), gomock.Any()).
+		Times(1).
+		Return(db.HoldTxResult{}, db.ErrRecordNotFound)
+
+	err := NewExpirer(store).ExpireDue(context.Background(), now)
+	require.Error(t, err)
+}
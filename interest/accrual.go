@@ -0,0 +1,32 @@
+package interest
+
+import (
+	"fmt"
+	"math/big"
+	"time"
+
+	db "github.com/XiaozhouCui/go-bank/db/sqlc"
+)
+
+// DailyInterest returns the interest earned by an end-of-day balance on a given date,
+// in millionths of the minor currency unit. Negative balances earn nothing.
+func DailyInterest(balance int64, annualRateBps int64, dayCount string, date time.Time) (int64, error) {
+	days, basis, err := yearFraction(dayCount, date)
+	if err != nil {
+		return 0, err
+	}
+	if balance <= 0 || annualRateBps <= 0 || days == 0 {
+		return 0, nil
+	}
+
+	// balance * (annualRateBps / 10,000) * (days / basis) * MicrosPerMinorUnit,
+	// computed with big integers so large balances cannot overflow before the division
+	interest := new(big.Int).Mul(big.NewInt(balance), big.NewInt(annualRateBps))
+	interest.Mul(interest, big.NewInt(days*db.MicrosPerMinorUnit))
+	interest.Quo(interest, big.NewInt(basis*10_000))
+
+	if !interest.IsInt64() {
+		return 0, fmt.Errorf("interest on balance %d overflows", balance)
+	}
+	return interest.Int64(), nil
+}
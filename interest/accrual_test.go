@@ -0,0 +1,41 @@
+package interest
+
+import (
+	"math"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestDailyInterest(t *testing.T) {
+	// 365.00 at 10% a year earns exactly 0.10 a day with ACT/365
+	amount, err := DailyInterest(36500, 1000, Actual365, date(2023, time.June, 1))
+	require.NoError(t, err)
+	require.Equal(t, int64(10_000_000), amount)
+
+	// 1.00 at 2.5% a year earns 1/146 of a cent a day, kept to the micro
+	amount, err = DailyInterest(100, 250, Actual365, date(2023, time.June, 1))
+	require.NoError(t, err)
+	require.Equal(t, int64(6849), amount)
+
+	// a 31-day month has one day without interest with 30/360
+	amount, err = DailyInterest(36500, 1000, Thirty360, date(2023, time.May, 30))
+	require.NoError(t, err)
+	require.Zero(t, amount)
+}
+
+func TestDailyInterestNoBalance(t *testing.T) {
+	amount, err := DailyInterest(0, 250, Actual365, date(2023, time.June, 1))
+	require.NoError(t, err)
+	require.Zero(t, amount)
+
+	amount, err = DailyInterest(-1000, 250, Actual365, date(2023, time.June, 1))
+	require.NoError(t, err)
+	require.Zero(t, amount)
+}
+
+func TestDailyInterestOverflow(t *testing.T) {
+	_, err := DailyInterest(math.MaxInt64, 10_000, Actual360, date(2023, time.June, 1))
+	require.Error(t, err)
+}
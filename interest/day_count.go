@@ -0,0 +1,63 @@
+package interest
+
+import (
+	"fmt"
+	"time"
+)
+
+// day count conventions supported by interest plans
+const (
+	Actual365    = "ACT/365" // every day is 1/365 of a year
+	Actual360    = "ACT/360" // every day is 1/360 of a year
+	ActualActual = "ACT/ACT" // every day is 1/365 or 1/366 of a year, depending on leap years
+	Thirty360    = "30/360"  // every month has 30 days and the year has 360 days
+)
+
+// IsSupportedDayCount returns true if the day count convention is supported
+func IsSupportedDayCount(dayCount string) bool {
+	switch dayCount {
+	case Actual365, Actual360, ActualActual, Thirty360:
+		return true
+	}
+	return false
+}
+
+// yearFraction returns the share of a year accrued by a single day under the day count convention,
+// as the number of days counted for that date and the number of days in the year.
+func yearFraction(dayCount string, date time.Time) (days int64, basis int64, err error) {
+	switch dayCount {
+	case Actual365:
+		return 1, 365, nil
+	case Actual360:
+		return 1, 360, nil
+	case ActualActual:
+		return 1, daysInYear(date.Year()), nil
+	case Thirty360:
+		return days360(date, date.AddDate(0, 0, 1)), 360, nil
+	}
+	return 0, 0, fmt.Errorf("unsupported day count convention %q", dayCount)
+}
+
+func daysInYear(year int) int64 {
+	if year%4 == 0 && (year%100 != 0 || year%400 == 0) {
+		return 366
+	}
+	return 365
+}
+
+// days360 counts the days between two dates with the 30/360 (bond basis) rule.
+// Every month counts 30 days: one day of a 31-day month accrues nothing,
+// and the last day of February accrues the missing days of the month.
+func days360(start, end time.Time) int64 {
+	y1, m1, d1 := start.Date()
+	y2, m2, d2 := end.Date()
+
+	if d1 == 31 {
+		d1 = 30
+	}
+	if d2 == 31 && d1 == 30 {
+		d2 = 30
+	}
+
+	return int64(360*(y2-y1) + 30*(int(m2)-int(m1)) + (d2 - d1))
+}
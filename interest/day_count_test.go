@@ -0,0 +1,63 @@
+package interest
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func date(year int, month time.Month, day int) time.Time {
+	return time.Date(year, month, day, 0, 0, 0, 0, time.UTC)
+}
+
+func TestYearFraction(t *testing.T) {
+	testCases := []struct {
+		name     string
+		dayCount string
+		date     time.Time
+		days     int64
+		basis    int64
+	}{
+		{"Actual365", Actual365, date(2024, time.March, 1), 1, 365},
+		{"Actual360", Actual360, date(2024, time.March, 1), 1, 360},
+		{"ActualActualLeapYear", ActualActual, date(2024, time.March, 1), 1, 366},
+		{"ActualActualCommonYear", ActualActual, date(2023, time.March, 1), 1, 365},
+		{"ActualActualCentury", ActualActual, date(2100, time.March, 1), 1, 365},
+		{"Thirty360MidMonth", Thirty360, date(2023, time.January, 15), 1, 360},
+		{"Thirty360On30thOf31DayMonth", Thirty360, date(2023, time.January, 30), 0, 360},
+		{"Thirty360On31st", Thirty360, date(2023, time.January, 31), 1, 360},
+		{"Thirty360EndOfFebruary", Thirty360, date(2023, time.February, 28), 3, 360},
+		{"Thirty360EndOfFebruaryLeapYear", Thirty360, date(2024, time.February, 29), 2, 360},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+
+		t.Run(tc.name, func(t *testing.T) {
+			days, basis, err := yearFraction(tc.dayCount, tc.date)
+			require.NoError(t, err)
+			require.Equal(t, tc.days, days)
+			require.Equal(t, tc.basis, basis)
+		})
+	}
+}
+
+func TestThirty360MonthHas30Days(t *testing.T) {
+	// whatever the length of the month, 30/360 always accrues 30 days in total
+	for month := time.January; month <= time.December; month++ {
+		var total int64
+		for day := date(2023, month, 1); day.Month() == month; day = day.AddDate(0, 0, 1) {
+			days, _, err := yearFraction(Thirty360, day)
+			require.NoError(t, err)
+			total += days
+		}
+		require.Equal(t, int64(30), total, month.String())
+	}
+}
+
+func TestUnsupportedDayCount(t *testing.T) {
+	require.False(t, IsSupportedDayCount("ACT/999"))
+	_, _, err := yearFraction("ACT/999", date(2023, time.January, 1))
+	require.Error(t, err)
+}
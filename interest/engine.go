@@ -0,0 +1,198 @@
+package interest
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"time"
+
+	db "github.com/XiaozhouCui/go-bank/db/sqlc"
+)
+
+// number of accounts loaded from the db at a time
+const batchSize = 100
+
+// Engine accrues interest on interest-bearing accounts every day, and credits it every month.
+type Engine struct {
+	store db.Store
+}
+
+// NewEngine creates a new interest engine.
+func NewEngine(store db.Store) *Engine {
+	return &Engine{
+		store: store,
+	}
+}
+
+// Run accrues the interest of the previous day and posts the interest of the previous month
+// right away, then again at every interval, until the context is done.
+// The days missed while the engine was not running are accrued too, see AccrueMissed.
+// Both steps are idempotent, so running them more than once a day is harmless.
+func (engine *Engine) Run(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		engine.runOnce(ctx, time.Now())
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+func (engine *Engine) runOnce(ctx context.Context, now time.Time) {
+	today := startOfDay(now)
+	yesterday := today.AddDate(0, 0, -1)
+
+	if err := engine.AccrueMissed(ctx, yesterday); err != nil {
+		log.Println("cannot accrue interest:", err)
+	}
+
+	// yesterday has just been accrued, so the previous month is complete
+	if err := engine.PostMonthly(ctx, today.AddDate(0, -1, 0)); err != nil {
+		log.Println("cannot post interest:", err)
+	}
+}
+
+// AccrueDaily records the interest earned on the given date by every interest-bearing account,
+// computed on its end-of-day balance (UTC). Dates already accrued are skipped.
+func (engine *Engine) AccrueDaily(ctx context.Context, date time.Time) error {
+	date = startOfDay(date)
+	endOfDay := date.AddDate(0, 0, 1)
+
+	failed := 0
+	err := engine.forEachAccount(ctx, func(account db.ListInterestBearingAccountsRow) {
+		// the plan was attached after that day
+		if !account.PlanAttachedAt.Before(endOfDay) {
+			return
+		}
+		if err := engine.accrue(ctx, account, date, endOfDay); err != nil {
+			log.Printf("cannot accrue interest of account [%d] on %s: %v", account.AccountID, date.Format("2006-01-02"), err)
+			failed++
+		}
+	})
+	if err != nil {
+		return err
+	}
+
+	if failed > 0 {
+		return fmt.Errorf("interest accrual failed for %d accounts", failed)
+	}
+	return nil
+}
+
+// AccrueMissed records the interest of every interest-bearing account for each day after the last day accrued
+// for it, up to the given date, or from the day its plan was attached if nothing was accrued yet.
+// Each account catches up from its own last day, so an account that failed on some days is not skipped
+// because the others were accrued. A day that fails stops its account, the next run starts again from it.
+func (engine *Engine) AccrueMissed(ctx context.Context, until time.Time) error {
+	until = startOfDay(until)
+
+	failed := 0
+	err := engine.forEachAccount(ctx, func(account db.ListInterestBearingAccountsRow) {
+		for date := startOfDay(account.LastAccrualDate).AddDate(0, 0, 1); !date.After(until); date = date.AddDate(0, 0, 1) {
+			if err := engine.accrue(ctx, account, date, date.AddDate(0, 0, 1)); err != nil {
+				log.Printf("cannot accrue interest of account [%d] on %s: %v", account.AccountID, date.Format("2006-01-02"), err)
+				failed++
+				return
+			}
+		}
+	})
+	if err != nil {
+		return err
+	}
+
+	if failed > 0 {
+		return fmt.Errorf("interest accrual failed for %d accounts", failed)
+	}
+	return nil
+}
+
+func (engine *Engine) accrue(ctx context.Context, account db.ListInterestBearingAccountsRow, date, endOfDay time.Time) error {
+	balance, err := engine.store.GetAccountBalanceAt(ctx, db.GetAccountBalanceAtParams{
+		At:        endOfDay,
+		AccountID: account.AccountID,
+	})
+	if err != nil {
+		return err
+	}
+
+	amount, err := DailyInterest(balance, account.AnnualRateBps, account.DayCount, date)
+	if err != nil {
+		return err
+	}
+
+	return engine.store.CreateInterestAccrual(ctx, db.CreateInterestAccrualParams{
+		AccountID:     account.AccountID,
+		AccrualDate:   date,
+		Balance:       balance,
+		AnnualRateBps: account.AnnualRateBps,
+		DayCount:      account.DayCount,
+		AmountMicros:  amount,
+	})
+}
+
+// PostMonthly credits the interest accrued during the month of the given date to every interest-bearing account.
+// Accounts already credited for that month are skipped.
+func (engine *Engine) PostMonthly(ctx context.Context, month time.Time) error {
+	periodStart := startOfMonth(month)
+	periodEnd := periodStart.AddDate(0, 1, 0)
+
+	failed := 0
+	err := engine.forEachAccount(ctx, func(account db.ListInterestBearingAccountsRow) {
+		_, err := engine.store.PostInterestTx(ctx, db.PostInterestTxParams{
+			AccountID:   account.AccountID,
+			PeriodStart: periodStart,
+			PeriodEnd:   periodEnd,
+		})
+		if err != nil && !errors.Is(err, db.ErrInterestAlreadyPosted) {
+			log.Printf("cannot post interest of account [%d] for %s: %v", account.AccountID, periodStart.Format("2006-01"), err)
+			failed++
+		}
+	})
+	if err != nil {
+		return err
+	}
+
+	if failed > 0 {
+		return fmt.Errorf("interest posting failed for %d accounts", failed)
+	}
+	return nil
+}
+
+// forEachAccount calls fn for every interest-bearing account, loading them in batches.
+func (engine *Engine) forEachAccount(ctx context.Context, fn func(account db.ListInterestBearingAccountsRow)) error {
+	var afterID int64
+	for {
+		accounts, err := engine.store.ListInterestBearingAccounts(ctx, db.ListInterestBearingAccountsParams{
+			AfterID: afterID,
+			Limit:   batchSize,
+		})
+		if err != nil {
+			return err
+		}
+
+		for _, account := range accounts {
+			fn(account)
+			afterID = account.AccountID
+		}
+
+		if len(accounts) < batchSize {
+			return nil
+		}
+	}
+}
+
+func startOfDay(t time.Time) time.Time {
+	year, month, day := t.UTC().Date()
+	return time.Date(year, month, day, 0, 0, 0, 0, time.UTC)
+}
+
+func startOfMonth(t time.Time) time.Time {
+	year, month, _ := t.UTC().Date()
+	return time.Date(year, month, 1, 0, 0, 0, 0, time.UTC)
+}
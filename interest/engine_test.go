@@ -0,0 +1,195 @@
+package interest
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	mockdb "github.com/XiaozhouCui/go-bank/db/mock"
+	db "github.com/XiaozhouCui/go-bank/db/sqlc"
+	"github.com/XiaozhouCui/go-bank/db/util"
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAccrueDaily(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+	store := mockdb.NewMockStore(ctrl)
+
+	day := date(2023, time.June, 1)
+	account1 := db.ListInterestBearingAccountsRow{
+		AccountID:      util.RandomInt(1, 1000),
+		Currency:       util.USD,
+		PlanAttachedAt: day.AddDate(0, -1, 0),
+		AnnualRateBps:  1000,
+		DayCount:       Actual365,
+	}
+	// plan attached after the day, no interest yet
+	account2 := db.ListInterestBearingAccountsRow{
+		AccountID:      account1.AccountID + 1,
+		Currency:       util.USD,
+		PlanAttachedAt: day.AddDate(0, 0, 2),
+		AnnualRateBps:  1000,
+		DayCount:       Actual365,
+	}
+
+	store.EXPECT().
+		ListInterestBearingAccounts(gomock.Any(), gomock.Eq(db.ListInterestBearingAccountsParams{AfterID: 0, Limit: batchSize})).
+		Times(1).
+		Return([]db.ListInterestBearingAccountsRow{account1, account2}, nil)
+
+	store.EXPECT().
+		GetAccountBalanceAt(gomock.Any(), gomock.Eq(db.GetAccountBalanceAtParams{At: day.AddDate(0, 0, 1), AccountID: account1.AccountID})).
+		Times(1).
+		Return(int64(36500), nil)
+	store.EXPECT().
+		GetAccountBalanceAt(gomock.Any(), gomock.Eq(db.GetAccountBalanceAtParams{At: day.AddDate(0, 0, 1), AccountID: account2.AccountID})).
+		Times(0)
+
+	store.EXPECT().
+		CreateInterestAccrual(gomock.Any(), gomock.Eq(db.CreateInterestAccrualParams{
+			AccountID:     account1.AccountID,
+			AccrualDate:   day,
+			Balance:       36500,
+			AnnualRateBps: 1000,
+			DayCount:      Actual365,
+			AmountMicros:  10_000_000,
+		})).
+		Times(1).
+		Return(nil)
+
+	// any time of the day accrues that day
+	err := NewEngine(store).AccrueDaily(context.Background(), day.Add(15*time.Hour))
+	require.NoError(t, err)
+}
+
+func TestPostMonthly(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+	store := mockdb.NewMockStore(ctrl)
+
+	accounts := make([]db.ListInterestBearingAccountsRow, 3)
+	for i := range accounts {
+		accounts[i] = db.ListInterestBearingAccountsRow{
+			AccountID:     int64(i + 1),
+			Currency:      util.EUR,
+			AnnualRateBps: 250,
+			DayCount:      Thirty360,
+		}
+	}
+
+	store.EXPECT().
+		ListInterestBearingAccounts(gomock.Any(), gomock.Any()).
+		Times(1).
+		Return(accounts, nil)
+
+	periodStart := date(2023, time.February, 1)
+	periodEnd := date(2023, time.March, 1)
+	for i, account := range accounts {
+		arg := db.PostInterestTxParams{
+			AccountID:   account.AccountID,
+			PeriodStart: periodStart,
+			PeriodEnd:   periodEnd,
+		}
+		call := store.EXPECT().PostInterestTx(gomock.Any(), gomock.Eq(arg)).Times(1)
+		switch i {
+		case 0:
+			call.Return(db.PostInterestTxResult{}, nil)
+		case 1:
+			call.Return(db.PostInterestTxResult{}, db.ErrInterestAlreadyPosted)
+		case 2:
+			call.Return(db.PostInterestTxResult{}, db.ErrAccountNotActive)
+		}
+	}
+
+	// already posted is fine, but any other failure is reported
+	err := NewEngine(store).PostMonthly(context.Background(), date(2023, time.February, 17))
+	require.EqualError(t, err, "interest posting failed for 1 accounts")
+}
+
+func TestRunOnceCatchesUp(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+	store := mockdb.NewMockStore(ctrl)
+
+	// the engine runs on June 5th: account1 is up to date,
+	// account2 failed after June 1st and catches up on its own
+	now := date(2023, time.June, 5).Add(9 * time.Hour)
+	account1 := db.ListInterestBearingAccountsRow{
+		AccountID:       util.RandomInt(1, 1000),
+		Currency:        util.USD,
+		PlanAttachedAt:  date(2023, time.May, 1),
+		AnnualRateBps:   1000,
+		DayCount:        Actual365,
+		LastAccrualDate: date(2023, time.June, 4),
+	}
+	account2 := account1
+	account2.AccountID = account1.AccountID + 1
+	account2.LastAccrualDate = date(2023, time.June, 1)
+
+	store.EXPECT().
+		ListInterestBearingAccounts(gomock.Any(), gomock.Any()).
+		AnyTimes().
+		Return([]db.ListInterestBearingAccountsRow{account1, account2}, nil)
+	store.EXPECT().
+		GetAccountBalanceAt(gomock.Any(), gomock.Any()).
+		Times(3).
+		DoAndReturn(func(_ context.Context, arg db.GetAccountBalanceAtParams) (int64, error) {
+			require.Equal(t, account2.AccountID, arg.AccountID)
+			return 36500, nil
+		})
+
+	// every day after its last one, up to yesterday
+	for day := 2; day <= 4; day++ {
+		arg := db.CreateInterestAccrualParams{
+			AccountID:     account2.AccountID,
+			AccrualDate:   date(2023, time.June, day),
+			Balance:       36500,
+			AnnualRateBps: 1000,
+			DayCount:      Actual365,
+			AmountMicros:  10_000_000,
+		}
+		store.EXPECT().CreateInterestAccrual(gomock.Any(), gomock.Eq(arg)).Times(1).Return(nil)
+	}
+	store.EXPECT().PostInterestTx(gomock.Any(), gomock.Any()).Times(2).Return(db.PostInterestTxResult{}, db.ErrInterestAlreadyPosted)
+
+	NewEngine(store).runOnce(context.Background(), now)
+}
+
+func TestAccrueMissedStopsOnFailure(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+	store := mockdb.NewMockStore(ctrl)
+
+	// nothing accrued yet, the plan was attached on June 2nd
+	account := db.ListInterestBearingAccountsRow{
+		AccountID:       util.RandomInt(1, 1000),
+		Currency:        util.USD,
+		PlanAttachedAt:  date(2023, time.June, 2).Add(10 * time.Hour),
+		AnnualRateBps:   1000,
+		DayCount:        Actual365,
+		LastAccrualDate: date(2023, time.June, 1),
+	}
+
+	store.EXPECT().
+		ListInterestBearingAccounts(gomock.Any(), gomock.Any()).
+		Times(1).
+		Return([]db.ListInterestBearingAccountsRow{account}, nil)
+	store.EXPECT().GetAccountBalanceAt(gomock.Any(), gomock.Any()).Times(2).Return(int64(0), nil)
+
+	// June 3rd fails, June 4th waits for it so the next run starts again from June 3rd
+	store.EXPECT().
+		CreateInterestAccrual(gomock.Any(), gomock.Any()).
+		Times(2).
+		DoAndReturn(func(_ context.Context, arg db.CreateInterestAccrualParams) error {
+			if arg.AccrualDate.Equal(date(2023, time.June, 3)) {
+				return db.ErrRecordNotFound
+			}
+			require.Equal(t, date(2023, time.June, 2), arg.AccrualDate)
+			return nil
+		})
+
+	err := NewEngine(store).AccrueMissed(context.Background(), date(2023, time.June, 4).Add(time.Hour))
+	require.EqualError(t, err, "interest accrual failed for 1 accounts")
+}
@@ -0,0 +1,105 @@
+package ledger
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"sync"
+	"time"
+
+	db "github.com/XiaozhouCui/go-bank/db/sqlc"
+)
+
+// Verifier checks the ledger in the background and keeps the last report,
+// which the API exposes as metrics and as a health signal.
+type Verifier struct {
+	store db.Store
+
+	mu   sync.RWMutex
+	last *Report
+}
+
+// NewVerifier creates a new ledger verifier.
+func NewVerifier(store db.Store) *Verifier {
+	return &Verifier{
+		store: store,
+	}
+}
+
+// Run checks the ledger right away, then again at every interval, until the context is done.
+func (verifier *Verifier) Run(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		if _, err := verifier.Check(ctx); err != nil {
+			log.Println("cannot verify ledger:", err)
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// Check verifies the ledger and keeps the report. Discrepancies are logged as JSON.
+func (verifier *Verifier) Check(ctx context.Context) (Report, error) {
+	report, err := Verify(ctx, verifier.store, time.Now())
+	if err != nil {
+		return report, err
+	}
+
+	if !report.OK {
+		data, err := json.Marshal(report)
+		if err != nil {
+			return report, err
+		}
+		log.Printf("ledger discrepancies found: %s", data)
+	}
+
+	verifier.mu.Lock()
+	verifier.last = &report
+	verifier.mu.Unlock()
+	return report, nil
+}
+
+// Last returns the report of the last check, false if the ledger has not been checked yet.
+func (verifier *Verifier) Last() (Report, bool) {
+	verifier.mu.RLock()
+	defer verifier.mu.RUnlock()
+
+	if verifier.last == nil {
+		return Report{}, false
+	}
+	return *verifier.last, true
+}
+
+// WriteMetrics writes the result of the last check in the Prometheus text format.
+func (verifier *Verifier) WriteMetrics(w io.Writer) error {
+	report, checked := verifier.Last()
+	if !checked {
+		return nil
+	}
+
+	notConserved := 0
+	for _, currency := range report.Currencies {
+		if !currency.Conserved {
+			notConserved++
+		}
+	}
+
+	_, err := fmt.Fprintf(w, `# HELP bank_ledger_discrepancies Number of broken ledger invariants found by the last check.
+# TYPE bank_ledger_discrepancies gauge
+bank_ledger_discrepancies{invariant="account_balance"} %d
+bank_ledger_discrepancies{invariant="transfer_entries"} %d
+bank_ledger_discrepancies{invariant="currency_conservation"} %d
+# HELP bank_ledger_last_check_timestamp_seconds Time of the last ledger check.
+# TYPE bank_ledger_last_check_timestamp_seconds gauge
+bank_ledger_last_check_timestamp_seconds %d
+`, report.AccountMismatchCount, report.UnbalancedTransferCount, notConserved, report.CheckedAt.Unix())
+	return err
+}
@@ -0,0 +1,87 @@
+package ledger
+
+import (
+	"context"
+	"time"
+
+	db "github.com/XiaozhouCui/go-bank/db/sqlc"
+)
+
+// number of discrepancies of each kind listed in a report, the most recent first
+const maxReported = 100
+
+// Report is the outcome of a ledger check.
+type Report struct {
+	CheckedAt time.Time `json:"checked_at"`
+	OK        bool      `json:"ok"`
+	// accounts whose balance is not the sum of their entries
+	AccountMismatchCount int64                                `json:"account_mismatch_count"`
+	AccountMismatches    []db.ListAccountBalanceMismatchesRow `json:"account_mismatches"`
+	// transfers without exactly one debit of the sender and one credit of the receiver,
+	// plus the two entries of the fee if there is one, all adding up to zero
+	UnbalancedTransferCount int64                           `json:"unbalanced_transfer_count"`
+	UnbalancedTransfers     []db.ListUnbalancedTransfersRow `json:"unbalanced_transfers"`
+	Currencies              []CurrencyTotal                 `json:"currencies"`
+}
+
+// CurrencyTotal is the money held in one currency.
+// Money only moves between accounts, so the entries of a currency add up to zero, and so do the balances.
+type CurrencyTotal struct {
+	Currency     string `json:"currency"`
+	BalanceTotal int64  `json:"balance_total"`
+	EntriesTotal int64  `json:"entries_total"`
+	Conserved    bool   `json:"conserved"`
+}
+
+// Discrepancies returns the number of broken invariants found by the check.
+func (report Report) Discrepancies() int64 {
+	n := report.AccountMismatchCount + report.UnbalancedTransferCount
+	for _, currency := range report.Currencies {
+		if !currency.Conserved {
+			n++
+		}
+	}
+	return n
+}
+
+// Verify checks that every account balance is the sum of its entries, that every transfer
+// has matching entries, and that money is conserved in every currency.
+// Each invariant is checked by a single query, so it sees a consistent snapshot of the db.
+func Verify(ctx context.Context, store db.Store, now time.Time) (Report, error) {
+	report := Report{
+		CheckedAt: now,
+	}
+
+	var err error
+	report.AccountMismatches, err = store.ListAccountBalanceMismatches(ctx, maxReported)
+	if err != nil {
+		return report, err
+	}
+	if len(report.AccountMismatches) > 0 {
+		report.AccountMismatchCount = report.AccountMismatches[0].MismatchCount
+	}
+
+	report.UnbalancedTransfers, err = store.ListUnbalancedTransfers(ctx, maxReported)
+	if err != nil {
+		return report, err
+	}
+	if len(report.UnbalancedTransfers) > 0 {
+		report.UnbalancedTransferCount = report.UnbalancedTransfers[0].UnbalancedCount
+	}
+
+	totals, err := store.ListCurrencyTotals(ctx)
+	if err != nil {
+		return report, err
+	}
+	for _, total := range totals {
+		report.Currencies = append(report.Currencies, CurrencyTotal{
+			Currency:     total.Currency,
+			BalanceTotal: total.BalanceTotal,
+			EntriesTotal: total.EntriesTotal,
+			Conserved:    total.EntriesTotal == 0 && total.BalanceTotal == 0,
+		})
+	}
+
+	report.OK = report.Discrepancies() == 0
+	return report, nil
+}
@@ -0,0 +1,113 @@
+package ledger
+
+import (
+	"bytes"
+	"context"
+	"testing"
+	"time"
+
+	mockdb "github.com/XiaozhouCui/go-bank/db/mock"
+	db "github.com/XiaozhouCui/go-bank/db/sqlc"
+	"github.com/XiaozhouCui/go-bank/db/util"
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/require"
+)
+
+func TestVerify(t *testing.T) {
+	now := time.Now()
+
+	testCases := []struct {
+		name        string
+		buildStubs  func(store *mockdb.MockStore)
+		checkReport func(report Report)
+	}{
+		{
+			name: "OK",
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().ListAccountBalanceMismatches(gomock.Any(), gomock.Eq(int32(maxReported))).
+					Times(1).Return([]db.ListAccountBalanceMismatchesRow{}, nil)
+				store.EXPECT().ListUnbalancedTransfers(gomock.Any(), gomock.Eq(int32(maxReported))).
+					Times(1).Return([]db.ListUnbalancedTransfersRow{}, nil)
+				store.EXPECT().ListCurrencyTotals(gomock.Any()).
+					Times(1).Return([]db.ListCurrencyTotalsRow{{Currency: util.USD}}, nil)
+			},
+			checkReport: func(report Report) {
+				require.True(t, report.OK)
+				require.Zero(t, report.Discrepancies())
+				require.Equal(t, now, report.CheckedAt)
+				require.True(t, report.Currencies[0].Conserved)
+			},
+		},
+		{
+			name: "Discrepancies",
+			buildStubs: func(store *mockdb.MockStore) {
+				// the count covers all mismatches, not only the listed ones
+				store.EXPECT().ListAccountBalanceMismatches(gomock.Any(), gomock.Any()).
+					Times(1).Return([]db.ListAccountBalanceMismatchesRow{{AccountID: 1, Balance: 10, MismatchCount: 150}}, nil)
+				store.EXPECT().ListUnbalancedTransfers(gomock.Any(), gomock.Any()).
+					Times(1).Return([]db.ListUnbalancedTransfersRow{{TransferID: 2, EntryCount: 1, UnbalancedCount: 1}}, nil)
+				store.EXPECT().ListCurrencyTotals(gomock.Any()).
+					Times(1).Return([]db.ListCurrencyTotalsRow{
+					{Currency: util.USD, BalanceTotal: 10, EntriesTotal: 0},
+					{Currency: util.EUR},
+				}, nil)
+			},
+			checkReport: func(report Report) {
+				require.False(t, report.OK)
+				require.Equal(t, int64(150), report.AccountMismatchCount)
+				require.Equal(t, int64(1), report.UnbalancedTransferCount)
+				require.False(t, report.Currencies[0].Conserved)
+				require.True(t, report.Currencies[1].Conserved)
+				require.Equal(t, int64(152), report.Discrepancies())
+			},
+		},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			store := mockdb.NewMockStore(ctrl)
+			tc.buildStubs(store)
+
+			report, err := Verify(context.Background(), store, now)
+			require.NoError(t, err)
+			tc.checkReport(report)
+		})
+	}
+}
+
+func TestVerifierMetrics(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	store := mockdb.NewMockStore(ctrl)
+	store.EXPECT().ListAccountBalanceMismatches(gomock.Any(), gomock.Any()).
+		Times(1).Return([]db.ListAccountBalanceMismatchesRow{{AccountID: 1, MismatchCount: 3}}, nil)
+	store.EXPECT().ListUnbalancedTransfers(gomock.Any(), gomock.Any()).
+		Times(1).Return([]db.ListUnbalancedTransfersRow{}, nil)
+	store.EXPECT().ListCurrencyTotals(gomock.Any()).
+		Times(1).Return([]db.ListCurrencyTotalsRow{}, nil)
+
+	verifier := NewVerifier(store)
+
+	// nothing to report before the first check
+	_, checked := verifier.Last()
+	require.False(t, checked)
+
+	_, err := verifier.Check(context.Background())
+	require.NoError(t, err)
+
+	report, checked := verifier.Last()
+	require.True(t, checked)
+	require.False(t, report.OK)
+
+	var metrics bytes.Buffer
+	err = verifier.WriteMetrics(&metrics)
+	require.NoError(t, err)
+	require.Contains(t, metrics.String(), `bank_ledger_discrepancies{invariant="account_balance"} 3`)
+	require.Contains(t, metrics.String(), `bank_ledger_discrepancies{invariant="transfer_entries"} 0`)
+}
@@ -1,13 +1,26 @@
 package main
 
 import (
-	"database/sql"
+	"context"
+	"encoding/json"
+	"flag"
 	"log"
+	"os"
+	"time"
 
 	"github.com/XiaozhouCui/go-bank/api"
+	"github.com/XiaozhouCui/go-bank/audit"
+	"github.com/XiaozhouCui/go-bank/bulk"
 	db "github.com/XiaozhouCui/go-bank/db/sqlc"
 	"github.com/XiaozhouCui/go-bank/db/util"
-	_ "github.com/lib/pq"
+	"github.com/XiaozhouCui/go-bank/hold"
+	"github.com/XiaozhouCui/go-bank/interest"
+	"github.com/XiaozhouCui/go-bank/ledger"
+	"github.com/XiaozhouCui/go-bank/outbox"
+	"github.com/XiaozhouCui/go-bank/paymentrequest"
+	"github.com/XiaozhouCui/go-bank/scheduler"
+	"github.com/XiaozhouCui/go-bank/stream"
+	"github.com/XiaozhouCui/go-bank/webhook"
 )
 
 func main() {
@@ -16,14 +29,83 @@ func main() {
 	if err != nil {
 		log.Fatal("cannot load config:", err)
 	}
-	// connect to db
-	conn, err := sql.Open(config.DBDriver, config.DBSource)
+	// connect to db through a pgx connection pool
+	connPool, err := db.NewConnPool(context.Background(), config)
 	if err != nil {
 		log.Fatal("cannot connect to db:", err)
 	}
 
-	store := db.NewStore(conn) // return a store interface
-	server, err := api.NewServer(config, store)
+	store := db.NewStore(connPool) // return a store interface
+
+	// `bank verify-ledger` checks the ledger once and exits, instead of starting the server
+	if len(os.Args) > 1 && os.Args[1] == "verify-ledger" {
+		verifyLedger(store)
+		return
+	}
+
+	// `bank verify-audit-log` checks the hash chain of the audit log once and exits
+	if len(os.Args) > 1 && os.Args[1] == "verify-audit-log" {
+		verifyAuditLog(store)
+		return
+	}
+
+	// `bank import-payments` validates a payment file, or executes an import job, and exits
+	if len(os.Args) > 1 && os.Args[1] == "import-payments" {
+		importPayments(store, os.Args[2:])
+		return
+	}
+
+	// accrue and post interest in the background
+	if config.InterestJobInterval > 0 {
+		go interest.NewEngine(store).Run(context.Background(), config.InterestJobInterval)
+	}
+
+	// run the scheduled transfers when they are due
+	if config.SchedulerInterval > 0 {
+		go scheduler.NewScheduler(store, config.SchedulerRetryDelay).Run(context.Background(), config.SchedulerInterval)
+	}
+
+	// release the holds that have expired
+	if config.HoldExpiryInterval > 0 {
+		go hold.NewExpirer(store).Run(context.Background(), config.HoldExpiryInterval)
+	}
+
+	// expire the payment requests that were not paid in time
+	if config.PaymentRequestExpiryInterval > 0 {
+		go paymentrequest.NewSweeper(store).Run(context.Background(), config.PaymentRequestExpiryInterval)
+	}
+
+	// publish the events of the outbox to downstream systems,
+	// and turn them into the webhook deliveries of the customers subscribed to them
+	if config.OutboxRelayInterval > 0 {
+		sink, err := outbox.NewSink(config.OutboxSink)
+		if err != nil {
+			log.Fatal("cannot create outbox sink:", err)
+		}
+		sinks := outbox.MultiSink{sink}
+		if config.WebhookDeliveryInterval > 0 {
+			sinks = append(sinks, webhook.NewDispatcher(store))
+		}
+		go outbox.NewRelay(store, sinks).Run(context.Background(), config.OutboxRelayInterval)
+	}
+
+	// post the webhook deliveries to the customers' endpoints
+	if config.WebhookDeliveryInterval > 0 {
+		deliverer := webhook.NewDeliverer(store, config.WebhookMaxAttempts, config.WebhookRetryBackoff)
+		go deliverer.Run(context.Background(), config.WebhookDeliveryInterval)
+	}
+
+	// check the ledger in the background, the server reports the result
+	verifier := ledger.NewVerifier(store)
+	if config.LedgerCheckInterval > 0 {
+		go verifier.Run(context.Background(), config.LedgerCheckInterval)
+	}
+
+	// push the new entries to the clients streaming their accounts, whichever instance wrote them
+	hub := stream.NewHub()
+	go stream.NewListener(connPool, hub).Run(context.Background())
+
+	server, err := api.NewServer(config, store, verifier, hub)
 	if err != nil {
 		log.Fatal("cannot create server:", err)
 	}
@@ -35,3 +117,106 @@ func main() {
 		log.Fatal("cannot start server:", err)
 	}
 }
+
+// verifyLedger prints the ledger report as JSON, and exits with status 1 if it found discrepancies.
+func verifyLedger(store db.Store) {
+	report, err := ledger.Verify(context.Background(), store, time.Now())
+	if err != nil {
+		log.Fatal("cannot verify ledger:", err)
+	}
+
+	encoder := json.NewEncoder(os.Stdout)
+	encoder.SetIndent("", "  ")
+	if err := encoder.Encode(report); err != nil {
+		log.Fatal("cannot print ledger report:", err)
+	}
+
+	if !report.OK {
+		os.Exit(1)
+	}
+}
+
+// verifyAuditLog prints the audit log report as JSON, and exits with status 1 if the hash chain is broken.
+func verifyAuditLog(store db.Store) {
+	report, err := audit.Verify(context.Background(), store)
+	if err != nil {
+		log.Fatal("cannot verify audit log:", err)
+	}
+
+	encoder := json.NewEncoder(os.Stdout)
+	encoder.SetIndent("", "  ")
+	if err := encoder.Encode(report); err != nil {
+		log.Fatal("cannot print audit log report:", err)
+	}
+
+	if !report.OK {
+		os.Exit(1)
+	}
+}
+
+// importPayments imports a payment file for a user and prints the validation report as JSON:
+//
+//	bank import-payments -user alice -format csv payments.csv
+//
+// The job is only executed with -execute, or later with -job and its ID. It exits with status 1
+// if the file is rejected.
+func importPayments(store db.Store, args []string) {
+	flags := flag.NewFlagSet("import-payments", flag.ExitOnError)
+	username := flags.String("user", "", "user sending the payments, from accounts they own or can spend from")
+	format := flags.String("format", db.ImportFormatCSV, "format of the payment file, pain.001 or csv")
+	execute := flags.Bool("execute", false, "execute the job once the file is validated")
+	jobID := flags.Int64("job", 0, "execute this import job instead of importing a file")
+	flags.Parse(args)
+
+	api.RegisterValidators()
+	ctx := context.Background()
+
+	var job db.ImportJob
+	var lines []db.ImportJobLine
+	var err error
+	if *jobID > 0 {
+		job, err = store.GetImportJob(ctx, *jobID)
+		if err != nil {
+			log.Fatal("cannot get import job:", err)
+		}
+		*execute = true
+	} else {
+		if *username == "" || flags.NArg() != 1 {
+			log.Fatal("usage: bank import-payments -user <username> [-format pain.001|csv] [-execute] <file>")
+		}
+		data, err := os.ReadFile(flags.Arg(0))
+		if err != nil {
+			log.Fatal("cannot read payment file:", err)
+		}
+		instructions, err := bulk.Parse(*format, data)
+		if err != nil {
+			log.Fatal("cannot parse payment file:", err)
+		}
+		result, err := bulk.Import(ctx, store, *username, *format, instructions)
+		if err != nil {
+			log.Fatal("cannot import payment file:", err)
+		}
+		job, lines = result.Job, result.Lines
+	}
+
+	if *execute && job.Status != db.ImportJobRejected {
+		job, lines, err = bulk.Execute(ctx, store, job)
+		if err != nil {
+			log.Fatal("cannot execute import job:", err)
+		}
+	}
+
+	encoder := json.NewEncoder(os.Stdout)
+	encoder.SetIndent("", "  ")
+	report := struct {
+		Job   db.ImportJob       `json:"job"`
+		Lines []db.ImportJobLine `json:"lines"`
+	}{job, lines}
+	if err := encoder.Encode(report); err != nil {
+		log.Fatal("cannot print import report:", err)
+	}
+
+	if job.Status == db.ImportJobRejected {
+		os.Exit(1)
+	}
+}
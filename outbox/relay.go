@@ -0,0 +1,105 @@
+// Package outbox relays the events written to the outbox table to a downstream sink.
+// Delivery is at least once: an event is marked as published only after the sink accepted it,
+// so it is published again if the relay stops in between or if two relays run at the same time.
+// Consumers ignore the events whose ID they have already seen.
+package outbox
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"time"
+
+	db "github.com/XiaozhouCui/go-bank/db/sqlc"
+	"github.com/XiaozhouCui/go-bank/events"
+)
+
+// number of events loaded from the db at a time
+const batchSize = 100
+
+// Relay publishes the pending events of the outbox to a sink, in the order they were written.
+type Relay struct {
+	store db.Store
+	sink  Sink
+}
+
+// NewRelay creates a new relay publishing to sink.
+func NewRelay(store db.Store, sink Sink) *Relay {
+	return &Relay{
+		store: store,
+		sink:  sink,
+	}
+}
+
+// Run publishes the pending events right away, then again at every interval, until the context is done.
+func (relay *Relay) Run(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		if err := relay.PublishPending(ctx); err != nil {
+			log.Println("cannot relay outbox events:", err)
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// PublishPending publishes every event of the outbox that has not been published yet.
+// It stops at the first event the sink rejects, after recording the failure, so events are never
+// published out of order: that event and the ones after it are tried again at the next run.
+func (relay *Relay) PublishPending(ctx context.Context) error {
+	var afterID int64
+	for {
+		pending, err := relay.store.ListPendingOutboxEvents(ctx, db.ListPendingOutboxEventsParams{
+			AfterID: afterID,
+			Limit:   batchSize,
+		})
+		if err != nil {
+			return err
+		}
+
+		for _, row := range pending {
+			if err := relay.publish(ctx, row); err != nil {
+				return err
+			}
+			afterID = row.ID
+		}
+
+		if len(pending) < batchSize {
+			return nil
+		}
+	}
+}
+
+// publish sends one event to the sink, and marks it as published once the sink has accepted it.
+func (relay *Relay) publish(ctx context.Context, row db.Outbox) error {
+	err := relay.sink.Publish(ctx, NewEvent(row))
+	if err != nil {
+		if recordErr := relay.store.RecordOutboxEventFailure(ctx, db.RecordOutboxEventFailureParams{
+			ID:        row.ID,
+			LastError: err.Error(),
+		}); recordErr != nil {
+			log.Printf("cannot record the failure of outbox event [%d]: %v", row.ID, recordErr)
+		}
+		return fmt.Errorf("cannot publish outbox event [%d]: %w", row.ID, err)
+	}
+
+	// if this fails, the event is published again at the next run
+	return relay.store.MarkOutboxEventPublished(ctx, row.ID)
+}
+
+// NewEvent wraps a row of the outbox in the envelope it is published in.
+func NewEvent(row db.Outbox) events.Event {
+	return events.Event{
+		ID:            row.ID,
+		Type:          row.EventType,
+		SchemaVersion: row.SchemaVersion,
+		OccurredAt:    row.CreatedAt,
+		Data:          row.Payload,
+	}
+}
@@ -0,0 +1,85 @@
+package outbox
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	mockdb "github.com/XiaozhouCui/go-bank/db/mock"
+	db "github.com/XiaozhouCui/go-bank/db/sqlc"
+	"github.com/XiaozhouCui/go-bank/events"
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/require"
+)
+
+// failingSink rejects the events with the given IDs
+type failingSink struct {
+	published []int64
+	failing   map[int64]bool
+}
+
+func (sink *failingSink) Publish(ctx context.Context, event events.Event) error {
+	if sink.failing[event.ID] {
+		return errors.New("sink is down")
+	}
+	sink.published = append(sink.published, event.ID)
+	return nil
+}
+
+func outboxRows(ids ...int64) []db.Outbox {
+	rows := make([]db.Outbox, len(ids))
+	for i, id := range ids {
+		rows[i] = db.Outbox{
+			ID:            id,
+			EventType:     events.AccountCreated,
+			SchemaVersion: 1,
+			Payload:       []byte(`{"account_id":1}`),
+			CreatedAt:     time.Now(),
+		}
+	}
+	return rows
+}
+
+func TestPublishPending(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	store := mockdb.NewMockStore(ctrl)
+	store.EXPECT().
+		ListPendingOutboxEvents(gomock.Any(), gomock.Eq(db.ListPendingOutboxEventsParams{AfterID: 0, Limit: batchSize})).
+		Times(1).
+		Return(outboxRows(1, 2), nil)
+	store.EXPECT().MarkOutboxEventPublished(gomock.Any(), gomock.Eq(int64(1))).Times(1).Return(nil)
+	store.EXPECT().MarkOutboxEventPublished(gomock.Any(), gomock.Eq(int64(2))).Times(1).Return(nil)
+
+	sink := NewChannelSink(2)
+	require.NoError(t, NewRelay(store, sink).PublishPending(context.Background()))
+
+	event := <-sink.Events()
+	require.Equal(t, int64(1), event.ID)
+	require.Equal(t, events.AccountCreated, event.Type)
+	require.Equal(t, int64(1), event.SchemaVersion)
+	require.JSONEq(t, `{"account_id":1}`, string(event.Data))
+	require.Equal(t, int64(2), (<-sink.Events()).ID)
+}
+
+func TestPublishPendingStopsAtFailure(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	store := mockdb.NewMockStore(ctrl)
+	store.EXPECT().ListPendingOutboxEvents(gomock.Any(), gomock.Any()).Times(1).Return(outboxRows(1, 2, 3), nil)
+	store.EXPECT().MarkOutboxEventPublished(gomock.Any(), gomock.Eq(int64(1))).Times(1).Return(nil)
+	store.EXPECT().
+		RecordOutboxEventFailure(gomock.Any(), gomock.Eq(db.RecordOutboxEventFailureParams{ID: 2, LastError: "sink is down"})).
+		Times(1).
+		Return(nil)
+	// 3 waits for 2, so the events stay in order
+	store.EXPECT().MarkOutboxEventPublished(gomock.Any(), gomock.Eq(int64(3))).Times(0)
+
+	sink := &failingSink{failing: map[int64]bool{2: true}}
+	err := NewRelay(store, sink).PublishPending(context.Background())
+	require.ErrorContains(t, err, "outbox event [2]")
+	require.Equal(t, []int64{1}, sink.published)
+}
@@ -0,0 +1,154 @@
+package outbox
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/XiaozhouCui/go-bank/events"
+)
+
+// Sink is where the relay publishes events. Publish returns nil only once the event is delivered.
+type Sink interface {
+	Publish(ctx context.Context, event events.Event) error
+}
+
+// NewSink creates the sink configured by target:
+// "file:<path>" appends the events to a file, and an http:// or https:// URL posts them to a webhook.
+func NewSink(target string) (Sink, error) {
+	switch {
+	case strings.HasPrefix(target, "file:"):
+		return NewFileSink(strings.TrimPrefix(target, "file:"))
+	case strings.HasPrefix(target, "http://"), strings.HasPrefix(target, "https://"):
+		return NewWebhookSink(target), nil
+	}
+	return nil, fmt.Errorf("unsupported outbox sink %q", target)
+}
+
+// MultiSink publishes every event to several sinks, in order.
+// An event one of them rejects is published again to all of them, which at least once consumers handle already.
+type MultiSink []Sink
+
+// Publish publishes the event to each sink, and stops at the first one that rejects it.
+func (sinks MultiSink) Publish(ctx context.Context, event events.Event) error {
+	for _, sink := range sinks {
+		if err := sink.Publish(ctx, event); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// ChannelSink delivers the events to a channel, for consumers running in the same process.
+type ChannelSink struct {
+	events chan events.Event
+}
+
+// NewChannelSink creates a channel sink buffering up to size events.
+func NewChannelSink(size int) *ChannelSink {
+	return &ChannelSink{
+		events: make(chan events.Event, size),
+	}
+}
+
+// Events returns the channel the events are delivered to.
+func (sink *ChannelSink) Events() <-chan events.Event {
+	return sink.events
+}
+
+// Publish waits until the event is received or buffered, or the context is done.
+func (sink *ChannelSink) Publish(ctx context.Context, event events.Event) error {
+	select {
+	case sink.events <- event:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// FileSink appends the events to a file, one JSON object per line.
+type FileSink struct {
+	mu   sync.Mutex
+	file *os.File
+}
+
+// NewFileSink opens the file at path for appending, creating it if needed.
+func NewFileSink(path string) (*FileSink, error) {
+	file, err := os.OpenFile(path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0644)
+	if err != nil {
+		return nil, err
+	}
+	return &FileSink{file: file}, nil
+}
+
+// Publish writes the event and flushes it to disk.
+func (sink *FileSink) Publish(ctx context.Context, event events.Event) error {
+	line, err := json.Marshal(event)
+	if err != nil {
+		return err
+	}
+
+	sink.mu.Lock()
+	defer sink.mu.Unlock()
+	if _, err := sink.file.Write(append(line, '\n')); err != nil {
+		return err
+	}
+	return sink.file.Sync()
+}
+
+// Close closes the file.
+func (sink *FileSink) Close() error {
+	return sink.file.Close()
+}
+
+// WebhookSink posts each event as JSON to a URL.
+type WebhookSink struct {
+	url    string
+	client *http.Client
+}
+
+// NewWebhookSink creates a webhook sink posting to url.
+func NewWebhookSink(url string) *WebhookSink {
+	return &WebhookSink{
+		url:    url,
+		client: &http.Client{Timeout: 10 * time.Second},
+	}
+}
+
+// Publish posts the event, any 2xx response means it was delivered.
+// The event ID and type are also sent as the X-Event-Id and X-Event-Type headers.
+func (sink *WebhookSink) Publish(ctx context.Context, event events.Event) error {
+	body, err := json.Marshal(event)
+	if err != nil {
+		return err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, sink.url, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("X-Event-Id", strconv.FormatInt(event.ID, 10))
+	req.Header.Set("X-Event-Type", event.Type)
+
+	rsp, err := sink.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer rsp.Body.Close()
+	// drain the body so the connection can be reused
+	io.Copy(io.Discard, rsp.Body)
+
+	if rsp.StatusCode < 200 || rsp.StatusCode >= 300 {
+		return fmt.Errorf("webhook answered %s", rsp.Status)
+	}
+	return nil
+}
@@ -0,0 +1,104 @@
+package outbox
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/XiaozhouCui/go-bank/events"
+	"github.com/stretchr/testify/require"
+)
+
+func testEvent(id int64) events.Event {
+	return events.Event{
+		ID:            id,
+		Type:          events.TransferCompleted,
+		SchemaVersion: 1,
+		OccurredAt:    time.Date(2023, time.January, 31, 9, 0, 0, 0, time.UTC),
+		Data:          json.RawMessage(`{"transfer_id":12}`),
+	}
+}
+
+func TestNewSink(t *testing.T) {
+	sink, err := NewSink("file:" + filepath.Join(t.TempDir(), "events.jsonl"))
+	require.NoError(t, err)
+	require.IsType(t, &FileSink{}, sink)
+
+	sink, err = NewSink("https://example.com/events")
+	require.NoError(t, err)
+	require.IsType(t, &WebhookSink{}, sink)
+
+	_, err = NewSink("kafka://localhost:9092")
+	require.Error(t, err)
+}
+
+func TestMultiSink(t *testing.T) {
+	first := NewChannelSink(1)
+	failing := &failingSink{failing: map[int64]bool{2: true}}
+	sink := MultiSink{first, failing}
+
+	require.NoError(t, sink.Publish(context.Background(), testEvent(1)))
+	require.Equal(t, int64(1), (<-first.Events()).ID)
+	require.Equal(t, []int64{1}, failing.published)
+
+	require.Error(t, sink.Publish(context.Background(), testEvent(2)))
+}
+
+func TestFileSink(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "events.jsonl")
+	sink, err := NewFileSink(path)
+	require.NoError(t, err)
+
+	require.NoError(t, sink.Publish(context.Background(), testEvent(1)))
+	require.NoError(t, sink.Publish(context.Background(), testEvent(2)))
+	require.NoError(t, sink.Close())
+
+	file, err := os.Open(path)
+	require.NoError(t, err)
+	defer file.Close()
+
+	var ids []int64
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		var event events.Event
+		require.NoError(t, json.Unmarshal(scanner.Bytes(), &event))
+		require.Equal(t, events.TransferCompleted, event.Type)
+		ids = append(ids, event.ID)
+	}
+	require.NoError(t, scanner.Err())
+	require.Equal(t, []int64{1, 2}, ids)
+}
+
+func TestWebhookSink(t *testing.T) {
+	status := http.StatusNoContent
+	var received events.Event
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		require.Equal(t, http.MethodPost, r.Method)
+		require.Equal(t, "application/json", r.Header.Get("Content-Type"))
+		require.Equal(t, "7", r.Header.Get("X-Event-Id"))
+		require.Equal(t, events.TransferCompleted, r.Header.Get("X-Event-Type"))
+
+		body, err := io.ReadAll(r.Body)
+		require.NoError(t, err)
+		require.NoError(t, json.Unmarshal(body, &received))
+		w.WriteHeader(status)
+	}))
+	defer server.Close()
+
+	sink := NewWebhookSink(server.URL)
+	require.NoError(t, sink.Publish(context.Background(), testEvent(7)))
+	require.Equal(t, int64(7), received.ID)
+	require.JSONEq(t, `{"transfer_id":12}`, string(received.Data))
+
+	// the event is not delivered until the webhook accepts it
+	status = http.StatusServiceUnavailable
+	err := sink.Publish(context.Background(), testEvent(7))
+	require.ErrorContains(t, err, "503")
+}
@@ -0,0 +1,46 @@
+package paymentrequest
+
+import (
+	"context"
+	"log"
+	"time"
+
+	db "github.com/XiaozhouCui/go-bank/db/sqlc"
+)
+
+// Sweeper marks the pending payment requests that have expired, so they leave the lists of pending requests.
+// A request cannot be paid after it expires even before it is swept.
+type Sweeper struct {
+	store db.Store
+}
+
+// NewSweeper creates a new payment request sweeper.
+func NewSweeper(store db.Store) *Sweeper {
+	return &Sweeper{
+		store: store,
+	}
+}
+
+// Run sweeps the expired requests right away, then again at every interval, until the context is done.
+func (sweeper *Sweeper) Run(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		if _, err := sweeper.SweepDue(ctx, time.Now()); err != nil {
+			log.Println("cannot expire payment requests:", err)
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// SweepDue marks expired every pending payment request that expired at or before now,
+// and returns the requests it expired. A request paid, declined or cancelled in the meantime is skipped.
+func (sweeper *Sweeper) SweepDue(ctx context.Context, now time.Time) ([]db.PaymentRequest, error) {
+	return sweeper.store.ExpirePaymentRequests(ctx, now)
+}
@@ -0,0 +1,49 @@
+package paymentrequest
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	mockdb "github.com/XiaozhouCui/go-bank/db/mock"
+	db "github.com/XiaozhouCui/go-bank/db/sqlc"
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSweepDue(t *testing.T) {
+	now := time.Now()
+
+	expired := []db.PaymentRequest{
+		{ID: 1, Status: db.PaymentRequestExpired},
+		{ID: 2, Status: db.PaymentRequestExpired},
+	}
+
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	store := mockdb.NewMockStore(ctrl)
+	store.EXPECT().
+		ExpirePaymentRequests(gomock.Any(), gomock.Eq(now)).
+		Times(1).
+		Return(expired, nil)
+
+	requests, err := NewSweeper(store).SweepDue(context.Background(), now)
+	require.NoError(t, err)
+	require.Equal(t, expired, requests)
+}
+
+func TestSweepDueError(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	store := mockdb.NewMockStore(ctrl)
+	store.EXPECT().
+		ExpirePaymentRequests(gomock.Any(), gomock.Any()).
+		Times(1).
+		Return(nil, errors.New("connection lost"))
+
+	_, err := NewSweeper(store).SweepDue(context.Background(), time.Now())
+	require.Error(t, err)
+}
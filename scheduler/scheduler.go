@@ -0,0 +1,111 @@
+package scheduler
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"time"
+
+	db "github.com/XiaozhouCui/go-bank/db/sqlc"
+)
+
+// number of scheduled transfers loaded from the db at a time
+const batchSize = 100
+
+// Scheduler runs the scheduled transfers when they are due.
+type Scheduler struct {
+	store      db.Store
+	retryDelay time.Duration
+}
+
+// NewScheduler creates a new scheduler. Failed runs with the retry policy are tried again after retryDelay.
+func NewScheduler(store db.Store, retryDelay time.Duration) *Scheduler {
+	return &Scheduler{
+		store:      store,
+		retryDelay: retryDelay,
+	}
+}
+
+// Run runs the due scheduled transfers right away, then again at every interval, until the context is done.
+// Each occurrence is paid at most once, even if several servers run a scheduler.
+func (scheduler *Scheduler) Run(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		if err := scheduler.RunDue(ctx, time.Now()); err != nil {
+			log.Println("cannot run scheduled transfers:", err)
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// RunDue runs every active scheduled transfer whose next run is at or before now.
+// A run that fails is recorded with its error, and the failure policy of the transfer decides what happens next.
+func (scheduler *Scheduler) RunDue(ctx context.Context, now time.Time) error {
+	failed := 0
+
+	var afterID int64
+	for {
+		due, err := scheduler.store.ListDueScheduledTransfers(ctx, db.ListDueScheduledTransfersParams{
+			Now:     now,
+			AfterID: afterID,
+			Limit:   batchSize,
+		})
+		if err != nil {
+			return err
+		}
+
+		for _, scheduled := range due {
+			if err := scheduler.run(ctx, scheduled, now); err != nil {
+				log.Printf("cannot record the run of scheduled transfer [%d]: %v", scheduled.ID, err)
+				failed++
+			}
+			afterID = scheduled.ID
+		}
+
+		if len(due) < batchSize {
+			break
+		}
+	}
+
+	if failed > 0 {
+		return fmt.Errorf("recording failed for %d scheduled transfers", failed)
+	}
+	return nil
+}
+
+// run runs one occurrence, and records its failure if the transfer could not be made.
+func (scheduler *Scheduler) run(ctx context.Context, scheduled db.ScheduledTransfer, now time.Time) error {
+	dueAt := scheduled.NextRunAt.Time
+
+	_, err := scheduler.store.RunScheduledTransferTx(ctx, db.RunScheduledTransferTxParams{
+		ID:    scheduled.ID,
+		DueAt: dueAt,
+		Now:   now,
+	})
+	// already run by another scheduler, or changed by its owner in the meantime
+	if err == nil || errors.Is(err, db.ErrScheduledTransferNotDue) {
+		return nil
+	}
+
+	_, err = scheduler.store.FailScheduledTransferTx(ctx, db.FailScheduledTransferTxParams{
+		ID:         scheduled.ID,
+		DueAt:      dueAt,
+		Now:        now,
+		Error:      err.Error(),
+		RetryDelay: scheduler.retryDelay,
+		// its owner can no longer send money from the account, or the amount needs approvals
+		Cancel: errors.Is(err, db.ErrSenderNotAllowed) || errors.Is(err, db.ErrApprovalRequired),
+	})
+	if err != nil && !errors.Is(err, db.ErrScheduledTransferNotDue) {
+		return err
+	}
+	return nil
+}
@@ -0,0 +1,168 @@
+package scheduler
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	mockdb "github.com/XiaozhouCui/go-bank/db/mock"
+	db "github.com/XiaozhouCui/go-bank/db/sqlc"
+	"github.com/golang/mock/gomock"
+	"github.com/jackc/pgx/v5/pgtype"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRunDue(t *testing.T) {
+	now := time.Now()
+	dueAt := now.Add(-time.Minute)
+
+	due := []db.ScheduledTransfer{
+		{ID: 1, NextRunAt: pgtype.Timestamptz{Time: dueAt, Valid: true}},
+		{ID: 2, NextRunAt: pgtype.Timestamptz{Time: dueAt, Valid: true}},
+		{ID: 3, NextRunAt: pgtype.Timestamptz{Time: dueAt, Valid: true}},
+	}
+
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	store := mockdb.NewMockStore(ctrl)
+	store.EXPECT().
+		ListDueScheduledTransfers(gomock.Any(), gomock.Eq(db.ListDueScheduledTransfersParams{Now: now, Limit: batchSize})).
+		Times(1).
+		Return(due, nil)
+
+	// 1 runs, 2 has already been run by another scheduler, 3 fails
+	store.EXPECT().
+		RunScheduledTransferTx(gomock.Any(), gomock.Eq(db.RunScheduledTransferTxParams{ID: 1, DueAt: dueAt, Now: now})).
+		Times(1).
+		Return(db.RunScheduledTransferTxResult{}, nil)
+	store.EXPECT().
+		RunScheduledTransferTx(gomock.Any(), gomock.Eq(db.RunScheduledTransferTxParams{ID: 2, DueAt: dueAt, Now: now})).
+		Times(1).
+		Return(db.RunScheduledTransferTxResult{}, db.ErrScheduledTransferNotDue)
+	store.EXPECT().
+		RunScheduledTransferTx(gomock.Any(), gomock.Eq(db.RunScheduledTransferTxParams{ID: 3, DueAt: dueAt, Now: now})).
+		Times(1).
+		Return(db.RunScheduledTransferTxResult{}, db.ErrInsufficientFunds)
+
+	arg := db.FailScheduledTransferTxParams{
+		ID:         3,
+		DueAt:      dueAt,
+		Now:        now,
+		Error:      db.ErrInsufficientFunds.Error(),
+		RetryDelay: time.Hour,
+	}
+	store.EXPECT().
+		FailScheduledTransferTx(gomock.Any(), gomock.Eq(arg)).
+		Times(1).
+		Return(db.FailScheduledTransferTxResult{}, nil)
+
+	scheduler := NewScheduler(store, time.Hour)
+	err := scheduler.RunDue(context.Background(), now)
+	require.NoError(t, err)
+}
+
+func TestRunDueSenderNotAllowed(t *testing.T) {
+	now := time.Now()
+	dueAt := now.Add(-time.Minute)
+
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	store := mockdb.NewMockStore(ctrl)
+	store.EXPECT().
+		ListDueScheduledTransfers(gomock.Any(), gomock.Any()).
+		Times(1).
+		Return([]db.ScheduledTransfer{{ID: 1, NextRunAt: pgtype.Timestamptz{Time: dueAt, Valid: true}}}, nil)
+	store.EXPECT().
+		RunScheduledTransferTx(gomock.Any(), gomock.Any()).
+		Times(1).
+		Return(db.RunScheduledTransferTxResult{}, db.ErrSenderNotAllowed)
+
+	// its owner was removed from the account, it is cancelled whatever its policy
+	arg := db.FailScheduledTransferTxParams{
+		ID:         1,
+		DueAt:      dueAt,
+		Now:        now,
+		Error:      db.ErrSenderNotAllowed.Error(),
+		RetryDelay: time.Hour,
+		Cancel:     true,
+	}
+	store.EXPECT().
+		FailScheduledTransferTx(gomock.Any(), gomock.Eq(arg)).
+		Times(1).
+		Return(db.FailScheduledTransferTxResult{}, nil)
+
+	scheduler := NewScheduler(store, time.Hour)
+	err := scheduler.RunDue(context.Background(), now)
+	require.NoError(t, err)
+}
+
+func TestRunDueApprovalRequired(t *testing.T) {
+	now := time.Now()
+	dueAt := now.Add(-time.Minute)
+
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	store := mockdb.NewMockStore(ctrl)
+	store.EXPECT().
+		ListDueScheduledTransfers(gomock.Any(), gomock.Any()).
+		Times(1).
+		Return([]db.ScheduledTransfer{{ID: 1, NextRunAt: pgtype.Timestamptz{Time: dueAt, Valid: true}}}, nil)
+	store.EXPECT().
+		RunScheduledTransferTx(gomock.Any(), gomock.Any()).
+		Times(1).
+		Return(db.RunScheduledTransferTxResult{}, db.ErrApprovalRequired)
+
+	// an approval policy covers its amount, it is cancelled rather than retried
+	arg := db.FailScheduledTransferTxParams{
+		ID:         1,
+		DueAt:      dueAt,
+		Now:        now,
+		Error:      db.ErrApprovalRequired.Error(),
+		RetryDelay: time.Hour,
+		Cancel:     true,
+	}
+	store.EXPECT().
+		FailScheduledTransferTx(gomock.Any(), gomock.Eq(arg)).
+		Times(1).
+		Return(db.FailScheduledTransferTxResult{}, nil)
+
+	scheduler := NewScheduler(store, time.Hour)
+	err := scheduler.RunDue(context.Background(), now)
+	require.NoError(t, err)
+}
+
+func TestRunDueBatches(t *testing.T) {
+	now := time.Now()
+
+	first := make([]db.ScheduledTransfer, batchSize)
+	for i := range first {
+		first[i] = db.ScheduledTransfer{ID: int64(i + 1), NextRunAt: pgtype.Timestamptz{Time: now, Valid: true}}
+	}
+
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	store := mockdb.NewMockStore(ctrl)
+	gomock.InOrder(
+		store.EXPECT().
+			ListDueScheduledTransfers(gomock.Any(), gomock.Eq(db.ListDueScheduledTransfersParams{Now: now, Limit: batchSize})).
+			Times(1).
+			Return(first, nil),
+		// the next batch starts after the last transfer of the previous one
+		store.EXPECT().
+			ListDueScheduledTransfers(gomock.Any(), gomock.Eq(db.ListDueScheduledTransfersParams{Now: now, AfterID: batchSize, Limit: batchSize})).
+			Times(1).
+			Return([]db.ScheduledTransfer{}, nil),
+	)
+	store.EXPECT().
+		RunScheduledTransferTx(gomock.Any(), gomock.Any()).
+		Times(batchSize).
+		Return(db.RunScheduledTransferTxResult{}, nil)
+
+	scheduler := NewScheduler(store, time.Hour)
+	err := scheduler.RunDue(context.Background(), now)
+	require.NoError(t, err)
+}
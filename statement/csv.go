@@ -0,0 +1,35 @@
+package statement
+
+import (
+	"bytes"
+	"encoding/csv"
+	"strconv"
+	"time"
+)
+
+// CSV renders the statement as comma separated values, one row per entry
+// between the opening and the closing balance.
+func (statement Statement) CSV() ([]byte, error) {
+	var buf bytes.Buffer
+	w := csv.NewWriter(&buf)
+
+	rows := [][]string{
+		{"date", "entry_id", "description", "amount", "balance"},
+		{statement.PeriodStart.Format(time.RFC3339), "", "Opening balance", "", FormatAmount(statement.OpeningBalance)},
+	}
+	for _, line := range statement.Lines {
+		rows = append(rows, []string{
+			line.Date.UTC().Format(time.RFC3339),
+			strconv.FormatInt(line.EntryID, 10),
+			line.Description,
+			FormatAmount(line.Amount),
+			FormatAmount(line.Balance),
+		})
+	}
+	rows = append(rows, []string{statement.PeriodEnd.Format(time.RFC3339), "", "Closing balance", "", FormatAmount(statement.ClosingBalance)})
+
+	if err := w.WriteAll(rows); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
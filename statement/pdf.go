@@ -0,0 +1,209 @@
+package statement
+
+import (
+	"bytes"
+	"compress/zlib"
+	"fmt"
+	"strings"
+)
+
+// page layout in points, on A4 paper
+const (
+	pageWidth    = 595
+	pageHeight   = 842
+	margin       = 50
+	fontSize     = 9
+	lineHeight   = 14
+	linesPerPage = 45
+	// the table uses a fixed width font, so columns line up
+	charWidth = 0.6 * fontSize
+)
+
+// table columns: the amounts are aligned right, the description is cut to fit
+const (
+	dateX           = margin
+	descriptionX    = margin + 11*charWidth
+	maxDescription  = 52
+	amountRight     = pageWidth - margin - 13*charWidth
+	balanceRight    = pageWidth - margin
+	firstTableLineY = pageHeight - margin - 120
+)
+
+// PDF renders the statement as a PDF document. The document only uses the standard
+// Helvetica and Courier fonts, which every PDF reader provides, so nothing is embedded.
+func (statement Statement) PDF() ([]byte, error) {
+	rows := make([][]string, 0, len(statement.Lines)+2)
+	rows = append(rows, []string{statement.PeriodStart.Format("2006-01-02"), "Opening balance", "", FormatAmount(statement.OpeningBalance)})
+	for _, line := range statement.Lines {
+		rows = append(rows, []string{
+			line.Date.UTC().Format("2006-01-02"),
+			line.Description,
+			FormatAmount(line.Amount),
+			FormatAmount(line.Balance),
+		})
+	}
+	rows = append(rows, []string{statement.PeriodEnd.AddDate(0, 0, -1).Format("2006-01-02"), "Closing balance", "", FormatAmount(statement.ClosingBalance)})
+
+	pageCount := (len(rows) + linesPerPage - 1) / linesPerPage
+	var doc pdfDocument
+	for page := 0; page < pageCount; page++ {
+		var content pdfContent
+		statement.writeHeader(&content)
+
+		y := float64(firstTableLineY)
+		content.text(courierBold, fontSize, dateX, y, "Date")
+		content.text(courierBold, fontSize, descriptionX, y, "Description")
+		content.textRight(courierBold, amountRight, y, "Amount")
+		content.textRight(courierBold, balanceRight, y, "Balance")
+
+		end := (page + 1) * linesPerPage
+		if end > len(rows) {
+			end = len(rows)
+		}
+		for _, row := range rows[page*linesPerPage : end] {
+			y -= lineHeight
+			content.text(courier, fontSize, dateX, y, row[0])
+			content.text(courier, fontSize, descriptionX, y, truncate(row[1], maxDescription))
+			content.textRight(courier, amountRight, y, row[2])
+			content.textRight(courier, balanceRight, y, row[3])
+		}
+
+		content.text(helvetica, fontSize, margin, margin, fmt.Sprintf("Page %d of %d", page+1, pageCount))
+		doc.pages = append(doc.pages, content.Bytes())
+	}
+
+	return doc.render()
+}
+
+// writeHeader writes the title and the account details at the top of a page
+func (statement Statement) writeHeader(content *pdfContent) {
+	account := statement.Account
+	y := float64(pageHeight - margin - 16)
+	content.text(helveticaBold, 16, margin, y, "Account statement")
+
+	details := []string{
+		fmt.Sprintf("Account #%d, %s, owned by %s", account.ID, account.Type, account.Owner),
+		fmt.Sprintf("Currency: %s", account.Currency),
+		fmt.Sprintf("Period: %s to %s",
+			statement.PeriodStart.Format("2006-01-02"),
+			statement.PeriodEnd.AddDate(0, 0, -1).Format("2006-01-02"),
+		),
+	}
+	y -= 10
+	for _, detail := range details {
+		y -= lineHeight
+		content.text(helvetica, 10, margin, y, detail)
+	}
+}
+
+func truncate(s string, max int) string {
+	if len(s) <= max {
+		return s
+	}
+	return s[:max-3] + "..."
+}
+
+// pdfContent is the content stream of a page
+type pdfContent struct {
+	bytes.Buffer
+}
+
+// text writes s with its baseline starting at x, y
+func (content *pdfContent) text(font int, size, x, y float64, s string) {
+	if s == "" {
+		return
+	}
+	fmt.Fprintf(content, "BT /F%d %g Tf %.2f %.2f Td (%s) Tj ET\n", font+1, size, x, y, escapePDF(s))
+}
+
+// textRight writes s in a fixed width font at the table font size, ending at x
+func (content *pdfContent) textRight(font int, x, y float64, s string) {
+	content.text(font, fontSize, x-float64(len(s))*charWidth, y, s)
+}
+
+// escapePDF escapes a string literal, replacing what the standard fonts cannot show
+func escapePDF(s string) string {
+	var b strings.Builder
+	for _, r := range s {
+		switch {
+		case r == '\\' || r == '(' || r == ')':
+			b.WriteByte('\\')
+			b.WriteRune(r)
+		case r < 32 || r > 126:
+			b.WriteByte('?')
+		default:
+			b.WriteRune(r)
+		}
+	}
+	return b.String()
+}
+
+// pdfDocument is a minimal PDF 1.4 writer for pages of text
+type pdfDocument struct {
+	pages [][]byte // content streams
+}
+
+// fonts of the page resources, F1 is helvetica and so on
+const (
+	helvetica = iota
+	helveticaBold
+	courier
+	courierBold
+)
+
+var pdfFonts = []string{"Helvetica", "Helvetica-Bold", "Courier", "Courier-Bold"}
+
+func (doc *pdfDocument) render() ([]byte, error) {
+	var buf bytes.Buffer
+	var offsets []int
+
+	// objects are numbered from 1 in the order they are written
+	object := func(body string) {
+		offsets = append(offsets, buf.Len())
+		fmt.Fprintf(&buf, "%d 0 obj\n%s\nendobj\n", len(offsets), body)
+	}
+
+	// 1 is the catalog, 2 the page tree, then the fonts, then each page and its content
+	firstPage := 3 + len(pdfFonts)
+	kids := make([]string, len(doc.pages))
+	for i := range doc.pages {
+		kids[i] = fmt.Sprintf("%d 0 R", firstPage+2*i)
+	}
+	fontRefs := make([]string, len(pdfFonts))
+	for i := range pdfFonts {
+		fontRefs[i] = fmt.Sprintf("/F%d %d 0 R", i+1, 3+i)
+	}
+
+	// the comment with binary characters tells transfer tools the file is binary
+	buf.WriteString("%PDF-1.4\n%\xe2\xe3\xcf\xd3\n")
+	object("<< /Type /Catalog /Pages 2 0 R >>")
+	object(fmt.Sprintf("<< /Type /Pages /Kids [%s] /Count %d >>", strings.Join(kids, " "), len(doc.pages)))
+	for _, font := range pdfFonts {
+		object(fmt.Sprintf("<< /Type /Font /Subtype /Type1 /BaseFont /%s /Encoding /WinAnsiEncoding >>", font))
+	}
+
+	for i, page := range doc.pages {
+		object(fmt.Sprintf("<< /Type /Page /Parent 2 0 R /MediaBox [0 0 %d %d] /Resources << /Font << %s >> >> /Contents %d 0 R >>",
+			pageWidth, pageHeight, strings.Join(fontRefs, " "), firstPage+2*i+1))
+
+		var compressed bytes.Buffer
+		w := zlib.NewWriter(&compressed)
+		if _, err := w.Write(page); err != nil {
+			return nil, err
+		}
+		if err := w.Close(); err != nil {
+			return nil, err
+		}
+		object(fmt.Sprintf("<< /Length %d /Filter /FlateDecode >>\nstream\n%s\nendstream", compressed.Len(), compressed.Bytes()))
+	}
+
+	// cross-reference table: the byte offset of every object
+	xref := buf.Len()
+	fmt.Fprintf(&buf, "xref\n0 %d\n0000000000 65535 f \n", len(offsets)+1)
+	for _, offset := range offsets {
+		fmt.Fprintf(&buf, "%010d 00000 n \n", offset)
+	}
+	fmt.Fprintf(&buf, "trailer\n<< /Size %d /Root 1 0 R >>\nstartxref\n%d\n%%%%EOF\n", len(offsets)+1, xref)
+
+	return buf.Bytes(), nil
+}
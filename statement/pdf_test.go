@@ -0,0 +1,71 @@
+package statement
+
+import (
+	"bytes"
+	"compress/zlib"
+	"fmt"
+	"io"
+	"regexp"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestPDF(t *testing.T) {
+	statement := Statement{
+		Account:        testAccount,
+		PeriodStart:    january,
+		PeriodEnd:      february,
+		OpeningBalance: 10000,
+		ClosingBalance: 10000 - 100*linesPerPage,
+	}
+	// enough lines for two pages
+	for i := 1; i <= linesPerPage; i++ {
+		statement.Lines = append(statement.Lines, Line{
+			EntryID:     int64(i),
+			Date:        january.Add(time.Duration(i) * time.Hour),
+			Description: fmt.Sprintf("Transfer #%d to account #9 (carol)", i),
+			Amount:      -100,
+			Balance:     10000 - 100*int64(i),
+		})
+	}
+
+	pdf, err := statement.PDF()
+	require.NoError(t, err)
+	require.True(t, bytes.HasPrefix(pdf, []byte("%PDF-1.4\n")))
+	require.True(t, bytes.HasSuffix(pdf, []byte("%%EOF\n")))
+	require.Contains(t, string(pdf), "/Count 2")
+
+	// startxref points to the cross-reference table
+	match := regexp.MustCompile(`startxref\n(\d+)\n`).FindSubmatch(pdf)
+	require.NotNil(t, match)
+	xref, err := strconv.Atoi(string(match[1]))
+	require.NoError(t, err)
+	require.True(t, bytes.HasPrefix(pdf[xref:], []byte("xref\n")))
+
+	// every object is where the cross-reference table says it is
+	offsets := regexp.MustCompile(`(\d{10}) 00000 n \n`).FindAllSubmatch(pdf[xref:], -1)
+	require.Len(t, offsets, 4+2*2+2) // catalog, pages, fonts, then a page and its content for each page
+	for i, offset := range offsets {
+		n, err := strconv.Atoi(string(offset[1]))
+		require.NoError(t, err)
+		require.True(t, bytes.HasPrefix(pdf[n:], []byte(fmt.Sprintf("%d 0 obj\n", i+1))))
+	}
+
+	// the content of the last page ends with the closing balance
+	streams := regexp.MustCompile(`(?s)stream\n(.*?)\nendstream`).FindAllSubmatch(pdf, -1)
+	require.Len(t, streams, 2)
+	r, err := zlib.NewReader(bytes.NewReader(streams[1][1]))
+	require.NoError(t, err)
+	content, err := io.ReadAll(r)
+	require.NoError(t, err)
+	require.Contains(t, string(content), "(Closing balance) Tj")
+	require.Contains(t, string(content), "(55.00) Tj")
+	require.Contains(t, string(content), "(Page 2 of 2) Tj")
+}
+
+func TestEscapePDF(t *testing.T) {
+	require.Equal(t, `Fee \(50%\) \\ caf?`, escapePDF(`Fee (50%) \ café`))
+}
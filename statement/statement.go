@@ -0,0 +1,198 @@
+package statement
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	db "github.com/XiaozhouCui/go-bank/db/sqlc"
+)
+
+// MonthLayout is the layout of a statement period, e.g. 2023-01.
+const MonthLayout = "2006-01"
+
+// Statement is the activity of an account over a period, usually a calendar month.
+type Statement struct {
+	Account        db.Account
+	PeriodStart    time.Time
+	PeriodEnd      time.Time // exclusive, e.g. the start of the next month
+	OpeningBalance int64
+	ClosingBalance int64
+	Lines          []Line
+}
+
+// Line is one entry of the account, with the balance right after it.
+type Line struct {
+	EntryID     int64
+	Date        time.Time
+	Description string
+	Amount      int64
+	Balance     int64
+	// zero for entries made before transfers were recorded on them
+	TransferID int64
+	// the fee of a transfer, rather than the amount
+	Fee bool
+	// the other account of the transfer, zero if unknown
+	CounterpartyAccountID int64
+	CounterpartyOwner     string
+	// description and end to end reference of the transfer from its sender, empty if none
+	Memo      string
+	Reference string
+}
+
+// Period returns the start and the end of the month, in UTC.
+func Period(month time.Time) (start, end time.Time) {
+	start = time.Date(month.Year(), month.Month(), 1, 0, 0, 0, 0, time.UTC)
+	return start, start.AddDate(0, 1, 0)
+}
+
+// Build pulls the opening balance, the entries with their counterparties and the closing balance
+// of the account for the month starting at start.
+func Build(ctx context.Context, store db.Store, account db.Account, start time.Time) (Statement, error) {
+	start, end := Period(start)
+	return BuildPeriod(ctx, store, account, start, end)
+}
+
+// BuildPeriod is Build for any period, from start included to end excluded.
+func BuildPeriod(ctx context.Context, store db.Store, account db.Account, start, end time.Time) (Statement, error) {
+	statement := Statement{
+		Account:     account,
+		PeriodStart: start,
+		PeriodEnd:   end,
+	}
+
+	var err error
+	statement.OpeningBalance, err = store.GetAccountBalanceAt(ctx, db.GetAccountBalanceAtParams{
+		At:        start,
+		AccountID: account.ID,
+	})
+	if err != nil {
+		return statement, fmt.Errorf("opening balance: %w", err)
+	}
+
+	statement.ClosingBalance, err = store.GetAccountBalanceAt(ctx, db.GetAccountBalanceAtParams{
+		At:        end,
+		AccountID: account.ID,
+	})
+	if err != nil {
+		return statement, fmt.Errorf("closing balance: %w", err)
+	}
+
+	entries, err := store.ListStatementEntries(ctx, db.ListStatementEntriesParams{
+		AccountID:   account.ID,
+		PeriodStart: start,
+		PeriodEnd:   end,
+	})
+	if err != nil {
+		return statement, fmt.Errorf("entries: %w", err)
+	}
+
+	for _, entry := range entries {
+		// the description from the sender is about the amount, the fee is paid to the bank
+		memo := entry.Description.String
+		if entry.Fee {
+			memo = ""
+		}
+		statement.Lines = append(statement.Lines, Line{
+			EntryID:               entry.ID,
+			Date:                  entry.CreatedAt,
+			Description:           describe(entry),
+			Amount:                entry.Amount,
+			Balance:               entry.BalanceAfter,
+			TransferID:            entry.TransferID.Int64,
+			Fee:                   entry.Fee,
+			CounterpartyAccountID: entry.CounterpartyAccountID.Int64,
+			CounterpartyOwner:     entry.CounterpartyOwner.String,
+			Memo:                  memo,
+			Reference:             entry.Reference.String,
+		})
+	}
+	return statement, nil
+}
+
+// describe names what an entry is for, and who is on the other side of it,
+// followed by the description of the transfer from its sender if any
+func describe(entry db.ListStatementEntriesRow) string {
+	if !entry.TransferID.Valid {
+		return "Adjustment"
+	}
+
+	description := describeTransfer(entry)
+	if entry.Description.String != "" && !entry.Fee {
+		description += ": " + entry.Description.String
+	}
+	return description
+}
+
+func describeTransfer(entry db.ListStatementEntriesRow) string {
+	counterparty := "unknown account"
+	if entry.CounterpartyAccountID.Valid {
+		counterparty = fmt.Sprintf("account #%d (%s)", entry.CounterpartyAccountID.Int64, entry.CounterpartyOwner.String)
+	}
+
+	switch {
+	case entry.Fee && entry.Amount < 0:
+		return fmt.Sprintf("Fee for transfer #%d to %s", entry.TransferID.Int64, counterparty)
+	case entry.Fee:
+		return fmt.Sprintf("Fee for transfer #%d from %s", entry.TransferID.Int64, counterparty)
+	case entry.Amount < 0:
+		return fmt.Sprintf("Transfer #%d to %s", entry.TransferID.Int64, counterparty)
+	default:
+		return fmt.Sprintf("Transfer #%d from %s", entry.TransferID.Int64, counterparty)
+	}
+}
+
+// Load returns the stored statement of the account for the month starting at start,
+// generating and storing it the first time it is asked for.
+// The caller makes sure the month has ended, so the stored statement never changes.
+func Load(ctx context.Context, store db.Store, account db.Account, start time.Time) (db.Statement, error) {
+	start, _ = Period(start)
+	arg := db.GetStatementParams{
+		AccountID:   account.ID,
+		PeriodStart: start,
+	}
+
+	stored, err := store.GetStatement(ctx, arg)
+	if !errors.Is(err, db.ErrRecordNotFound) {
+		return stored, err
+	}
+
+	statement, err := Build(ctx, store, account, start)
+	if err != nil {
+		return stored, err
+	}
+	csv, err := statement.CSV()
+	if err != nil {
+		return stored, err
+	}
+	pdf, err := statement.PDF()
+	if err != nil {
+		return stored, err
+	}
+
+	stored, err = store.CreateStatement(ctx, db.CreateStatementParams{
+		AccountID:      account.ID,
+		PeriodStart:    statement.PeriodStart,
+		PeriodEnd:      statement.PeriodEnd,
+		OpeningBalance: statement.OpeningBalance,
+		ClosingBalance: statement.ClosingBalance,
+		Csv:            csv,
+		Pdf:            pdf,
+	})
+	if db.ErrorCode(err) == db.UniqueViolation {
+		// stored by a concurrent request in the meantime
+		return store.GetStatement(ctx, arg)
+	}
+	return stored, err
+}
+
+// FormatAmount renders an amount in the minor currency unit as a decimal, e.g. -1234 as -12.34
+func FormatAmount(amount int64) string {
+	sign := ""
+	if amount < 0 {
+		sign = "-"
+		amount = -amount
+	}
+	return fmt.Sprintf("%s%d.%02d", sign, amount/100, amount%100)
+}
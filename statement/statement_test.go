@@ -0,0 +1,178 @@
+package statement
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	mockdb "github.com/XiaozhouCui/go-bank/db/mock"
+	db "github.com/XiaozhouCui/go-bank/db/sqlc"
+	"github.com/golang/mock/gomock"
+	"github.com/jackc/pgx/v5/pgtype"
+	"github.com/stretchr/testify/require"
+)
+
+var (
+	testAccount = db.Account{ID: 7, Owner: "alice", Currency: "USD", Type: "checking"}
+	january     = time.Date(2023, time.January, 1, 0, 0, 0, 0, time.UTC)
+	february    = time.Date(2023, time.February, 1, 0, 0, 0, 0, time.UTC)
+)
+
+// stubStatement expects the queries that build the january statement of the test account
+func stubStatement(store *mockdb.MockStore) {
+	store.EXPECT().
+		GetAccountBalanceAt(gomock.Any(), gomock.Eq(db.GetAccountBalanceAtParams{At: january, AccountID: testAccount.ID})).
+		Times(1).
+		Return(int64(10000), nil)
+	store.EXPECT().
+		GetAccountBalanceAt(gomock.Any(), gomock.Eq(db.GetAccountBalanceAtParams{At: february, AccountID: testAccount.ID})).
+		Times(1).
+		Return(int64(12450), nil)
+
+	day := january.AddDate(0, 0, 9)
+	entries := []db.ListStatementEntriesRow{
+		{
+			ID: 1, Amount: 5000, BalanceAfter: 15000, CreatedAt: day,
+			TransferID:            pgtype.Int8{Int64: 11, Valid: true},
+			CounterpartyAccountID: pgtype.Int8{Int64: 8, Valid: true},
+			CounterpartyOwner:     pgtype.Text{String: "bob", Valid: true},
+		},
+		{
+			ID: 2, Amount: -2500, BalanceAfter: 12500, CreatedAt: day,
+			TransferID:            pgtype.Int8{Int64: 12, Valid: true},
+			CounterpartyAccountID: pgtype.Int8{Int64: 9, Valid: true},
+			CounterpartyOwner:     pgtype.Text{String: "carol", Valid: true},
+			Description:           pgtype.Text{String: "March rent", Valid: true},
+			Reference:             pgtype.Text{String: "INV-42", Valid: true},
+		},
+		{
+			ID: 3, Amount: -50, BalanceAfter: 12450, CreatedAt: day, Fee: true,
+			TransferID:            pgtype.Int8{Int64: 12, Valid: true},
+			CounterpartyAccountID: pgtype.Int8{Int64: 9, Valid: true},
+			CounterpartyOwner:     pgtype.Text{String: "carol", Valid: true},
+			Description:           pgtype.Text{String: "March rent", Valid: true},
+			Reference:             pgtype.Text{String: "INV-42", Valid: true},
+		},
+	}
+	store.EXPECT().
+		ListStatementEntries(gomock.Any(), gomock.Eq(db.ListStatementEntriesParams{
+			AccountID:   testAccount.ID,
+			PeriodStart: january,
+			PeriodEnd:   february,
+		})).
+		Times(1).
+		Return(entries, nil)
+}
+
+func TestBuild(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	store := mockdb.NewMockStore(ctrl)
+	stubStatement(store)
+
+	// any time in the month picks the whole month
+	statement, err := Build(context.Background(), store, testAccount, january.AddDate(0, 0, 14))
+	require.NoError(t, err)
+	require.Equal(t, january, statement.PeriodStart)
+	require.Equal(t, february, statement.PeriodEnd)
+	require.Equal(t, int64(10000), statement.OpeningBalance)
+	require.Equal(t, int64(12450), statement.ClosingBalance)
+
+	require.Len(t, statement.Lines, 3)
+	require.Equal(t, "Transfer #11 from account #8 (bob)", statement.Lines[0].Description)
+	// the description of the sender follows the transfer, but not its fee
+	require.Equal(t, "Transfer #12 to account #9 (carol): March rent", statement.Lines[1].Description)
+	require.Equal(t, "March rent", statement.Lines[1].Memo)
+	require.Equal(t, "INV-42", statement.Lines[1].Reference)
+	require.Empty(t, statement.Lines[2].Memo)
+	require.Equal(t, "Fee for transfer #12 to account #9 (carol)", statement.Lines[2].Description)
+	require.Equal(t, int64(12), statement.Lines[2].TransferID)
+	require.True(t, statement.Lines[2].Fee)
+	require.Equal(t, int64(9), statement.Lines[2].CounterpartyAccountID)
+	require.Equal(t, "carol", statement.Lines[2].CounterpartyOwner)
+
+	csv, err := statement.CSV()
+	require.NoError(t, err)
+	require.Equal(t, strings.Join([]string{
+		"date,entry_id,description,amount,balance",
+		"2023-01-01T00:00:00Z,,Opening balance,,100.00",
+		"2023-01-10T00:00:00Z,1,Transfer #11 from account #8 (bob),50.00,150.00",
+		"2023-01-10T00:00:00Z,2,Transfer #12 to account #9 (carol): March rent,-25.00,125.00",
+		"2023-01-10T00:00:00Z,3,Fee for transfer #12 to account #9 (carol),-0.50,124.50",
+		"2023-02-01T00:00:00Z,,Closing balance,,124.50",
+		"",
+	}, "\n"), string(csv))
+}
+
+func TestLoadStored(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	stored := db.Statement{ID: 1, AccountID: testAccount.ID, PeriodStart: january, Pdf: []byte("%PDF-1.4")}
+
+	store := mockdb.NewMockStore(ctrl)
+	store.EXPECT().
+		GetStatement(gomock.Any(), gomock.Eq(db.GetStatementParams{AccountID: testAccount.ID, PeriodStart: january})).
+		Times(1).
+		Return(stored, nil)
+	store.EXPECT().CreateStatement(gomock.Any(), gomock.Any()).Times(0)
+
+	statement, err := Load(context.Background(), store, testAccount, january)
+	require.NoError(t, err)
+	require.Equal(t, stored, statement)
+}
+
+func TestLoadGenerates(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	store := mockdb.NewMockStore(ctrl)
+	store.EXPECT().
+		GetStatement(gomock.Any(), gomock.Any()).
+		Times(1).
+		Return(db.Statement{}, db.ErrRecordNotFound)
+	stubStatement(store)
+
+	var created db.CreateStatementParams
+	store.EXPECT().
+		CreateStatement(gomock.Any(), gomock.Any()).
+		Times(1).
+		DoAndReturn(func(_ context.Context, arg db.CreateStatementParams) (db.Statement, error) {
+			created = arg
+			return db.Statement{ID: 1, AccountID: arg.AccountID, Csv: arg.Csv, Pdf: arg.Pdf}, nil
+		})
+
+	statement, err := Load(context.Background(), store, testAccount, january)
+	require.NoError(t, err)
+	require.Equal(t, testAccount.ID, created.AccountID)
+	require.Equal(t, january, created.PeriodStart)
+	require.Equal(t, february, created.PeriodEnd)
+	require.Equal(t, int64(10000), created.OpeningBalance)
+	require.Equal(t, int64(12450), created.ClosingBalance)
+	require.Contains(t, string(statement.Csv), "Closing balance")
+	require.True(t, strings.HasPrefix(string(statement.Pdf), "%PDF-1.4"))
+}
+
+func TestLoadConcurrent(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	stored := db.Statement{ID: 1, AccountID: testAccount.ID, PeriodStart: january}
+
+	store := mockdb.NewMockStore(ctrl)
+	gomock.InOrder(
+		store.EXPECT().GetStatement(gomock.Any(), gomock.Any()).Times(1).Return(db.Statement{}, db.ErrRecordNotFound),
+		store.EXPECT().GetStatement(gomock.Any(), gomock.Any()).Times(1).Return(stored, nil),
+	)
+	stubStatement(store)
+	store.EXPECT().
+		CreateStatement(gomock.Any(), gomock.Any()).
+		Times(1).
+		Return(db.Statement{}, db.ErrUniqueViolation)
+
+	statement, err := Load(context.Background(), store, testAccount, january)
+	require.NoError(t, err)
+	require.Equal(t, stored, statement)
+}
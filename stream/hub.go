@@ -0,0 +1,83 @@
+// Package stream pushes the new entries of accounts to the clients following them.
+//
+// Every entry written to the db is notified on the account_entries channel by a trigger, when its
+// transaction commits, so the entries made through any server instance reach the clients of all of them.
+// The listener of each instance receives these notifications and publishes them to its hub, which hands
+// them to the subscribers of the account.
+package stream
+
+import (
+	"sync"
+
+	db "github.com/XiaozhouCui/go-bank/db/sqlc"
+)
+
+// entries buffered for each subscriber
+const subscriberBuffer = 64
+
+// Hub hands the published entries to the subscribers of their account.
+type Hub struct {
+	mu          sync.Mutex
+	subscribers map[int64]map[chan db.Entry]struct{}
+}
+
+// NewHub creates a hub without subscribers.
+func NewHub() *Hub {
+	return &Hub{
+		subscribers: make(map[int64]map[chan db.Entry]struct{}),
+	}
+}
+
+// Subscribe returns the channel receiving the entries of an account published from now on,
+// and the function to call once done with it.
+// The channel is closed if the subscriber falls too far behind, it must subscribe again and reload the account.
+func (hub *Hub) Subscribe(accountID int64) (<-chan db.Entry, func()) {
+	entries := make(chan db.Entry, subscriberBuffer)
+
+	hub.mu.Lock()
+	defer hub.mu.Unlock()
+	if hub.subscribers[accountID] == nil {
+		hub.subscribers[accountID] = make(map[chan db.Entry]struct{})
+	}
+	hub.subscribers[accountID][entries] = struct{}{}
+
+	return entries, func() {
+		hub.mu.Lock()
+		defer hub.mu.Unlock()
+		hub.remove(accountID, entries)
+	}
+}
+
+// Subscribers returns the number of subscribers of an account.
+func (hub *Hub) Subscribers(accountID int64) int {
+	hub.mu.Lock()
+	defer hub.mu.Unlock()
+	return len(hub.subscribers[accountID])
+}
+
+// Publish hands an entry to the subscribers of its account, without waiting for them.
+func (hub *Hub) Publish(entry db.Entry) {
+	hub.mu.Lock()
+	defer hub.mu.Unlock()
+
+	for entries := range hub.subscribers[entry.AccountID] {
+		select {
+		case entries <- entry:
+		default:
+			// a subscriber missing an entry would show a wrong balance, it is better to drop it
+			hub.remove(entry.AccountID, entries)
+		}
+	}
+}
+
+// remove closes the channel of a subscriber, if it is still subscribed. The caller holds the lock.
+func (hub *Hub) remove(accountID int64, entries chan db.Entry) {
+	if _, ok := hub.subscribers[accountID][entries]; !ok {
+		return
+	}
+	delete(hub.subscribers[accountID], entries)
+	if len(hub.subscribers[accountID]) == 0 {
+		delete(hub.subscribers, accountID)
+	}
+	close(entries)
+}
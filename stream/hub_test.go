@@ -0,0 +1,47 @@
+package stream
+
+import (
+	"testing"
+
+	db "github.com/XiaozhouCui/go-bank/db/sqlc"
+	"github.com/stretchr/testify/require"
+)
+
+func TestHub(t *testing.T) {
+	hub := NewHub()
+	entries1, unsubscribe1 := hub.Subscribe(1)
+	entries2, unsubscribe2 := hub.Subscribe(2)
+	defer unsubscribe2()
+
+	hub.Publish(db.Entry{ID: 10, AccountID: 1, Amount: 5})
+	hub.Publish(db.Entry{ID: 11, AccountID: 2, Amount: -5})
+
+	require.Equal(t, int64(10), (<-entries1).ID)
+	require.Equal(t, int64(11), (<-entries2).ID)
+	require.Empty(t, entries1)
+
+	// an unsubscribed channel is closed and receives nothing more
+	unsubscribe1()
+	hub.Publish(db.Entry{ID: 12, AccountID: 1})
+	_, ok := <-entries1
+	require.False(t, ok)
+	// unsubscribing twice is harmless
+	unsubscribe1()
+}
+
+func TestHubDropsSlowSubscribers(t *testing.T) {
+	hub := NewHub()
+	slow, unsubscribe := hub.Subscribe(1)
+	defer unsubscribe()
+
+	for i := 0; i <= subscriberBuffer; i++ {
+		hub.Publish(db.Entry{ID: int64(i), AccountID: 1})
+	}
+
+	// the buffered entries are still received, then the channel is closed
+	received := 0
+	for range slow {
+		received++
+	}
+	require.Equal(t, subscriberBuffer, received)
+}
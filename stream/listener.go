@@ -0,0 +1,85 @@
+package stream
+
+import (
+	"context"
+	"encoding/json"
+	"log"
+	"time"
+
+	db "github.com/XiaozhouCui/go-bank/db/sqlc"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// Channel is the notification channel of the new entries, see migration add_entry_notifications.
+const Channel = "account_entries"
+
+// wait before listening again after the connection failed
+const reconnectDelay = time.Second
+
+// Listener receives the notifications of new entries from postgres and publishes them to a hub.
+type Listener struct {
+	connPool *pgxpool.Pool
+	hub      *Hub
+}
+
+// NewListener creates a new listener. It takes a connection of the pool for itself while it runs.
+func NewListener(connPool *pgxpool.Pool, hub *Hub) *Listener {
+	return &Listener{
+		connPool: connPool,
+		hub:      hub,
+	}
+}
+
+// Run listens to the notifications until the context is done, and reconnects if the connection fails.
+// The entries notified while it is disconnected are not published.
+func (listener *Listener) Run(ctx context.Context) {
+	for {
+		err := listener.listen(ctx)
+		if ctx.Err() != nil {
+			return
+		}
+		log.Println("cannot listen to entry notifications:", err)
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(reconnectDelay):
+		}
+	}
+}
+
+// listen takes a connection out of the pool, listens on it and publishes the notifications until it fails.
+func (listener *Listener) listen(ctx context.Context) error {
+	pooled, err := listener.connPool.Acquire(ctx)
+	if err != nil {
+		return err
+	}
+	// the connection keeps listening, it must not go back to the pool
+	conn := pooled.Hijack()
+	defer conn.Close(context.Background())
+
+	if _, err := conn.Exec(ctx, "LISTEN "+Channel); err != nil {
+		return err
+	}
+
+	for {
+		notification, err := conn.WaitForNotification(ctx)
+		if err != nil {
+			return err
+		}
+
+		entry, err := ParseNotification(notification.Payload)
+		if err != nil {
+			log.Printf("cannot parse entry notification %q: %v", notification.Payload, err)
+			continue
+		}
+		listener.hub.Publish(entry)
+	}
+}
+
+// ParseNotification reads the entry of a notification on Channel.
+func ParseNotification(payload string) (db.Entry, error) {
+	var entry db.Entry
+	err := json.Unmarshal([]byte(payload), &entry)
+	return entry, err
+}
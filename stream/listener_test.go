@@ -0,0 +1,30 @@
+package stream
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseNotification(t *testing.T) {
+	// as built by json_build_object in the notify_entry trigger
+	payload := `{"id" : 7, "account_id" : 3, "amount" : -250, "created_at" : "2023-01-31T09:00:00.123456+00:00", "transfer_id" : 4, "balance_after" : 750}`
+
+	entry, err := ParseNotification(payload)
+	require.NoError(t, err)
+	require.Equal(t, int64(7), entry.ID)
+	require.Equal(t, int64(3), entry.AccountID)
+	require.Equal(t, int64(-250), entry.Amount)
+	require.Equal(t, int64(750), entry.BalanceAfter)
+	require.True(t, entry.TransferID.Valid)
+	require.Equal(t, int64(4), entry.TransferID.Int64)
+	require.True(t, time.Date(2023, time.January, 31, 9, 0, 0, 123456000, time.UTC).Equal(entry.CreatedAt))
+
+	entry, err = ParseNotification(`{"id" : 8, "account_id" : 3, "amount" : 1, "created_at" : "2023-01-31T09:00:00+00:00", "transfer_id" : null, "balance_after" : 1}`)
+	require.NoError(t, err)
+	require.False(t, entry.TransferID.Valid)
+
+	_, err = ParseNotification("not json")
+	require.Error(t, err)
+}
@@ -29,9 +29,9 @@ func NewPasetoMaker(symmetricKey string) (Maker, error) {
 }
 
 // use receiver to append methods to PasetoMaker, to satisfy Maker interface
-// CreateToken creates a new token for a specific username and duration
-func (maker *PasetoMaker) CreateToken(username string, duration time.Duration) (string, error) {
-	payload, err := NewPayload(username, duration)
+// CreateToken creates a new token for a specific username, role and duration
+func (maker *PasetoMaker) CreateToken(username string, role string, duration time.Duration) (string, error) {
+	payload, err := NewPayload(username, role, duration)
 	if err != nil {
 		return "", err
 	}
@@ -0,0 +1,89 @@
+package webhook
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"strings"
+	"syscall"
+)
+
+// ErrAddressNotPublic is returned when the URL of a subscription resolves to an address of a private network,
+// so customers cannot make the deliverer post to the services it can reach inside ours.
+var ErrAddressNotPublic = errors.New("webhook URL does not resolve to a public address")
+
+// ranges that are not routed on the internet, on top of the loopback, private, link-local and multicast ones
+var reservedNetworks = parseNetworks(
+	"0.0.0.0/8",       // this network
+	"100.64.0.0/10",   // carrier-grade NAT
+	"192.0.0.0/24",    // IETF protocol assignments
+	"192.0.2.0/24",    // documentation
+	"192.88.99.0/24",  // 6to4 relay anycast
+	"198.18.0.0/15",   // benchmarking
+	"198.51.100.0/24", // documentation
+	"203.0.113.0/24",  // documentation
+	"240.0.0.0/4",     // reserved
+	"64:ff9b::/96",    // NAT64, it reaches IPv4 addresses
+	"64:ff9b:1::/48",  // local NAT64
+	"100::/64",        // discard
+	"2001::/32",       // Teredo, it embeds an IPv4 address
+	"2001:db8::/32",   // documentation
+	"2002::/16",       // 6to4, it embeds an IPv4 address
+	"fec0::/10",       // deprecated site-local
+)
+
+func parseNetworks(cidrs ...string) []*net.IPNet {
+	networks := make([]*net.IPNet, len(cidrs))
+	for i, cidr := range cidrs {
+		_, network, err := net.ParseCIDR(cidr)
+		if err != nil {
+			panic(err)
+		}
+		networks[i] = network
+	}
+	return networks
+}
+
+// IsPublicIP returns true if ip is a unicast address routed on the internet.
+// Loopback, private (RFC 1918 and fc00::/7), link-local (which holds the 169.254.169.254 metadata service)
+// and other reserved addresses are not.
+func IsPublicIP(ip net.IP) bool {
+	if ip4 := ip.To4(); ip4 != nil {
+		ip = ip4
+	}
+	if !ip.IsGlobalUnicast() || ip.IsPrivate() {
+		return false
+	}
+	for _, network := range reservedNetworks {
+		if network.Contains(ip) {
+			return false
+		}
+	}
+	return true
+}
+
+// isPublicHost returns false if host is obviously not public: a local name, or an address that is not public.
+// Other names are only checked once they are resolved, when a delivery is posted.
+func isPublicHost(host string) bool {
+	host = strings.ToLower(strings.TrimSuffix(host, "."))
+	if host == "localhost" || strings.HasSuffix(host, ".localhost") {
+		return false
+	}
+	if ip := net.ParseIP(host); ip != nil {
+		return IsPublicIP(ip)
+	}
+	return true
+}
+
+// dialPublicOnly is the Control of the dialer of the deliverer. It runs after the name of the host is resolved,
+// right before connecting, so a name cannot resolve to a public address when checked then to a private one.
+func dialPublicOnly(network, address string, c syscall.RawConn) error {
+	host, _, err := net.SplitHostPort(address)
+	if err != nil {
+		return err
+	}
+	if ip := net.ParseIP(host); ip == nil || !IsPublicIP(ip) {
+		return fmt.Errorf("%s: %w", address, ErrAddressNotPublic)
+	}
+	return nil
+}
@@ -0,0 +1,231 @@
+package webhook
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net"
+	"net/http"
+	"strconv"
+	"time"
+
+	db "github.com/XiaozhouCui/go-bank/db/sqlc"
+)
+
+// errEndpointUnreachable is recorded when a delivery could not be posted, whatever the reason.
+var errEndpointUnreachable = errors.New("endpoint could not be reached")
+
+// number of deliveries loaded from the db at a time
+const batchSize = 100
+
+const (
+	// a claimed delivery is not attempted by another deliverer before this, it covers the request timeout
+	leaseDuration = time.Minute
+	// longest wait between two attempts
+	maxRetryDelay = 24 * time.Hour
+)
+
+// Deliverer posts the pending webhook deliveries to the URLs of their subscriptions.
+type Deliverer struct {
+	store       db.Store
+	client      *http.Client
+	dialer      *net.Dialer
+	maxAttempts int64
+	backoff     time.Duration
+}
+
+// NewDeliverer creates a new deliverer. A failed delivery is tried again after backoff, then twice as long
+// after each failure, until it has been attempted maxAttempts times and is dead.
+// Deliveries are only posted to public addresses, see IsPublicIP.
+func NewDeliverer(store db.Store, maxAttempts int64, backoff time.Duration) *Deliverer {
+	dialer := &net.Dialer{
+		Timeout: 5 * time.Second,
+		Control: dialPublicOnly,
+	}
+	return &Deliverer{
+		store: store,
+		client: &http.Client{
+			Timeout: 10 * time.Second,
+			// no proxy: the address connected to must be the one checked by the dialer
+			Transport: &http.Transport{
+				DialContext:         dialer.DialContext,
+				TLSHandshakeTimeout: 5 * time.Second,
+				MaxIdleConnsPerHost: 2,
+				IdleConnTimeout:     90 * time.Second,
+			},
+			// a redirect is answered like any other non 2xx status, the request is not sent anywhere else
+			CheckRedirect: func(req *http.Request, via []*http.Request) error {
+				return http.ErrUseLastResponse
+			},
+		},
+		dialer:      dialer,
+		maxAttempts: maxAttempts,
+		backoff:     backoff,
+	}
+}
+
+// Run delivers the due webhooks right away, then again at every interval, until the context is done.
+// Each attempt is made by a single deliverer, even if several servers run one.
+func (deliverer *Deliverer) Run(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		if err := deliverer.DeliverDue(ctx, time.Now()); err != nil {
+			log.Println("cannot deliver webhooks:", err)
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// DeliverDue attempts every pending delivery of an active subscription whose next attempt is at or before now.
+// Every attempt is logged, whatever its outcome.
+func (deliverer *Deliverer) DeliverDue(ctx context.Context, now time.Time) error {
+	failed := 0
+	subscriptions := make(map[int64]db.WebhookSubscription)
+
+	var afterID int64
+	for {
+		due, err := deliverer.store.ListDueWebhookDeliveries(ctx, db.ListDueWebhookDeliveriesParams{
+			Now:     now,
+			AfterID: afterID,
+			Limit:   batchSize,
+		})
+		if err != nil {
+			return err
+		}
+
+		for _, delivery := range due {
+			if err := deliverer.deliver(ctx, delivery, subscriptions, now); err != nil {
+				log.Printf("cannot record the attempt of webhook delivery [%d]: %v", delivery.ID, err)
+				failed++
+			}
+			afterID = delivery.ID
+		}
+
+		if len(due) < batchSize {
+			break
+		}
+	}
+
+	if failed > 0 {
+		return fmt.Errorf("recording failed for %d webhook deliveries", failed)
+	}
+	return nil
+}
+
+// deliver claims a delivery, posts it and records the attempt.
+// subscriptions caches the subscriptions already loaded by this run.
+func (deliverer *Deliverer) deliver(
+	ctx context.Context,
+	delivery db.WebhookDelivery,
+	subscriptions map[int64]db.WebhookSubscription,
+	now time.Time,
+) error {
+	claimed, err := deliverer.store.ClaimWebhookDelivery(ctx, db.ClaimWebhookDeliveryParams{
+		LeaseUntil: now.Add(leaseDuration),
+		ID:         delivery.ID,
+		Now:        now,
+	})
+	// already claimed by another deliverer
+	if errors.Is(err, db.ErrRecordNotFound) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	subscription, ok := subscriptions[claimed.SubscriptionID]
+	if !ok {
+		subscription, err = deliverer.store.GetWebhookSubscription(ctx, claimed.SubscriptionID)
+		if err != nil {
+			return err
+		}
+		subscriptions[subscription.ID] = subscription
+	}
+
+	start := time.Now()
+	statusCode, err := deliverer.send(ctx, subscription, claimed)
+	arg := db.RecordWebhookAttemptTxParams{
+		Delivery:      claimed,
+		StatusCode:    statusCode,
+		Duration:      time.Since(start),
+		Status:        db.WebhookDeliverySucceeded,
+		NextAttemptAt: claimed.NextAttemptAt,
+	}
+	if err != nil {
+		arg.Error = err.Error()
+		arg.Status = db.WebhookDeliveryPending
+		arg.NextAttemptAt = now.Add(retryDelay(deliverer.backoff, claimed.Attempts+1))
+		if claimed.Attempts+1 >= deliverer.maxAttempts {
+			arg.Status = db.WebhookDeliveryDead
+		}
+	}
+
+	_, err = deliverer.store.RecordWebhookAttemptTx(ctx, arg)
+	return err
+}
+
+// send posts a delivery to the URL of its subscription, signed with its secret.
+// It returns the status code of the response, 0 if there was none, and an error unless it is 2xx.
+func (deliverer *Deliverer) send(ctx context.Context, subscription db.WebhookSubscription, delivery db.WebhookDelivery) (int64, error) {
+	body, err := json.Marshal(Event{
+		ID:        delivery.ID,
+		Type:      delivery.EventType,
+		CreatedAt: delivery.CreatedAt,
+		Data:      delivery.Payload,
+	})
+	if err != nil {
+		return 0, err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, subscription.Url, bytes.NewReader(body))
+	if err != nil {
+		return 0, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set(HeaderID, strconv.FormatInt(delivery.ID, 10))
+	req.Header.Set(HeaderEvent, delivery.EventType)
+	req.Header.Set(HeaderSignature, Sign(subscription.Secret, time.Now(), body))
+
+	rsp, err := deliverer.client.Do(req)
+	if err != nil {
+		// the error is shown to the customer: only say why if the address is not public,
+		// the details of the network would tell them what our servers can reach
+		log.Printf("cannot post webhook delivery [%d]: %v", delivery.ID, err)
+		if errors.Is(err, ErrAddressNotPublic) {
+			return 0, ErrAddressNotPublic
+		}
+		return 0, errEndpointUnreachable
+	}
+	defer rsp.Body.Close()
+	// drain the body so the connection can be reused
+	io.Copy(io.Discard, io.LimitReader(rsp.Body, 64<<10))
+
+	if rsp.StatusCode < 200 || rsp.StatusCode >= 300 {
+		return int64(rsp.StatusCode), fmt.Errorf("endpoint answered %s", rsp.Status)
+	}
+	return int64(rsp.StatusCode), nil
+}
+
+// retryDelay is how long to wait after the given number of failed attempts:
+// backoff after the first one, doubling after each of the next ones, up to maxRetryDelay.
+func retryDelay(backoff time.Duration, attempts int64) time.Duration {
+	delay := backoff
+	for i := int64(1); i < attempts && delay < maxRetryDelay; i++ {
+		delay *= 2
+	}
+	if delay > maxRetryDelay {
+		return maxRetryDelay
+	}
+	return delay
+}
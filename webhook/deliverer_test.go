@@ -0,0 +1,216 @@
+package webhook_test
+
+import (
+	"context"
+	"net/http"
+	"testing"
+	"time"
+
+	mockdb "github.com/XiaozhouCui/go-bank/db/mock"
+	db "github.com/XiaozhouCui/go-bank/db/sqlc"
+	"github.com/XiaozhouCui/go-bank/webhook"
+	"github.com/XiaozhouCui/go-bank/webhook/webhooktest"
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/require"
+)
+
+const (
+	maxAttempts = 3
+	backoff     = 30 * time.Second
+)
+
+// deliverOnce runs the deliverer against a single due delivery of subscription,
+// and returns the attempt it recorded. The deliverer posts to local addresses unless publicOnly.
+func deliverOnce(
+	t *testing.T,
+	subscription db.WebhookSubscription,
+	delivery db.WebhookDelivery,
+	now time.Time,
+	publicOnly bool,
+) db.RecordWebhookAttemptTxParams {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	store := mockdb.NewMockStore(ctrl)
+	store.EXPECT().ListDueWebhookDeliveries(gomock.Any(), gomock.Any()).Times(1).Return([]db.WebhookDelivery{delivery}, nil)
+	store.EXPECT().ClaimWebhookDelivery(gomock.Any(), gomock.Eq(db.ClaimWebhookDeliveryParams{
+		LeaseUntil: now.Add(time.Minute),
+		ID:         delivery.ID,
+		Now:        now,
+	})).Times(1).Return(delivery, nil)
+	store.EXPECT().GetWebhookSubscription(gomock.Any(), gomock.Eq(subscription.ID)).Times(1).Return(subscription, nil)
+
+	var recorded db.RecordWebhookAttemptTxParams
+	store.EXPECT().
+		RecordWebhookAttemptTx(gomock.Any(), gomock.Any()).
+		Times(1).
+		DoAndReturn(func(ctx context.Context, arg db.RecordWebhookAttemptTxParams) (db.WebhookDelivery, error) {
+			recorded = arg
+			return db.WebhookDelivery{}, nil
+		})
+
+	deliverer := webhook.NewDeliverer(store, maxAttempts, backoff)
+	if !publicOnly {
+		deliverer.AllowLocalAddresses()
+	}
+	require.NoError(t, deliverer.DeliverDue(context.Background(), now))
+	return recorded
+}
+
+func TestDeliverDue(t *testing.T) {
+	receiver := webhooktest.NewReceiver("secret")
+	defer receiver.Close()
+
+	subscription := db.WebhookSubscription{
+		ID:         1,
+		Url:        receiver.URL,
+		EventTypes: []string{webhook.EventTransferReceived},
+		Secret:     "secret",
+		Active:     true,
+	}
+	now := time.Now()
+	delivery := db.WebhookDelivery{
+		ID:             7,
+		SubscriptionID: subscription.ID,
+		EventID:        3,
+		EventType:      webhook.EventTransferReceived,
+		Payload:        []byte(`{"transfer_id":5}`),
+		Status:         db.WebhookDeliveryPending,
+		NextAttemptAt:  now,
+		CreatedAt:      now.Add(-time.Minute),
+	}
+
+	testCases := []struct {
+		name          string
+		publicOnly    bool
+		setup         func(subscription *db.WebhookSubscription, delivery *db.WebhookDelivery)
+		checkAttempt  func(t *testing.T, attempt db.RecordWebhookAttemptTxParams)
+		checkReceived func(t *testing.T, received []webhook.Event)
+	}{
+		{
+			name: "OK",
+			setup: func(subscription *db.WebhookSubscription, delivery *db.WebhookDelivery) {
+				receiver.Respond(http.StatusOK)
+			},
+			checkAttempt: func(t *testing.T, attempt db.RecordWebhookAttemptTxParams) {
+				require.Equal(t, db.WebhookDeliverySucceeded, attempt.Status)
+				require.Equal(t, int64(http.StatusOK), attempt.StatusCode)
+				require.Empty(t, attempt.Error)
+			},
+			checkReceived: func(t *testing.T, received []webhook.Event) {
+				require.Len(t, received, 1)
+				require.Equal(t, int64(7), received[0].ID)
+				require.Equal(t, webhook.EventTransferReceived, received[0].Type)
+				require.JSONEq(t, `{"transfer_id":5}`, string(received[0].Data))
+			},
+		},
+		{
+			name: "Retry",
+			setup: func(subscription *db.WebhookSubscription, delivery *db.WebhookDelivery) {
+				delivery.Attempts = 1
+				receiver.Respond(http.StatusServiceUnavailable)
+			},
+			checkAttempt: func(t *testing.T, attempt db.RecordWebhookAttemptTxParams) {
+				require.Equal(t, db.WebhookDeliveryPending, attempt.Status)
+				require.Equal(t, int64(http.StatusServiceUnavailable), attempt.StatusCode)
+				require.Contains(t, attempt.Error, "503")
+				// the second failure waits twice as long as the first one
+				require.Equal(t, now.Add(2*backoff), attempt.NextAttemptAt)
+			},
+			checkReceived: func(t *testing.T, received []webhook.Event) {
+				require.Empty(t, received)
+			},
+		},
+		{
+			name: "Dead",
+			setup: func(subscription *db.WebhookSubscription, delivery *db.WebhookDelivery) {
+				delivery.Attempts = maxAttempts - 1
+				receiver.Respond(http.StatusInternalServerError)
+			},
+			checkAttempt: func(t *testing.T, attempt db.RecordWebhookAttemptTxParams) {
+				require.Equal(t, db.WebhookDeliveryDead, attempt.Status)
+				require.Equal(t, int64(maxAttempts-1), attempt.Delivery.Attempts)
+			},
+			checkReceived: func(t *testing.T, received []webhook.Event) {
+				require.Empty(t, received)
+			},
+		},
+		{
+			name: "WrongSecret",
+			setup: func(subscription *db.WebhookSubscription, delivery *db.WebhookDelivery) {
+				subscription.Secret = "other secret"
+			},
+			checkAttempt: func(t *testing.T, attempt db.RecordWebhookAttemptTxParams) {
+				require.Equal(t, db.WebhookDeliveryPending, attempt.Status)
+				require.Equal(t, int64(http.StatusUnauthorized), attempt.StatusCode)
+				require.Equal(t, 1, receiver.Rejected())
+			},
+			checkReceived: func(t *testing.T, received []webhook.Event) {
+				require.Empty(t, received)
+			},
+		},
+		{
+			name: "NoAnswer",
+			setup: func(subscription *db.WebhookSubscription, delivery *db.WebhookDelivery) {
+				subscription.Url = "http://127.0.0.1:1/webhook"
+			},
+			checkAttempt: func(t *testing.T, attempt db.RecordWebhookAttemptTxParams) {
+				require.Equal(t, db.WebhookDeliveryPending, attempt.Status)
+				require.Zero(t, attempt.StatusCode)
+				// the details of the network are not recorded
+				require.Equal(t, "endpoint could not be reached", attempt.Error)
+				require.Equal(t, now.Add(backoff), attempt.NextAttemptAt)
+			},
+			checkReceived: func(t *testing.T, received []webhook.Event) {
+				require.Empty(t, received)
+			},
+		},
+		{
+			name:       "LocalAddress",
+			publicOnly: true,
+			setup: func(subscription *db.WebhookSubscription, delivery *db.WebhookDelivery) {
+				receiver.Respond(http.StatusOK)
+			},
+			checkAttempt: func(t *testing.T, attempt db.RecordWebhookAttemptTxParams) {
+				require.Equal(t, db.WebhookDeliveryPending, attempt.Status)
+				require.Zero(t, attempt.StatusCode)
+				require.Equal(t, webhook.ErrAddressNotPublic.Error(), attempt.Error)
+			},
+			checkReceived: func(t *testing.T, received []webhook.Event) {
+				require.Empty(t, received)
+			},
+		},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+
+		t.Run(tc.name, func(t *testing.T) {
+			before := len(receiver.Received())
+			subscription, delivery := subscription, delivery
+			tc.setup(&subscription, &delivery)
+
+			attempt := deliverOnce(t, subscription, delivery, now, tc.publicOnly)
+			require.Equal(t, delivery.ID, attempt.Delivery.ID)
+			tc.checkAttempt(t, attempt)
+			tc.checkReceived(t, receiver.Received()[before:])
+		})
+	}
+}
+
+func TestDeliverDueClaimedElsewhere(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	store := mockdb.NewMockStore(ctrl)
+	store.EXPECT().
+		ListDueWebhookDeliveries(gomock.Any(), gomock.Any()).
+		Times(1).
+		Return([]db.WebhookDelivery{{ID: 7, SubscriptionID: 1}}, nil)
+	store.EXPECT().ClaimWebhookDelivery(gomock.Any(), gomock.Any()).Times(1).Return(db.WebhookDelivery{}, db.ErrRecordNotFound)
+	store.EXPECT().GetWebhookSubscription(gomock.Any(), gomock.Any()).Times(0)
+	store.EXPECT().RecordWebhookAttemptTx(gomock.Any(), gomock.Any()).Times(0)
+
+	deliverer := webhook.NewDeliverer(store, maxAttempts, backoff)
+	require.NoError(t, deliverer.DeliverDue(context.Background(), time.Now()))
+}
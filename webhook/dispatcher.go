@@ -0,0 +1,106 @@
+package webhook
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+
+	db "github.com/XiaozhouCui/go-bank/db/sqlc"
+	"github.com/XiaozhouCui/go-bank/events"
+)
+
+// Dispatcher is an outbox sink creating the webhook deliveries of the events relayed to it.
+type Dispatcher struct {
+	store db.Store
+}
+
+// NewDispatcher creates a new dispatcher.
+func NewDispatcher(store db.Store) *Dispatcher {
+	return &Dispatcher{
+		store: store,
+	}
+}
+
+// Publish creates a delivery for every active subscription to a customer event made from the event.
+// A completed transfer is TransferSent for the owner of the account debited and
+// TransferReceived for the owner of the account credited. Other events are ignored.
+// Deliveries are unique per subscription, event and type, so publishing an event again is harmless.
+func (dispatcher *Dispatcher) Publish(ctx context.Context, event events.Event) error {
+	if event.Type != events.TransferCompleted {
+		return nil
+	}
+	if event.SchemaVersion != 1 {
+		return fmt.Errorf("unsupported %s schema version %d", event.Type, event.SchemaVersion)
+	}
+
+	var transfer events.TransferCompletedV1
+	if err := json.Unmarshal(event.Data, &transfer); err != nil {
+		return err
+	}
+
+	err := dispatcher.notify(ctx, event.ID, transfer.ToAccountID, EventTransferReceived, TransferReceived{
+		TransferID:    transfer.TransferID,
+		AccountID:     transfer.ToAccountID,
+		FromAccountID: transfer.FromAccountID,
+		Amount:        transfer.Amount,
+		Currency:      transfer.Currency,
+		ReceivedAt:    transfer.CompletedAt,
+	})
+	if err != nil {
+		return err
+	}
+
+	return dispatcher.notify(ctx, event.ID, transfer.FromAccountID, EventTransferSent, TransferSent{
+		TransferID:  transfer.TransferID,
+		AccountID:   transfer.FromAccountID,
+		ToAccountID: transfer.ToAccountID,
+		Amount:      transfer.Amount,
+		Fee:         transfer.Fee,
+		Currency:    transfer.Currency,
+		SentAt:      transfer.CompletedAt,
+	})
+}
+
+// notify creates a delivery of data for each subscription of the account owner to eventType.
+func (dispatcher *Dispatcher) notify(ctx context.Context, eventID, accountID int64, eventType string, data interface{}) error {
+	account, err := dispatcher.store.GetAccount(ctx, accountID)
+	if err != nil {
+		return fmt.Errorf("cannot get account [%d]: %w", accountID, err)
+	}
+
+	subscriptions, err := dispatcher.store.ListActiveWebhookSubscriptions(ctx, account.Owner)
+	if err != nil {
+		return err
+	}
+
+	payload, err := json.Marshal(data)
+	if err != nil {
+		return err
+	}
+
+	for _, subscription := range subscriptions {
+		if !Subscribed(subscription, eventType) {
+			continue
+		}
+		err := dispatcher.store.CreateWebhookDelivery(ctx, db.CreateWebhookDeliveryParams{
+			SubscriptionID: subscription.ID,
+			EventID:        eventID,
+			EventType:      eventType,
+			Payload:        payload,
+		})
+		if err != nil {
+			return fmt.Errorf("cannot create delivery for subscription [%d]: %w", subscription.ID, err)
+		}
+	}
+	return nil
+}
+
+// Subscribed reports whether the subscription is to eventType.
+func Subscribed(subscription db.WebhookSubscription, eventType string) bool {
+	for _, subscribed := range subscription.EventTypes {
+		if subscribed == eventType {
+			return true
+		}
+	}
+	return false
+}
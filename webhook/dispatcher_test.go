@@ -0,0 +1,94 @@
+package webhook
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+	"time"
+
+	mockdb "github.com/XiaozhouCui/go-bank/db/mock"
+	db "github.com/XiaozhouCui/go-bank/db/sqlc"
+	"github.com/XiaozhouCui/go-bank/events"
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/require"
+)
+
+func transferEvent(t *testing.T, transfer events.TransferCompletedV1) events.Event {
+	data, err := json.Marshal(transfer)
+	require.NoError(t, err)
+	return events.Event{
+		ID:            9,
+		Type:          events.TransferCompleted,
+		SchemaVersion: 1,
+		OccurredAt:    transfer.CompletedAt,
+		Data:          data,
+	}
+}
+
+func TestDispatcherPublish(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	transfer := events.TransferCompletedV1{
+		TransferID:    5,
+		FromAccountID: 1,
+		ToAccountID:   2,
+		Amount:        100,
+		Fee:           3,
+		Currency:      "USD",
+		CompletedAt:   time.Date(2023, time.March, 1, 12, 0, 0, 0, time.UTC),
+	}
+
+	store := mockdb.NewMockStore(ctrl)
+	store.EXPECT().GetAccount(gomock.Any(), gomock.Eq(int64(2))).Times(1).Return(db.Account{ID: 2, Owner: "bob"}, nil)
+	store.EXPECT().
+		ListActiveWebhookSubscriptions(gomock.Any(), gomock.Eq("bob")).
+		Times(1).
+		Return([]db.WebhookSubscription{
+			{ID: 10, EventTypes: []string{EventTransferReceived}},
+			{ID: 11, EventTypes: []string{EventTransferSent}},
+		}, nil)
+	store.EXPECT().GetAccount(gomock.Any(), gomock.Eq(int64(1))).Times(1).Return(db.Account{ID: 1, Owner: "alice"}, nil)
+	store.EXPECT().
+		ListActiveWebhookSubscriptions(gomock.Any(), gomock.Eq("alice")).
+		Times(1).
+		Return([]db.WebhookSubscription{{ID: 12, EventTypes: []string{EventTransferReceived, EventTransferSent}}}, nil)
+
+	deliveries := make(map[int64]db.CreateWebhookDeliveryParams)
+	store.EXPECT().
+		CreateWebhookDelivery(gomock.Any(), gomock.Any()).
+		Times(2).
+		DoAndReturn(func(ctx context.Context, arg db.CreateWebhookDeliveryParams) error {
+			deliveries[arg.SubscriptionID] = arg
+			return nil
+		})
+
+	err := NewDispatcher(store).Publish(context.Background(), transferEvent(t, transfer))
+	require.NoError(t, err)
+	require.Len(t, deliveries, 2)
+
+	received := deliveries[10]
+	require.Equal(t, int64(9), received.EventID)
+	require.Equal(t, EventTransferReceived, received.EventType)
+	require.JSONEq(t, `{"transfer_id":5,"account_id":2,"from_account_id":1,"amount":100,"currency":"USD","received_at":"2023-03-01T12:00:00Z"}`, string(received.Payload))
+
+	sent := deliveries[12]
+	require.Equal(t, EventTransferSent, sent.EventType)
+	require.JSONEq(t, `{"transfer_id":5,"account_id":1,"to_account_id":2,"amount":100,"fee":3,"currency":"USD","sent_at":"2023-03-01T12:00:00Z"}`, string(sent.Payload))
+}
+
+func TestDispatcherIgnoresOtherEvents(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	store := mockdb.NewMockStore(ctrl)
+	store.EXPECT().GetAccount(gomock.Any(), gomock.Any()).Times(0)
+
+	err := NewDispatcher(store).Publish(context.Background(), events.Event{
+		ID:            1,
+		Type:          events.AccountCreated,
+		SchemaVersion: 1,
+		Data:          json.RawMessage(`{"account_id":1}`),
+	})
+	require.NoError(t, err)
+}
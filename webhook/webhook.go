@@ -0,0 +1,137 @@
+// Package webhook notifies customers of the events of their accounts by posting them to the URLs they registered.
+//
+// The dispatcher turns the events relayed from the outbox into one delivery per matching subscription,
+// and the deliverer posts the pending deliveries, retrying with exponential backoff until the endpoint
+// accepts them or they fail too many times and are dead. Each request is signed with the secret of its
+// subscription, see Sign and Verify.
+package webhook
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// event types customers can subscribe to
+const (
+	EventTransferReceived = "TransferReceived"
+	EventTransferSent     = "TransferSent"
+)
+
+// IsSupportedEventType returns true if customers can subscribe to eventType.
+func IsSupportedEventType(eventType string) bool {
+	switch eventType {
+	case EventTransferReceived, EventTransferSent:
+		return true
+	}
+	return false
+}
+
+// IsValidURL returns true if deliveries can be posted to rawURL: an absolute https URL without credentials,
+// whose host is not local nor an address of a private network. The deliverer checks again where a name resolves.
+func IsValidURL(rawURL string) bool {
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return false
+	}
+	return u.Scheme == "https" && u.Hostname() != "" && u.User == nil && isPublicHost(u.Hostname())
+}
+
+// headers of a delivery request
+const (
+	// HeaderID is the ID of the delivery. It is the same for every attempt, so receivers can drop duplicates.
+	HeaderID = "Webhook-Id"
+	// HeaderEvent is the type of the event.
+	HeaderEvent = "Webhook-Event"
+	// HeaderSignature is "t=<unix timestamp>,v1=<hex HMAC-SHA256 of the timestamp, a dot and the body>".
+	HeaderSignature = "Webhook-Signature"
+)
+
+// DefaultTolerance is how old a signature can be before Verify rejects it, to prevent replays.
+const DefaultTolerance = 5 * time.Minute
+
+var (
+	ErrInvalidSignatureHeader = errors.New("invalid signature header")
+	ErrSignatureMismatch      = errors.New("signature does not match")
+	ErrSignatureExpired       = errors.New("signature has expired")
+)
+
+// Event is the body of a delivery request.
+type Event struct {
+	ID        int64           `json:"id"` // ID of the delivery
+	Type      string          `json:"type"`
+	CreatedAt time.Time       `json:"created_at"`
+	Data      json.RawMessage `json:"data"`
+}
+
+// TransferReceived is the data of a TransferReceived event, sent to the owner of the account credited.
+type TransferReceived struct {
+	TransferID    int64     `json:"transfer_id"`
+	AccountID     int64     `json:"account_id"`
+	FromAccountID int64     `json:"from_account_id"`
+	Amount        int64     `json:"amount"`
+	Currency      string    `json:"currency"`
+	ReceivedAt    time.Time `json:"received_at"`
+}
+
+// TransferSent is the data of a TransferSent event, sent to the owner of the account debited.
+type TransferSent struct {
+	TransferID  int64     `json:"transfer_id"`
+	AccountID   int64     `json:"account_id"`
+	ToAccountID int64     `json:"to_account_id"`
+	Amount      int64     `json:"amount"`
+	Fee         int64     `json:"fee"`
+	Currency    string    `json:"currency"`
+	SentAt      time.Time `json:"sent_at"`
+}
+
+// Sign returns the signature header of a request sent at timestamp.
+func Sign(secret string, timestamp time.Time, body []byte) string {
+	t := strconv.FormatInt(timestamp.Unix(), 10)
+	return fmt.Sprintf("t=%s,v1=%s", t, signature(secret, t, body))
+}
+
+// Verify checks the signature header of a request received at now.
+// It fails if the signature was not made with secret over this body, or if it is older than tolerance.
+func Verify(secret, header string, body []byte, now time.Time, tolerance time.Duration) error {
+	var t, v1 string
+	for _, part := range strings.Split(header, ",") {
+		key, value, found := strings.Cut(part, "=")
+		if !found {
+			return ErrInvalidSignatureHeader
+		}
+		switch key {
+		case "t":
+			t = value
+		case "v1":
+			v1 = value
+		}
+	}
+
+	unix, err := strconv.ParseInt(t, 10, 64)
+	if err != nil || v1 == "" {
+		return ErrInvalidSignatureHeader
+	}
+	if !hmac.Equal([]byte(v1), []byte(signature(secret, t, body))) {
+		return ErrSignatureMismatch
+	}
+	if now.Sub(time.Unix(unix, 0)) > tolerance {
+		return ErrSignatureExpired
+	}
+	return nil
+}
+
+func signature(secret, timestamp string, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(timestamp))
+	mac.Write([]byte("."))
+	mac.Write(body)
+	return hex.EncodeToString(mac.Sum(nil))
+}
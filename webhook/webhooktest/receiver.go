@@ -0,0 +1,96 @@
+// Package webhooktest provides a local webhook endpoint for the tests of the webhook deliveries.
+package webhooktest
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/XiaozhouCui/go-bank/webhook"
+)
+
+// Receiver is an HTTP server receiving webhooks the way an integrator would:
+// it rejects the requests whose signature does not verify with its secret, and records the others.
+type Receiver struct {
+	*httptest.Server
+	secret string
+
+	mu       sync.Mutex
+	statuses []int
+	received []webhook.Event
+	rejected int
+}
+
+// NewReceiver starts a receiver verifying the signatures with secret. Close it when done.
+func NewReceiver(secret string) *Receiver {
+	receiver := &Receiver{secret: secret}
+	receiver.Server = httptest.NewServer(http.HandlerFunc(receiver.handle))
+	return receiver
+}
+
+// Respond makes the receiver answer the next valid requests with statuses, in order.
+// Once they are used up, valid requests are answered with 204 No Content.
+func (receiver *Receiver) Respond(statuses ...int) {
+	receiver.mu.Lock()
+	defer receiver.mu.Unlock()
+	receiver.statuses = append(receiver.statuses, statuses...)
+}
+
+// Received returns the events of the valid requests answered with a 2xx status, in the order they arrived.
+func (receiver *Receiver) Received() []webhook.Event {
+	receiver.mu.Lock()
+	defer receiver.mu.Unlock()
+	return append([]webhook.Event(nil), receiver.received...)
+}
+
+// Rejected returns the number of requests that were not valid.
+func (receiver *Receiver) Rejected() int {
+	receiver.mu.Lock()
+	defer receiver.mu.Unlock()
+	return receiver.rejected
+}
+
+func (receiver *Receiver) handle(w http.ResponseWriter, r *http.Request) {
+	receiver.mu.Lock()
+	defer receiver.mu.Unlock()
+
+	event, ok := receiver.verify(r)
+	if !ok {
+		receiver.rejected++
+		w.WriteHeader(http.StatusUnauthorized)
+		return
+	}
+
+	status := http.StatusNoContent
+	if len(receiver.statuses) > 0 {
+		status = receiver.statuses[0]
+		receiver.statuses = receiver.statuses[1:]
+	}
+	if status >= 200 && status < 300 {
+		receiver.received = append(receiver.received, event)
+	}
+	w.WriteHeader(status)
+}
+
+// verify checks the signature of a request, and that its headers match the event in its body.
+func (receiver *Receiver) verify(r *http.Request) (webhook.Event, bool) {
+	var event webhook.Event
+
+	body, err := io.ReadAll(r.Body)
+	if err != nil || r.Method != http.MethodPost {
+		return event, false
+	}
+	header := r.Header.Get(webhook.HeaderSignature)
+	if webhook.Verify(receiver.secret, header, body, time.Now(), webhook.DefaultTolerance) != nil {
+		return event, false
+	}
+	if json.Unmarshal(body, &event) != nil {
+		return event, false
+	}
+	return event, r.Header.Get(webhook.HeaderID) == strconv.FormatInt(event.ID, 10) &&
+		r.Header.Get(webhook.HeaderEvent) == event.Type
+}